@@ -6,8 +6,8 @@ import (
 	"time"
 
 	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/middleware"
 	"rentPro/rentpro-admin/common/models/system"
-	"rentPro/rentpro-admin/common/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -33,7 +33,7 @@ func SetupAuthRoutes(api *gin.RouterGroup) {
 
 		// 验证用户凭据
 		var user system.SysUser
-		result := database.DB.Where("username = ?", loginData.Username).First(&user)
+		result := database.Request(c).Where("username = ?", loginData.Username).First(&user)
 		if result.Error != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":    401,
@@ -61,13 +61,7 @@ func SetupAuthRoutes(api *gin.RouterGroup) {
 		}
 
 		// 生成JWT token
-		jwtConfig := utils.JWTConfig{
-			Secret:  "rentpro-admin-secret-key",
-			Timeout: 86400, // 24 hours in seconds
-		}
-		jwtInstance := &utils.JWT{
-			Config: jwtConfig,
-		}
+		jwtInstance := middleware.NewJWT()
 		token, err := jwtInstance.GenerateToken(user.ID, user.Username)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -125,12 +119,7 @@ func SetupAuthRoutes(api *gin.RouterGroup) {
 		}
 
 		// 解析token
-		jwtInstance := &utils.JWT{
-			Config: utils.JWTConfig{
-				Secret:  "rentpro-admin-secret-key",
-				Timeout: 86400, // 24 hours in seconds
-			},
-		}
+		jwtInstance := middleware.NewJWT()
 		claims, err := jwtInstance.ParseToken(tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -143,7 +132,7 @@ func SetupAuthRoutes(api *gin.RouterGroup) {
 
 		// 获取用户信息
 		var user system.SysUser
-		result := database.DB.Where("id = ?", claims.UserID).First(&user)
+		result := database.Request(c).Where("id = ?", claims.UserID).First(&user)
 		if result.Error != nil {
 			c.JSON(http.StatusNotFound, gin.H{
 				"code":    404,
@@ -187,12 +176,7 @@ func SetupAuthRoutes(api *gin.RouterGroup) {
 		}
 
 		// 解析token
-		jwtInstance := &utils.JWT{
-			Config: utils.JWTConfig{
-				Secret:  "rentpro-admin-secret-key",
-				Timeout: 86400, // 24 hours in seconds
-			},
-		}
+		jwtInstance := middleware.NewJWT()
 		claims, err := jwtInstance.ParseToken(tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
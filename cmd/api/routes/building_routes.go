@@ -1,144 +1,84 @@
 package routes
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/middleware"
+	"rentPro/rentpro-admin/common/models/rental"
+	"rentPro/rentpro-admin/common/query"
 	"rentPro/rentpro-admin/common/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 // SetupBuildingRoutes 设置楼盘管理相关路由
 func SetupBuildingRoutes(api *gin.RouterGroup) {
 	// 获取楼盘列表
-	api.GET("/buildings", func(c *gin.Context) {
-		// 获取查询参数
-		page := c.DefaultQuery("page", "1")
-		pageSize := c.DefaultQuery("pageSize", "10")
-		name := c.Query("name")
-		city := c.Query("city")
-		district := c.Query("district")
-		businessArea := c.Query("business_area")
-		status := c.Query("status")
-		deleted := c.Query("deleted") // 是否查询软删除的数据
-
-		// 转换分页参数
-		pageNum, _ := strconv.Atoi(page)
-		pageSizeNum, _ := strconv.Atoi(pageSize)
-		offset := (pageNum - 1) * pageSizeNum
-
-		// 构建查询条件 - 根据deleted参数决定查询条件，并关联用户表获取用户姓名
-		var query string
-		if deleted == "true" {
-			// 查询软删除的数据，包含deleted_at字段
-			query = `SELECT b.id, b.name, b.city, b.district, b.business_area, b.property_type, b.status, b.rent_count, 
-					b.created_at, b.updated_at, b.created_by, b.updated_by, b.deleted_at,
-					COALESCE(u_updated.nick_name, u_created.nick_name, b.updated_by, b.created_by) as editor_name
-					FROM sys_buildings b
-					LEFT JOIN sys_user u_created ON b.created_by = u_created.username
-					LEFT JOIN sys_user u_updated ON b.updated_by = u_updated.username
-					WHERE b.deleted_at IS NOT NULL`
-		} else {
-			// 查询正常数据
-			query = `SELECT b.id, b.name, b.city, b.district, b.business_area, b.property_type, b.status, b.rent_count, 
-					b.created_at, b.updated_at, b.created_by, b.updated_by,
-					COALESCE(u_updated.nick_name, u_created.nick_name, b.updated_by, b.created_by) as editor_name
-					FROM sys_buildings b
-					LEFT JOIN sys_user u_created ON b.created_by = u_created.username
-					LEFT JOIN sys_user u_updated ON b.updated_by = u_updated.username
-					WHERE b.deleted_at IS NULL`
-		}
-		args := []interface{}{}
-
-		if name != "" {
-			query += " AND b.name LIKE ?"
-			args = append(args, "%"+name+"%")
-		}
-		if city != "" {
-			query += " AND b.city = ?"
-			args = append(args, city)
-		}
-		if district != "" {
-			query += " AND b.district = ?"
-			args = append(args, district)
-		}
-		if businessArea != "" {
-			query += " AND b.business_area = ?"
-			args = append(args, businessArea)
-		}
-		if status != "" {
-			query += " AND b.status = ?"
-			args = append(args, status)
-		}
-
-		query += " ORDER BY b.rent_count DESC, b.created_at ASC LIMIT ? OFFSET ?"
-		args = append(args, pageSizeNum, offset)
+	// 未登录或没有审核权限时只返回审核通过的楼盘（及自己提交的楼盘）
+	api.GET("/buildings", middleware.OptionalJWTAuth(), func(c *gin.Context) {
+		params, ok := parseListParams(c, buildingListQuery)
+		if !ok {
+			return
+		}
 
-		var buildings []map[string]interface{}
-		result := database.DB.Raw(query, args...).Scan(&buildings)
+		// 根据deleted参数决定查询正常数据还是回收站数据
+		db := database.Request(c).Where("b.deleted_at IS NULL")
+		if c.Query("deleted") == "true" {
+			db = database.Request(c).Where("b.deleted_at IS NOT NULL")
+		}
+		// 按审核状态限制可见范围（published=true 时只返回审核通过的楼盘）
+		db = visibleOnly(c, db, "b.status", "b.created_by")
 
-		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "查询楼盘列表失败",
-				"error":   result.Error.Error(),
+		// 周边POI和配套设施筛选
+		db, err := applyNearbyFilters(c, db)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "查询参数错误",
+				"error":   err.Error(),
 			})
 			return
 		}
 
-		// 获取总数 - 根据deleted参数决定查询条件
-		var countQuery string
-		if deleted == "true" {
-			countQuery = "SELECT COUNT(*) FROM sys_buildings b WHERE b.deleted_at IS NOT NULL"
-		} else {
-			countQuery = "SELECT COUNT(*) FROM sys_buildings b WHERE b.deleted_at IS NULL"
+		// 流式导出：按游标分批输出全部匹配数据
+		if c.Query("format") == "ndjson" {
+			query.WriteNDJSON(c, func(emit func(rows interface{}) error) error {
+				return buildingListQuery.Each(db, params, query.StreamBatchSize, func() interface{} {
+					return &[]map[string]interface{}{}
+				}, emit)
+			})
+			return
 		}
-		countArgs := []interface{}{}
 
-		if name != "" {
-			countQuery += " AND b.name LIKE ?"
-			countArgs = append(countArgs, "%"+name+"%")
-		}
-		if city != "" {
-			countQuery += " AND b.city = ?"
-			countArgs = append(countArgs, city)
-		}
-		if district != "" {
-			countQuery += " AND b.district = ?"
-			countArgs = append(countArgs, district)
-		}
-		if businessArea != "" {
-			countQuery += " AND b.business_area = ?"
-			countArgs = append(countArgs, businessArea)
-		}
-		if status != "" {
-			countQuery += " AND b.status = ?"
-			countArgs = append(countArgs, status)
+		var buildings []map[string]interface{}
+		page, err := buildingListQuery.Find(db, params, &buildings)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "查询楼盘列表失败",
+				"error":   err.Error(),
+			})
+			return
 		}
 
-		var total int64
-		database.DB.Raw(countQuery, countArgs...).Scan(&total)
-
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "获取楼盘列表成功",
-			"data":    buildings,
-			"page":    pageNum,
-			"size":    pageSizeNum,
-			"total":   total,
-		})
+		// 兼容迁移前的 size 字段
+		page.JSONCompat(c, "获取楼盘列表成功", gin.H{"size": page.PageSize})
 	})
 
 	// 获取单个楼盘信息
-	api.GET("/buildings/:id", func(c *gin.Context) {
+	api.GET("/buildings/:id", middleware.OptionalJWTAuth(), func(c *gin.Context) {
 		id := c.Param("id")
 
 		var building map[string]interface{}
-		result := database.DB.Table("sys_buildings").Where("id = ? AND deleted_at IS NULL", id).First(&building)
+		db := database.Request(c).Table("sys_buildings").Where("id = ? AND deleted_at IS NULL", id)
+		result := visibleOnly(c, db, "status", "created_by").Take(&building)
 
 		if result.Error != nil {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -148,25 +88,37 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 			return
 		}
 
+		// 必填图片分类缺少图片时提示，不影响获取详情
+		buildingID, _ := strconv.ParseUint(id, 10, 64)
+		imageWarnings, err := utils.RequiredImageWarnings(c, []string{"building"}, buildingID)
+		if err != nil {
+			log.Printf("⚠️  检查楼盘图片完整性失败: %v", err)
+		}
+
 		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "获取楼盘信息成功",
-			"data":    building,
+			"code":          200,
+			"message":       "获取楼盘信息成功",
+			"data":          building,
+			"imageWarnings": imageWarnings,
 		})
 	})
 
 	// 创建楼盘
-	api.POST("/buildings", func(c *gin.Context) {
+	api.POST("/buildings", middleware.JWTAuth(), func(c *gin.Context) {
 
 		// 解析请求体
 		var buildingData struct {
-			Name         string `json:"name" binding:"required"`
-			City         string `json:"city" binding:"required"`
-			District     string `json:"district" binding:"required"`
-			BusinessArea string `json:"businessArea"`
-			PropertyType string `json:"propertyType"`
-			Description  string `json:"description"`
-			Status       string `json:"status"`
+			Name     string `json:"name" binding:"required"`
+			City     string `json:"city" binding:"required"`
+			District string `json:"district" binding:"required"`
+			// DetailedAddress 详细地址，列为 NOT NULL 且没有默认值，未提供时写入空字符串
+			DetailedAddress string  `json:"detailedAddress"`
+			BusinessArea    string  `json:"businessArea"`
+			PropertyType    string  `json:"propertyType"`
+			Description     string  `json:"description"`
+			Longitude       float64 `json:"longitude"`
+			Latitude        float64 `json:"latitude"`
+			Status          string  `json:"status"`
 		}
 
 		if err := c.ShouldBindJSON(&buildingData); err != nil {
@@ -178,45 +130,59 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		// 设置默认值
-		if buildingData.Status == "" {
-			buildingData.Status = "active"
-		}
-
-		// 获取当前用户 (暂时使用admin)
-		currentUser := "admin" // TODO: 从JWT token或上下文中获取真实用户
+		// 新建楼盘一律进入审核中，审核通过后才对外展示
+		buildingData.Status = rental.StatusPending
+
+		// 提交人为当前登录用户
+		currentUser := c.GetString(middleware.ContextUsername)
+
+		// 插入数据库并记录提交审核（同一事务保证插入ID取自同一连接）
+		var newBuildingID uint64
+		now := time.Now()
+		err := database.Request(c).Transaction(func(tx *gorm.DB) error {
+			result := tx.Exec(
+				"INSERT INTO sys_buildings (name, city, district, detailed_address, business_area, property_type, description, longitude, latitude, status, created_by, updated_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				buildingData.Name,
+				buildingData.City,
+				buildingData.District,
+				buildingData.DetailedAddress,
+				buildingData.BusinessArea,
+				buildingData.PropertyType,
+				buildingData.Description,
+				buildingData.Longitude,
+				buildingData.Latitude,
+				buildingData.Status,
+				currentUser,
+				currentUser,
+				now,
+				now,
+			)
+			if result.Error != nil {
+				return result.Error
+			}
 
-		// 插入数据库
-		result := database.DB.Exec(
-			"INSERT INTO sys_buildings (name, city, district, business_area, property_type, description, status, created_by, updated_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())",
-			buildingData.Name,
-			buildingData.City,
-			buildingData.District,
-			buildingData.BusinessArea,
-			buildingData.PropertyType,
-			buildingData.Description,
-			buildingData.Status,
-			currentUser,
-			currentUser,
-		)
+			// 获取新创建的楼盘ID
+			id, err := database.LastInsertID(tx)
+			if err != nil {
+				return err
+			}
+			newBuildingID = id
+			return recordReview(tx, buildingReviewTarget, newBuildingID, rental.ReviewActionSubmit, "", rental.StatusPending, "", currentUser)
+		})
 
-		if result.Error != nil {
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
 				"message": "创建楼盘失败",
-				"error":   result.Error.Error(),
+				"error":   err.Error(),
 			})
 			return
 		}
 
-		// 获取新创建的楼盘ID
-		var newBuildingID int64
-		database.DB.Raw("SELECT LAST_INSERT_ID()").Scan(&newBuildingID)
-
 		// 初始化楼盘文件夹结构
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager != nil {
-			if err := imageManager.CreateBuildingFolder(uint64(newBuildingID), buildingData.Name); err != nil {
+			if err := imageManager.CreateBuildingFolder(newBuildingID, buildingData.Name); err != nil {
 				// 文件夹创建失败不影响楼盘创建成功，只记录日志
 				// 这里可以记录到日志系统
 			}
@@ -239,18 +205,20 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 	})
 
 	// 更新楼盘信息
-	api.PUT("/buildings/:id", func(c *gin.Context) {
+	api.PUT("/buildings/:id", middleware.JWTAuth(), func(c *gin.Context) {
 		id := c.Param("id")
 
 		// 解析请求体
 		var buildingData struct {
-			Name         string `json:"name"`
-			City         string `json:"city"`
-			District     string `json:"district"`
-			BusinessArea string `json:"businessArea"`
-			PropertyType string `json:"propertyType"`
-			Description  string `json:"description"`
-			Status       string `json:"status"`
+			Name         string   `json:"name"`
+			City         string   `json:"city"`
+			District     string   `json:"district"`
+			BusinessArea string   `json:"businessArea"`
+			PropertyType string   `json:"propertyType"`
+			Description  string   `json:"description"`
+			Longitude    *float64 `json:"longitude"`
+			Latitude     *float64 `json:"latitude"`
+			Status       string   `json:"status"`
 		}
 
 		if err := c.ShouldBindJSON(&buildingData); err != nil {
@@ -290,13 +258,39 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 			setParts = append(setParts, "description = ?")
 			values = append(values, buildingData.Description)
 		}
-		if buildingData.Status != "" {
+		if buildingData.Longitude != nil {
+			setParts = append(setParts, "longitude = ?")
+			values = append(values, *buildingData.Longitude)
+		}
+		if buildingData.Latitude != nil {
+			setParts = append(setParts, "latitude = ?")
+			values = append(values, *buildingData.Latitude)
+		}
+
+		// 状态只能手动改为停用，其余状态由审核流程变更
+		if buildingData.Status != "" && buildingData.Status != rental.StatusInactive {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "楼盘状态需通过审核流程变更",
+			})
+			return
+		}
+		// 修改了楼盘内容时需要重新审核；停用不能与修改内容同时提交，否则重新审核会覆盖停用
+		contentChanged := len(setParts) > 0
+		if buildingData.Status == rental.StatusInactive {
+			if contentChanged {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"code":    400,
+					"message": "停用楼盘不能同时修改楼盘内容，请分别提交",
+				})
+				return
+			}
 			setParts = append(setParts, "status = ?")
 			values = append(values, buildingData.Status)
 		}
 
-		// 获取当前用户 (暂时使用admin)
-		currentUser := "admin" // TODO: 从JWT token或上下文中获取真实用户
+		// 编辑人（重新提交审核的提交人）为当前登录用户
+		currentUser := c.GetString(middleware.ContextUsername)
 
 		// 总是更新 updated_at 和 updated_by
 		setParts = append(setParts, "updated_at = ?")
@@ -306,15 +300,41 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 		// 注意：id 参数放在最后
 		values = append(values, id)
 
-		// 执行原生SQL更新
-		sql := "UPDATE sys_buildings SET " + strings.Join(setParts, ", ") + " WHERE id = ?"
-		result := database.DB.Exec(sql, values...)
+		buildingID, _ := strconv.ParseUint(id, 10, 64)
+		err := database.Request(c).Transaction(func(tx *gorm.DB) error {
+			// 执行原生SQL更新
+			sql := "UPDATE sys_buildings SET " + strings.Join(setParts, ", ") + " WHERE id = ? AND deleted_at IS NULL"
+			result := tx.Exec(sql, values...)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errReviewTargetNotFound
+			}
+			// 坐标变更后重新计算周边POI距离
+			if buildingData.Longitude != nil || buildingData.Latitude != nil {
+				if err := recomputePoiDistances(tx, "building_id", buildingID); err != nil {
+					return err
+				}
+			}
+			if contentChanged {
+				return submitForReview(tx, buildingReviewTarget, buildingID, currentUser)
+			}
+			return nil
+		})
 
-		if result.Error != nil {
+		if err == errReviewTargetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    404,
+				"message": "楼盘不存在",
+			})
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
 				"message": "更新楼盘失败",
-				"error":   result.Error.Error(),
+				"error":   err.Error(),
 			})
 			return
 		}
@@ -326,12 +346,12 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 	})
 
 	// 删除楼盘
-	api.DELETE("/buildings/:id", func(c *gin.Context) {
+	api.DELETE("/buildings/:id", middleware.JWTAuth(), func(c *gin.Context) {
 		id := c.Param("id")
 
 		// 检查楼盘是否存在
 		var buildingExists int64
-		database.DB.Raw("SELECT COUNT(*) FROM sys_buildings WHERE id = ? AND deleted_at IS NULL", id).Scan(&buildingExists)
+		database.Request(c).Clauses(dbresolver.Write).Raw("SELECT COUNT(*) FROM sys_buildings WHERE id = ? AND deleted_at IS NULL", id).Scan(&buildingExists)
 		if buildingExists == 0 {
 			c.JSON(http.StatusNotFound, gin.H{
 				"code":    404,
@@ -342,7 +362,7 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 
 		// 检查是否有关联的户型数据
 		var houseTypeCount int64
-		database.DB.Raw("SELECT COUNT(*) FROM sys_house_types WHERE building_id = ? AND deleted_at IS NULL", id).Scan(&houseTypeCount)
+		database.Request(c).Clauses(dbresolver.Write).Raw("SELECT COUNT(*) FROM sys_house_types WHERE building_id = ? AND deleted_at IS NULL", id).Scan(&houseTypeCount)
 
 		if houseTypeCount > 0 {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -356,7 +376,7 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 		}
 
 		// 删除数据库记录（软删除）
-		result := database.DB.Exec("UPDATE sys_buildings SET deleted_at = NOW() WHERE id = ?", id)
+		result := database.Request(c).Exec("UPDATE sys_buildings SET deleted_at = ? WHERE id = ?", time.Now(), id)
 
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -374,11 +394,14 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 	})
 
 	// 获取楼盘详细信息（包含户型统计等）
-	api.GET("/buildings/:id/info", func(c *gin.Context) {
+	api.GET("/buildings/:id/info", middleware.OptionalJWTAuth(), func(c *gin.Context) {
 		id := c.Param("id")
+		if !requireBuildingVisible(c, id) {
+			return
+		}
 
 		var building map[string]interface{}
-		result := database.DB.Raw(`
+		result := database.Request(c).Raw(`
 			SELECT
 				b.*,
 				COUNT(ht.id) as house_type_count,
@@ -406,7 +429,7 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 	})
 
 	// 获取楼盘图片列表
-	api.GET("/buildings/images/:buildingId", func(c *gin.Context) {
+	api.GET("/buildings/images/:buildingId", middleware.OptionalJWTAuth(), func(c *gin.Context) {
 		buildingIDStr := c.Param("buildingId")
 		category := c.Query("category")
 
@@ -418,8 +441,11 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 			})
 			return
 		}
+		if !requireBuildingVisible(c, buildingID) {
+			return
+		}
 
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -446,7 +472,7 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 	})
 
 	// 获取楼盘户型图列表
-	api.GET("/buildings/floor-plans/:buildingId", func(c *gin.Context) {
+	api.GET("/buildings/floor-plans/:buildingId", middleware.OptionalJWTAuth(), func(c *gin.Context) {
 		buildingIDStr := c.Param("buildingId")
 
 		buildingID, err := strconv.ParseUint(buildingIDStr, 10, 64)
@@ -457,8 +483,11 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 			})
 			return
 		}
+		if !requireBuildingVisible(c, buildingID) {
+			return
+		}
 
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -488,72 +517,48 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 
 	// 获取区域列表（支持按城市筛选）
 	api.GET("/districts", func(c *gin.Context) {
-		cityId := c.Query("cityId")
-
-		query := "SELECT id, code, name, city_code, city_id, sort, status FROM sys_districts WHERE status = 'active'"
-		args := []interface{}{}
-
-		if cityId != "" {
-			query += " AND city_id = ?"
-			args = append(args, cityId)
+		params, ok := parseListParams(c, districtListQuery)
+		if !ok {
+			return
 		}
 
-		query += " ORDER BY sort ASC"
-
 		var districts []map[string]interface{}
-		result := database.DB.Raw(query, args...).Scan(&districts)
-
-		if result.Error != nil {
+		page, err := districtListQuery.Find(database.Request(c).Where("status = ?", "active"), params, &districts)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
 				"message": "获取区域列表失败",
-				"error":   result.Error.Error(),
+				"error":   err.Error(),
 			})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "获取区域列表成功",
-			"data":    districts,
-		})
+		page.JSON(c, "获取区域列表成功")
 	})
 
 	// 获取商圈列表
 	api.GET("/business-areas", func(c *gin.Context) {
-		districtId := c.Query("districtId")
-
-		query := "SELECT id, code, name, district_id, city_code, sort, status FROM sys_business_areas WHERE status = 'active'"
-		args := []interface{}{}
-
-		if districtId != "" {
-			query += " AND district_id = ?"
-			args = append(args, districtId)
+		params, ok := parseListParams(c, businessAreaListQuery)
+		if !ok {
+			return
 		}
 
-		query += " ORDER BY sort ASC"
-
 		var businessAreas []map[string]interface{}
-		result := database.DB.Raw(query, args...).Scan(&businessAreas)
-
-		if result.Error != nil {
+		page, err := businessAreaListQuery.Find(database.Request(c).Where("status = ?", "active"), params, &businessAreas)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
 				"message": "获取商圈列表失败",
-				"error":   result.Error.Error(),
+				"error":   err.Error(),
 			})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "获取商圈列表成功",
-			"data":    businessAreas,
-		})
+		page.JSON(c, "获取商圈列表成功")
 	})
 
 	// 恢复楼盘（软删除恢复）
-	api.POST("/buildings/:id/restore", func(c *gin.Context) {
+	api.POST("/buildings/:id/restore", middleware.JWTAuth(), func(c *gin.Context) {
 		id := c.Param("id")
 
 		// 验证ID
@@ -566,7 +571,7 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 		}
 
 		// 恢复楼盘（将deleted_at设置为NULL）
-		result := database.DB.Exec("UPDATE sys_buildings SET deleted_at = NULL, updated_at = NOW() WHERE id = ? AND deleted_at IS NOT NULL", id)
+		result := database.Request(c).Exec("UPDATE sys_buildings SET deleted_at = NULL, updated_at = ? WHERE id = ? AND deleted_at IS NOT NULL", time.Now(), id)
 
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -592,7 +597,7 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 	})
 
 	// 永久删除楼盘
-	api.DELETE("/buildings/:id/permanent", func(c *gin.Context) {
+	api.DELETE("/buildings/:id/permanent", middleware.JWTAuth(), func(c *gin.Context) {
 		id := c.Param("id")
 
 		// 验证ID
@@ -606,7 +611,7 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 
 		// 检查楼盘是否已被软删除
 		var count int64
-		database.DB.Raw("SELECT COUNT(*) FROM sys_buildings WHERE id = ? AND deleted_at IS NOT NULL", id).Scan(&count)
+		database.Request(c).Clauses(dbresolver.Write).Raw("SELECT COUNT(*) FROM sys_buildings WHERE id = ? AND deleted_at IS NOT NULL", id).Scan(&count)
 
 		if count == 0 {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -617,7 +622,7 @@ func SetupBuildingRoutes(api *gin.RouterGroup) {
 		}
 
 		// 永久删除楼盘（物理删除）
-		result := database.DB.Exec("DELETE FROM sys_buildings WHERE id = ? AND deleted_at IS NOT NULL", id)
+		result := database.Request(c).Exec("DELETE FROM sys_buildings WHERE id = ? AND deleted_at IS NOT NULL", id)
 
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -8,6 +8,7 @@ import (
 	"rentPro/rentpro-admin/common/models/rental"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/plugin/dbresolver"
 )
 
 // SetupCityRoutes 设置城市相关路由
@@ -35,47 +36,13 @@ type CityResponse struct {
 
 // getCities 获取城市列表
 func getCities(c *gin.Context) {
-	// 获取查询参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "100"))
-	status := c.Query("status")
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 1000 {
-		pageSize = 100
-	}
-
-	offset := (page - 1) * pageSize
-
-	// 构建查询
-	db := database.DB
-	query := db.Model(&rental.SysCity{})
-
-	// 状态过滤
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-
-	// 获取总数
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "获取城市总数失败",
-			"error":   err.Error(),
-		})
+	params, ok := parseListParams(c, cityListQuery)
+	if !ok {
 		return
 	}
 
-	// 获取城市列表
 	var cities []rental.SysCity
-	err := query.Order("sort ASC, id ASC").
-		Limit(pageSize).
-		Offset(offset).
-		Find(&cities).Error
-
+	page, err := cityListQuery.Find(database.Request(c), params, &cities)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -86,7 +53,7 @@ func getCities(c *gin.Context) {
 	}
 
 	// 转换为响应格式
-	var cityList []CityResponse
+	cityList := make([]CityResponse, 0, len(cities))
 	for _, city := range cities {
 		cityList = append(cityList, CityResponse{
 			ID:        city.ID,
@@ -98,15 +65,10 @@ func getCities(c *gin.Context) {
 			UpdatedAt: city.UpdatedAt.Format("2006-01-02 15:04:05"),
 		})
 	}
+	page.List = cityList
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":      200,
-		"message":   "获取城市列表成功",
-		"data":      cityList, // 直接返回城市列表，前端期望的格式
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
-	})
+	// 兼容迁移前的 page_size 字段
+	page.JSONCompat(c, "获取城市列表成功", gin.H{"page_size": page.PageSize})
 }
 
 // getCityByID 根据ID获取城市
@@ -121,7 +83,7 @@ func getCityByID(c *gin.Context) {
 	}
 
 	var city rental.SysCity
-	db := database.DB
+	db := database.Request(c)
 	if err := db.First(&city, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    404,
@@ -179,7 +141,7 @@ func createCity(c *gin.Context) {
 		Status: req.Status,
 	}
 
-	db := database.DB
+	db := database.Request(c)
 	if err := db.Create(&city).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -235,9 +197,9 @@ func updateCity(c *gin.Context) {
 		return
 	}
 
-	db := database.DB
+	db := database.Request(c)
 	var city rental.SysCity
-	if err := db.First(&city, id).Error; err != nil {
+	if err := db.Clauses(dbresolver.Write).First(&city, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    404,
 			"message": "城市不存在",
@@ -296,9 +258,9 @@ func deleteCity(c *gin.Context) {
 		return
 	}
 
-	db := database.DB
+	db := database.Request(c)
 	var city rental.SysCity
-	if err := db.First(&city, id).Error; err != nil {
+	if err := db.Clauses(dbresolver.Write).First(&city, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    404,
 			"message": "城市不存在",
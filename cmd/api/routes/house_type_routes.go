@@ -8,9 +8,13 @@ import (
 	"time"
 
 	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/middleware"
+	"rentPro/rentpro-admin/common/models/rental"
 	"rentPro/rentpro-admin/common/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 // HouseTypeResponse 户型响应结构
@@ -39,7 +43,8 @@ type HouseTypeResponse struct {
 // SetupHouseTypeRoutes 设置户型相关路由
 func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 	// 获取楼盘的户型列表
-	api.GET("/house-types/building/:buildingId", func(c *gin.Context) {
+	// 未登录或没有审核权限时只返回审核通过的户型（及自己提交的户型）
+	api.GET("/house-types/building/:buildingId", middleware.OptionalJWTAuth(), func(c *gin.Context) {
 		buildingIdStr := c.Param("buildingId")
 		buildingId, err := strconv.ParseUint(buildingIdStr, 10, 64)
 		if err != nil {
@@ -50,38 +55,16 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		// 分页参数
-		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-		pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
-		if page < 1 {
-			page = 1
-		}
-		if pageSize < 1 || pageSize > 100 {
-			pageSize = 10
+		params, ok := parseListParams(c, houseTypeListQuery)
+		if !ok {
+			return
 		}
-		offset := (page - 1) * pageSize
-
-		// 构建查询
-		query := `SELECT ht.id, ht.building_id, ht.name, ht.code, ht.rooms, ht.halls, ht.bathrooms, 
-				 COALESCE(ht.balconies, 0) as balconies,
-				 COALESCE(ht.maid_rooms, 0) as maid_rooms,
-				 ht.standard_area, 
-				 COALESCE(ht.standard_orientation, '') as standard_orientation, 
-				 COALESCE(ht.floor_plan_url, '') as floor_plan_url,
-				 CASE WHEN ht.floor_plan_url IS NOT NULL AND ht.floor_plan_url != '' THEN true ELSE false END as has_floor_plan,
-				 ht.created_at, ht.updated_at, 
-				 COALESCE(ht.created_by, '') as created_by, 
-				 COALESCE(ht.updated_by, '') as updated_by,
-				 COALESCE(u_updated.nick_name, u_created.nick_name, ht.updated_by, ht.created_by, '系统') as editor_name
-				 FROM sys_house_types ht
-				 LEFT JOIN sys_user u_created ON ht.created_by = u_created.username
-				 LEFT JOIN sys_user u_updated ON ht.updated_by = u_updated.username
-				 WHERE ht.building_id = ? AND ht.deleted_at IS NULL
-				 ORDER BY ht.created_at DESC
-				 LIMIT ? OFFSET ?`
 
 		var houseTypes []HouseTypeResponse
-		err = database.DB.Raw(query, buildingId, pageSize, offset).Scan(&houseTypes).Error
+		db := database.Request(c).Where("ht.building_id = ? AND ht.deleted_at IS NULL", buildingId)
+		// 按审核状态限制可见范围，户型和所属楼盘都需可见（published=true 时只返回审核通过的户型）
+		db = visibleOnly(c, visibleOnly(c, db, "ht.status", "ht.created_by"), "b.status", "b.created_by")
+		page, err := houseTypeListQuery.Find(db, params, &houseTypes)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -91,31 +74,12 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		// 查询总数
-		var total int64
-		countQuery := "SELECT COUNT(*) FROM sys_house_types WHERE building_id = ? AND deleted_at IS NULL"
-		err = database.DB.Raw(countQuery, buildingId).Scan(&total).Error
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "查询户型总数失败",
-				"error":   err.Error(),
-			})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "获取户型列表成功",
-			"data":    houseTypes,
-			"total":   total,
-			"page":    page,
-			"size":    pageSize,
-		})
+		// 兼容迁移前的 size 字段
+		page.JSONCompat(c, "获取户型列表成功", gin.H{"size": page.PageSize})
 	})
 
 	// 获取单个户型信息
-	api.GET("/house-types/:id", func(c *gin.Context) {
+	api.GET("/house-types/:id", middleware.OptionalJWTAuth(), func(c *gin.Context) {
 		idStr := c.Param("id")
 		id, err := strconv.ParseUint(idStr, 10, 64)
 		if err != nil {
@@ -125,6 +89,9 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			})
 			return
 		}
+		if !requireHouseTypeVisible(c, id) {
+			return
+		}
 
 		query := `SELECT ht.id, ht.building_id, ht.name, ht.code, ht.rooms, ht.halls, ht.bathrooms, 
 				 COALESCE(ht.balconies, 0) as balconies,
@@ -143,7 +110,7 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 				 WHERE ht.id = ? AND ht.deleted_at IS NULL`
 
 		var houseType HouseTypeResponse
-		err = database.DB.Raw(query, id).First(&houseType).Error
+		err = database.Request(c).Raw(query, id).First(&houseType).Error
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{
 				"code":    404,
@@ -152,15 +119,22 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			return
 		}
 
+		// 必填图片分类缺少图片时提示，户型图片分布在 house_type、house_floor_plan 两个模块
+		imageWarnings, err := utils.RequiredImageWarnings(c, []string{"house_type", "house_floor_plan"}, id)
+		if err != nil {
+			fmt.Printf("⚠️  检查户型图片完整性失败: %v\n", err)
+		}
+
 		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "获取户型信息成功",
-			"data":    houseType,
+			"code":          200,
+			"message":       "获取户型信息成功",
+			"data":          houseType,
+			"imageWarnings": imageWarnings,
 		})
 	})
 
 	// 创建户型
-	api.POST("/house-types", func(c *gin.Context) {
+	api.POST("/house-types", middleware.JWTAuth(), func(c *gin.Context) {
 		var houseType struct {
 			BuildingID          uint64  `json:"building_id" binding:"required"`
 			Name                string  `json:"name" binding:"required"`
@@ -188,36 +162,70 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			houseType.Code = strings.ToUpper(strings.ReplaceAll(houseType.Name, " ", "_"))
 		}
 
-		// TODO: 从JWT token获取真实用户
-		currentUser := "admin"
-
-		result := database.DB.Exec(
-			"INSERT INTO sys_house_types (building_id, name, code, rooms, halls, bathrooms, balconies, maid_rooms, standard_area, standard_orientation, created_by, updated_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())",
-			houseType.BuildingID,
-			houseType.Name,
-			houseType.Code,
-			houseType.Rooms,
-			houseType.Halls,
-			houseType.Bathrooms,
-			houseType.Balconies,
-			houseType.MaidRooms,
-			houseType.StandardArea,
-			houseType.StandardOrientation,
-			currentUser,
-			currentUser,
-		)
+		// 所属楼盘必须存在
+		var buildingCount int64
+		if err := database.Request(c).Clauses(dbresolver.Write).Table("sys_buildings").Where("id = ? AND deleted_at IS NULL", houseType.BuildingID).Count(&buildingCount).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "查询楼盘失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if buildingCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    404,
+				"message": "楼盘不存在",
+			})
+			return
+		}
+
+		// 提交人为当前登录用户
+		currentUser := c.GetString(middleware.ContextUsername)
+
+		// 新建户型进入审核中，与提交记录在同一事务中写入
+		now := time.Now()
+		err := database.Request(c).Transaction(func(tx *gorm.DB) error {
+			result := tx.Exec(
+				"INSERT INTO sys_house_types (building_id, name, code, rooms, halls, bathrooms, balconies, maid_rooms, standard_area, standard_orientation, status, created_by, updated_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				houseType.BuildingID,
+				houseType.Name,
+				houseType.Code,
+				houseType.Rooms,
+				houseType.Halls,
+				houseType.Bathrooms,
+				houseType.Balconies,
+				houseType.MaidRooms,
+				houseType.StandardArea,
+				houseType.StandardOrientation,
+				rental.StatusPending,
+				currentUser,
+				currentUser,
+				now,
+				now,
+			)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			newHouseTypeID, err := database.LastInsertID(tx)
+			if err != nil {
+				return err
+			}
+			return recordReview(tx, houseTypeReviewTarget, newHouseTypeID, rental.ReviewActionSubmit, "", rental.StatusPending, "", currentUser)
+		})
 
-		if result.Error != nil {
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
 				"message": "创建户型失败",
-				"error":   result.Error.Error(),
+				"error":   err.Error(),
 			})
 			return
 		}
 
 		// 在七牛云创建户型文件夹
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if err := imageManager.CreateHouseTypeFolder(houseType.BuildingID, houseType.Name, houseType.StandardArea); err != nil {
 			// 记录错误但不影响户型创建成功
 			fmt.Printf("⚠️  创建户型文件夹失败: %v\n", err)
@@ -230,7 +238,7 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 	})
 
 	// 更新户型
-	api.PUT("/house-types/:id", func(c *gin.Context) {
+	api.PUT("/house-types/:id", middleware.JWTAuth(), func(c *gin.Context) {
 		idStr := c.Param("id")
 		id, err := strconv.ParseUint(idStr, 10, 64)
 		if err != nil {
@@ -262,8 +270,8 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		// TODO: 从JWT token获取真实用户
-		currentUser := "admin"
+		// 编辑人（重新提交审核的提交人）为当前登录用户
+		currentUser := c.GetString(middleware.ContextUsername)
 
 		var setParts []string
 		var values []interface{}
@@ -322,19 +330,35 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 		// 添加WHERE条件的参数
 		values = append(values, id)
 
-		query := "UPDATE sys_house_types SET " + strings.Join(setParts, ", ") + " WHERE id = ?"
-
-		result := database.DB.Exec(query, values...)
-		if result.Error != nil {
+		query := "UPDATE sys_house_types SET " + strings.Join(setParts, ", ") + " WHERE id = ? AND deleted_at IS NULL"
+
+		// 修改户型内容后需要重新审核
+		var rowsAffected int64
+		err = database.Request(c).Transaction(func(tx *gorm.DB) error {
+			result := tx.Exec(query, values...)
+			if result.Error != nil {
+				return result.Error
+			}
+			rowsAffected = result.RowsAffected
+			if rowsAffected == 0 {
+				return nil
+			}
+			return submitForReview(tx, houseTypeReviewTarget, id, currentUser)
+		})
+		if err == errReviewTargetNotFound {
+			rowsAffected = 0
+			err = nil
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
 				"message": "更新户型失败",
-				"error":   result.Error.Error(),
+				"error":   err.Error(),
 			})
 			return
 		}
 
-		if result.RowsAffected == 0 {
+		if rowsAffected == 0 {
 			c.JSON(http.StatusNotFound, gin.H{
 				"code":    404,
 				"message": "户型不存在",
@@ -349,7 +373,7 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 	})
 
 	// 删除户型（软删除）
-	api.DELETE("/house-types/:id", func(c *gin.Context) {
+	api.DELETE("/house-types/:id", middleware.JWTAuth(), func(c *gin.Context) {
 		idStr := c.Param("id")
 		id, err := strconv.ParseUint(idStr, 10, 64)
 		if err != nil {
@@ -360,10 +384,9 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		// TODO: 从JWT token获取真实用户
-		currentUser := "admin"
+		currentUser := c.GetString(middleware.ContextUsername)
 
-		result := database.DB.Exec("UPDATE sys_house_types SET deleted_at = NOW(), updated_by = ? WHERE id = ? AND deleted_at IS NULL", currentUser, id)
+		result := database.Request(c).Exec("UPDATE sys_house_types SET deleted_at = ?, updated_by = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), currentUser, id)
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -388,7 +411,7 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 	})
 
 	// 获取已删除的户型列表（回收站）
-	api.GET("/house-types/building/:buildingId/deleted", func(c *gin.Context) {
+	api.GET("/house-types/building/:buildingId/deleted", middleware.OptionalJWTAuth(), func(c *gin.Context) {
 		buildingIdStr := c.Param("buildingId")
 		buildingId, err := strconv.ParseUint(buildingIdStr, 10, 64)
 		if err != nil {
@@ -399,43 +422,15 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		pageStr := c.DefaultQuery("page", "1")
-		pageSizeStr := c.DefaultQuery("pageSize", "10")
-
-		page, err := strconv.Atoi(pageStr)
-		if err != nil || page < 1 {
-			page = 1
-		}
-
-		pageSize, err := strconv.Atoi(pageSizeStr)
-		if err != nil || pageSize < 1 || pageSize > 100 {
-			pageSize = 10
+		params, ok := parseListParams(c, deletedHouseTypeListQuery)
+		if !ok {
+			return
 		}
 
-		offset := (page - 1) * pageSize
-
-		// 查询已删除的户型列表
-		query := `SELECT ht.id, ht.building_id, COALESCE(b.name, '') as building_name, ht.name, ht.code, ht.rooms, ht.halls, ht.bathrooms, 
-				 COALESCE(ht.balconies, 0) as balconies,
-				 COALESCE(ht.maid_rooms, 0) as maid_rooms,
-				 ht.standard_area, 
-				 COALESCE(ht.standard_orientation, '') as standard_orientation, 
-				 COALESCE(ht.floor_plan_url, '') as floor_plan_url,
-				 CASE WHEN ht.floor_plan_url IS NOT NULL AND ht.floor_plan_url != '' THEN true ELSE false END as has_floor_plan,
-				 ht.created_at, ht.updated_at, ht.deleted_at,
-				 COALESCE(ht.created_by, '') as created_by, 
-				 COALESCE(ht.updated_by, '') as updated_by,
-				 COALESCE(u_updated.nick_name, u_created.nick_name, ht.updated_by, ht.created_by, '系统') as editor_name
-				 FROM sys_house_types ht
-				 LEFT JOIN sys_buildings b ON ht.building_id = b.id AND b.deleted_at IS NULL
-				 LEFT JOIN sys_user u_created ON ht.created_by = u_created.username
-				 LEFT JOIN sys_user u_updated ON ht.updated_by = u_updated.username
-				 WHERE ht.building_id = ? AND ht.deleted_at IS NOT NULL
-				 ORDER BY ht.deleted_at DESC
-				 LIMIT ? OFFSET ?`
-
 		var houseTypes []HouseTypeResponse
-		err = database.DB.Raw(query, buildingId, pageSize, offset).Scan(&houseTypes).Error
+		db := database.Request(c).Where("ht.building_id = ? AND ht.deleted_at IS NOT NULL", buildingId)
+		db = visibleOnly(c, visibleOnly(c, db, "ht.status", "ht.created_by"), "b.status", "b.created_by")
+		page, err := deletedHouseTypeListQuery.Find(db, params, &houseTypes)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -445,29 +440,11 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		// 查询总数
-		var total int64
-		countQuery := "SELECT COUNT(*) FROM sys_house_types WHERE building_id = ? AND deleted_at IS NOT NULL"
-		err = database.DB.Raw(countQuery, buildingId).Scan(&total).Error
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "查询已删除户型总数失败",
-				"error":   err.Error(),
-			})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "获取已删除户型列表成功",
-			"data":    houseTypes,
-			"total":   total,
-		})
+		page.JSON(c, "获取已删除户型列表成功")
 	})
 
 	// 恢复户型（取消软删除）
-	api.POST("/house-types/:id/restore", func(c *gin.Context) {
+	api.POST("/house-types/:id/restore", middleware.JWTAuth(), func(c *gin.Context) {
 		idStr := c.Param("id")
 		id, err := strconv.ParseUint(idStr, 10, 64)
 		if err != nil {
@@ -478,10 +455,9 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		// TODO: 从JWT token获取真实用户
-		currentUser := "admin"
+		currentUser := c.GetString(middleware.ContextUsername)
 
-		result := database.DB.Exec("UPDATE sys_house_types SET deleted_at = NULL, updated_by = ? WHERE id = ? AND deleted_at IS NOT NULL", currentUser, id)
+		result := database.Request(c).Exec("UPDATE sys_house_types SET deleted_at = NULL, updated_by = ? WHERE id = ? AND deleted_at IS NOT NULL", currentUser, id)
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -506,7 +482,7 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 	})
 
 	// 永久删除户型
-	api.DELETE("/house-types/:id/permanent", func(c *gin.Context) {
+	api.DELETE("/house-types/:id/permanent", middleware.JWTAuth(), func(c *gin.Context) {
 		idStr := c.Param("id")
 		id, err := strconv.ParseUint(idStr, 10, 64)
 		if err != nil {
@@ -517,7 +493,7 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		result := database.DB.Exec("DELETE FROM sys_house_types WHERE id = ? AND deleted_at IS NOT NULL", id)
+		result := database.Request(c).Exec("DELETE FROM sys_house_types WHERE id = ? AND deleted_at IS NOT NULL", id)
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -542,7 +518,7 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 	})
 
 	// 获取户型的所有户型图
-	api.GET("/house-types/:id/floor-plans", func(c *gin.Context) {
+	api.GET("/house-types/:id/floor-plans", middleware.OptionalJWTAuth(), func(c *gin.Context) {
 		idStr := c.Param("id")
 		houseTypeId, err := strconv.ParseUint(idStr, 10, 64)
 		if err != nil {
@@ -552,6 +528,9 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			})
 			return
 		}
+		if !requireHouseTypeVisible(c, houseTypeId) {
+			return
+		}
 
 		// 查询户型图片列表
 		query := `SELECT id, name, description, file_name, file_size, url, thumbnail_url, 
@@ -561,7 +540,7 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 				 ORDER BY sort_order ASC, created_at ASC`
 
 		var images []map[string]interface{}
-		err = database.DB.Raw(query, houseTypeId).Scan(&images).Error
+		err = database.Request(c).Raw(query, houseTypeId).Scan(&images).Error
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -579,7 +558,7 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 	})
 
 	// 删除单张户型图
-	api.DELETE("/house-types/:id/floor-plans/:imageId", func(c *gin.Context) {
+	api.DELETE("/house-types/:id/floor-plans/:imageId", middleware.JWTAuth(), func(c *gin.Context) {
 		idStr := c.Param("id")
 		houseTypeId, err := strconv.ParseUint(idStr, 10, 64)
 		if err != nil {
@@ -600,13 +579,12 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		// TODO: 从JWT token获取真实用户
-		currentUser := "admin"
+		currentUser := c.GetString(middleware.ContextUsername)
 
 		// 软删除图片记录
-		result := database.DB.Exec(
-			"UPDATE sys_images SET deleted_at = NOW(), updated_by = ? WHERE id = ? AND module = 'house_floor_plan' AND module_id = ? AND deleted_at IS NULL",
-			currentUser, imageId, houseTypeId)
+		result := database.Request(c).Exec(
+			"UPDATE sys_images SET deleted_at = ?, updated_by = ? WHERE id = ? AND module = 'house_floor_plan' AND module_id = ? AND deleted_at IS NULL",
+			time.Now(), currentUser, imageId, houseTypeId)
 
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -627,16 +605,16 @@ func SetupHouseTypeRoutes(api *gin.RouterGroup) {
 
 		// 更新户型表的 floor_plan_url（如果删除的是第一张图片，则设置为下一张图片的URL）
 		var firstImageUrl string
-		err = database.DB.Raw(
+		err = database.Request(c).Clauses(dbresolver.Write).Raw(
 			"SELECT url FROM sys_images WHERE module = 'house_floor_plan' AND module_id = ? AND deleted_at IS NULL ORDER BY sort_order ASC, created_at ASC LIMIT 1",
 			houseTypeId).Scan(&firstImageUrl).Error
 
 		if err == nil {
 			// 更新户型表的 floor_plan_url
-			database.DB.Exec("UPDATE sys_house_types SET floor_plan_url = ? WHERE id = ?", firstImageUrl, houseTypeId)
+			database.Request(c).Exec("UPDATE sys_house_types SET floor_plan_url = ? WHERE id = ?", firstImageUrl, houseTypeId)
 		} else {
 			// 没有图片了，清空 floor_plan_url
-			database.DB.Exec("UPDATE sys_house_types SET floor_plan_url = NULL WHERE id = ?", houseTypeId)
+			database.Request(c).Exec("UPDATE sys_house_types SET floor_plan_url = NULL WHERE id = ?", houseTypeId)
 		}
 
 		c.JSON(http.StatusOK, gin.H{
@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"rentPro/rentpro-admin/common/middleware"
+	"rentPro/rentpro-admin/common/models/image"
+	"rentPro/rentpro-admin/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupImageCategoryRoutes 设置图片分类配置相关路由
+// 分类的大小、类型、数量限制作用于所有图片上传接口，必填分类在楼盘、户型详情中提示
+func SetupImageCategoryRoutes(api *gin.RouterGroup) {
+	// 获取图片分类列表
+	api.GET("/image-categories", func(c *gin.Context) {
+		categories, err := utils.ListImageCategories(c, c.Query("status"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "获取图片分类列表失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "获取图片分类列表成功",
+			"data":    categories,
+		})
+	})
+
+	// 获取图片分类详情
+	api.GET("/image-categories/:id", func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "图片分类ID格式错误",
+			})
+			return
+		}
+
+		category, err := utils.GetImageCategory(c, id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    404,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "获取图片分类成功",
+			"data":    category,
+		})
+	})
+
+	// 创建图片分类
+	api.POST("/image-categories", middleware.JWTAuth(), func(c *gin.Context) {
+		var req image.ImageCategoryCreateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		category, err := utils.CreateImageCategory(c, &req)
+		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "创建图片分类失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "创建图片分类成功",
+			"data":    category,
+		})
+	})
+
+	// 更新图片分类
+	api.PUT("/image-categories/:id", middleware.JWTAuth(), func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "图片分类ID格式错误",
+			})
+			return
+		}
+
+		var req image.ImageCategoryUpdateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		category, err := utils.UpdateImageCategory(c, id, &req)
+		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "更新图片分类失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "更新图片分类成功",
+			"data":    category,
+		})
+	})
+
+	// 删除图片分类
+	api.DELETE("/image-categories/:id", middleware.JWTAuth(), func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "图片分类ID格式错误",
+			})
+			return
+		}
+
+		if err := utils.DeleteImageCategory(c, id); err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "删除图片分类失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "删除图片分类成功",
+		})
+	})
+}
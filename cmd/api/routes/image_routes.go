@@ -8,10 +8,13 @@ import (
 	"time"
 
 	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/middleware"
 	"rentPro/rentpro-admin/common/models/image"
+	"rentPro/rentpro-admin/common/query"
 	"rentPro/rentpro-admin/common/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/plugin/dbresolver"
 )
 
 // SetupImageRoutes 设置图片管理相关路由
@@ -51,7 +54,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		}
 
 		// 获取图片管理器
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -63,8 +66,172 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		// 上传图片
 		img, err := imageManager.UploadImage(file, &req, userID.(uint64))
 		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "上传图片失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "图片上传成功",
+			"data":    img,
+		})
+	})
+
+	// 浏览器直传：获取上传参数和上传凭证
+	api.POST("/images/upload-token", middleware.JWTAuth(), func(c *gin.Context) {
+		var req image.DirectUploadRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		imageManager := utils.GetImageManager().WithContext(c)
+		if imageManager == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "图片管理器未初始化",
+			})
+			return
+		}
+
+		// 存储驱动不支持直传时上传到服务端的直传接口
+		fallbackURL := strings.TrimSuffix(c.FullPath(), "upload-token") + "direct-upload"
+		upload, err := imageManager.CreateDirectUpload(&req, c.GetUint64(middleware.ContextUserID), fallbackURL)
+		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "获取上传凭证失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "获取上传凭证成功",
+			"data":    upload,
+		})
+	})
+
+	// 浏览器直传：上传完成回调，由上传凭证授权
+	// 存储服务回调需带回调签名，客户端调用需登录且为申请上传凭证的用户
+	api.POST("/images/upload-callback", middleware.OptionalJWTAuth(), func(c *gin.Context) {
+		imageManager := utils.GetImageManager().WithContext(c)
+		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
+				"message": "图片管理器未初始化",
+			})
+			return
+		}
+
+		// 存储服务回调需验证签名
+		signed, err := imageManager.VerifyUploadCallback(c.Request)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		var req struct {
+			Ticket string `form:"ticket" json:"ticket" binding:"required"`
+		}
+		if err := c.ShouldBind(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+		ticket, err := utils.ParseDirectUploadTicket(req.Ticket)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": err.Error(),
+			})
+			return
+		}
+		if !signed && c.GetUint64(middleware.ContextUserID) != ticket.UserID {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "需要存储服务回调签名或申请上传凭证的用户登录",
+			})
+			return
+		}
+
+		img, err := imageManager.CompleteDirectUpload(ticket)
+		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "上传图片失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "图片上传成功",
+			"data":    img,
+		})
+	})
+
+	// 浏览器直传：存储驱动不支持直传时由服务端接收文件，需登录且为申请上传凭证的用户
+	api.POST("/images/direct-upload", middleware.JWTAuth(), func(c *gin.Context) {
+		ticket, err := utils.ParseDirectUploadTicket(c.PostForm("ticket"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": err.Error(),
+			})
+			return
+		}
+		if c.GetUint64(middleware.ContextUserID) != ticket.UserID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "只能由申请上传凭证的用户上传",
+			})
+			return
+		}
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "获取上传文件失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		imageManager := utils.GetImageManager().WithContext(c)
+		if imageManager == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "图片管理器未初始化",
+			})
+			return
+		}
+
+		img, err := imageManager.ReceiveDirectUpload(ticket, file)
+		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
 				"message": "上传图片失败",
 				"error":   err.Error(),
 			})
@@ -80,8 +247,13 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 
 	// 获取图片列表
 	api.GET("/images", func(c *gin.Context) {
-		var req image.ImageListRequest
-		if err := c.ShouldBindQuery(&req); err != nil {
+		values := c.Request.URL.Query()
+		// 兼容旧参数 orderBy/orderDir
+		if values.Get("sort") == "" && values.Get("orderBy") != "" {
+			values.Set("sort", legacyImageSort(values.Get("orderBy"), values.Get("orderDir")))
+		}
+		params, err := utils.ImageListQuery.ParseValues(values)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"code":    400,
 				"message": "请求参数错误",
@@ -90,7 +262,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -99,7 +271,15 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		result, err := imageManager.ListImages(&req)
+		// 流式导出：按游标分批输出全部匹配数据
+		if c.Query("format") == "ndjson" {
+			query.WriteNDJSON(c, func(emit func(rows interface{}) error) error {
+				return imageManager.EachImages(params, emit)
+			})
+			return
+		}
+
+		page, err := imageManager.ListImages(params)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -109,10 +289,9 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "获取图片列表成功",
-			"data":    result,
+		// 兼容迁移前的格式：data 为 {total, list}，分页字段同时放在顶层
+		page.JSONCompat(c, "获取图片列表成功", gin.H{
+			"data": gin.H{"total": page.Total, "list": page.List},
 		})
 	})
 
@@ -128,7 +307,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -184,7 +363,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -194,8 +373,10 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		}
 
 		if err := imageManager.UpdateImage(imageID, &req, userID.(uint64)); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
+			// 新分类的规则不符合时返回 400
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
 				"message": "更新图片信息失败",
 				"error":   err.Error(),
 			})
@@ -229,7 +410,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -274,7 +455,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -299,7 +480,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 	})
 
 	// 获取模块图片
-	api.GET("/images/module/:module/:moduleId", func(c *gin.Context) {
+	api.GET("/images/module/:module/:moduleId", middleware.OptionalJWTAuth(), func(c *gin.Context) {
 		module := c.Param("module")
 		moduleIDStr := c.Param("moduleId")
 		category := c.Query("category")
@@ -312,8 +493,12 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			})
 			return
 		}
+		// 楼盘、户型的图片随所属对象的审核状态可见
+		if !requireModuleVisible(c, module, moduleID) {
+			return
+		}
 
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -340,7 +525,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 	})
 
 	// 设置主图
-	api.PUT("/images/:id/set-main", func(c *gin.Context) {
+	api.PUT("/images/:id/set-main", middleware.JWTAuth(), func(c *gin.Context) {
 		id := c.Param("id")
 		imageID, err := strconv.ParseUint(id, 10, 64)
 		if err != nil {
@@ -373,7 +558,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -383,8 +568,9 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		}
 
 		if err := imageManager.SetMainImage(req.Module, req.ModuleID, imageID, userID.(uint64)); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
 				"message": "设置主图失败",
 				"error":   err.Error(),
 			})
@@ -397,9 +583,104 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		})
 	})
 
+	// 调整图库顺序（同一模块、模块ID下的图片），同步所属对象的封面和图片列表
+	api.PUT("/images/module/:module/:moduleId/order", middleware.JWTAuth(), func(c *gin.Context) {
+		module := c.Param("module")
+		moduleID, err := strconv.ParseUint(c.Param("moduleId"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "模块ID格式错误",
+			})
+			return
+		}
+
+		var req struct {
+			IDs []uint64 `json:"ids" binding:"required,min=1"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		imageManager := utils.GetImageManager().WithContext(c)
+		if imageManager == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "图片管理器未初始化",
+			})
+			return
+		}
+
+		images, err := imageManager.ReorderImages(module, moduleID, req.IDs, c.GetUint64(middleware.ContextUserID))
+		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "调整图片顺序失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "调整图片顺序成功",
+			"data":    images,
+		})
+	})
+
+	// 移动图片到另一个对象的图库（如户型图片移动到具体房屋）
+	api.POST("/images/move", middleware.JWTAuth(), func(c *gin.Context) {
+		var req struct {
+			IDs      []uint64 `json:"ids" binding:"required,min=1"`
+			Module   string   `json:"module" binding:"required"`
+			ModuleID uint64   `json:"moduleId" binding:"required"`
+			Category string   `json:"category"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		imageManager := utils.GetImageManager().WithContext(c)
+		if imageManager == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "图片管理器未初始化",
+			})
+			return
+		}
+
+		images, err := imageManager.MoveImages(req.IDs, req.Module, req.ModuleID, req.Category, c.GetUint64(middleware.ContextUserID))
+		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "移动图片失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": fmt.Sprintf("成功移动%d张图片", len(images)),
+			"data":    images,
+		})
+	})
+
 	// 获取图片统计信息
 	api.GET("/images/stats", func(c *gin.Context) {
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -448,12 +729,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		}
 
 		// 解析token
-		jwtInstance := &utils.JWT{
-			Config: utils.JWTConfig{
-				Secret:  "rentpro-admin-secret-key",
-				Timeout: 86400, // 24 hours in seconds
-			},
-		}
+		jwtInstance := middleware.NewJWT()
 		claims, err := jwtInstance.ParseToken(tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -499,7 +775,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		var houseType struct {
 			BuildingID uint `json:"building_id"`
 		}
-		result := database.DB.Table("sys_house_types").Where("id = ? AND deleted_at IS NULL", houseTypeID).First(&houseType)
+		result := database.Request(c).Clauses(dbresolver.Write).Table("sys_house_types").Where("id = ? AND deleted_at IS NULL", houseTypeID).First(&houseType)
 		if result.Error != nil {
 			c.JSON(http.StatusNotFound, gin.H{
 				"code":    404,
@@ -520,7 +796,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		}
 
 		// 使用图片管理器上传楼盘户型图
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -532,27 +808,16 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		// 上传楼盘户型图
 		img, err := imageManager.UploadBuildingFloorPlan(file, uint64(houseType.BuildingID), houseTypeID, uint64(userID))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
 				"message": "上传户型图失败",
 				"error":   err.Error(),
 			})
 			return
 		}
 
-		// 更新户型的floor_plan_url
-		updateResult := database.DB.Model(&struct{}{}).Table("sys_house_types").Where("id = ?", houseTypeID).Update("floor_plan_url", img.URL)
-		if updateResult.Error != nil {
-			// 如果数据库更新失败，删除已上传的文件
-			imageManager.DeleteImage(img.ID, uint64(userID))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "更新数据库失败",
-				"error":   updateResult.Error.Error(),
-			})
-			return
-		}
-
+		// 户型的 floor_plan_url 由图库按主图同步，不在这里覆盖
 		c.JSON(http.StatusOK, gin.H{
 			"code":    200,
 			"message": "户型图上传成功",
@@ -595,7 +860,7 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			BuildingID uint   `json:"building_id"`
 			Name       string `json:"name"`
 		}
-		result := database.DB.Table("sys_house_types").Where("id = ? AND deleted_at IS NULL", houseTypeID).First(&houseType)
+		result := database.Request(c).Clauses(dbresolver.Write).Table("sys_house_types").Where("id = ? AND deleted_at IS NULL", houseTypeID).First(&houseType)
 		if result.Error != nil {
 			c.JSON(http.StatusNotFound, gin.H{
 				"code":    404,
@@ -624,16 +889,8 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		if len(files) > 5 {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    400,
-				"message": "一次最多只能上传5张图片",
-			})
-			return
-		}
-
 		// 使用图片管理器上传多张户型图
-		imageManager := utils.GetImageManager()
+		imageManager := utils.GetImageManager().WithContext(c)
 		if imageManager == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -645,8 +902,9 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		// 批量上传户型图
 		images, err := imageManager.UploadHouseTypeFloorPlans(files, houseTypeID, uint64(userID))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
 				"message": "上传户型图失败",
 				"error":   err.Error(),
 			})
@@ -660,3 +918,26 @@ func SetupImageRoutes(api *gin.RouterGroup) {
 		})
 	})
 }
+
+// uploadErrorStatus 上传内容不符合图片分类等要求时返回 400，其他错误返回 500
+func uploadErrorStatus(err error) int {
+	if utils.IsRejected(err) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// legacyImageSort 将旧的 orderBy（列名）/orderDir 参数转换为 sort 参数
+func legacyImageSort(orderBy, orderDir string) string {
+	field := orderBy
+	for name, f := range utils.ImageListQuery.Fields {
+		if f.Column == orderBy {
+			field = name
+			break
+		}
+	}
+	if orderDir == "asc" {
+		return field
+	}
+	return "-" + field
+}
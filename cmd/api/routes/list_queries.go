@@ -0,0 +1,164 @@
+package routes
+
+import (
+	"net/http"
+
+	"rentPro/rentpro-admin/common/query"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 列表接口的查询定义
+// 每个定义声明可筛选/可排序字段，数据查询和总数查询都由它生成
+
+// buildingListQuery 楼盘列表
+var buildingListQuery = &query.Definition{
+	Table: "sys_buildings b",
+	Joins: []string{
+		"LEFT JOIN sys_user u_created ON b.created_by = u_created.username",
+		"LEFT JOIN sys_user u_updated ON b.updated_by = u_updated.username",
+	},
+	Select: `b.id, b.name, b.city, b.district, b.business_area, b.property_type, b.status, b.rent_count,
+		b.created_at, b.updated_at, b.created_by, b.updated_by, b.deleted_at,
+		COALESCE(u_updated.nick_name, u_created.nick_name, b.updated_by, b.created_by) as editor_name`,
+	Key: "b.id",
+	Fields: map[string]query.Field{
+		"id":            {Column: "b.id", Type: query.Int, Ops: query.EnumOps, Sortable: true},
+		"name":          {Column: "b.name", Ops: query.TextOps, Sortable: true},
+		"city":          {Column: "b.city", Ops: query.EnumOps},
+		"district":      {Column: "b.district", Ops: query.EnumOps},
+		"business_area": {Column: "b.business_area", Ops: query.EnumOps},
+		"property_type": {Column: "b.property_type", Ops: query.EnumOps},
+		"status":        {Column: "b.status", Ops: query.EnumOps},
+		"rent_count":    {Column: "b.rent_count", Type: query.Int, Ops: query.RangeOps, Sortable: true},
+		"created_by":    {Column: "b.created_by", Ops: query.EnumOps},
+		"created_at":    {Column: "b.created_at", Type: query.Time, Ops: query.RangeOps, Sortable: true},
+		"updated_at":    {Column: "b.updated_at", Type: query.Time, Ops: query.RangeOps, Sortable: true},
+		"deleted_at":    {Column: "b.deleted_at", Type: query.Time, Ops: query.RangeOps, Sortable: true},
+	},
+	DefaultSort: "-rent_count,created_at",
+}
+
+// districtListQuery 区域列表（字典数据，默认一次返回全部）
+var districtListQuery = &query.Definition{
+	Table:  "sys_districts",
+	Select: "id, code, name, city_code, city_id, sort, status",
+	Key:    "id",
+	Fields: map[string]query.Field{
+		"cityId":   {Column: "city_id", Type: query.Int, Ops: query.EnumOps},
+		"cityCode": {Column: "city_code", Ops: query.EnumOps},
+		"name":     {Column: "name", Ops: query.TextOps, Sortable: true},
+		"sort":     {Column: "sort", Type: query.Int, Sortable: true},
+	},
+	DefaultSort:     "sort",
+	DefaultPageSize: 1000,
+	MaxPageSize:     1000,
+}
+
+// businessAreaListQuery 商圈列表（字典数据，默认一次返回全部）
+var businessAreaListQuery = &query.Definition{
+	Table:  "sys_business_areas",
+	Select: "id, code, name, district_id, city_code, sort, status",
+	Key:    "id",
+	Fields: map[string]query.Field{
+		"districtId": {Column: "district_id", Type: query.Int, Ops: query.EnumOps},
+		"cityCode":   {Column: "city_code", Ops: query.EnumOps},
+		"name":       {Column: "name", Ops: query.TextOps, Sortable: true},
+		"sort":       {Column: "sort", Type: query.Int, Sortable: true},
+	},
+	DefaultSort:     "sort",
+	DefaultPageSize: 1000,
+	MaxPageSize:     1000,
+}
+
+// houseTypeListQuery 楼盘下的户型列表
+var houseTypeListQuery = &query.Definition{
+	Table: "sys_house_types ht",
+	Joins: []string{
+		"LEFT JOIN sys_buildings b ON ht.building_id = b.id AND b.deleted_at IS NULL",
+		"LEFT JOIN sys_user u_created ON ht.created_by = u_created.username",
+		"LEFT JOIN sys_user u_updated ON ht.updated_by = u_updated.username",
+	},
+	Select: `ht.id, ht.building_id, COALESCE(b.name, '') as building_name, ht.name, ht.code, ht.rooms, ht.halls, ht.bathrooms,
+		COALESCE(ht.balconies, 0) as balconies,
+		COALESCE(ht.maid_rooms, 0) as maid_rooms,
+		ht.standard_area,
+		COALESCE(ht.standard_orientation, '') as standard_orientation,
+		COALESCE(ht.floor_plan_url, '') as floor_plan_url,
+		CASE WHEN ht.floor_plan_url IS NOT NULL AND ht.floor_plan_url != '' THEN true ELSE false END as has_floor_plan,
+		ht.created_at, ht.updated_at,
+		COALESCE(ht.created_by, '') as created_by,
+		COALESCE(ht.updated_by, '') as updated_by,
+		COALESCE(u_updated.nick_name, u_created.nick_name, ht.updated_by, ht.created_by, '系统') as editor_name`,
+	Key: "ht.id",
+	Fields: map[string]query.Field{
+		"name":        {Column: "ht.name", Ops: query.TextOps, Sortable: true},
+		"code":        {Column: "ht.code", Ops: query.TextOps, Sortable: true},
+		"rooms":       {Column: "ht.rooms", Type: query.Int, Ops: query.RangeOps, Sortable: true},
+		"halls":       {Column: "ht.halls", Type: query.Int, Ops: query.RangeOps},
+		"bathrooms":   {Column: "ht.bathrooms", Type: query.Int, Ops: query.RangeOps},
+		"area":        {Column: "ht.standard_area", Type: query.Float, Ops: query.RangeOps, Sortable: true},
+		"price":       {Column: "ht.base_rent_price", Type: query.Float, Ops: query.RangeOps, Sortable: true},
+		"orientation": {Column: "ht.standard_orientation", Ops: query.EnumOps},
+		"status":      {Column: "ht.status", Ops: query.EnumOps},
+		"created_at":  {Column: "ht.created_at", Type: query.Time, Ops: query.RangeOps, Sortable: true},
+		"updated_at":  {Column: "ht.updated_at", Type: query.Time, Ops: query.RangeOps, Sortable: true},
+		"deleted_at":  {Column: "ht.deleted_at", Type: query.Time, Ops: query.RangeOps, Sortable: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+// deletedHouseTypeListQuery 回收站户型列表，字段同户型列表，默认按删除时间倒序
+var deletedHouseTypeListQuery = func() *query.Definition {
+	def := *houseTypeListQuery
+	def.DefaultSort = "-deleted_at"
+	return &def
+}()
+
+// cityListQuery 城市列表
+var cityListQuery = &query.Definition{
+	Table: "sys_cities",
+	Key:   "id",
+	Fields: map[string]query.Field{
+		"code":       {Column: "code", Ops: query.EnumOps},
+		"name":       {Column: "name", Ops: query.TextOps, Sortable: true},
+		"status":     {Column: "status", Ops: query.EnumOps},
+		"sort":       {Column: "sort", Type: query.Int, Sortable: true},
+		"created_at": {Column: "created_at", Type: query.Time, Ops: query.RangeOps, Sortable: true},
+	},
+	DefaultSort:     "sort",
+	DefaultPageSize: 100,
+	MaxPageSize:     1000,
+}
+
+// userListQuery 用户列表（不返回密码、盐等敏感字段）
+var userListQuery = &query.Definition{
+	Table: "sys_user",
+	Select: `id, username, nick_name, avatar, email, phone, status, is_admin, remark,
+		dept_id, post_id, role_id, last_login_ip, last_login_at, created_at, updated_at`,
+	Key: "id",
+	Fields: map[string]query.Field{
+		"username":   {Column: "username", Ops: query.TextOps, Sortable: true},
+		"nick_name":  {Column: "nick_name", Ops: query.TextOps, Sortable: true},
+		"phone":      {Column: "phone", Ops: query.TextOps},
+		"status":     {Column: "status", Type: query.Int, Ops: query.EnumOps},
+		"dept_id":    {Column: "dept_id", Type: query.Int, Ops: query.EnumOps},
+		"role_id":    {Column: "role_id", Type: query.Int, Ops: query.EnumOps},
+		"created_at": {Column: "created_at", Type: query.Time, Ops: query.RangeOps, Sortable: true},
+	},
+	DefaultSort: "id",
+}
+
+// parseListParams 解析列表查询参数，参数不合法时直接返回400
+func parseListParams(c *gin.Context, def *query.Definition) (*query.Params, bool) {
+	params, err := def.Parse(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "查询参数错误",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	return params, true
+}
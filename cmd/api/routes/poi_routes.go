@@ -0,0 +1,711 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/middleware"
+	"rentPro/rentpro-admin/common/models/rental"
+	"rentPro/rentpro-admin/common/query"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// poiEditPermission POI字典维护权限，POI被所有楼盘共用，只有拥有该权限的用户可以增删改
+const poiEditPermission = "rental:poi:edit"
+
+// SetupPoiRoutes 设置周边POI字典和楼盘配套相关路由
+func SetupPoiRoutes(api *gin.RouterGroup) {
+	poiGroup := api.Group("/pois")
+	{
+		// 未登录或没有审核权限时只返回启用的POI
+		poiGroup.GET("", middleware.OptionalJWTAuth(), getPois)           // 获取POI列表
+		poiGroup.GET("/categories", getPoiCategories)                     // 获取POI分类
+		poiGroup.GET("/lines", middleware.OptionalJWTAuth(), getPoiLines) // 获取城市的地铁/公交线路
+		poiGroup.GET("/:id", middleware.OptionalJWTAuth(), getPoiByID)    // 根据ID获取POI
+		// 维护POI字典需要 rental:poi:edit 权限
+		poiGroup.POST("", middleware.JWTAuth(), middleware.RequirePermission(poiEditPermission), createPoi)       // 创建POI
+		poiGroup.PUT("/:id", middleware.JWTAuth(), middleware.RequirePermission(poiEditPermission), updatePoi)    // 更新POI
+		poiGroup.DELETE("/:id", middleware.JWTAuth(), middleware.RequirePermission(poiEditPermission), deletePoi) // 删除POI
+	}
+
+	api.GET("/amenities", getAmenityOptions) // 获取配套设施选项
+	// 楼盘需对当前请求可见（见 visibleOnly）
+	api.GET("/buildings/:id/pois", middleware.OptionalJWTAuth(), getBuildingPois)           // 获取楼盘周边POI
+	api.PUT("/buildings/:id/pois", middleware.JWTAuth(), saveBuildingPois)                  // 保存楼盘周边POI
+	api.GET("/buildings/:id/amenities", middleware.OptionalJWTAuth(), getBuildingAmenities) // 获取楼盘配套设施
+	api.PUT("/buildings/:id/amenities", middleware.JWTAuth(), saveBuildingAmenities)        // 保存楼盘配套设施
+}
+
+// poiListQuery POI列表
+var poiListQuery = &query.Definition{
+	Table: "sys_pois",
+	Key:   "id",
+	Fields: map[string]query.Field{
+		"cityCode": {Column: "city_code", Ops: query.EnumOps},
+		"category": {Column: "category", Ops: query.EnumOps},
+		"line":     {Column: "line", Ops: query.EnumOps},
+		"name":     {Column: "name", Ops: query.TextOps, Sortable: true},
+		"status":   {Column: "status", Ops: query.EnumOps},
+		"sort":     {Column: "sort", Type: query.Int, Sortable: true},
+	},
+	DefaultSort:     "sort",
+	DefaultPageSize: 50,
+	MaxPageSize:     1000,
+}
+
+// PoiRequest 创建/更新POI请求
+type PoiRequest struct {
+	CityCode  string  `json:"cityCode" binding:"required"`
+	Category  string  `json:"category" binding:"required"`
+	Name      string  `json:"name" binding:"required"`
+	Line      string  `json:"line"`
+	Address   string  `json:"address"`
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+	Sort      int     `json:"sort"`
+	Status    string  `json:"status"`
+}
+
+// BuildingPoiResponse 楼盘周边POI响应结构
+type BuildingPoiResponse struct {
+	PoiID       uint64 `json:"poiId"`
+	Category    string `json:"category"`
+	Name        string `json:"name"`
+	Line        string `json:"line"`
+	Address     string `json:"address"`
+	Distance    int    `json:"distance"`
+	WalkMinutes int    `json:"walkMinutes"`
+}
+
+// getPois 获取POI列表
+func getPois(c *gin.Context) {
+	params, ok := parseListParams(c, poiListQuery)
+	if !ok {
+		return
+	}
+
+	var pois []rental.SysPoi
+	page, err := poiListQuery.Find(activePoisOnly(c, database.Request(c), "status"), params, &pois)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取POI列表失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	page.JSON(c, "获取POI列表成功")
+}
+
+// activePoisOnly 未登录或没有审核权限时只返回启用的POI，column 为状态列
+func activePoisOnly(c *gin.Context, db *gorm.DB, column string) *gorm.DB {
+	if canViewUnpublished(c) {
+		return db
+	}
+	return db.Where(column+" = ?", "active")
+}
+
+// getPoiCategories 获取POI分类
+func getPoiCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取POI分类成功",
+		"data":    rental.PoiCategories,
+	})
+}
+
+// getPoiLines 获取城市的线路列表（默认地铁）
+func getPoiLines(c *gin.Context) {
+	category := c.DefaultQuery("category", rental.PoiCategorySubway)
+
+	db := database.Request(c).Model(&rental.SysPoi{}).Where("category = ? AND line <> ''", category)
+	db = activePoisOnly(c, db, "status")
+	if cityCode := c.Query("cityCode"); cityCode != "" {
+		db = db.Where("city_code = ?", cityCode)
+	}
+
+	var lines []string
+	if err := db.Distinct("line").Order("line ASC").Pluck("line", &lines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取线路列表失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取线路列表成功",
+		"data":    lines,
+	})
+}
+
+// getPoiByID 根据ID获取POI
+func getPoiByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的POI ID",
+		})
+		return
+	}
+
+	var poi rental.SysPoi
+	if err := activePoisOnly(c, database.Request(c), "status").First(&poi, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": "POI不存在",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取POI成功",
+		"data":    poi,
+	})
+}
+
+// createPoi 创建POI
+func createPoi(c *gin.Context) {
+	var req PoiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if _, ok := rental.PoiCategories[req.Category]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "不支持的POI分类",
+		})
+		return
+	}
+
+	poi := rental.SysPoi{}
+	req.applyTo(&poi)
+	if err := database.Request(c).Create(&poi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "创建POI失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "创建POI成功",
+		"data":    poi,
+	})
+}
+
+// updatePoi 更新POI
+func updatePoi(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的POI ID",
+		})
+		return
+	}
+
+	var req PoiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if _, ok := rental.PoiCategories[req.Category]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "不支持的POI分类",
+		})
+		return
+	}
+
+	// 读取后整行写回，从主库读取避免用副本上的旧数据覆盖
+	var poi rental.SysPoi
+	if err := database.Request(c).Clauses(dbresolver.Write).First(&poi, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": "POI不存在",
+		})
+		return
+	}
+
+	locationChanged := poi.Longitude != req.Longitude || poi.Latitude != req.Latitude
+	req.applyTo(&poi)
+	err = database.Request(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&poi).Error; err != nil {
+			return err
+		}
+		// 坐标变更后重新计算与各楼盘的距离
+		if locationChanged {
+			return recomputePoiDistances(tx, "poi_id", poi.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "更新POI失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新POI成功",
+		"data":    poi,
+	})
+}
+
+// deletePoi 删除POI及其与楼盘的关联
+func deletePoi(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的POI ID",
+		})
+		return
+	}
+
+	var deleted int64
+	err = database.Request(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("poi_id = ?", id).Delete(&rental.SysBuildingPoi{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&rental.SysPoi{}, id)
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "删除POI失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": "POI不存在",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "删除POI成功",
+	})
+}
+
+// applyTo 将请求内容写入POI模型
+func (req *PoiRequest) applyTo(poi *rental.SysPoi) {
+	poi.CityCode = req.CityCode
+	poi.Category = req.Category
+	poi.Name = req.Name
+	poi.Line = req.Line
+	poi.Address = req.Address
+	poi.Longitude = req.Longitude
+	poi.Latitude = req.Latitude
+	poi.Sort = req.Sort
+	poi.Status = req.Status
+	if poi.Status == "" {
+		poi.Status = "active"
+	}
+}
+
+// getAmenityOptions 获取配套设施选项
+func getAmenityOptions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取配套设施选项成功",
+		"data":    rental.Amenities,
+	})
+}
+
+// getBuildingPois 获取楼盘周边POI（按分类、距离排序）
+func getBuildingPois(c *gin.Context) {
+	buildingID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "楼盘ID格式错误",
+		})
+		return
+	}
+	if !requireBuildingVisible(c, buildingID) {
+		return
+	}
+
+	db := database.Request(c).Table("sys_building_pois bp").
+		Select("p.id as poi_id, p.category, p.name, p.line, p.address, bp.distance, bp.walk_minutes").
+		Joins("JOIN sys_pois p ON p.id = bp.poi_id").
+		Where("bp.building_id = ?", buildingID)
+	db = activePoisOnly(c, db, "p.status")
+	if category := c.Query("category"); category != "" {
+		db = db.Where("p.category = ?", category)
+	}
+
+	var pois []BuildingPoiResponse
+	if err := db.Order("p.category ASC, bp.distance ASC").Scan(&pois).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取楼盘周边POI失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取楼盘周边POI成功",
+		"data":    pois,
+	})
+}
+
+// saveBuildingPois 保存楼盘周边POI（整体替换）
+// 未提供距离时，根据楼盘和POI坐标计算直线距离
+func saveBuildingPois(c *gin.Context) {
+	buildingID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "楼盘ID格式错误",
+		})
+		return
+	}
+
+	var req struct {
+		Items []struct {
+			PoiID    uint64 `json:"poiId" binding:"required"`
+			Distance *int   `json:"distance"`
+		} `json:"items"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 按楼盘和POI坐标计算距离后写入，从主库读取
+	var building rental.SysBuildings
+	if err := database.Request(c).Clauses(dbresolver.Write).Where("id = ? AND deleted_at IS NULL", buildingID).First(&building).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": "楼盘不存在",
+		})
+		return
+	}
+
+	poiIDs := make([]uint64, 0, len(req.Items))
+	for _, item := range req.Items {
+		poiIDs = append(poiIDs, item.PoiID)
+	}
+	var pois []rental.SysPoi
+	if len(poiIDs) > 0 {
+		if err := database.Request(c).Clauses(dbresolver.Write).Where("id IN ?", poiIDs).Find(&pois).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "查询POI失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+	poiMap := make(map[uint64]rental.SysPoi, len(pois))
+	for _, poi := range pois {
+		poiMap[poi.ID] = poi
+	}
+
+	links := make([]rental.SysBuildingPoi, 0, len(req.Items))
+	seen := make(map[uint64]bool, len(req.Items))
+	for _, item := range req.Items {
+		poi, ok := poiMap[item.PoiID]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": fmt.Sprintf("POI %d 不存在", item.PoiID),
+			})
+			return
+		}
+		if seen[item.PoiID] {
+			continue
+		}
+		seen[item.PoiID] = true
+
+		var distance int
+		switch {
+		case item.Distance != nil && *item.Distance >= 0:
+			distance = *item.Distance
+		case poi.HasLocation() && (building.Longitude != 0 || building.Latitude != 0):
+			distance = rental.Distance(building.Longitude, building.Latitude, poi.Longitude, poi.Latitude)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": fmt.Sprintf("POI %s 缺少距离，且楼盘或POI没有坐标", poi.Name),
+			})
+			return
+		}
+
+		links = append(links, rental.SysBuildingPoi{
+			BuildingID:  buildingID,
+			PoiID:       poi.ID,
+			Distance:    distance,
+			WalkMinutes: rental.WalkMinutes(distance),
+		})
+	}
+
+	err = database.Request(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("building_id = ?", buildingID).Delete(&rental.SysBuildingPoi{}).Error; err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		return tx.Create(&links).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "保存楼盘周边POI失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "保存楼盘周边POI成功",
+		"data":    links,
+	})
+}
+
+// getBuildingAmenities 获取楼盘配套设施
+func getBuildingAmenities(c *gin.Context) {
+	buildingID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "楼盘ID格式错误",
+		})
+		return
+	}
+	if !requireBuildingVisible(c, buildingID) {
+		return
+	}
+
+	var amenities []rental.SysBuildingAmenity
+	if err := database.Request(c).Where("building_id = ?", buildingID).Order("id ASC").Find(&amenities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取楼盘配套设施失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	data := make([]gin.H, 0, len(amenities))
+	for _, amenity := range amenities {
+		data = append(data, gin.H{
+			"code":   amenity.Code,
+			"name":   rental.Amenities[amenity.Code],
+			"remark": amenity.Remark,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取楼盘配套设施成功",
+		"data":    data,
+	})
+}
+
+// saveBuildingAmenities 保存楼盘配套设施（整体替换）
+func saveBuildingAmenities(c *gin.Context) {
+	buildingID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "楼盘ID格式错误",
+		})
+		return
+	}
+
+	var req struct {
+		Items []struct {
+			Code   string `json:"code" binding:"required"`
+			Remark string `json:"remark"`
+		} `json:"items"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	amenities := make([]rental.SysBuildingAmenity, 0, len(req.Items))
+	seen := make(map[string]bool, len(req.Items))
+	for _, item := range req.Items {
+		if _, ok := rental.Amenities[item.Code]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": fmt.Sprintf("不支持的配套设施: %s", item.Code),
+			})
+			return
+		}
+		if seen[item.Code] {
+			continue
+		}
+		seen[item.Code] = true
+		amenities = append(amenities, rental.SysBuildingAmenity{
+			BuildingID: buildingID,
+			Code:       item.Code,
+			Remark:     item.Remark,
+		})
+	}
+
+	err = database.Request(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("building_id = ?", buildingID).Delete(&rental.SysBuildingAmenity{}).Error; err != nil {
+			return err
+		}
+		if len(amenities) == 0 {
+			return nil
+		}
+		return tx.Create(&amenities).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "保存楼盘配套设施失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "保存楼盘配套设施成功",
+		"data":    amenities,
+	})
+}
+
+// recomputePoiDistances 按楼盘和POI的当前坐标重新计算周边POI的距离和步行时间
+// 楼盘或POI坐标变更后在同一事务中调用，column 为 building_id 或 poi_id；楼盘或POI没有坐标的关联保持原距离
+func recomputePoiDistances(tx *gorm.DB, column string, id uint64) error {
+	var rows []struct {
+		ID           uint64
+		BuildingLng  float64
+		BuildingLat  float64
+		PoiLongitude float64
+		PoiLatitude  float64
+	}
+	err := tx.Table("sys_building_pois bp").
+		Select("bp.id, b.longitude as building_lng, b.latitude as building_lat, p.longitude as poi_longitude, p.latitude as poi_latitude").
+		Joins("JOIN sys_buildings b ON b.id = bp.building_id").
+		Joins("JOIN sys_pois p ON p.id = bp.poi_id").
+		Where("bp."+column+" = ?", id).
+		Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("查询楼盘周边POI失败: %v", err)
+	}
+
+	for _, row := range rows {
+		if (row.BuildingLng == 0 && row.BuildingLat == 0) || (row.PoiLongitude == 0 && row.PoiLatitude == 0) {
+			continue
+		}
+		distance := rental.Distance(row.BuildingLng, row.BuildingLat, row.PoiLongitude, row.PoiLatitude)
+		err := tx.Model(&rental.SysBuildingPoi{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+			"distance":     distance,
+			"walk_minutes": rental.WalkMinutes(distance),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("更新周边POI距离失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// applyNearbyFilters 楼盘列表的周边和配套筛选
+//   - near_line=10号线：附近有该线路的站点
+//   - near_category=subway：附近有该分类的POI
+//   - near_poi=123：附近有指定POI
+//   - within=800：与上述POI的距离不超过800米
+//   - amenities=gym,pool：同时具备全部配套设施
+func applyNearbyFilters(c *gin.Context, db *gorm.DB) (*gorm.DB, error) {
+	line := c.Query("near_line")
+	category := c.Query("near_category")
+	poiID := c.Query("near_poi")
+	within := c.Query("within")
+
+	if line != "" || category != "" || poiID != "" || within != "" {
+		// 停用的POI不参与筛选
+		conditions := []string{"bp.building_id = b.id", "p.status = 'active'"}
+		var args []interface{}
+		if line != "" {
+			conditions = append(conditions, "p.line = ?")
+			args = append(args, line)
+		}
+		if category != "" {
+			conditions = append(conditions, "p.category = ?")
+			args = append(args, category)
+		}
+		if poiID != "" {
+			id, err := strconv.ParseUint(poiID, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("参数 near_poi 格式错误")
+			}
+			conditions = append(conditions, "p.id = ?")
+			args = append(args, id)
+		}
+		if within != "" {
+			distance, err := strconv.Atoi(within)
+			if err != nil || distance < 0 {
+				return nil, fmt.Errorf("参数 within 格式错误")
+			}
+			conditions = append(conditions, "bp.distance <= ?")
+			args = append(args, distance)
+		}
+		db = db.Where("EXISTS (SELECT 1 FROM sys_building_pois bp JOIN sys_pois p ON p.id = bp.poi_id WHERE "+
+			strings.Join(conditions, " AND ")+")", args...)
+	}
+
+	if amenities := c.Query("amenities"); amenities != "" {
+		for _, code := range strings.Split(amenities, ",") {
+			code = strings.TrimSpace(code)
+			if code == "" {
+				continue
+			}
+			db = db.Where("EXISTS (SELECT 1 FROM sys_building_amenities ba WHERE ba.building_id = b.id AND ba.code = ?)", code)
+		}
+	}
+
+	return db, nil
+}
@@ -0,0 +1,283 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/middleware"
+	"rentPro/rentpro-admin/common/models/rental"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// reviewPermission 楼盘、户型审核权限
+	reviewPermission = "rental:building:review"
+	// viewPermission 楼盘、户型查看权限
+	viewPermission = "rental:building:view"
+)
+
+// reviewTarget 可审核的对象
+type reviewTarget struct {
+	Type  string
+	Title string
+	Table string
+}
+
+var (
+	buildingReviewTarget  = reviewTarget{Type: rental.ReviewTargetBuilding, Title: "楼盘", Table: "sys_buildings"}
+	houseTypeReviewTarget = reviewTarget{Type: rental.ReviewTargetHouseType, Title: "户型", Table: "sys_house_types"}
+)
+
+// SetupReviewRoutes 设置审核流程相关路由
+// 新建或编辑的楼盘、户型进入审核中(pending)，审核人通过后变为 active 对外展示，驳回后退回编辑人修改
+func SetupReviewRoutes(api *gin.RouterGroup) {
+	for _, target := range []reviewTarget{buildingReviewTarget, houseTypeReviewTarget} {
+		prefix := "/buildings"
+		if target.Type == rental.ReviewTargetHouseType {
+			prefix = "/house-types"
+		}
+
+		// 审核（通过/驳回）
+		api.POST(prefix+"/:id/review", middleware.JWTAuth(), middleware.RequirePermission(reviewPermission), reviewHandler(target))
+		// 审核历史（驳回意见只对管理端可见）
+		api.GET(prefix+"/:id/reviews", middleware.JWTAuth(), middleware.RequirePermission(viewPermission), reviewHistoryHandler(target))
+	}
+}
+
+// reviewHandler 审核通过或驳回
+func reviewHandler(target reviewTarget) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": fmt.Sprintf("无效的%sID", target.Title),
+			})
+			return
+		}
+
+		var req struct {
+			Action  string `json:"action" binding:"required,oneof=approve reject"`
+			Comment string `json:"comment" binding:"max=500"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+		req.Comment = strings.TrimSpace(req.Comment)
+		if req.Action == rental.ReviewActionReject && req.Comment == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "驳回时必须填写审核意见",
+			})
+			return
+		}
+
+		toStatus := rental.StatusActive
+		if req.Action == rental.ReviewActionReject {
+			toStatus = rental.StatusRejected
+		}
+		operator := c.GetString(middleware.ContextUsername)
+
+		err = database.Request(c).Transaction(func(tx *gorm.DB) error {
+			// 只能审核处于审核中的数据，条件更新避免并发重复审核
+			result := tx.Exec(
+				"UPDATE "+target.Table+" SET status = ?, updated_at = ? WHERE id = ? AND status = ? AND deleted_at IS NULL",
+				toStatus, time.Now(), id, rental.StatusPending,
+			)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errNotPending
+			}
+			return recordReview(tx, target, id, req.Action, rental.StatusPending, toStatus, req.Comment, operator)
+		})
+
+		if err == errNotPending {
+			c.JSON(http.StatusConflict, gin.H{
+				"code":    409,
+				"message": fmt.Sprintf("%s不存在或不在审核中", target.Title),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": fmt.Sprintf("审核%s失败", target.Title),
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		message := "审核通过"
+		if req.Action == rental.ReviewActionReject {
+			message = "已驳回"
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": message,
+			"data": gin.H{
+				"id":     id,
+				"status": toStatus,
+			},
+		})
+	}
+}
+
+// reviewHistoryHandler 查询审核历史（按时间倒序）
+func reviewHistoryHandler(target reviewTarget) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": fmt.Sprintf("无效的%sID", target.Title),
+			})
+			return
+		}
+
+		var records []rental.SysReviewRecord
+		err = database.Request(c).Where("target_type = ? AND target_id = ?", target.Type, id).
+			Order("id DESC").
+			Find(&records).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "查询审核历史失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "获取审核历史成功",
+			"data":    records,
+		})
+	}
+}
+
+// errNotPending 审核对象不存在或不在审核中
+var errNotPending = fmt.Errorf("not pending")
+
+// recordReview 写入一条审核记录
+func recordReview(tx *gorm.DB, target reviewTarget, id uint64, action, fromStatus, toStatus, comment, operator string) error {
+	return tx.Create(&rental.SysReviewRecord{
+		TargetType: target.Type,
+		TargetID:   id,
+		Action:     action,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+		Comment:    comment,
+		Operator:   operator,
+	}).Error
+}
+
+// errReviewTargetNotFound 提交审核的楼盘或户型不存在
+var errReviewTargetNotFound = fmt.Errorf("review target not found")
+
+// submitForReview 将楼盘或户型置为审核中并记录提交
+// 新建和编辑后调用，tx 需在同一事务中完成数据写入；对象不存在或已删除时返回 errReviewTargetNotFound
+func submitForReview(tx *gorm.DB, target reviewTarget, id uint64, operator string) error {
+	var rows []struct {
+		Status string
+	}
+	if err := tx.Raw("SELECT status FROM "+target.Table+" WHERE id = ? AND deleted_at IS NULL", id).Scan(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return errReviewTargetNotFound
+	}
+	if err := tx.Exec("UPDATE "+target.Table+" SET status = ? WHERE id = ?", rental.StatusPending, id).Error; err != nil {
+		return err
+	}
+	return recordReview(tx, target, id, rental.ReviewActionSubmit, rows[0].Status, rental.StatusPending, "", operator)
+}
+
+// canViewUnpublished 当前请求能否查看未审核通过的数据
+// 需要登录且为管理员或拥有审核权限；published=true 时按对外展示处理（用于管理端预览）
+func canViewUnpublished(c *gin.Context) bool {
+	if c.Query("published") == "true" || c.GetString(middleware.ContextUsername) == "" {
+		return false
+	}
+	return middleware.HasPermission(c, reviewPermission)
+}
+
+// visibleOnly 按当前请求限制楼盘、户型的可见范围
+// 审核人员可以看到全部数据；其他登录用户只能看到审核通过的数据和自己提交的数据；未登录时只能看到审核通过的数据
+// statusColumn、createdByColumn 为状态列和创建人列，如 "b.status"、"b.created_by"
+func visibleOnly(c *gin.Context, db *gorm.DB, statusColumn, createdByColumn string) *gorm.DB {
+	if canViewUnpublished(c) {
+		return db
+	}
+	if username := c.GetString(middleware.ContextUsername); username != "" && c.Query("published") != "true" {
+		return db.Where("("+statusColumn+" = ? OR "+createdByColumn+" = ?)", rental.StatusActive, username)
+	}
+	return publishedOnly(db, statusColumn)
+}
+
+// publishedOnly 对外展示的查询只返回审核通过的数据
+// column 为状态列，如 "b.status"
+func publishedOnly(db *gorm.DB, column string) *gorm.DB {
+	return db.Where(column+" = ?", rental.StatusActive)
+}
+
+// requireBuildingVisible 检查楼盘存在且对当前请求可见，否则返回404
+// 用于楼盘详情、图片、周边等按楼盘ID查询的接口
+func requireBuildingVisible(c *gin.Context, buildingID interface{}) bool {
+	db := database.Request(c).Table("sys_buildings b").Where("b.id = ? AND b.deleted_at IS NULL", buildingID)
+	db = visibleOnly(c, db, "b.status", "b.created_by")
+	return requireVisible(c, db, "楼盘不存在")
+}
+
+// requireHouseTypeVisible 检查户型存在且对当前请求可见（所属楼盘也需可见），否则返回404
+func requireHouseTypeVisible(c *gin.Context, houseTypeID interface{}) bool {
+	db := database.Request(c).Table("sys_house_types ht").
+		Joins("JOIN sys_buildings b ON b.id = ht.building_id AND b.deleted_at IS NULL").
+		Where("ht.id = ? AND ht.deleted_at IS NULL", houseTypeID)
+	db = visibleOnly(c, visibleOnly(c, db, "ht.status", "ht.created_by"), "b.status", "b.created_by")
+	return requireVisible(c, db, "户型不存在")
+}
+
+// requireModuleVisible 图片所属的楼盘或户型需对当前请求可见，其他模块不限制
+func requireModuleVisible(c *gin.Context, module string, moduleID uint64) bool {
+	switch module {
+	case "building":
+		return requireBuildingVisible(c, moduleID)
+	case "house_type", "house_floor_plan":
+		return requireHouseTypeVisible(c, moduleID)
+	}
+	return true
+}
+
+// requireVisible 查询结果为空时返回404
+func requireVisible(c *gin.Context, db *gorm.DB, notFound string) bool {
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "查询失败",
+			"error":   err.Error(),
+		})
+		return false
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": notFound,
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,353 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SearchItem 全局搜索结果条目
+type SearchItem struct {
+	ID       uint64            `json:"id"`
+	Title    string            `json:"title"`
+	Subtitle string            `json:"subtitle"`
+	Status   string            `json:"status"`
+	Refs     map[string]uint64 `json:"refs,omitempty"` // 前端跳转需要的关联ID，如 buildingId
+}
+
+// SearchGroup 按实体类型分组的搜索结果
+type SearchGroup struct {
+	Type    string       `json:"type"`
+	Title   string       `json:"title"`
+	Items   []SearchItem `json:"items"`
+	HasMore bool         `json:"hasMore"`
+}
+
+// searchSource 单个实体类型的搜索定义
+type searchSource struct {
+	Type       string
+	Title      string
+	Permission string
+	Table      string
+	// Where 根据关键字构建匹配条件
+	Where func(db *gorm.DB, keyword string) *gorm.DB
+	// Visible 按审核状态限制可见范围（楼盘、户型），为空表示不限制
+	Visible func(c *gin.Context, db *gorm.DB) *gorm.DB
+	// Scan 查询并转换为搜索条目
+	Scan func(db *gorm.DB, limit int) ([]SearchItem, error)
+}
+
+// maskedPhonePattern 匹配脱敏手机号，如 138****5678
+var maskedPhonePattern = regexp.MustCompile(`^(\d{3})\*+(\d{4})$`)
+
+// digitsPattern 匹配纯数字（手机号片段）
+var digitsPattern = regexp.MustCompile(`^\d{4,11}$`)
+
+// SetupSearchRoutes 设置全局搜索路由
+func SetupSearchRoutes(api *gin.RouterGroup) {
+	// 全局跨实体搜索（顶部搜索栏）
+	api.GET("/search/global", middleware.JWTAuth(), func(c *gin.Context) {
+		keyword := strings.TrimSpace(c.Query("q"))
+		if keyword == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "搜索关键字不能为空",
+			})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+		if err != nil || limit < 1 {
+			limit = 5
+		}
+		if limit > 20 {
+			limit = 20
+		}
+
+		scope, err := middleware.GetDataScope(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "获取数据权限失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		groups := []SearchGroup{}
+		for _, source := range searchSources() {
+			// 没有对应菜单权限的实体类型直接跳过
+			if !middleware.HasPermission(c, source.Permission) {
+				continue
+			}
+
+			query := database.Request(c).Table(source.Table).Where(source.Table + ".deleted_at IS NULL")
+			query = source.Where(query, keyword)
+			query = scope.Apply(query, source.Table+".created_by")
+			if source.Visible != nil {
+				query = source.Visible(c, query)
+			}
+
+			// 多查一条用于判断是否还有更多结果
+			items, err := source.Scan(query, limit+1)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"code":    500,
+					"message": fmt.Sprintf("搜索%s失败", source.Title),
+					"error":   err.Error(),
+				})
+				return
+			}
+
+			group := SearchGroup{
+				Type:  source.Type,
+				Title: source.Title,
+				Items: items,
+			}
+			if len(items) > limit {
+				group.Items = items[:limit]
+				group.HasMore = true
+			}
+			if len(group.Items) > 0 {
+				groups = append(groups, group)
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "搜索成功",
+			"data": gin.H{
+				"keyword": keyword,
+				"groups":  groups,
+			},
+		})
+	})
+}
+
+// searchSources 全局搜索支持的实体类型，按展示顺序排列
+func searchSources() []searchSource {
+	return []searchSource{
+		{
+			Type:       "building",
+			Title:      "楼盘",
+			Permission: "rental:building:view",
+			Table:      "sys_buildings",
+			Where: func(db *gorm.DB, keyword string) *gorm.DB {
+				return db.Where("sys_buildings.name "+database.Like(db)+" ?", "%"+keyword+"%")
+			},
+			Visible: func(c *gin.Context, db *gorm.DB) *gorm.DB {
+				return visibleOnly(c, db, "sys_buildings.status", "sys_buildings.created_by")
+			},
+			Scan: func(db *gorm.DB, limit int) ([]SearchItem, error) {
+				var rows []struct {
+					ID           uint64
+					Name         string
+					City         string
+					District     string
+					BusinessArea string
+					Status       string
+				}
+				err := db.Select("id, name, city, district, business_area, status").
+					Order("rent_count DESC, id ASC").Limit(limit).Scan(&rows).Error
+				items := make([]SearchItem, 0, len(rows))
+				for _, row := range rows {
+					items = append(items, SearchItem{
+						ID:       row.ID,
+						Title:    row.Name,
+						Subtitle: joinNonEmpty(" / ", row.City, row.District, row.BusinessArea),
+						Status:   row.Status,
+					})
+				}
+				return items, err
+			},
+		},
+		{
+			Type:       "house_type",
+			Title:      "户型",
+			Permission: "rental:building:view",
+			Table:      "sys_house_types",
+			Where: func(db *gorm.DB, keyword string) *gorm.DB {
+				return db.Where("sys_house_types.code "+database.Like(db)+" ?", "%"+keyword+"%")
+			},
+			Visible: func(c *gin.Context, db *gorm.DB) *gorm.DB {
+				// 所属楼盘也需可见
+				buildings := database.Request(c).Table("sys_buildings b").Select("b.id").Where("b.deleted_at IS NULL")
+				buildings = visibleOnly(c, buildings, "b.status", "b.created_by")
+				db = visibleOnly(c, db, "sys_house_types.status", "sys_house_types.created_by")
+				return db.Where("sys_house_types.building_id IN (?)", buildings)
+			},
+			Scan: func(db *gorm.DB, limit int) ([]SearchItem, error) {
+				var rows []struct {
+					ID           uint64
+					BuildingID   uint64
+					Name         string
+					Code         string
+					BuildingName string
+					Status       string
+				}
+				err := db.Select("sys_house_types.id, sys_house_types.building_id, sys_house_types.name, sys_house_types.code, COALESCE(b.name, '') as building_name, sys_house_types.status").
+					Joins("LEFT JOIN sys_buildings b ON b.id = sys_house_types.building_id").
+					Order("sys_house_types.id DESC").Limit(limit).Scan(&rows).Error
+				items := make([]SearchItem, 0, len(rows))
+				for _, row := range rows {
+					items = append(items, SearchItem{
+						ID:       row.ID,
+						Title:    fmt.Sprintf("%s (%s)", row.Code, row.Name),
+						Subtitle: row.BuildingName,
+						Status:   row.Status,
+						Refs:     map[string]uint64{"buildingId": row.BuildingID},
+					})
+				}
+				return items, err
+			},
+		},
+		{
+			Type:       "house",
+			Title:      "房屋",
+			Permission: "rental:house:view",
+			Table:      "sys_houses",
+			Where: func(db *gorm.DB, keyword string) *gorm.DB {
+				return db.Where("(sys_houses.code "+database.Like(db)+" ? OR sys_houses.room_number "+database.Like(db)+" ?)", "%"+keyword+"%", "%"+keyword+"%")
+			},
+			Scan: func(db *gorm.DB, limit int) ([]SearchItem, error) {
+				var rows []struct {
+					ID           uint64
+					BuildingID   uint64
+					HouseTypeID  uint64
+					Code         string
+					Unit         string
+					RoomNumber   string
+					BuildingName string
+					Status       string
+				}
+				err := db.Select("sys_houses.id, sys_houses.building_id, sys_houses.house_type_id, sys_houses.code, sys_houses.unit, sys_houses.room_number, COALESCE(b.name, '') as building_name, sys_houses.status").
+					Joins("LEFT JOIN sys_buildings b ON b.id = sys_houses.building_id").
+					Order("sys_houses.id DESC").Limit(limit).Scan(&rows).Error
+				items := make([]SearchItem, 0, len(rows))
+				for _, row := range rows {
+					room := row.RoomNumber
+					if row.Unit != "" && row.RoomNumber != "" {
+						room = fmt.Sprintf("%s单元%s室", row.Unit, row.RoomNumber)
+					}
+					items = append(items, SearchItem{
+						ID:       row.ID,
+						Title:    row.Code,
+						Subtitle: joinNonEmpty(" ", row.BuildingName, room),
+						Status:   row.Status,
+						Refs: map[string]uint64{
+							"buildingId":  row.BuildingID,
+							"houseTypeId": row.HouseTypeID,
+						},
+					})
+				}
+				return items, err
+			},
+		},
+		{
+			Type:       "contract",
+			Title:      "合同",
+			Permission: "rental:contract:view",
+			Table:      "sys_contracts",
+			Where: func(db *gorm.DB, keyword string) *gorm.DB {
+				return db.Where("sys_contracts.contract_number "+database.Like(db)+" ?", "%"+keyword+"%")
+			},
+			Scan: func(db *gorm.DB, limit int) ([]SearchItem, error) {
+				var rows []struct {
+					ID             uint64
+					ContractNumber string
+					Title          string
+					PropertyID     uint64
+					TenantID       uint64
+					LandlordID     uint64
+					Status         string
+				}
+				err := db.Select("id, contract_number, title, property_id, tenant_id, landlord_id, status").
+					Order("id DESC").Limit(limit).Scan(&rows).Error
+				items := make([]SearchItem, 0, len(rows))
+				for _, row := range rows {
+					items = append(items, SearchItem{
+						ID:       row.ID,
+						Title:    row.ContractNumber,
+						Subtitle: row.Title,
+						Status:   row.Status,
+						Refs: map[string]uint64{
+							"propertyId": row.PropertyID,
+							"tenantId":   row.TenantID,
+							"landlordId": row.LandlordID,
+						},
+					})
+				}
+				return items, err
+			},
+		},
+		personSearchSource("tenant", "租户", "rental:tenant:view", "sys_tenants"),
+		personSearchSource("landlord", "房东", "rental:landlord:view", "sys_landlords"),
+		personSearchSource("agent", "经纪人", "rental:agent:view", "sys_agents"),
+	}
+}
+
+// personSearchSource 租户、房东、经纪人共用的按姓名或手机号搜索定义
+// 手机号支持完整号码片段（至少4位数字）或脱敏格式（如 138****5678），结果中手机号始终脱敏
+func personSearchSource(sourceType, title, permission, table string) searchSource {
+	return searchSource{
+		Type:       sourceType,
+		Title:      title,
+		Permission: permission,
+		Table:      table,
+		Where: func(db *gorm.DB, keyword string) *gorm.DB {
+			if matches := maskedPhonePattern.FindStringSubmatch(keyword); matches != nil {
+				return db.Where(table+".phone LIKE ?", matches[1]+"%"+matches[2])
+			}
+			if digitsPattern.MatchString(keyword) {
+				return db.Where(table+".phone LIKE ?", "%"+keyword+"%")
+			}
+			return db.Where(table+".name "+database.Like(db)+" ?", "%"+keyword+"%")
+		},
+		Scan: func(db *gorm.DB, limit int) ([]SearchItem, error) {
+			var rows []struct {
+				ID     uint64
+				Name   string
+				Phone  string
+				Status string
+			}
+			err := db.Select("id, name, phone, status").Order("id DESC").Limit(limit).Scan(&rows).Error
+			items := make([]SearchItem, 0, len(rows))
+			for _, row := range rows {
+				items = append(items, SearchItem{
+					ID:       row.ID,
+					Title:    row.Name,
+					Subtitle: maskPhone(row.Phone),
+					Status:   row.Status,
+				})
+			}
+			return items, err
+		},
+	}
+}
+
+// maskPhone 手机号脱敏，保留前3位和后4位
+func maskPhone(phone string) string {
+	if len(phone) < 8 {
+		return phone
+	}
+	return phone[:3] + strings.Repeat("*", len(phone)-7) + phone[len(phone)-4:]
+}
+
+// joinNonEmpty 使用分隔符拼接非空字符串
+func joinNonEmpty(sep string, parts ...string) string {
+	values := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			values = append(values, part)
+		}
+	}
+	return strings.Join(values, sep)
+}
@@ -0,0 +1,232 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rentPro/rentpro-admin/cmd/migrate"
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/models/rental"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// setupSQLiteMemory 按 sqlite3 :memory: 配置连接数据库（与 api --migrate 相同的连接方式）并执行全部迁移
+func setupSQLiteMemory(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "settings.yml")
+	settings := "settings:\n  application:\n    mode: test\n  database:\n    driver: sqlite3\n    source: \":memory:\"\n"
+	if err := os.WriteFile(path, []byte(settings), 0o600); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+	if _, err := config.Load(path); err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	if err := database.Connect(); err != nil {
+		t.Fatalf("连接 SQLite 失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := migrate.ApplyTo(database.DB, ""); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+}
+
+// appliedVersions 已执行的迁移版本数
+func appliedVersions(t *testing.T) (applied, total int) {
+	t.Helper()
+	statuses, err := migration.Migrate.Status()
+	if err != nil {
+		t.Fatalf("查询迁移状态失败: %v", err)
+	}
+	for _, s := range statuses {
+		if s.Applied {
+			applied++
+		}
+	}
+	return applied, len(statuses)
+}
+
+// TestSQLiteMigrations SQLite 内存数据库上执行全部迁移、回滚到初始版本后重新执行
+func TestSQLiteMigrations(t *testing.T) {
+	setupSQLiteMemory(t)
+
+	applied, total := appliedVersions(t)
+	if applied != total {
+		t.Fatalf("已执行 %d 个迁移，共 %d 个", applied, total)
+	}
+
+	// 初始版本（创建所有系统表和业务表）没有回滚函数，回滚之后的全部版本
+	if err := migration.Migrate.Down(total - 1); err != nil {
+		t.Fatalf("回滚迁移失败: %v", err)
+	}
+	if applied, _ := appliedVersions(t); applied != 1 {
+		t.Fatalf("回滚后仍有 %d 个迁移已执行，应只剩初始版本", applied)
+	}
+	for _, table := range []string{"sys_houses", "sys_review_records", "sys_pois", "sys_building_pois", "sys_direct_uploads"} {
+		if database.DB.Migrator().HasTable(table) {
+			t.Errorf("回滚后 %s 仍然存在", table)
+		}
+	}
+	for _, table := range []string{"sys_user", "sys_buildings", "sys_house_types"} {
+		if !database.DB.Migrator().HasTable(table) {
+			t.Errorf("初始版本创建的 %s 不应删除", table)
+		}
+	}
+
+	if err := migrate.ApplyTo(database.DB, ""); err != nil {
+		t.Fatalf("重新执行迁移失败: %v", err)
+	}
+	if applied, _ := appliedVersions(t); applied != total {
+		t.Fatalf("重新执行后已执行 %d 个迁移，共 %d 个", applied, total)
+	}
+}
+
+// insertBuilding 与创建楼盘接口相同的原生 INSERT，返回 LastInsertID
+func insertBuilding(t *testing.T, tx *gorm.DB, name, status string) uint64 {
+	t.Helper()
+	now := time.Now()
+	err := tx.Exec(
+		"INSERT INTO sys_buildings (name, city, district, detailed_address, business_area, property_type, description, longitude, latitude, status, created_by, updated_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		name, "深圳", "南山", "", "科技园", "住宅", "", 113.95, 22.54, status, "admin", "admin", now, now,
+	).Error
+	if err != nil {
+		t.Fatalf("插入楼盘失败: %v", err)
+	}
+	id, err := database.LastInsertID(tx)
+	if err != nil {
+		t.Fatalf("查询楼盘ID失败: %v", err)
+	}
+	return id
+}
+
+// insertHouseType 与创建户型接口相同的原生 INSERT，返回 LastInsertID
+func insertHouseType(t *testing.T, tx *gorm.DB, buildingID uint64, name, status string) uint64 {
+	t.Helper()
+	now := time.Now()
+	err := tx.Exec(
+		"INSERT INTO sys_house_types (building_id, name, code, rooms, halls, bathrooms, balconies, maid_rooms, standard_area, standard_orientation, status, created_by, updated_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		buildingID, name, name, 2, 1, 1, 1, 0, 89.5, "南", status, "admin", "admin", now, now,
+	).Error
+	if err != nil {
+		t.Fatalf("插入户型失败: %v", err)
+	}
+	id, err := database.LastInsertID(tx)
+	if err != nil {
+		t.Fatalf("查询户型ID失败: %v", err)
+	}
+	return id
+}
+
+// getJSON 请求接口并解析响应
+func getJSON(t *testing.T, router http.Handler, url string, v interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: 状态码 %d: %s", url, w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("GET %s: 解析响应失败: %v", url, err)
+	}
+}
+
+// TestSQLiteRawQueries SQLite 上的原生 SQL：LastInsertID、Like 以及楼盘、户型列表
+func TestSQLiteRawQueries(t *testing.T) {
+	setupSQLiteMemory(t)
+	db := database.DB
+
+	// LastInsertID 与 INSERT 在同一事务中
+	var towerID, parkID, pendingID, houseTypeID uint64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		towerID = insertBuilding(t, tx, "Sky Tower", rental.StatusActive)
+		parkID = insertBuilding(t, tx, "River Park", rental.StatusActive)
+		pendingID = insertBuilding(t, tx, "Sky Garden", rental.StatusPending)
+		houseTypeID = insertHouseType(t, tx, towerID, "A1", rental.StatusActive)
+		insertHouseType(t, tx, towerID, "B1", rental.StatusPending)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("插入测试数据失败: %v", err)
+	}
+	for name, id := range map[string]uint64{"Sky Tower": towerID, "River Park": parkID, "Sky Garden": pendingID} {
+		var actual uint64
+		db.Table("sys_buildings").Where("name = ?", name).Select("id").Scan(&actual)
+		if actual == 0 || actual != id {
+			t.Errorf("%s: LastInsertID 为 %d，实际ID为 %d", name, id, actual)
+		}
+	}
+	var actualHouseType uint64
+	db.Table("sys_house_types").Where("name = ?", "A1").Select("id").Scan(&actualHouseType)
+	if actualHouseType != houseTypeID {
+		t.Errorf("户型 LastInsertID 为 %d，实际ID为 %d", houseTypeID, actualHouseType)
+	}
+
+	// Like：SQLite 的 LIKE 对 ASCII 不区分大小写，与 MySQL 一致
+	if op := database.Like(db); op != "LIKE" {
+		t.Errorf("Like 为 %s，应为 LIKE", op)
+	}
+	var names []string
+	db.Table("sys_buildings").Where("name "+database.Like(db)+" ?", "%sky%").Order("id").Pluck("name", &names)
+	if len(names) != 2 || names[0] != "Sky Tower" || names[1] != "Sky Garden" {
+		t.Errorf("模糊匹配结果为 %v", names)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api/v1")
+	SetupBuildingRoutes(api)
+	SetupHouseTypeRoutes(api)
+
+	// 楼盘列表：未登录只返回审核通过的楼盘，按名称模糊筛选
+	var buildings struct {
+		Data  []map[string]interface{} `json:"data"`
+		Total int64                    `json:"total"`
+	}
+	getJSON(t, router, "/api/v1/buildings?sort=name", &buildings)
+	if buildings.Total != 2 || len(buildings.Data) != 2 {
+		t.Fatalf("楼盘列表返回 %d 条（total %d），应为 2 条", len(buildings.Data), buildings.Total)
+	}
+	if buildings.Data[0]["name"] != "River Park" || buildings.Data[1]["name"] != "Sky Tower" {
+		t.Errorf("楼盘列表顺序为 %v、%v", buildings.Data[0]["name"], buildings.Data[1]["name"])
+	}
+	if buildings.Data[1]["editor_name"] != "admin" {
+		t.Errorf("editor_name 为 %v", buildings.Data[1]["editor_name"])
+	}
+	getJSON(t, router, "/api/v1/buildings?name=TOWER", &buildings)
+	if buildings.Total != 1 || len(buildings.Data) != 1 || buildings.Data[0]["name"] != "Sky Tower" {
+		t.Errorf("按名称筛选返回 %v", buildings.Data)
+	}
+
+	// 户型列表：COALESCE、CASE WHEN 布尔值和时间字段在 SQLite 上能正确扫描
+	var houseTypes struct {
+		Data  []HouseTypeResponse `json:"data"`
+		Total int64               `json:"total"`
+	}
+	getJSON(t, router, "/api/v1/house-types/building/1", &houseTypes)
+	if houseTypes.Total != 1 || len(houseTypes.Data) != 1 {
+		t.Fatalf("户型列表返回 %d 条（total %d），应为 1 条", len(houseTypes.Data), houseTypes.Total)
+	}
+	ht := houseTypes.Data[0]
+	if ht.ID != houseTypeID || ht.BuildingName != "Sky Tower" || ht.Name != "A1" || ht.StandardArea != 89.5 {
+		t.Errorf("户型数据为 %+v", ht)
+	}
+	if ht.HasFloorPlan || ht.FloorPlanUrl != "" {
+		t.Errorf("没有户型图时 has_floor_plan 为 %v，floor_plan_url 为 %q", ht.HasFloorPlan, ht.FloorPlanUrl)
+	}
+	if ht.CreatedAt.IsZero() {
+		t.Error("created_at 未能解析")
+	}
+}
@@ -2,34 +2,35 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
 	"rentPro/rentpro-admin/common/database"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/plugin/dbresolver"
 )
 
 // SetupUserRoutes 设置用户管理相关路由
 func SetupUserRoutes(api *gin.RouterGroup) {
 	// 获取用户列表
 	api.GET("/users", func(c *gin.Context) {
-		// 从数据库查询用户列表
-		var users []map[string]interface{}
-		result := database.DB.Table("sys_users").Where("deleted_at IS NULL").Find(&users)
+		params, ok := parseListParams(c, userListQuery)
+		if !ok {
+			return
+		}
 
-		if result.Error != nil {
+		var users []map[string]interface{}
+		page, err := userListQuery.Find(database.Request(c).Where("deleted_at IS NULL"), params, &users)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
 				"message": "查询用户列表失败",
-				"error":   result.Error.Error(),
+				"error":   err.Error(),
 			})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "获取用户列表成功",
-			"data":    users,
-		})
+		page.JSON(c, "获取用户列表成功")
 	})
 
 	// 获取单个用户信息
@@ -37,7 +38,7 @@ func SetupUserRoutes(api *gin.RouterGroup) {
 		id := c.Param("id")
 
 		var user map[string]interface{}
-		result := database.DB.Table("sys_users").Where("id = ? AND deleted_at IS NULL", id).First(&user)
+		result := database.Request(c).Table("sys_users").Where("id = ? AND deleted_at IS NULL", id).First(&user)
 
 		if result.Error != nil {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -75,7 +76,7 @@ func SetupUserRoutes(api *gin.RouterGroup) {
 
 		// 检查用户名是否已存在
 		var count int64
-		database.DB.Table("sys_users").Where("username = ?", userData.Username).Count(&count)
+		database.Request(c).Clauses(dbresolver.Write).Table("sys_users").Where("username = ?", userData.Username).Count(&count)
 		if count > 0 {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"code":    400,
@@ -84,14 +85,17 @@ func SetupUserRoutes(api *gin.RouterGroup) {
 			return
 		}
 
-		// 插入数据库
-		result := database.DB.Exec(
-			"INSERT INTO sys_users (username, password, nickname, role_id, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, NOW(), NOW())",
+		// 插入数据库（时间由程序传入，不依赖数据库的 NOW() 函数）
+		now := time.Now()
+		result := database.Request(c).Exec(
+			"INSERT INTO sys_users (username, password, nickname, role_id, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
 			userData.Username,
 			userData.Password,
 			userData.Nickname,
 			userData.RoleID,
 			userData.Status,
+			now,
+			now,
 		)
 
 		if result.Error != nil {
@@ -144,7 +148,7 @@ func SetupUserRoutes(api *gin.RouterGroup) {
 			updateData["status"] = userData.Status
 		}
 
-		result := database.DB.Table("sys_users").Where("id = ?", id).Updates(updateData)
+		result := database.Request(c).Table("sys_users").Where("id = ?", id).Updates(updateData)
 
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -165,7 +169,7 @@ func SetupUserRoutes(api *gin.RouterGroup) {
 	api.DELETE("/users/:id", func(c *gin.Context) {
 		id := c.Param("id")
 
-		result := database.DB.Exec("UPDATE sys_users SET deleted_at = NOW() WHERE id = ?", id)
+		result := database.Request(c).Exec("UPDATE sys_users SET deleted_at = ? WHERE id = ?", time.Now(), id)
 
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -0,0 +1,220 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"rentPro/rentpro-admin/common/middleware"
+	"rentPro/rentpro-admin/common/models/image"
+	"rentPro/rentpro-admin/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupWatermarkRoutes 设置水印策略相关路由
+// 水印在服务端生成衍生图时按图片分类、模块叠加，策略变更后后台重新生成受影响图片的衍生图
+func SetupWatermarkRoutes(api *gin.RouterGroup) {
+	// 获取水印策略列表
+	api.GET("/watermark-policies", middleware.JWTAuth(), func(c *gin.Context) {
+		imageManager := utils.GetImageManager().WithContext(c)
+		if imageManager == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "图片管理器未初始化",
+			})
+			return
+		}
+
+		policies, err := imageManager.ListWatermarkPolicies(c.Query("status"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "获取水印策略列表失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "获取水印策略列表成功",
+			"data":    policies,
+		})
+	})
+
+	// 获取水印策略详情
+	api.GET("/watermark-policies/:id", middleware.JWTAuth(), func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "水印策略ID格式错误",
+			})
+			return
+		}
+
+		imageManager := utils.GetImageManager().WithContext(c)
+		if imageManager == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "图片管理器未初始化",
+			})
+			return
+		}
+
+		policy, err := imageManager.GetWatermarkPolicy(id)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if utils.IsRejected(err) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "获取水印策略成功",
+			"data":    policy,
+		})
+	})
+
+	// 创建水印策略
+	api.POST("/watermark-policies", middleware.JWTAuth(), func(c *gin.Context) {
+		var req image.WatermarkPolicyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		imageManager := utils.GetImageManager().WithContext(c)
+		if imageManager == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "图片管理器未初始化",
+			})
+			return
+		}
+
+		policy, regenerating, err := imageManager.CreateWatermarkPolicy(&req, c.GetUint64(middleware.ContextUserID))
+		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "创建水印策略失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":         200,
+			"message":      regeneratingMessage("创建水印策略成功", regenerating),
+			"data":         policy,
+			"regenerating": regenerating,
+		})
+	})
+
+	// 更新水印策略
+	api.PUT("/watermark-policies/:id", middleware.JWTAuth(), func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "水印策略ID格式错误",
+			})
+			return
+		}
+
+		var req image.WatermarkPolicyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		imageManager := utils.GetImageManager().WithContext(c)
+		if imageManager == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "图片管理器未初始化",
+			})
+			return
+		}
+
+		policy, regenerating, err := imageManager.UpdateWatermarkPolicy(id, &req, c.GetUint64(middleware.ContextUserID))
+		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "更新水印策略失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":         200,
+			"message":      regeneratingMessage("更新水印策略成功", regenerating),
+			"data":         policy,
+			"regenerating": regenerating,
+		})
+	})
+
+	// 删除水印策略
+	api.DELETE("/watermark-policies/:id", middleware.JWTAuth(), func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "水印策略ID格式错误",
+			})
+			return
+		}
+
+		imageManager := utils.GetImageManager().WithContext(c)
+		if imageManager == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "图片管理器未初始化",
+			})
+			return
+		}
+
+		regenerating, err := imageManager.DeleteWatermarkPolicy(id)
+		if err != nil {
+			status := uploadErrorStatus(err)
+			c.JSON(status, gin.H{
+				"code":    status,
+				"message": "删除水印策略失败",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":         200,
+			"message":      regeneratingMessage("删除水印策略成功", regenerating),
+			"regenerating": regenerating,
+		})
+	})
+}
+
+// regeneratingMessage 水印策略变更的提示，附带后台重新生成衍生图的文件数
+func regeneratingMessage(message string, regenerating int) string {
+	if regenerating == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s，正在后台重新生成%d个文件的衍生图", message, regenerating)
+}
@@ -9,62 +9,31 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 
 	"rentPro/rentpro-admin/cmd/api/routes"
+	"rentPro/rentpro-admin/cmd/migrate"
+	"rentPro/rentpro-admin/common/config"
 	"rentPro/rentpro-admin/common/database"
 	"rentPro/rentpro-admin/common/global"
 	"rentPro/rentpro-admin/common/initialize"
+	"rentPro/rentpro-admin/common/middleware"
+	"rentPro/rentpro-admin/common/seed"
+	"rentPro/rentpro-admin/common/storage"
 	"rentPro/rentpro-admin/common/utils"
 )
 
-// fileExists 检查文件是否存在
-func fileExists(filename string) bool {
-	info, err := os.Stat(filename)
-	if os.IsNotExist(err) {
-		return false
-	}
-	return !info.IsDir()
-}
-
-// 配置数据结构，用于解析 settings.yml
-type Config struct {
-	Settings struct {
-		Application struct {
-			Mode         string `yaml:"mode"`
-			Host         string `yaml:"host"`
-			Name         string `yaml:"name"`
-			Port         int    `yaml:"port"`
-			ReadTimeout  int    `yaml:"readtimeout"`
-			WriteTimeout int    `yaml:"writetimeout"`
-			EnabledDP    bool   `yaml:"enabledp"`
-		} `yaml:"application"`
-		Logger struct {
-			Path      string `yaml:"path"`
-			Stdout    string `yaml:"stdout"`
-			Level     string `yaml:"level"`
-			EnabledDB bool   `yaml:"enableddb"`
-		} `yaml:"logger"`
-		JWT struct {
-			Secret  string `yaml:"secret"`
-			Timeout int    `yaml:"timeout"`
-		} `yaml:"jwt"`
-		Database struct {
-			Driver string `yaml:"driver"`
-			Source string `yaml:"source"`
-		} `yaml:"database"`
-	} `yaml:"settings"`
-}
-
 var (
 	configYml   string
 	port        int
 	showVersion bool
+	autoMigrate bool
+	autoSeed    bool
 
 	// StartCmd 定义了 api 子命令
 	// 用于启动HTTP API服务器，支持以下功能：
@@ -76,6 +45,7 @@ var (
 	// 使用方式：
 	//   - rentpro-admin api -c config/settings.yml : 使用指定配置文件启动API服务器
 	//   - rentpro-admin api -p 8002                : 指定端口启动API服务器
+	//   - rentpro-admin api --migrate --seed       : 启动前执行数据库迁移并写入初始化数据（SQLite 内存数据库）
 	//   - rentpro-admin api -v                     : 显示版本信息
 	// 版本信息来源：common/global/adm.go 中的 Version 常量
 	StartCmd = &cobra.Command{
@@ -108,6 +78,10 @@ func init() {
 
 	// 端口标志
 	StartCmd.PersistentFlags().IntVarP(&port, "port", "p", 0, "指定服务端口号")
+
+	// 启动前初始化数据库
+	StartCmd.Flags().BoolVar(&autoMigrate, "migrate", false, "启动前执行数据库迁移")
+	StartCmd.Flags().BoolVar(&autoSeed, "seed", false, "启动前按 application.mode 写入 config/seed 初始化数据")
 }
 
 // run 执行API服务器启动的核心逻辑
@@ -115,35 +89,67 @@ func run() error {
 	fmt.Printf("=== rentpro-admin API服务器 v%s ===\n", global.Version)
 
 	// 加载配置文件
-	config, err := loadConfig(configYml)
+	cfg, err := config.Load(configYml)
 	if err != nil {
 		return fmt.Errorf("加载配置文件失败: %v", err)
 	}
 
+	// 签名密钥为空、为默认值（dev 以外的模式还包括强度不足）时拒绝启动
+	if err := checkSecrets(cfg); err != nil {
+		return err
+	}
+
 	// 初始化数据库连接
 	fmt.Println("初始化数据库连接...")
 	database.Setup()
 
-	// 初始化七牛云服务
-	fmt.Println("初始化七牛云服务...")
-	// TODO: 取消注释以启用七牛云服务
-	err = initialize.InitQiniu(config.Settings.Application.Mode)
-	if err != nil {
-		log.Printf("⚠️  七牛云服务初始化失败: %v", err)
-		log.Println("将使用本地文件存储")
-	} else {
-		// 初始化图片管理器
-		fmt.Println("初始化图片管理器...")
-		err = utils.InitImageManager()
+	// 启动前执行迁移、写入初始化数据
+	if autoMigrate {
+		if err := migrate.Apply(); err != nil {
+			return fmt.Errorf("数据库迁移失败: %v", err)
+		}
+	}
+	if autoSeed {
+		ds, _, err := seed.Load("config/seed", cfg.Settings.Application.Mode)
+		if err != nil {
+			return fmt.Errorf("加载初始化数据失败: %v", err)
+		}
+		report, err := seed.Run(database.DB, ds, seed.Options{})
 		if err != nil {
-			log.Printf("⚠️  图片管理器初始化失败: %v", err)
-		} else {
-			log.Println("✅ 图片管理器初始化成功")
+			return fmt.Errorf("写入初始化数据失败: %v", err)
 		}
+		fmt.Printf("✅ 初始化数据：创建 %d 条、更新 %d 条、跳过 %d 条\n",
+			report.Count(seed.ActionCreate), report.Count(seed.ActionUpdate), report.Count(seed.ActionSkipped))
+	}
+
+	// 启用只读副本（迁移、初始化数据已在主库完成）
+	if err := database.SetupReplicas(); err != nil {
+		return fmt.Errorf("初始化只读副本失败: %v", err)
+	}
+
+	// 初始化文件存储（七牛云不可用时仅 dev 模式回退到本地磁盘）
+	fmt.Println("初始化文件存储...")
+	err = initialize.InitStorage(cfg.Settings.Storage, cfg.Settings.Application.Mode)
+	if err != nil {
+		return fmt.Errorf("初始化文件存储失败: %v", err)
+	}
+
+	// 初始化图片管理器
+	fmt.Println("初始化图片管理器...")
+	err = utils.InitImageManager()
+	if err != nil {
+		log.Printf("⚠️  图片管理器初始化失败: %v", err)
+	} else {
+		log.Println("✅ 图片管理器初始化成功")
 	}
 
+	// 数据权限开关
+	middleware.EnableDataScope = cfg.Settings.Application.EnabledDP
+	// 登录签发和认证校验 token 使用 settings.jwt
+	middleware.SetJWTConfig(cfg.Settings.JWT.Secret, cfg.Settings.JWT.Timeout)
+
 	// 设置Gin模式
-	if config.Settings.Application.Mode == "prod" {
+	if cfg.Settings.Application.Mode == "prod" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
@@ -157,23 +163,23 @@ func run() error {
 	setupRoutes(router)
 
 	// 确定端口
-	serverPort := config.Settings.Application.Port
+	serverPort := cfg.Settings.Application.Port
 	if port > 0 {
 		serverPort = port
 	}
 
 	// 创建HTTP服务器
 	server := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", config.Settings.Application.Host, serverPort),
+		Addr:         fmt.Sprintf("%s:%d", cfg.Settings.Application.Host, serverPort),
 		Handler:      router,
-		ReadTimeout:  time.Duration(config.Settings.Application.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(config.Settings.Application.WriteTimeout) * time.Second,
+		ReadTimeout:  time.Duration(cfg.Settings.Application.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.Settings.Application.WriteTimeout) * time.Second,
 	}
 
 	// 启动服务器
-	fmt.Printf("启动API服务器: %s:%d\n", config.Settings.Application.Host, serverPort)
-	fmt.Printf("应用名称: %s\n", config.Settings.Application.Name)
-	fmt.Printf("运行模式: %s\n", config.Settings.Application.Mode)
+	fmt.Printf("启动API服务器: %s:%d\n", cfg.Settings.Application.Host, serverPort)
+	fmt.Printf("应用名称: %s\n", cfg.Settings.Application.Name)
+	fmt.Printf("运行模式: %s\n", cfg.Settings.Application.Mode)
 
 	// 在goroutine中启动服务器
 	go func() {
@@ -203,36 +209,13 @@ func run() error {
 	return nil
 }
 
-// loadConfig 加载和解析配置文件
-func loadConfig(configPath string) (*Config, error) {
-	// 检查文件是否存在
-	if !fileExists(configPath) {
-		return nil, fmt.Errorf("配置文件不存在: %s", configPath)
-	}
-
-	// 读取配置文件
-	configData, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %v", err)
-	}
-
-	// 解析 YAML 配置
-	var config Config
-	err = yaml.Unmarshal(configData, &config)
-	if err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %v", err)
-	}
-
-	return &config, nil
-}
-
 // setupMiddleware 设置中间件
 func setupMiddleware(router *gin.Engine) {
 	// 添加CORS中间件
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, "+middleware.ReadPrimaryHeader)
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -245,11 +228,16 @@ func setupMiddleware(router *gin.Engine) {
 	// 添加日志中间件
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+
+	// 写入后读主库
+	router.Use(middleware.ReadYourWrites())
 }
 
 func setupRoutes(router *gin.Engine) {
-	// 静态文件服务
-	router.Static("/uploads", "./uploads")
+	// 本地存储驱动的文件，由驱动校验签名URL（不使用静态目录，私有模式下未签名的请求被拒绝）
+	mountPath := config.GetStorageConfig().Local.MountPath()
+	router.GET(mountPath+"/*key", serveLocalFile)
+	router.HEAD(mountPath+"/*key", serveLocalFile)
 
 	// 健康检查
 	router.GET("/health", func(c *gin.Context) {
@@ -264,12 +252,17 @@ func setupRoutes(router *gin.Engine) {
 	api := router.Group("/api/v1")
 	{
 		// 设置各个模块的路由
-		routes.SetupAuthRoutes(api)      // 认证相关路由
-		routes.SetupUserRoutes(api)      // 用户管理路由
-		routes.SetupCityRoutes(api)      // 城市管理路由
-		routes.SetupBuildingRoutes(api)  // 楼盘管理路由
-		routes.SetupHouseTypeRoutes(api) // 户型管理路由
-		routes.SetupImageRoutes(api)     // 图片管理路由
+		routes.SetupAuthRoutes(api)          // 认证相关路由
+		routes.SetupUserRoutes(api)          // 用户管理路由
+		routes.SetupCityRoutes(api)          // 城市管理路由
+		routes.SetupBuildingRoutes(api)      // 楼盘管理路由
+		routes.SetupHouseTypeRoutes(api)     // 户型管理路由
+		routes.SetupImageRoutes(api)         // 图片管理路由
+		routes.SetupImageCategoryRoutes(api) // 图片分类配置路由
+		routes.SetupWatermarkRoutes(api)     // 水印策略路由
+		routes.SetupSearchRoutes(api)        // 全局搜索路由
+		routes.SetupReviewRoutes(api)        // 审核流程路由
+		routes.SetupPoiRoutes(api)           // 周边POI和配套设施路由
 	}
 
 	// 根路径
@@ -281,3 +274,34 @@ func setupRoutes(router *gin.Engine) {
 		})
 	})
 }
+
+// serveLocalFile 提供本地存储驱动的文件，当前驱动不是本地磁盘时返回404
+func serveLocalFile(c *gin.Context) {
+	local, ok := storage.Default().(*storage.Local)
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	local.Serve(c.Writer, c.Request, strings.TrimPrefix(c.Param("key"), "/"))
+}
+
+// checkSecrets 检查 token 和直传凭证的签名密钥，不可用时返回错误，只有提示时输出警告
+func checkSecrets(cfg *config.Config) error {
+	mode := cfg.Settings.Application.Mode
+	secrets := []struct {
+		path  string
+		check config.SecretCheck
+	}{
+		{"settings.jwt.secret", config.CheckJWTSecret(cfg.Settings.JWT.Secret, mode)},
+		{"settings.storage.direct_upload.secret", config.CheckDirectUploadSecret(cfg.Settings.Storage.DirectUpload.Secret, mode)},
+	}
+	for _, s := range secrets {
+		if len(s.check.Errors) > 0 {
+			return fmt.Errorf("%s 不可用: %s", s.path, strings.Join(s.check.Errors, "；"))
+		}
+		for _, warning := range s.check.Warnings {
+			log.Printf("⚠️  %s %s，dev 以外的模式将拒绝启动", s.path, warning)
+		}
+	}
+	return nil
+}
@@ -7,7 +7,12 @@ import (
 
 	"rentPro/rentpro-admin/cmd/api"
 	"rentPro/rentpro-admin/cmd/config"
+	"rentPro/rentpro-admin/cmd/db"
+	"rentPro/rentpro-admin/cmd/doctor"
+	"rentPro/rentpro-admin/cmd/images"
 	"rentPro/rentpro-admin/cmd/migrate"
+	"rentPro/rentpro-admin/cmd/seed"
+	"rentPro/rentpro-admin/cmd/storage"
 	"rentPro/rentpro-admin/cmd/version"
 
 	"github.com/spf13/cobra"
@@ -100,6 +105,43 @@ func init() {
 	// 服务端口: 默认8000（可在config/settings.yml中配置）
 	rootCmd.AddCommand(api.StartCmd)
 
+	// 注册 images 子命令到根命令
+	// images.StartCmd 来自 cmd/images/server.go，提供图片维护功能
+	// 注册后用户可以通过以下方式为已有图片生成衍生图：
+	//   - rentpro-admin images derivatives -c config/settings.yml : 生成缺少的衍生图
+	//   - rentpro-admin images derivatives --force               : 重新生成全部衍生图
+	rootCmd.AddCommand(images.StartCmd)
+
+	// 注册 storage 子命令到根命令
+	// storage.StartCmd 来自 cmd/storage/server.go，提供文件存储维护功能
+	// 注册后用户可以通过以下方式比对存储文件和图片记录：
+	//   - rentpro-admin storage reconcile -c config/settings.yml : 生成对账报告
+	//   - rentpro-admin storage reconcile --quarantine --dry-run : 预览隔离操作
+	rootCmd.AddCommand(storage.StartCmd)
+
+	// 注册 seed 子命令到根命令
+	// seed.StartCmd 来自 cmd/seed/server.go，提供初始化数据写入功能
+	// 注册后用户可以通过以下方式写入部门、岗位、角色、菜单、用户、城市区域商圈：
+	//   - rentpro-admin seed -c config/settings.yml      : 写入 base 和当前环境数据集
+	//   - rentpro-admin seed --only menus --dry-run      : 预览指定数据集的差异
+	rootCmd.AddCommand(seed.StartCmd)
+
+	// 注册 db 子命令到根命令
+	// db.StartCmd 来自 cmd/db/server.go，提供数据库备份与恢复功能
+	// 注册后用户可以通过以下方式备份、恢复、验证和清理备份：
+	//   - rentpro-admin db backup -c config/settings.yml : 备份到 backups 目录
+	//   - rentpro-admin db restore <备份文件>             : 检查迁移版本后恢复
+	//   - rentpro-admin db verify                        : 在临时库中验证最新的备份
+	//   - rentpro-admin db prune --keep 7                : 清理旧备份
+	rootCmd.AddCommand(db.StartCmd)
+
+	// 注册 doctor 子命令到根命令
+	// doctor.StartCmd 来自 cmd/doctor/server.go，提供运行环境诊断功能
+	// 注册后用户可以通过以下方式检查配置、数据库、迁移、表结构、存储、图片管理器和 JWT 密钥：
+	//   - rentpro-admin doctor -c config/settings.yml : 执行全部检查，有失败项时以非零状态退出
+	//   - rentpro-admin doctor --strict               : 警告也视为失败
+	rootCmd.AddCommand(doctor.StartCmd)
+
 }
 
 // Execute 是命令行应用的入口函数，由main.go调用
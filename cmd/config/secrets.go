@@ -0,0 +1,157 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	appconfig "rentPro/rentpro-admin/common/config"
+)
+
+var (
+	// secretsCmd 管理加密密钥文件
+	// 使用方式：
+	//   - rentpro-admin config secrets init                 : 生成主密钥 config/master.key
+	//   - rentpro-admin config secrets set db_source        : 从标准输入读取值，加密后写入 config/secrets.enc.yml
+	//   - rentpro-admin config secrets list                 : 列出密钥名（不显示值）
+	//   - rentpro-admin config secrets remove db_source     : 删除密钥
+	// 配置项中使用 secret:db_source 引用
+	secretsCmd = &cobra.Command{
+		Use:   "secrets",
+		Short: "管理加密密钥文件",
+		Long: `加密密钥文件 secrets.enc.yml 与配置文件在同一目录，每个值使用主密钥以 AES-256-GCM 加密，可以提交到版本库。
+主密钥依次从环境变量 RENTPRO_MASTER_KEY、RENTPRO_MASTER_KEY_FILE 指定的文件、配置目录中的 master.key 读取，不能提交到版本库。
+配置项（database.source、qiniu.yml 的 access_key 等）写为 secret:<名称> 时从该文件解密。`,
+		Example: "printf '%s' \"$DSN\" | rentpro-admin config secrets set db_source",
+	}
+
+	secretsInitCmd = &cobra.Command{
+		Use:   "init",
+		Short: "生成主密钥",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := filepath.Join(filepath.Dir(configYml), appconfig.MasterKeyFile)
+			if err := appconfig.GenerateMasterKey(path); err != nil {
+				return err
+			}
+			fmt.Printf("✅ 已生成主密钥 %s（权限 0600）\n", path)
+			fmt.Println("请妥善保管，不要提交到版本库；部署时可以改用环境变量 RENTPRO_MASTER_KEY 传入文件内容")
+			return nil
+		},
+	}
+
+	secretsSetCmd = &cobra.Command{
+		Use:   "set <名称>",
+		Short: "加密并保存密钥，值从标准输入读取",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			value, err := readValue(args[0])
+			if err != nil {
+				return err
+			}
+			if err := store.Set(args[0], value); err != nil {
+				return err
+			}
+			if err := store.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("✅ 已保存 %s 到 %s，配置中使用 secret:%s 引用\n", args[0], store.Path(), args[0])
+			return nil
+		},
+	}
+
+	secretsListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "列出密钥名",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			names := store.Names()
+			if len(names) == 0 {
+				fmt.Printf("%s 中没有密钥\n", store.Path())
+				return nil
+			}
+			fmt.Printf("%s:\n", store.Path())
+			for _, name := range names {
+				// 逐个解密，检查主密钥是否正确
+				status := "✅"
+				if _, err := store.Get(name); err != nil {
+					status = "❌ " + err.Error()
+				}
+				fmt.Printf("  %-32s %s\n", name, status)
+			}
+			return nil
+		},
+	}
+
+	secretsRemoveCmd = &cobra.Command{
+		Use:   "remove <名称>",
+		Short: "删除密钥",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			if !store.Delete(args[0]) {
+				return fmt.Errorf("%s 中没有 %s", store.Path(), args[0])
+			}
+			if err := store.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("✅ 已从 %s 删除 %s\n", store.Path(), args[0])
+			return nil
+		},
+	}
+)
+
+func init() {
+	secretsCmd.AddCommand(secretsInitCmd, secretsSetCmd, secretsListCmd, secretsRemoveCmd)
+}
+
+// openStore 打开配置目录中的加密密钥文件
+func openStore() (*appconfig.SecretStore, error) {
+	return appconfig.OpenSecretStore(filepath.Dir(configYml))
+}
+
+// readValue 从标准输入读取密钥值；终端输入时读取一行，管道输入时读取全部内容并去掉末尾换行
+func readValue(name string) (string, error) {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return "", fmt.Errorf("读取标准输入失败: %v", err)
+	}
+
+	var value string
+	if info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Printf("请输入 %s 的值（输入内容会显示在终端上，建议使用管道传入）: ", name)
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("读取输入失败: %v", err)
+		}
+		value = line
+	} else {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("读取标准输入失败: %v", err)
+		}
+		value = string(data)
+	}
+
+	value = strings.TrimRight(value, "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("密钥值不能为空")
+	}
+	return value, nil
+}
@@ -3,43 +3,18 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
-	"os"
 	"path/filepath"
-	"rentPro/rentpro-admin/common/global"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
-)
 
-// 配置数据结构
-type Config struct {
-	Settings struct {
-		Application struct {
-			Mode         string `yaml:"mode"`
-			Host         string `yaml:"host"`
-			Name         string `yaml:"name"`
-			Port         int    `yaml:"port"`
-			ReadTimeout  int    `yaml:"readtimeout"`
-			WriteTimeout int    `yaml:"writertimeout"`
-			EnabledDP    bool   `yaml:"enabledp"`
-		} `yaml:"application"`
-		Logger struct {
-			Path      string `yaml:"path"`
-			Stdout    string `yaml:"stdout"`
-			Level     string `yaml:"level"`
-			EnabledDB bool   `yaml:"enableddb"`
-		} `yaml:"logger"`
-		JWT struct {
-			Secret  string `yaml:"secret"`
-			Timeout int    `yaml:"timeout"`
-		} `yaml:"jwt"`
-		Database struct {
-			Driver string `yaml:"driver"`
-			Source string `yaml:"source"`
-		} `yaml:"database"`
-	} `yaml:"settings"`
-}
+	appconfig "rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/global"
+)
 
 var (
 	// configYml 配置文件路径
@@ -51,9 +26,10 @@ var (
 	// StartCmd 定义了 config 子命令
 	// 用于显示和验证系统配置信息
 	StartCmd = &cobra.Command{
-		Use:     "config",
-		Short:   "获取应用程序配置信息",
-		Long:    `显示 rentpro-admin 系统的完整配置信息，包括应用设置、数据库配置、日志配置等`,
+		Use:   "config",
+		Short: "获取应用程序配置信息",
+		Long: `显示 rentpro-admin 合并后的完整配置：settings.yml、settings.{mode}.yml 和 RENTPRO_* 环境变量依次覆盖，
+补充默认值并校验后输出。密钥和连接字符串不显示，只显示来源（配置文件、环境变量、文件或加密密钥文件）`,
 		Example: "rentpro-admin config -c config/settings.yml",
 
 		// PreRun 在实际命令执行前运行
@@ -84,69 +60,87 @@ func init() {
 
 	// 添加配置文件标志
 	StartCmd.PersistentFlags().StringVarP(&configYml, "config", "c", "config/settings.yml", "指定配置文件路径")
+
+	StartCmd.AddCommand(secretsCmd)
 }
 
 // run 执行配置信息显示的核心逻辑
-// 读取指定的 YAML 配置文件并显示内容
+// 按各命令相同的方式加载配置，显示来源和合并结果
 func run() error {
 	fmt.Printf("=== rentpro-admin 配置信息 v%s ===\n", global.Version)
-	fmt.Printf("配置文件: %s\n", configYml)
 
-	// 检查配置文件是否存在
-	if !fileExists(configYml) {
-		return fmt.Errorf("配置文件不存在: %s", configYml)
-	}
-
-	// 读取配置文件
-	configData, err := os.ReadFile(configYml)
+	cfg, err := appconfig.Read(configYml)
 	if err != nil {
-		return fmt.Errorf("读取配置文件失败: %v", err)
+		return err
 	}
 
-	// 解析 YAML 配置
-	var config Config
-	err = yaml.Unmarshal(configData, &config)
-	if err != nil {
-		return fmt.Errorf("解析配置文件失败: %v", err)
+	fmt.Printf("配置文件: %s\n", strings.Join(cfg.Files, " → "))
+	if len(cfg.Env) > 0 {
+		fmt.Println("环境变量覆盖:")
+		for _, f := range cfg.Fields() {
+			if env, ok := cfg.Env[f.Path]; ok {
+				fmt.Printf("  %-40s ← %s\n", "settings."+f.Path, env)
+			}
+		}
+	}
+	for _, warning := range cfg.Warnings {
+		fmt.Printf("⚠️  %s\n", warning)
 	}
 
-	// 显示配置信息
-	fmt.Println("\n=== 应用程序配置 ===")
-	fmt.Printf("运行模式: %s\n", config.Settings.Application.Mode)
-	fmt.Printf("绑定地址: %s:%d\n", config.Settings.Application.Host, config.Settings.Application.Port)
-	fmt.Printf("应用名称: %s\n", config.Settings.Application.Name)
-	fmt.Printf("数据权限: %t\n", config.Settings.Application.EnabledDP)
-
-	fmt.Println("\n=== 日志配置 ===")
-	fmt.Printf("日志路径: %s\n", config.Settings.Logger.Path)
-	fmt.Printf("日志级别: %s\n", config.Settings.Logger.Level)
-	fmt.Printf("数据库日志: %t\n", config.Settings.Logger.EnabledDB)
-
-	fmt.Println("\n=== JWT 配置 ===")
-	fmt.Printf("密钥: %s\n", maskSensitiveInfo(config.Settings.JWT.Secret))
-	fmt.Printf("过期时间: %d秒\n", config.Settings.JWT.Timeout)
-
-	fmt.Println("\n=== 数据库配置 ===")
-	fmt.Printf("数据库类型: %s\n", config.Settings.Database.Driver)
-	fmt.Printf("连接字符串: %s\n", maskSensitiveInfo(config.Settings.Database.Source))
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]interface{}{"settings": cfg.Redacted()}); err != nil {
+		return fmt.Errorf("输出配置失败: %v", err)
+	}
+	fmt.Printf("\n%s", out.String())
+
+	if len(cfg.Settings.Other) > 0 {
+		var names []string
+		for name := range cfg.Settings.Other {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Printf("\n未校验的配置节点（未显示）: %s\n", strings.Join(names, ", "))
+	}
 
-	fmt.Println("\n✅ 配置信息显示完成！")
+	fmt.Println("\n密钥来源:")
+	printSecrets("settings.", cfg.Secrets)
+	printStorageSecrets(cfg)
 
+	fmt.Println("\n✅ 配置校验通过！")
 	return nil
 }
 
-// fileExists 检查文件是否存在
-func fileExists(filename string) bool {
-	_, err := os.Stat(filename)
-	return !os.IsNotExist(err)
+// printSecrets 输出敏感配置项的来源，不输出值
+func printSecrets(prefix string, secrets []appconfig.Secret) {
+	for _, secret := range secrets {
+		fmt.Printf("  %-40s %s\n", prefix+secret.Path, secret.Source)
+	}
 }
 
-// maskSensitiveInfo 隐藏敏感信息，用于安全显示
-func maskSensitiveInfo(info string) string {
-	if len(info) <= 8 {
-		return "***"
+// printStorageSecrets 输出当前存储驱动配置文件（qiniu.yml、s3.yml）中密钥的来源
+func printStorageSecrets(cfg *appconfig.Config) {
+	dir := filepath.Dir(configYml)
+	mode := cfg.Settings.Application.Mode
+	switch cfg.Settings.Storage.Driver {
+	case "", "qiniu":
+		path := filepath.Join(dir, "qiniu.yml")
+		manager, err := appconfig.NewQiniuConfigManager(path, mode)
+		if err != nil {
+			fmt.Printf("  %-40s ⚠️  %v\n", path, err)
+			return
+		}
+		printSecrets("", manager.GetConfig().Secrets)
+	case "s3":
+		path := filepath.Join(dir, "s3.yml")
+		s3Config, err := appconfig.LoadS3Config(path, mode)
+		if err != nil {
+			fmt.Printf("  %-40s ⚠️  %v\n", path, err)
+			return
+		}
+		printSecrets("", s3Config.Secrets)
 	}
-	return info[:4] + "***" + info[len(info)-4:]
 }
 
 // GetConfigVersion 获取配置工具版本信息
@@ -156,29 +150,14 @@ func GetConfigVersion() string {
 }
 
 // ValidateConfigFile 验证配置文件是否有效
-// 用于配置文件验证和调试
+// 用于配置文件验证和调试，与各命令使用相同的加载和校验规则
 func ValidateConfigFile(configPath string) error {
-	if !fileExists(configPath) {
-		return fmt.Errorf("配置文件不存在: %s", configPath)
-	}
-
 	// 检查文件扩展名
 	ext := filepath.Ext(configPath)
 	if ext != ".yml" && ext != ".yaml" {
 		return fmt.Errorf("不支持的配置文件格式: %s，只支持 .yml 或 .yaml", ext)
 	}
 
-	// 尝试解析配置文件
-	configData, err := os.ReadFile(configPath)
-	if err != nil {
-		return fmt.Errorf("读取配置文件失败: %v", err)
-	}
-
-	var config Config
-	err = yaml.Unmarshal(configData, &config)
-	if err != nil {
-		return fmt.Errorf("解析配置文件失败: %v", err)
-	}
-
-	return nil
+	_, err := appconfig.Read(configPath)
+	return err
 }
@@ -0,0 +1,331 @@
+// Package db 提供数据库备份与恢复相关的命令行功能
+// 备份为与驱动无关的压缩逻辑备份，恢复前检查迁移版本，支持保留策略清理和在临时库中验证
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"rentPro/rentpro-admin/cmd/migrate"
+	"rentPro/rentpro-admin/common/backup"
+	"rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/global"
+)
+
+var (
+	configYml   string
+	dir         string
+	images      bool
+	keep        int
+	maxAgeDays  int
+	verifyAfter bool
+	force       bool
+	noBackup    bool
+	dryRun      bool
+	assumeYes   bool
+	showVersion bool
+
+	// StartCmd 定义了 db 子命令
+	// 命令注册：通过 rootCmd.AddCommand(db.StartCmd) 注册到根命令
+	// 使用方式：
+	//   - rentpro-admin db backup -c config/settings.yml : 备份到 backups 目录并按保留策略清理
+	//   - rentpro-admin db backup --images --verify      : 包含图片清单，备份后在临时库中验证
+	//   - rentpro-admin db restore <备份文件>             : 检查迁移版本后恢复（恢复前先备份当前数据）
+	//   - rentpro-admin db verify [备份文件]              : 恢复到临时库并比对行数，默认最新的备份
+	//   - rentpro-admin db prune --keep 7 --dry-run       : 预览按保留策略清理的备份
+	//   - rentpro-admin db -v                             : 显示版本信息
+	// 版本信息来源：common/global/adm.go 中的 Version 常量
+	StartCmd = &cobra.Command{
+		Use:     "db",
+		Short:   "数据库备份与恢复",
+		Long:    `rentpro-admin 数据库备份与恢复工具，备份为 gzip 压缩的逻辑备份（JSON Lines），可在 MySQL、PostgreSQL、SQLite 上使用`,
+		Example: "rentpro-admin db backup -c config/settings.yml",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if showVersion {
+				fmt.Printf("rentpro-admin db version: %s\n", global.Version)
+				return nil
+			}
+			return cmd.Help()
+		},
+	}
+
+	// backupCmd 备份数据库
+	backupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "备份数据库",
+		Long: `在一个只读事务中导出除迁移记录外的所有表，写入 backups/backup_{时间}.jsonl.gz。
+sys_images（图片清单）默认不备份，使用 --images 包含。备份完成后按 --keep、--max-age 清理旧备份。`,
+		Example: "rentpro-admin db backup --images --keep 14",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBackup()
+		},
+	}
+
+	// restoreCmd 恢复数据库
+	restoreCmd = &cobra.Command{
+		Use:   "restore <备份文件>",
+		Short: "从备份恢复数据库",
+		Long: `恢复前检查当前库 sys_migration 中的最新版本与备份一致，并先备份当前数据（--no-backup 跳过）。
+在一个事务中清空备份包含的表并写入数据，失败时整体回滚；备份中不包含的表保持不变。`,
+		Example: "rentpro-admin db restore backups/backup_20251019_060000.jsonl.gz",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRestore(args[0])
+		},
+	}
+
+	// verifyCmd 验证备份
+	verifyCmd = &cobra.Command{
+		Use:   "verify [备份文件]",
+		Short: "在临时库中验证备份",
+		Long: `创建临时库（MySQL 数据库、PostgreSQL schema、SQLite 临时文件），执行迁移到备份的版本后恢复备份，
+比对各表行数，完成后删除临时库。不指定文件时验证备份目录中最新的备份。`,
+		Example: "rentpro-admin db verify",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			}
+			return runVerify(path)
+		},
+	}
+
+	// pruneCmd 清理旧备份
+	pruneCmd = &cobra.Command{
+		Use:     "prune",
+		Short:   "按保留策略清理旧备份",
+		Long:    `保留最近 --keep 个备份，并删除超过 --max-age 天的备份；最新的备份总是保留。`,
+		Example: "rentpro-admin db prune --keep 7 --max-age 30 --dry-run",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPrune()
+		},
+	}
+)
+
+// init 初始化命令标志
+func init() {
+	StartCmd.PersistentFlags().BoolVarP(&showVersion, "version", "v", false, "显示版本信息")
+	StartCmd.PersistentFlags().StringVarP(&configYml, "config", "c", "config/settings.yml", "指定配置文件路径")
+	StartCmd.PersistentFlags().StringVar(&dir, "dir", "backups", "备份目录")
+
+	for _, cmd := range []*cobra.Command{backupCmd, restoreCmd, pruneCmd} {
+		cmd.Flags().IntVar(&keep, "keep", 7, "保留最近的备份个数，0 表示不按个数清理")
+		cmd.Flags().IntVar(&maxAgeDays, "max-age", 0, "删除超过该天数的备份，0 表示不按时间清理")
+	}
+	backupCmd.Flags().BoolVar(&images, "images", false, "包含图片清单（sys_images）")
+	backupCmd.Flags().BoolVar(&verifyAfter, "verify", false, "备份完成后在临时库中验证")
+	restoreCmd.Flags().BoolVar(&force, "force", false, "迁移版本与备份不一致时仍然恢复")
+	restoreCmd.Flags().BoolVar(&noBackup, "no-backup", false, "恢复前不备份当前数据")
+	restoreCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "跳过确认")
+	pruneCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只显示将要删除的备份")
+
+	StartCmd.AddCommand(backupCmd, restoreCmd, verifyCmd, pruneCmd)
+}
+
+// connect 连接数据库，只输出警告和错误（备份、恢复会执行大量语句）
+func connect() (*gorm.DB, error) {
+	if configYml == "" {
+		return nil, fmt.Errorf("请指定配置文件路径，使用 -c 参数")
+	}
+	if _, err := config.Load(configYml); err != nil {
+		return nil, fmt.Errorf("加载配置文件失败: %v", err)
+	}
+
+	database.Setup()
+	return database.DB.Session(&gorm.Session{Logger: logger.New(
+		log.New(os.Stdout, "\r\n", log.LstdFlags),
+		logger.Config{
+			SlowThreshold: 10 * time.Second,
+			LogLevel:      logger.Warn,
+			Colorful:      true,
+		},
+	)}), nil
+}
+
+// runBackup 备份数据库并清理旧备份
+func runBackup() error {
+	fmt.Printf("=== rentpro-admin 数据库备份 v%s ===\n", global.Version)
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	path, err := backupTo(db)
+	if err != nil {
+		return err
+	}
+	if verifyAfter {
+		if err := verify(path); err != nil {
+			return err
+		}
+	}
+	return prune()
+}
+
+// backupTo 备份到备份目录并打印结果
+func backupTo(db *gorm.DB) (string, error) {
+	start := time.Now()
+	path, header, err := backup.WriteFile(db, dir, backup.Options{Images: images})
+	if err != nil {
+		return "", fmt.Errorf("备份失败: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("\n迁移版本: %s\n", header.SchemaVersion)
+	for _, table := range header.Tables {
+		fmt.Printf("  %-32s %8d 行\n", table.Name, table.Rows)
+	}
+	fmt.Printf("✅ 已备份 %d 张表、%d 行到 %s（%s，耗时 %s）\n",
+		len(header.Tables), header.Rows(), path, formatSize(info.Size()), time.Since(start).Round(time.Millisecond))
+	return path, nil
+}
+
+// runRestore 检查迁移版本后恢复
+func runRestore(path string) error {
+	fmt.Printf("=== rentpro-admin 数据库恢复 v%s ===\n", global.Version)
+
+	r, err := backup.Open(path)
+	if err != nil {
+		return err
+	}
+	header := r.Header
+	r.Close()
+	fmt.Printf("备份文件: %s\n", path)
+	fmt.Printf("备份时间: %s，迁移版本: %s，%d 张表、%d 行\n",
+		header.CreatedAt.Format("2006-01-02 15:04:05"), header.SchemaVersion, len(header.Tables), header.Rows())
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	if err := backup.CheckSchema(db, header, force); err != nil {
+		return err
+	}
+	if !assumeYes && !confirm("\n恢复将清空并覆盖以上各表的数据，输入 yes 继续: ") {
+		fmt.Println("已取消")
+		return nil
+	}
+
+	// 恢复前备份当前数据，包含与恢复文件相同的表
+	if !noBackup {
+		fmt.Println("\n- 备份当前数据...")
+		images = header.Images
+		if _, err := backupTo(db); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	if _, err := backup.Restore(db, path, backup.RestoreOptions{Force: force}); err != nil {
+		return fmt.Errorf("恢复失败（已回滚）: %v", err)
+	}
+	fmt.Printf("✅ 已恢复 %d 张表、%d 行（耗时 %s）\n", len(header.Tables), header.Rows(), time.Since(start).Round(time.Millisecond))
+
+	if noBackup {
+		return nil
+	}
+	return prune()
+}
+
+// runVerify 在临时库中验证备份
+func runVerify(path string) error {
+	fmt.Printf("=== rentpro-admin 备份验证 v%s ===\n", global.Version)
+	if path == "" {
+		files, err := backup.List(dir)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("备份目录 %s 中没有备份", dir)
+		}
+		path = files[0].Path
+	}
+	if _, err := connect(); err != nil {
+		return err
+	}
+	return verify(path)
+}
+
+// verify 将备份恢复到临时库并打印比对结果
+func verify(path string) error {
+	fmt.Printf("\n- 在临时库中验证 %s...\n", path)
+	report, err := backup.Verify(path, database.OpenScratch, migrate.ApplyTo)
+	if err != nil {
+		return fmt.Errorf("验证失败: %v", err)
+	}
+
+	for _, t := range report.Tables {
+		mark := "✅"
+		if !t.OK() {
+			mark = "❌"
+		}
+		fmt.Printf("  %s %-32s 备份 %8d 行，恢复 %8d 行\n", mark, t.Name, t.Expected, t.Restored)
+	}
+	if !report.OK() {
+		return fmt.Errorf("验证失败：恢复后的行数与备份不一致")
+	}
+	fmt.Printf("✅ 备份可以恢复（迁移版本 %s）\n", report.Header.SchemaVersion)
+	return nil
+}
+
+// runPrune 按保留策略清理旧备份
+func runPrune() error {
+	fmt.Printf("=== rentpro-admin 备份清理 v%s ===\n", global.Version)
+	return prune()
+}
+
+// prune 按 --keep、--max-age 清理备份目录
+func prune() error {
+	retention := backup.Retention{Keep: keep, MaxAge: time.Duration(maxAgeDays) * 24 * time.Hour}
+	if retention.Keep == 0 && retention.MaxAge == 0 {
+		return nil
+	}
+
+	removed, err := backup.Prune(dir, retention, dryRun)
+	for _, file := range removed {
+		if dryRun {
+			fmt.Printf("  将删除 %s（%s）\n", file.Path, file.ModTime.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Printf("  已删除 %s\n", file.Path)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(removed) > 0 && !dryRun {
+		fmt.Printf("🧹 按保留策略清理了 %d 个旧备份\n", len(removed))
+	}
+	return nil
+}
+
+// confirm 读取确认输入
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line) == "yes"
+}
+
+// formatSize 文件大小
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d 字节", size)
+	}
+}
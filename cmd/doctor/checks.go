@@ -0,0 +1,358 @@
+package doctor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	_ "rentPro/rentpro-admin/cmd/migrate/migration/version"
+	"rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/initialize"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/image"
+	"rentPro/rentpro-admin/common/models/rental"
+	"rentPro/rentpro-admin/common/models/system"
+	"rentPro/rentpro-admin/common/storage"
+	"rentPro/rentpro-admin/common/utils"
+)
+
+// doctor 检查过程中建立的连接，供后续检查使用
+type doctor struct {
+	cfg   *config.Config
+	db    *gorm.DB
+	store storage.Storage
+}
+
+// models 迁移创建的所有模型，用于比对表结构
+var models = []interface{}{
+	&system.SysUser{}, &system.SysRole{}, &system.SysMenu{}, &system.SysDept{}, &system.SysPost{},
+	&rental.SysBuildings{}, &rental.SysHouseType{}, &rental.SysCity{}, &rental.SysDistrict{}, &rental.SysBusinessArea{},
+	&rental.SysHouse{}, &rental.SysTenant{}, &rental.SysLandlord{}, &rental.SysAgent{}, &rental.SysContract{},
+	&rental.SysPoi{}, &rental.SysBuildingPoi{}, &rental.SysBuildingAmenity{}, &rental.SysReviewRecord{},
+	&image.SysImage{}, &image.SysImageCategory{}, &image.SysWatermarkPolicy{}, &image.SysDirectUpload{},
+	&base.Migration{}, &base.MigrationLock{},
+}
+
+// checkConfig 加载并校验配置文件
+func checkConfig(d *doctor) result {
+	cfg, err := config.Load(configYml)
+	if err != nil {
+		return fail("%v", err)
+	}
+	d.cfg = cfg
+
+	r := pass("%s（mode=%s）", strings.Join(cfg.Files, " → "), cfg.Settings.Application.Mode)
+	if len(cfg.Warnings) > 0 {
+		r = warn("%s（mode=%s），%d 条提示", strings.Join(cfg.Files, " → "), cfg.Settings.Application.Mode, len(cfg.Warnings))
+		r = r.with(cfg.Warnings...)
+	}
+	return r
+}
+
+// checkDatabase 连接数据库并查询版本
+func checkDatabase(d *doctor) result {
+	if d.cfg == nil {
+		return skip("配置未加载")
+	}
+	start := time.Now()
+	if err := database.Connect(); err != nil {
+		return fail("%v", err).with("连接字符串: " + config.Mask(d.cfg.Settings.Database.Source))
+	}
+	d.db = database.DB.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+
+	version, err := serverVersion(d.db)
+	if err != nil {
+		return fail("已连接，查询版本失败: %v", err)
+	}
+	return pass("%s %s（耗时 %s）", d.cfg.Settings.Database.Driver, version, time.Since(start).Round(time.Millisecond))
+}
+
+// serverVersion 数据库版本
+func serverVersion(db *gorm.DB) (string, error) {
+	query := "SELECT VERSION()"
+	switch db.Dialector.Name() {
+	case "sqlite":
+		query = "SELECT sqlite_version()"
+	case "postgres":
+		query = "SHOW server_version"
+	}
+	var version string
+	if err := db.Raw(query).Scan(&version).Error; err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// checkMigrations 检查未执行、已修改和代码中缺失的迁移，不修改数据库
+func checkMigrations(d *doctor) result {
+	if d.db == nil {
+		return skip("数据库不可用")
+	}
+	if !d.db.Migrator().HasTable(&base.Migration{}) {
+		return fail("没有迁移记录表 sys_migration，请执行 rentpro-admin migrate")
+	}
+
+	migration.Migrate.SetDb(d.db)
+	statuses, err := migration.Migrate.Status()
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	var pending, modified, missing []string
+	latest := ""
+	for _, s := range statuses {
+		switch {
+		case s.Missing:
+			missing = append(missing, s.Version)
+		case !s.Applied:
+			pending = append(pending, s.Version)
+		case s.Modified():
+			modified = append(modified, s.Version)
+		}
+		if s.Applied {
+			latest = s.Version
+		}
+	}
+
+	if len(pending) > 0 {
+		return fail("%d 个迁移未执行，请执行 rentpro-admin migrate", len(pending)).with(pending...)
+	}
+	var details []string
+	if len(modified) > 0 {
+		details = append(details, "已执行后被修改: "+strings.Join(modified, ", "))
+	}
+	if len(missing) > 0 {
+		details = append(details, "已执行但代码中没有: "+strings.Join(missing, ", ")+"（数据库比代码新？）")
+	}
+	if len(details) > 0 {
+		return warn("已执行到 %s，%d 项需要确认", latest, len(details)).with(details...)
+	}
+	return pass("共 %d 个版本，已全部执行（最新 %s）", len(statuses), latest)
+}
+
+// checkSchema 比对模型与实际的数据表：缺少的表和列为失败，多出的列、表和缺少的索引为警告
+func checkSchema(d *doctor) result {
+	if d.db == nil {
+		return skip("数据库不可用")
+	}
+	migrator := d.db.Migrator()
+	known := make(map[string]bool)
+	var failures, warnings []string
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: d.db}
+		if err := stmt.Parse(model); err != nil {
+			return fail("解析模型失败: %v", err)
+		}
+		table := stmt.Schema.Table
+		known[table] = true
+		for _, rel := range stmt.Schema.Relationships.Relations {
+			if rel.JoinTable != nil {
+				known[rel.JoinTable.Table] = true
+			}
+		}
+
+		if !migrator.HasTable(model) {
+			failures = append(failures, fmt.Sprintf("缺少表 %s", table))
+			continue
+		}
+		columns, err := migrator.ColumnTypes(model)
+		if err != nil {
+			return fail("读取表 %s 的列失败: %v", table, err)
+		}
+		existing := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			existing[strings.ToLower(column.Name())] = true
+		}
+
+		fields := make(map[string]bool)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || field.IgnoreMigration {
+				continue
+			}
+			fields[strings.ToLower(field.DBName)] = true
+			if !existing[strings.ToLower(field.DBName)] {
+				failures = append(failures, fmt.Sprintf("表 %s 缺少列 %s", table, field.DBName))
+			}
+		}
+		for _, column := range columns {
+			if !fields[strings.ToLower(column.Name())] {
+				warnings = append(warnings, fmt.Sprintf("表 %s 的列 %s 没有对应的模型字段", table, column.Name()))
+			}
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			if !migrator.HasIndex(model, index.Name) {
+				warnings = append(warnings, fmt.Sprintf("表 %s 缺少索引 %s", table, index.Name))
+			}
+		}
+	}
+
+	tables, err := migrator.GetTables()
+	if err != nil {
+		return fail("读取数据表失败: %v", err)
+	}
+	for _, table := range tables {
+		if !known[table] && !strings.HasPrefix(table, "sqlite_") {
+			warnings = append(warnings, fmt.Sprintf("表 %s 没有对应的模型", table))
+		}
+	}
+	sort.Strings(warnings)
+
+	switch {
+	case len(failures) > 0:
+		return fail("%d 处缺失，%d 处差异", len(failures), len(warnings)).with(append(failures, warnings...)...)
+	case len(warnings) > 0:
+		return warn("%d 个模型的表都存在，%d 处差异", len(models), len(warnings)).with(warnings...)
+	}
+	return pass("%d 个模型与数据表一致", len(models))
+}
+
+// checkStorage 按配置初始化文件存储，上传、读取并删除测试文件
+// 七牛云初始化失败时 dev 模式的 api 会回退到本地存储，这里视为失败
+func checkStorage(d *doctor) result {
+	if d.cfg == nil {
+		return skip("配置未加载")
+	}
+	cfg := d.cfg.Settings.Storage
+	mode := d.cfg.Settings.Application.Mode
+	driver := cfg.Driver
+	if driver == "" {
+		driver = storage.DriverQiniu
+	}
+	if driver == storage.DriverQiniu {
+		if err := initialize.InitQiniu(mode); err != nil {
+			return fail("七牛云: %v", err).with("dev 模式下 api 会回退到本地存储，其他模式无法启动")
+		}
+	}
+	if err := initialize.InitStorage(cfg, mode); err != nil {
+		return fail("%v", err)
+	}
+	d.store = storage.Default()
+	if d.store == nil {
+		return fail("文件存储未初始化")
+	}
+	if d.store.Driver() != driver {
+		return fail("配置的驱动为 %s，实际使用 %s", driver, d.store.Driver())
+	}
+
+	if noUpload {
+		return warn("%s 已初始化，未上传测试文件（--no-upload）", driver)
+	}
+	start := time.Now()
+	if err := roundTrip(d.store); err != nil {
+		return fail("%s: %v", driver, err)
+	}
+	return pass("%s 上传、读取、删除测试文件成功（耗时 %s）", driver, time.Since(start).Round(time.Millisecond))
+}
+
+// roundTrip 上传测试文件，读取校验内容后删除
+func roundTrip(store storage.Storage) error {
+	key := fmt.Sprintf("rentpro-doctor-%d.txt", time.Now().UnixNano())
+	content := []byte("rentpro-admin doctor " + time.Now().Format(time.RFC3339))
+
+	if _, err := store.Put(key, bytes.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		return fmt.Errorf("上传测试文件失败: %v", err)
+	}
+	readErr := func() error {
+		r, err := store.Get(key)
+		if err != nil {
+			return fmt.Errorf("读取测试文件失败: %v", err)
+		}
+		defer r.Close()
+		var buf bytes.Buffer
+		if _, err := buf.ReadFrom(r); err != nil {
+			return fmt.Errorf("读取测试文件失败: %v", err)
+		}
+		if !bytes.Equal(buf.Bytes(), content) {
+			return fmt.Errorf("读取的测试文件内容不一致")
+		}
+		return nil
+	}()
+	if err := store.Delete(key); err != nil {
+		return fmt.Errorf("删除测试文件 %s 失败: %v", key, err)
+	}
+	return readErr
+}
+
+// checkImageManager 初始化图片管理器，检查图片分类、上传限制和水印字体
+func checkImageManager(d *doctor) result {
+	if d.db == nil || d.store == nil {
+		return skip("数据库或文件存储不可用")
+	}
+	if err := utils.InitImageManager(); err != nil {
+		return fail("%v", err)
+	}
+
+	var warnings []string
+	var categories int64
+	if err := d.db.Model(&image.SysImageCategory{}).Where("status = ?", "active").Count(&categories).Error; err != nil {
+		return fail("查询图片分类失败: %v", err)
+	}
+	if categories == 0 {
+		warnings = append(warnings, "没有启用的图片分类，上传只使用存储配置中的限制（可以通过 /api/v1/image-categories 接口创建）")
+	}
+
+	cfg := config.GetStorageConfig()
+	if cfg.Upload.MaxFileSize <= 0 {
+		warnings = append(warnings, "未配置上传文件大小限制")
+	}
+	if len(cfg.Upload.AllowedTypes) == 0 {
+		warnings = append(warnings, "未配置允许上传的文件类型")
+	}
+	if cfg.Watermark.Font != "" {
+		if _, err := os.Stat(cfg.Watermark.Font); err != nil {
+			warnings = append(warnings, fmt.Sprintf("水印字体 %s 不可用: %v", cfg.Watermark.Font, err))
+		}
+	}
+
+	if len(warnings) > 0 {
+		return warn("已初始化（%s），%d 项需要确认", d.store.Driver(), len(warnings)).with(warnings...)
+	}
+	return pass("已初始化（%s），%d 个图片分类，单个文件最大 %d KB", d.store.Driver(), categories, cfg.Upload.MaxFileSize/1024)
+}
+
+// checkJWT 检查 JWT 密钥，与 api 启动使用同一规则（config.CheckJWTSecret）
+func checkJWT(d *doctor) result {
+	if d.cfg == nil {
+		return skip("配置未加载")
+	}
+	secret := d.cfg.Settings.JWT.Secret
+	return secretResult(d, "jwt.secret", "RENTPRO_JWT_SECRET", len(secret),
+		config.CheckJWTSecret(secret, d.cfg.Settings.Application.Mode))
+}
+
+// checkDirectUploadSecret 检查浏览器直传凭证签名密钥，与 api 启动使用同一规则（config.CheckDirectUploadSecret）
+func checkDirectUploadSecret(d *doctor) result {
+	if d.cfg == nil {
+		return skip("配置未加载")
+	}
+	secret := d.cfg.Settings.Storage.DirectUpload.Secret
+	return secretResult(d, "storage.direct_upload.secret", "RENTPRO_STORAGE_DIRECT_UPLOAD_SECRET", len(secret),
+		config.CheckDirectUploadSecret(secret, d.cfg.Settings.Application.Mode))
+}
+
+// secretResult 签名密钥的检查结果：api 拒绝启动的情况为失败，只给出提示的情况为警告
+func secretResult(d *doctor, path, env string, length int, check config.SecretCheck) result {
+	source := ""
+	for _, s := range d.cfg.Secrets {
+		if s.Path == path {
+			source = s.Source
+		}
+	}
+	hint := fmt.Sprintf("可以使用 openssl rand -base64 48 生成，通过 %s 或加密密钥文件配置", env)
+	if len(check.Errors) > 0 {
+		return fail("api 将拒绝启动（来源: %s）", source).with(append(check.Errors, hint)...)
+	}
+	if len(check.Warnings) > 0 {
+		return warn("密钥强度不足（来源: %s），dev 以外的模式下 api 将拒绝启动", source).with(append(check.Warnings, hint)...)
+	}
+	return pass("长度 %d，来源: %s", length, source)
+}
@@ -0,0 +1,193 @@
+// Package doctor 提供运行环境诊断的命令行功能
+// 依次检查配置、数据库、迁移、表结构、文件存储、图片管理器、JWT 密钥和直传签名密钥，输出每项的结果，有失败项时以非零状态退出
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"rentPro/rentpro-admin/common/global"
+)
+
+var (
+	configYml   string
+	noUpload    bool
+	strict      bool
+	verbose     bool
+	showVersion bool
+
+	// StartCmd 定义了 doctor 子命令
+	// 命令注册：通过 rootCmd.AddCommand(doctor.StartCmd) 注册到根命令
+	// 使用方式：
+	//   - rentpro-admin doctor -c config/settings.yml : 执行全部检查
+	//   - rentpro-admin doctor --no-upload            : 不上传测试文件，只检查存储能否初始化
+	//   - rentpro-admin doctor --strict               : 警告也视为失败（部署流水线使用）
+	//   - rentpro-admin doctor -v                     : 显示版本信息
+	// 版本信息来源：common/global/adm.go 中的 Version 常量
+	StartCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "诊断运行环境",
+		Long: `依次检查配置文件、数据库连接和版本、未执行的迁移、模型与数据表结构差异、
+文件存储（上传并删除测试文件）、图片管理器、JWT 密钥和直传签名密钥强度，输出每项的通过/警告/失败。
+有失败项时以非零状态退出，可以在部署流水线中使用。`,
+		Example: "rentpro-admin doctor -c config/settings.yml",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if showVersion {
+				fmt.Printf("rentpro-admin doctor version: %s\n", global.Version)
+				return nil
+			}
+			return run()
+		},
+	}
+)
+
+// init 初始化命令标志
+func init() {
+	StartCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "显示版本信息")
+	StartCmd.Flags().StringVarP(&configYml, "config", "c", "config/settings.yml", "指定配置文件路径")
+	StartCmd.Flags().BoolVar(&noUpload, "no-upload", false, "不上传测试文件")
+	StartCmd.Flags().BoolVar(&strict, "strict", false, "警告也视为失败")
+	StartCmd.Flags().BoolVar(&verbose, "verbose", false, "输出各组件初始化的日志")
+}
+
+// status 检查结果
+type status int
+
+const (
+	statusPass status = iota
+	statusWarn
+	statusFail
+	statusSkip
+)
+
+// label 结果标记
+func (s status) label() string {
+	switch s {
+	case statusPass:
+		return "✅ 通过"
+	case statusWarn:
+		return "⚠️  警告"
+	case statusFail:
+		return "❌ 失败"
+	default:
+		return "⏭️  跳过"
+	}
+}
+
+// result 一项检查的结果
+type result struct {
+	status  status
+	message string
+	details []string
+}
+
+// pass、warn、fail、skip 构造检查结果
+func pass(format string, args ...interface{}) result {
+	return result{status: statusPass, message: fmt.Sprintf(format, args...)}
+}
+
+func warn(format string, args ...interface{}) result {
+	return result{status: statusWarn, message: fmt.Sprintf(format, args...)}
+}
+
+func fail(format string, args ...interface{}) result {
+	return result{status: statusFail, message: fmt.Sprintf(format, args...)}
+}
+
+func skip(format string, args ...interface{}) result {
+	return result{status: statusSkip, message: fmt.Sprintf(format, args...)}
+}
+
+// with 附加明细
+func (r result) with(details ...string) result {
+	r.details = append(r.details, details...)
+	return r
+}
+
+// check 一项检查，后面的检查依赖前面检查建立的连接（如数据库、存储），依赖不可用时跳过
+type check struct {
+	name string
+	run  func(d *doctor) result
+}
+
+// checks 检查项，按执行顺序
+var checks = []check{
+	{"配置文件", checkConfig},
+	{"数据库连接", checkDatabase},
+	{"数据库迁移", checkMigrations},
+	{"表结构", checkSchema},
+	{"文件存储", checkStorage},
+	{"图片管理器", checkImageManager},
+	{"JWT 密钥", checkJWT},
+	{"直传签名密钥", checkDirectUploadSecret},
+}
+
+// run 执行所有检查并汇总，有失败项（--strict 时包括警告）时返回错误
+func run() error {
+	fmt.Printf("=== rentpro-admin 诊断 v%s ===\n", global.Version)
+	fmt.Printf("配置文件: %s\n\n", configYml)
+
+	// 各组件初始化时的日志与检查结果重复，默认不输出
+	if !verbose {
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	d := &doctor{}
+	counts := make(map[status]int)
+	for _, c := range checks {
+		r := c.run(d)
+		counts[r.status]++
+		fmt.Printf("%s  %s %s\n", r.status.label(), pad(c.name, 12), r.message)
+		for _, detail := range r.details {
+			fmt.Printf("           - %s\n", detail)
+		}
+	}
+
+	fmt.Printf("\n汇总: %d 项通过，%d 项警告，%d 项失败，%d 项跳过\n",
+		counts[statusPass], counts[statusWarn], counts[statusFail], counts[statusSkip])
+
+	failed := counts[statusFail]
+	if strict {
+		failed += counts[statusWarn]
+	}
+	if failed > 0 {
+		return fmt.Errorf("诊断未通过：%s", summary(counts))
+	}
+	fmt.Println("✅ 诊断通过")
+	return nil
+}
+
+// pad 按显示宽度（中文占两列）补齐空格
+func pad(s string, width int) string {
+	n := 0
+	for _, r := range s {
+		if r >= 0x2E80 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	if n >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+// summary 失败原因摘要
+func summary(counts map[status]int) string {
+	var parts []string
+	if counts[statusFail] > 0 {
+		parts = append(parts, fmt.Sprintf("%d 项失败", counts[statusFail]))
+	}
+	if strict && counts[statusWarn] > 0 {
+		parts = append(parts, fmt.Sprintf("%d 项警告（--strict）", counts[statusWarn]))
+	}
+	return strings.Join(parts, "，")
+}
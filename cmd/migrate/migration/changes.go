@@ -0,0 +1,42 @@
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"rentPro/rentpro-admin/common/models/base"
+)
+
+// 迁移变更记录
+//
+// 部分迁移按数据库当前状态决定执行内容（表已存在时只补建索引、字段，只修改满足条件的数据），
+// 回滚时需要知道实际做了什么。迁移函数把实际的修改以 JSON 写入 sys_migration.changes，
+// 回滚函数读取后只撤销这些修改。
+
+// Changes 把迁移实际执行的修改编码为 JSON，写入 base.Migration.Changes
+func Changes(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("编码迁移变更记录失败: %v", err)
+	}
+	return string(data), nil
+}
+
+// LoadChanges 读取版本执行时记录的修改
+// 执行时没有记录修改（早于变更记录的版本）时返回 false
+func LoadChanges(db *gorm.DB, version string, v interface{}) (bool, error) {
+	var record base.Migration
+	err := db.Where("version = ?", version).Limit(1).Find(&record).Error
+	if err != nil {
+		return false, fmt.Errorf("读取迁移记录失败: %v", err)
+	}
+	if record.Changes == "" {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(record.Changes), v); err != nil {
+		return false, fmt.Errorf("解析迁移变更记录失败: %v", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,176 @@
+package migration
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"rentPro/rentpro-admin/common/models/base"
+)
+
+// 迁移校验和
+//
+// 版本包通过 SetSource 提供迁移文件源码（go:embed），校验和取 SetVersion 注册的迁移函数
+// 去掉注释后的源码 SHA-256。版本执行时记录到 sys_migration.checksum，
+// 之后执行迁移前比对，发现已执行的迁移函数被修改时拒绝继续（--force 时接受修改）。
+// 回滚函数、注释不参与计算；迁移函数引用的模型结构体变化无法检测。
+
+// SetSource 设置迁移文件源码，用于计算各版本迁移函数的校验和
+func (e *Migration) SetSource(source fs.FS) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.source = source
+	e.checksums = nil
+}
+
+// Verify 比对已执行版本的校验和
+// 迁移函数被修改的版本返回错误，force 为 true 时接受修改并更新记录的校验和；
+// 执行时还没有记录校验和的版本补写当前校验和。
+// skip 中的版本不检查（redo 会重新执行最近的版本，允许修改）
+func (e *Migration) Verify(force bool, skip ...string) error {
+	statuses, err := e.Status()
+	if err != nil {
+		return err
+	}
+
+	var modified, record []string
+	for _, s := range statuses {
+		if !s.Applied || s.Checksum == "" || contains(skip, s.Version) {
+			continue
+		}
+		switch {
+		case s.StoredChecksum == "":
+			record = append(record, s.Version)
+		case s.Modified():
+			modified = append(modified, s.Version)
+		}
+	}
+
+	if len(modified) > 0 {
+		if !force {
+			return fmt.Errorf("已执行的迁移被修改: %s，请恢复迁移文件或改为新增迁移版本，确认修改无误时使用 --force 继续",
+				strings.Join(modified, ", "))
+		}
+		fmt.Printf("⚠️  已执行的迁移被修改: %s，--force 已接受修改\n", strings.Join(modified, ", "))
+		record = append(record, modified...)
+	}
+
+	sums, err := e.sourceChecksums()
+	if err != nil {
+		return err
+	}
+	for _, v := range record {
+		err := e.db.Model(&base.Migration{}).Where("version = ?", v).Update("checksum", sums[v]).Error
+		if err != nil {
+			return fmt.Errorf("记录迁移 %s 校验和失败: %v", v, err)
+		}
+	}
+	return nil
+}
+
+// sourceChecksums 各版本迁移函数的校验和，没有源码的版本不包含在内
+func (e *Migration) sourceChecksums() (map[string]string, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.checksums != nil || e.source == nil {
+		return e.checksums, nil
+	}
+
+	files, err := fs.Glob(e.source, "*.go")
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移文件失败: %v", err)
+	}
+	sums := make(map[string]string)
+	for _, name := range files {
+		data, err := fs.ReadFile(e.source, name)
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件 %s 失败: %v", name, err)
+		}
+		if err := fileChecksums(name, data, sums); err != nil {
+			return nil, err
+		}
+	}
+	e.checksums = sums
+	return sums, nil
+}
+
+// fileChecksums 计算迁移文件中注册的迁移函数的校验和
+// 在 init 中查找 SetVersion(版本, 函数) 调用，版本为字符串常量或 GetFilename(文件名)
+func fileChecksums(name string, data []byte, sums map[string]string) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, data, 0)
+	if err != nil {
+		return fmt.Errorf("解析迁移文件 %s 失败: %v", name, err)
+	}
+
+	funcs := make(map[string]*ast.FuncDecl)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+			funcs[fn.Name.Name] = fn
+		}
+	}
+	initFn, ok := funcs["init"]
+	if !ok {
+		return nil
+	}
+
+	var walkErr error
+	ast.Inspect(initFn, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "SetVersion" {
+			return true
+		}
+		version := registeredVersion(name, call.Args[0])
+		ident, ok := call.Args[1].(*ast.Ident)
+		if version == "" || !ok || funcs[ident.Name] == nil {
+			return true
+		}
+
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, fset, funcs[ident.Name]); err != nil {
+			walkErr = fmt.Errorf("计算迁移 %s 校验和失败: %v", version, err)
+			return false
+		}
+		sum := sha256.Sum256(buf.Bytes())
+		sums[version] = hex.EncodeToString(sum[:])
+		return true
+	})
+	return walkErr
+}
+
+// registeredVersion SetVersion 第一个参数对应的版本号，无法确定时返回空
+func registeredVersion(name string, arg ast.Expr) string {
+	switch v := arg.(type) {
+	case *ast.BasicLit:
+		if s, err := strconv.Unquote(v.Value); err == nil {
+			return s
+		}
+	case *ast.CallExpr:
+		if sel, ok := v.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "GetFilename" {
+			return GetFilename(name)
+		}
+	}
+	return ""
+}
+
+// contains 版本是否在列表中
+func contains(versions []string, v string) bool {
+	for _, version := range versions {
+		if version == v {
+			return true
+		}
+	}
+	return false
+}
@@ -5,13 +5,21 @@
 // 1. 版本化迁移管理：按时间戳排序执行迁移脚本
 // 2. 迁移状态跟踪：记录已执行的迁移，避免重复执行
 // 3. 并发安全：使用互斥锁保证迁移注册的线程安全
-// 4. 错误处理：提供完整的错误处理和日志记录
+// 4. 错误处理：迁移失败时返回错误，由命令决定如何处理
+// 5. 回滚：版本可以注册回滚函数，支持回滚最近的迁移和重做
+// 6. 事务：驱动支持事务性 DDL（PostgreSQL、SQLite）时每个版本在事务中执行
+// 7. 迁移锁：数据库级别的锁，避免多个实例同时执行迁移
+// 8. 校验和：记录迁移函数的校验和，检测已执行的迁移被修改
 //
 // 使用方式：
 //  1. 通过 init() 函数自动注册迁移：
 //     migration.Migrate.SetVersion("版本号", 迁移函数)
+//     migration.Migrate.SetDown("版本号", 回滚函数) // 可选
 //  2. 在迁移命令中调用：
 //     migration.Migrate.SetDb(数据库连接)
+//     unlock, err := migration.Migrate.Lock(超时时间)
+//     defer unlock()
+//     migration.Migrate.Verify(force)
 //     migration.Migrate.Migrate()
 //
 // 设计模式：
@@ -21,24 +29,30 @@
 package migration
 
 import (
+	"fmt"
+	"io/fs"
 	"log"
 	"path/filepath"
 	"sort"
 	"sync"
+	"time"
 
 	"gorm.io/gorm"
+
+	"rentPro/rentpro-admin/common/models/base"
 )
 
 // Migrate 全局迁移管理器实例
 // 这是一个全局单例，用于管理所有的数据库迁移操作
-// 初始化时创建了一个空的 version map，用于存储所有注册的迁移函数
-// 通过各个包的 init() 函数调用 SetVersion() 方法进行迁移注册
+// 初始化时创建了空的 version、down map，用于存储所有注册的迁移、回滚函数
+// 通过各个包的 init() 函数调用 SetVersion()、SetDown() 方法进行迁移注册
 var Migrate = &Migration{
 	version: make(map[string]func(db *gorm.DB, version string) error),
+	down:    make(map[string]func(db *gorm.DB, version string) error),
 }
 
 // Migration 数据库迁移管理器结构体
-// 负责管理数据库迁移的整个生命周期，包括注册、执行和状态管理
+// 负责管理数据库迁移的整个生命周期，包括注册、执行、回滚和状态管理
 type Migration struct {
 	// db GORM 数据库连接实例
 	// 用于执行迁移操作和查询迁移状态
@@ -49,11 +63,40 @@ type Migration struct {
 	// value: 迁移执行函数，接收数据库连接和版本号参数
 	version map[string]func(db *gorm.DB, version string) error
 
+	// down 版本号到回滚函数的映射，没有注册回滚函数的版本不能回滚
+	down map[string]func(db *gorm.DB, version string) error
+
+	// source 迁移文件源码，用于计算校验和；checksums 为计算结果缓存
+	source    fs.FS
+	checksums map[string]string
+
+	// locked 是否持有迁移锁；lockLost 迁移锁续期时发现已失效
+	locked   bool
+	lockLost bool
+
 	// mutex 互斥锁，用于保证并发安全
 	// 在注册迁移函数时防止竞态条件，确保 map 操作的原子性
 	mutex sync.Mutex
 }
 
+// VersionStatus 迁移版本状态
+type VersionStatus struct {
+	Version    string    // 版本号
+	Name       string    // 迁移名称（已执行的版本取自 sys_migration）
+	Applied    bool      // 是否已执行
+	AppliedAt  time.Time // 执行时间
+	Reversible bool      // 是否注册了回滚函数
+	Missing    bool      // 已执行但代码中没有注册（版本文件已删除）
+
+	Checksum       string // 当前迁移函数的校验和，没有源码时为空
+	StoredChecksum string // 执行时记录的校验和，执行时还没有校验和功能的版本为空
+}
+
+// Modified 已执行的迁移函数是否被修改
+func (s *VersionStatus) Modified() bool {
+	return s.Checksum != "" && s.StoredChecksum != "" && s.Checksum != s.StoredChecksum
+}
+
 // GetDb 获取当前的数据库连接实例
 // 返回值: *gorm.DB - GORM 数据库连接实例，如果未设置则返回 nil
 // 使用场景：在迁移函数中获取数据库连接进行操作
@@ -64,7 +107,7 @@ func (e *Migration) GetDb() *gorm.DB {
 // SetDb 设置数据库连接实例
 // 参数: db *gorm.DB - GORM 数据库连接实例
 // 使用场景：在执行迁移之前设置数据库连接
-// 注意：必须在调用 Migrate() 方法之前设置数据库连接
+// 注意：必须在调用 Migrate() 等方法之前设置数据库连接
 func (e *Migration) SetDb(db *gorm.DB) {
 	e.db = db
 }
@@ -92,65 +135,248 @@ func (e *Migration) SetVersion(k string, f func(db *gorm.DB, version string) err
 	e.version[k] = f
 }
 
-// Migrate 执行所有已注册的数据库迁移
-// 这是整个迁移系统的核心方法，负责管理和执行所有迁移操作
+// SetDown 注册版本的回滚函数
+// 回滚函数撤销迁移函数的修改，不需要删除 sys_migration 记录（由管理器删除）
 //
-// 执行流程：
-// 1. 收集所有已注册的版本号
-// 2. 按版本号进行字典序排列（确保按时间顺序执行）
-// 3. 逐个检查每个版本是否已经执行过
-// 4. 对于未执行的版本，调用对应的迁移函数
-// 5. 在执行过程中记录日志和处理错误
+// 示例:
+//
+//	migration.Migrate.SetDown("1700000000001", dropUsersTable)
+func (e *Migration) SetDown(k string, f func(db *gorm.DB, version string) error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.down[k] = f
+}
+
+// Migrate 执行所有未执行的数据库迁移
+// 等同于 Up("")
+func (e *Migration) Migrate() error {
+	return e.Up("")
+}
+
+// Up 按版本号顺序执行未执行的迁移
+// to 不为空时只执行到该版本（包含），to 必须是已注册的版本
 //
 // 数据库表依赖：
 //
 //	依赖 sys_migration 表来记录已执行的迁移
-//	表结构包含 version 字段用于检查迁移状态
+//	迁移函数负责写入 sys_migration 记录，未写入时由管理器补写
 //
 // 错误处理：
 //
-//	如果任何迁移执行失败，会调用 log.Fatalln 终止程序
-//	这确保了数据库状态的一致性和安全性
-//
-// 注意事项：
-//
-//	调用前必须先调用 SetDb() 设置数据库连接
-func (e *Migration) Migrate() {
-	// 收集所有已注册的版本号
-	versions := make([]string, 0)
-	for k := range e.version {
-		versions = append(versions, k)
+//	任何版本执行失败时停止并返回错误，之前已执行的版本保留
+func (e *Migration) Up(to string) error {
+	if to != "" {
+		if _, ok := e.version[to]; !ok {
+			return fmt.Errorf("迁移版本不存在: %s", to)
+		}
 	}
 
-	// 确保版本号按字典序排列（时间戳格式保证了正确的时间顺序）
-	if !sort.StringsAreSorted(versions) {
-		sort.Strings(versions)
+	applied, err := e.applied()
+	if err != nil {
+		return err
+	}
+	sums, err := e.sourceChecksums()
+	if err != nil {
+		return err
 	}
 
-	var err error
-	var count int64
+	for _, v := range e.versions() {
+		if to != "" && v > to {
+			break
+		}
+		if _, ok := applied[v]; ok {
+			continue
+		}
 
-	// 逐个执行迁移
-	for _, v := range versions {
-		// 检查该版本是否已经执行过
-		err = e.db.Table("sys_migration").Where("version = ?", v).Count(&count).Error
+		log.Printf("执行迁移 %s", v)
+		err := e.run(func(tx *gorm.DB) error {
+			if err := e.version[v](tx, v); err != nil {
+				return err
+			}
+			if err := ensureRecord(tx, v); err != nil {
+				return err
+			}
+			if sums[v] == "" {
+				return nil
+			}
+			return tx.Model(&base.Migration{}).Where("version = ?", v).Update("checksum", sums[v]).Error
+		})
 		if err != nil {
-			log.Fatalln(err)
+			return fmt.Errorf("迁移 %s 执行失败: %v", v, err)
 		}
+	}
+	return nil
+}
 
-		// 如果已经执行过，跳过该版本
-		if count > 0 {
-			log.Println(count)
-			count = 0
-			continue
+// Down 回滚最近执行的 n 个迁移
+// 按执行的版本号倒序回滚，遇到没有注册回滚函数的版本时停止并返回错误
+func (e *Migration) Down(n int) error {
+	applied, err := e.applied()
+	if err != nil {
+		return err
+	}
+
+	versions := make([]string, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+	if n > len(versions) {
+		n = len(versions)
+	}
+
+	for _, v := range versions[:n] {
+		down, ok := e.down[v]
+		if !ok {
+			return fmt.Errorf("迁移 %s 没有注册回滚函数，无法回滚", v)
 		}
 
-		// 执行迁移函数
-		err = (e.version[v])(e.db.Debug(), v)
+		log.Printf("回滚迁移 %s", v)
+		err := e.run(func(tx *gorm.DB) error {
+			if err := down(tx, v); err != nil {
+				return err
+			}
+			return tx.Where("version = ?", v).Delete(&base.Migration{}).Error
+		})
 		if err != nil {
-			log.Fatalln(err)
+			return fmt.Errorf("迁移 %s 回滚失败: %v", v, err)
+		}
+	}
+	return nil
+}
+
+// Redo 回滚最近执行的迁移并重新执行
+func (e *Migration) Redo() error {
+	applied, err := e.applied()
+	if err != nil {
+		return err
+	}
+	last := ""
+	for v := range applied {
+		if v > last {
+			last = v
 		}
 	}
+	if last == "" {
+		return fmt.Errorf("没有已执行的迁移")
+	}
+	if _, ok := e.version[last]; !ok {
+		return fmt.Errorf("迁移 %s 在代码中没有注册，无法重新执行", last)
+	}
+
+	if err := e.Down(1); err != nil {
+		return err
+	}
+	return e.Up(last)
+}
+
+// Status 所有版本的执行状态，按版本号排序
+// 包含已注册的版本和 sys_migration 中有记录但代码中没有注册的版本
+func (e *Migration) Status() ([]VersionStatus, error) {
+	applied, err := e.applied()
+	if err != nil {
+		return nil, err
+	}
+	sums, err := e.sourceChecksums()
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[string]bool)
+	for _, v := range e.versions() {
+		all[v] = true
+	}
+	for v := range applied {
+		all[v] = true
+	}
+	versions := make([]string, 0, len(all))
+	for v := range all {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	statuses := make([]VersionStatus, 0, len(versions))
+	for _, v := range versions {
+		_, registered := e.version[v]
+		_, reversible := e.down[v]
+		status := VersionStatus{Version: v, Reversible: reversible, Missing: !registered, Checksum: sums[v]}
+		if record, ok := applied[v]; ok {
+			status.Applied = true
+			status.Name = record.Name
+			status.AppliedAt = record.CreatedAt
+			status.StoredChecksum = record.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// versions 已注册的版本号，按字典序排列（时间戳格式保证了正确的时间顺序）
+func (e *Migration) versions() []string {
+	versions := make([]string, 0, len(e.version))
+	for k := range e.version {
+		versions = append(versions, k)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// applied 已执行的迁移记录
+func (e *Migration) applied() (map[string]base.Migration, error) {
+	if e.db == nil {
+		return nil, fmt.Errorf("数据库连接未设置")
+	}
+	var records []base.Migration
+	if err := e.db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %v", err)
+	}
+	applied := make(map[string]base.Migration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// run 执行一个版本的迁移或回滚
+// 驱动支持事务性 DDL 时在事务中执行，失败时整体回滚；
+// MySQL 的 DDL 会隐式提交事务，不使用事务，失败时可能需要手动清理
+// 执行前后检查迁移锁，锁已失效时返回错误（事务中执行时不提交）
+func (e *Migration) run(fn func(tx *gorm.DB) error) error {
+	if err := e.checkLock(); err != nil {
+		return err
+	}
+	locked := func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return e.checkLock()
+	}
+	if transactionalDDL(e.db) {
+		return e.db.Transaction(locked)
+	}
+	return locked(e.db)
+}
+
+// transactionalDDL 驱动是否支持在事务中执行 DDL
+func transactionalDDL(db *gorm.DB) bool {
+	switch db.Dialector.Name() {
+	case "postgres", "sqlite":
+		return true
+	default:
+		return false
+	}
+}
+
+// ensureRecord 迁移函数没有写入 sys_migration 记录时补写
+func ensureRecord(db *gorm.DB, version string) error {
+	var count int64
+	if err := db.Model(&base.Migration{}).Where("version = ?", version).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return db.Create(&base.Migration{Version: version, Status: "completed"}).Error
 }
 
 // GetFilename 从文件路径中提取时间戳版本号
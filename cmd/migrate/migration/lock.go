@@ -0,0 +1,160 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"rentPro/rentpro-admin/common/models/base"
+)
+
+// 迁移锁
+//
+// 多个实例同时启动迁移时，都会把同一个版本判断为未执行并重复执行。
+// 执行迁移前在 sys_migration_lock 表插入 ID 为 1 的记录，插入成功即获得锁，
+// 主键冲突说明锁被其他进程持有，按间隔重试直到超时。
+// 持有者定期延长过期时间，进程异常退出后锁在过期后可以被其他进程获取。
+// 续期时锁已不属于当前进程（已过期并被清理或被其他进程获取），标记锁失效，
+// 每个版本执行前后检查，失效时停止执行，支持事务性 DDL 的数据库回滚当前版本。
+
+const (
+	lockID        = 1               // 锁记录ID
+	lockTTL       = time.Minute     // 锁的有效期，持有者每 lockTTL/3 延长一次
+	lockRetryWait = 2 * time.Second // 获取锁失败后的重试间隔
+)
+
+// Lock 获取迁移锁，timeout 内获取不到时返回错误（timeout 为 0 时只尝试一次）
+// 返回的 unlock 函数用于释放锁
+func (e *Migration) Lock(timeout time.Duration) (unlock func(), err error) {
+	if e.db == nil {
+		return nil, fmt.Errorf("数据库连接未设置")
+	}
+	// 加锁和续期不输出 SQL 日志
+	db := e.db.Session(&gorm.Session{Logger: logger.Discard})
+	owner := lockOwner()
+	deadline := time.Now().Add(timeout)
+	waiting := false
+
+	for {
+		acquired, holder, err := tryLock(db, owner)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+		if holder == nil {
+			// 持有者恰好释放了锁，稍后重试
+			holder = &base.MigrationLock{Owner: "未知进程", LockedAt: time.Now(), ExpiresAt: time.Now()}
+		}
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("等待迁移锁超时：锁由 %s 于 %s 获取，%s 过期",
+				holder.Owner, holder.LockedAt.Format("2006-01-02 15:04:05"), holder.ExpiresAt.Format("2006-01-02 15:04:05"))
+		}
+		if !waiting {
+			log.Printf("迁移锁由 %s 持有，等待释放（最长 %s）", holder.Owner, timeout)
+			waiting = true
+		}
+		time.Sleep(lockRetryWait)
+	}
+
+	// 定期延长过期时间
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				result := db.Model(&base.MigrationLock{}).
+					Where("id = ? AND owner = ?", lockID, owner).
+					Update("expires_at", time.Now().Add(lockTTL))
+				if result.Error != nil {
+					log.Printf("迁移锁续期失败: %v", result.Error)
+					continue
+				}
+				if result.RowsAffected == 0 {
+					// 锁已过期并被清理或被其他进程获取，停止执行后续迁移
+					log.Printf("迁移锁已失效，停止执行迁移")
+					e.setLockLost()
+					return
+				}
+			}
+		}
+	}()
+
+	e.mutex.Lock()
+	e.locked, e.lockLost = true, false
+	e.mutex.Unlock()
+
+	return func() {
+		close(stop)
+		<-done
+		e.mutex.Lock()
+		e.locked, e.lockLost = false, false
+		e.mutex.Unlock()
+		if err := db.Where("id = ? AND owner = ?", lockID, owner).Delete(&base.MigrationLock{}).Error; err != nil {
+			log.Printf("释放迁移锁失败: %v", err)
+		}
+	}, nil
+}
+
+// setLockLost 标记迁移锁已失效
+func (e *Migration) setLockLost() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.lockLost = true
+}
+
+// checkLock 持有迁移锁时检查锁是否仍然有效，未加锁时不检查
+func (e *Migration) checkLock() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.locked && e.lockLost {
+		return fmt.Errorf("迁移锁已失效（续期前锁已过期或被其他进程获取），停止执行迁移")
+	}
+	return nil
+}
+
+// tryLock 尝试获取一次迁移锁，获取失败时返回当前持有者（持有者恰好释放了锁时为 nil）
+func tryLock(db *gorm.DB, owner string) (bool, *base.MigrationLock, error) {
+	now := time.Now()
+	// 清理过期的锁（持有者异常退出）
+	if err := db.Where("id = ? AND expires_at < ?", lockID, now).Delete(&base.MigrationLock{}).Error; err != nil {
+		return false, nil, fmt.Errorf("清理过期迁移锁失败: %v", err)
+	}
+
+	lock := &base.MigrationLock{ID: lockID, Owner: owner, LockedAt: now, ExpiresAt: now.Add(lockTTL)}
+	if err := db.Create(lock).Error; err == nil {
+		return true, nil, nil
+	}
+
+	// 插入失败：锁被持有（主键冲突），或持有者恰好释放了锁
+	var holder base.MigrationLock
+	err := db.Where("id = ?", lockID).First(&holder).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("查询迁移锁失败: %v", err)
+	}
+	return false, &holder, nil
+}
+
+// lockOwner 锁持有者标识：主机名:进程号
+func lockOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
@@ -0,0 +1,81 @@
+package version
+
+import (
+	"fmt"
+
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/rental"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1760500000000", migrate_1760500000000)
+	migration.Migrate.SetDown("1760500000000", rollback_1760500000000)
+}
+
+// changes_1760500000000 迁移创建的表，回滚时只删除这些表
+type changes_1760500000000 struct {
+	Tables []string `json:"tables"`
+}
+
+// migrate_1760500000000 迁移函数
+// 创建房屋、合同、租户、房东、经纪人业务表（全局搜索依赖这些表）
+// 已存在的表（如初始化时创建的 sys_houses）只补齐字段，不记录为本迁移创建
+func migrate_1760500000000(db *gorm.DB, version string) error {
+	rentalModels := []interface{}{
+		&rental.SysHouse{},
+		&rental.SysTenant{},
+		&rental.SysLandlord{},
+		&rental.SysAgent{},
+		&rental.SysContract{},
+	}
+
+	var created changes_1760500000000
+	for _, model := range rentalModels {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		if !db.Migrator().HasTable(stmt.Schema.Table) {
+			created.Tables = append(created.Tables, stmt.Schema.Table)
+		}
+		if err := db.AutoMigrate(model); err != nil {
+			return err
+		}
+	}
+
+	changes, err := migration.Changes(created)
+	if err != nil {
+		return err
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "创建房屋、合同、租户、房东、经纪人业务表",
+		Status:  "completed",
+		Changes: changes,
+	}).Error
+}
+
+// rollback_1760500000000 回滚函数
+// 按创建的倒序删除本迁移创建的表，迁移前已存在的表保留
+func rollback_1760500000000(db *gorm.DB, version string) error {
+	var created changes_1760500000000
+	found, err := migration.LoadChanges(db, version, &created)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("执行时没有记录创建了哪些表，无法确定 sys_houses 等表是否由本迁移创建，请手工回滚")
+	}
+
+	for i := len(created.Tables) - 1; i >= 0; i-- {
+		if err := db.Migrator().DropTable(created.Tables[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package version
+
+import (
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/rental"
+	"rentPro/rentpro-admin/common/models/system"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1760600000000", migrate_1760600000000)
+	migration.Migrate.SetDown("1760600000000", rollback_1760600000000)
+}
+
+// migrate_1760600000000 迁移函数
+// 创建审核记录表，并添加楼盘审核按钮权限
+func migrate_1760600000000(db *gorm.DB, version string) error {
+	if err := db.AutoMigrate(&rental.SysReviewRecord{}); err != nil {
+		return err
+	}
+
+	// 楼盘审核权限挂在楼盘管理菜单下
+	reviewMenu := system.SysMenu{
+		Name:       "BuildingReview",
+		Title:      "楼盘审核",
+		Permission: "rental:building:review",
+		ParentID:   21,
+		Type:       "F",
+		Sort:       1,
+		Visible:    "1",
+		MenuType:   "3",
+		Status:     "0",
+		Perms:      "rental:building:review",
+	}
+	if err := db.Where("permission = ?", reviewMenu.Permission).FirstOrCreate(&reviewMenu).Error; err != nil {
+		return err
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "创建审核记录表并添加楼盘审核权限",
+		Status:  "completed",
+	}).Error
+}
+
+// rollback_1760600000000 回滚函数
+// 删除楼盘审核权限和审核记录表
+func rollback_1760600000000(db *gorm.DB, version string) error {
+	if err := db.Where("permission = ?", "rental:building:review").Delete(&system.SysMenu{}).Error; err != nil {
+		return err
+	}
+	return db.Migrator().DropTable(&rental.SysReviewRecord{})
+}
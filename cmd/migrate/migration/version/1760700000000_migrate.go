@@ -0,0 +1,94 @@
+package version
+
+import (
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/rental"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1760700000000", migrate_1760700000000)
+	migration.Migrate.SetDown("1760700000000", rollback_1760700000000)
+}
+
+// changes_1760700000000 迁移创建的表和楼盘表补加的经纬度字段，回滚时只撤销这些修改
+type changes_1760700000000 struct {
+	Tables  []string `json:"tables"`
+	Columns []string `json:"columns"`
+}
+
+// migrate_1760700000000 迁移函数
+// 创建周边POI字典、楼盘POI关联、楼盘配套设施表，楼盘增加经纬度
+// 新建的数据库中经纬度已由初始迁移创建，只记录本迁移实际补加的字段
+func migrate_1760700000000(db *gorm.DB, version string) error {
+	var done changes_1760700000000
+	for _, field := range []string{"Longitude", "Latitude"} {
+		if !db.Migrator().HasColumn(&rental.SysBuildings{}, field) {
+			done.Columns = append(done.Columns, field)
+		}
+	}
+	if err := db.AutoMigrate(&rental.SysBuildings{}); err != nil {
+		return err
+	}
+
+	models := []interface{}{
+		&rental.SysPoi{},
+		&rental.SysBuildingPoi{},
+		&rental.SysBuildingAmenity{},
+	}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		if !db.Migrator().HasTable(stmt.Schema.Table) {
+			done.Tables = append(done.Tables, stmt.Schema.Table)
+		}
+		if err := db.AutoMigrate(model); err != nil {
+			return err
+		}
+	}
+
+	changes, err := migration.Changes(done)
+	if err != nil {
+		return err
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "创建周边POI和楼盘配套设施表",
+		Status:  "completed",
+		Changes: changes,
+	}).Error
+}
+
+// rollback_1760700000000 回滚函数
+// 按创建的倒序删除本迁移创建的表，只删除本迁移补加的经纬度字段
+// 执行时没有记录修改的版本：POI 相关表只由本迁移创建，直接删除；经纬度字段无法确定来源，保留
+func rollback_1760700000000(db *gorm.DB, version string) error {
+	var done changes_1760700000000
+	found, err := migration.LoadChanges(db, version, &done)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return db.Migrator().DropTable(&rental.SysBuildingAmenity{}, &rental.SysBuildingPoi{}, &rental.SysPoi{})
+	}
+
+	for i := len(done.Tables) - 1; i >= 0; i-- {
+		if err := db.Migrator().DropTable(done.Tables[i]); err != nil {
+			return err
+		}
+	}
+	for _, field := range done.Columns {
+		if db.Migrator().HasColumn(&rental.SysBuildings{}, field) {
+			if err := db.Migrator().DropColumn(&rental.SysBuildings{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package version
+
+import (
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/image"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1760800000000", migrate_1760800000000)
+	migration.Migrate.SetDown("1760800000000", rollback_1760800000000)
+}
+
+// changes_1760800000000 迁移创建的表或索引，回滚时只撤销这些修改
+type changes_1760800000000 struct {
+	CreatedTable bool `json:"created_table"`
+	CreatedIndex bool `json:"created_index"`
+}
+
+// migrate_1760800000000 迁移函数
+// 图片表增加内容Hash索引，用于上传去重
+func migrate_1760800000000(db *gorm.DB, version string) error {
+	var done changes_1760800000000
+
+	// 图片表通常由 config/sql/migrations/create_images_table.sql 创建，只补建索引
+	if !db.Migrator().HasTable(&image.SysImage{}) {
+		if err := db.AutoMigrate(&image.SysImage{}); err != nil {
+			return err
+		}
+		done.CreatedTable = true
+	} else if !db.Migrator().HasIndex(&image.SysImage{}, "Hash") {
+		if err := db.Migrator().CreateIndex(&image.SysImage{}, "Hash"); err != nil {
+			return err
+		}
+		done.CreatedIndex = true
+	}
+
+	changes, err := migration.Changes(done)
+	if err != nil {
+		return err
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "图片表增加内容Hash索引",
+		Status:  "completed",
+		Changes: changes,
+	}).Error
+}
+
+// rollback_1760800000000 回滚函数
+// 迁移创建了图片表时删除图片表，只补建了索引时删除索引
+func rollback_1760800000000(db *gorm.DB, version string) error {
+	var done changes_1760800000000
+	found, err := migration.LoadChanges(db, version, &done)
+	if err != nil {
+		return err
+	}
+	if !found {
+		// 早于变更记录执行的版本无法区分，按只补建了索引处理
+		done.CreatedIndex = db.Migrator().HasIndex(&image.SysImage{}, "Hash")
+	}
+
+	switch {
+	case done.CreatedTable:
+		return db.Migrator().DropTable(&image.SysImage{})
+	case done.CreatedIndex:
+		return db.Migrator().DropIndex(&image.SysImage{}, "Hash")
+	}
+	return nil
+}
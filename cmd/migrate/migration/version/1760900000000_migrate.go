@@ -0,0 +1,98 @@
+package version
+
+import (
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/image"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1760900000000", migrate_1760900000000)
+	migration.Migrate.SetDown("1760900000000", rollback_1760900000000)
+}
+
+// changes_1760900000000 迁移创建的表、字段和填充的分类，回滚时只撤销这些修改
+type changes_1760900000000 struct {
+	CreatedTable bool     `json:"created_table"`
+	AddedColumn  bool     `json:"added_column"`
+	Filled       []string `json:"filled"`
+}
+
+// migrate_1760900000000 迁移函数
+// 图片分类表增加适用模块，用于楼盘、户型详情的必填图片提示
+func migrate_1760900000000(db *gorm.DB, version string) error {
+	var done changes_1760900000000
+
+	// 分类表通常由 config/sql/migrations/create_images_table.sql 创建，只补加字段
+	if !db.Migrator().HasTable(&image.SysImageCategory{}) {
+		if err := db.AutoMigrate(&image.SysImageCategory{}); err != nil {
+			return err
+		}
+		done.CreatedTable = true
+	} else if !db.Migrator().HasColumn(&image.SysImageCategory{}, "Modules") {
+		if err := db.Migrator().AddColumn(&image.SysImageCategory{}, "Modules"); err != nil {
+			return err
+		}
+		done.AddedColumn = true
+	}
+
+	// 默认分类的适用模块
+	modules := map[string]string{
+		"building":   `["building"]`,
+		"house":      `["house"]`,
+		"floor_plan": `["house", "house_floor_plan"]`,
+	}
+	for _, code := range []string{"building", "house", "floor_plan"} {
+		result := db.Model(&image.SysImageCategory{}).
+			Where("code = ? AND modules IS NULL", code).
+			Update("modules", gorm.Expr("?", modules[code]))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			done.Filled = append(done.Filled, code)
+		}
+	}
+
+	changes, err := migration.Changes(done)
+	if err != nil {
+		return err
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "图片分类表增加适用模块",
+		Status:  "completed",
+		Changes: changes,
+	}).Error
+}
+
+// rollback_1760900000000 回滚函数
+// 迁移创建了分类表时删除分类表，补加了字段时删除字段，
+// 字段原本存在时只把迁移填充的适用模块恢复为空
+func rollback_1760900000000(db *gorm.DB, version string) error {
+	var done changes_1760900000000
+	found, err := migration.LoadChanges(db, version, &done)
+	if err != nil {
+		return err
+	}
+	if !found {
+		// 早于变更记录执行的版本无法区分，按补加了字段处理
+		done.AddedColumn = db.Migrator().HasColumn(&image.SysImageCategory{}, "Modules")
+	}
+
+	switch {
+	case done.CreatedTable:
+		return db.Migrator().DropTable(&image.SysImageCategory{})
+	case done.AddedColumn:
+		return db.Migrator().DropColumn(&image.SysImageCategory{}, "Modules")
+	case len(done.Filled) > 0:
+		return db.Model(&image.SysImageCategory{}).
+			Where("code IN ?", done.Filled).
+			Update("modules", gorm.Expr("NULL")).Error
+	}
+	return nil
+}
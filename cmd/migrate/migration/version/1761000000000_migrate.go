@@ -0,0 +1,73 @@
+package version
+
+import (
+	"fmt"
+
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/image"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1761000000000", migrate_1761000000000)
+	migration.Migrate.SetDown("1761000000000", rollback_1761000000000)
+}
+
+// changes_1761000000000 迁移修改了模块的图片ID，回滚时只恢复这些图片
+type changes_1761000000000 struct {
+	IDs []uint `json:"ids"`
+}
+
+// migrate_1761000000000 迁移函数
+// 旧的户型图上传接口使用 house 模块、户型ID保存户型图，与房屋图片冲突，改为 house_floor_plan 模块
+func migrate_1761000000000(db *gorm.DB, version string) error {
+	var moved changes_1761000000000
+	err := db.Model(&image.SysImage{}).
+		Where("module = ? AND category = ?", "house", "floor_plan").
+		Pluck("id", &moved.IDs).Error
+	if err != nil {
+		return err
+	}
+	if len(moved.IDs) > 0 {
+		err = db.Model(&image.SysImage{}).
+			Where("id IN ?", moved.IDs).
+			Update("module", "house_floor_plan").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	changes, err := migration.Changes(moved)
+	if err != nil {
+		return err
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "户型图迁移到house_floor_plan模块",
+		Status:  "completed",
+		Changes: changes,
+	}).Error
+}
+
+// rollback_1761000000000 回滚函数
+// 只把迁移修改过的户型图恢复为 house 模块，之后通过户型图接口上传的 house_floor_plan 图片保留
+func rollback_1761000000000(db *gorm.DB, version string) error {
+	var moved changes_1761000000000
+	found, err := migration.LoadChanges(db, version, &moved)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("执行时没有记录修改了哪些图片，无法与之后上传的户型图区分，请手工回滚")
+	}
+	if len(moved.IDs) == 0 {
+		return nil
+	}
+	return db.Model(&image.SysImage{}).
+		Where("id IN ? AND module = ?", moved.IDs, "house_floor_plan").
+		Update("module", "house").Error
+}
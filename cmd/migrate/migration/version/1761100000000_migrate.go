@@ -0,0 +1,35 @@
+package version
+
+import (
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/image"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1761100000000", migrate_1761100000000)
+	migration.Migrate.SetDown("1761100000000", rollback_1761100000000)
+}
+
+// migrate_1761100000000 迁移函数
+// 创建图片水印策略表
+func migrate_1761100000000(db *gorm.DB, version string) error {
+	if err := db.AutoMigrate(&image.SysWatermarkPolicy{}); err != nil {
+		return err
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "创建图片水印策略表",
+		Status:  "completed",
+	}).Error
+}
+
+// rollback_1761100000000 回滚函数
+// 删除图片水印策略表
+func rollback_1761100000000(db *gorm.DB, version string) error {
+	return db.Migrator().DropTable(&image.SysWatermarkPolicy{})
+}
@@ -0,0 +1,109 @@
+package version
+
+import (
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/rental"
+	"rentPro/rentpro-admin/common/models/system"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1761200000000", migrate_1761200000000)
+	migration.Migrate.SetDown("1761200000000", rollback_1761200000000)
+}
+
+// indexRename 索引重命名
+type indexRename struct {
+	model   interface{}
+	oldName string
+	newName string
+}
+
+// indexRenames 多个表共用的索引名（如 idx_status、idx_name）
+// MySQL 的索引名只需在表内唯一，SQLite、PostgreSQL 要求在整个数据库内唯一，统一改为 idx_表名_字段名
+var indexRenames = []indexRename{
+	{&rental.SysAgent{}, "idx_name", "idx_sys_agents_name"},
+	{&rental.SysAgent{}, "idx_phone", "idx_sys_agents_phone"},
+	{&rental.SysAgent{}, "idx_id_card", "idx_sys_agents_id_card"},
+	{&rental.SysAgent{}, "idx_email", "idx_sys_agents_email"},
+	{&rental.SysAgent{}, "idx_status", "idx_sys_agents_status"},
+	{&rental.SysBuildings{}, "idx_name", "idx_sys_buildings_name"},
+	{&rental.SysBuildings{}, "idx_status", "idx_sys_buildings_status"},
+	{&rental.SysBuildings{}, "idx_is_hot", "idx_sys_buildings_is_hot"},
+	{&rental.SysHouse{}, "idx_name", "idx_sys_houses_name"},
+	{&rental.SysHouse{}, "idx_code", "idx_sys_houses_code"},
+	{&rental.SysHouse{}, "idx_building_id", "idx_sys_houses_building_id"},
+	{&rental.SysHouse{}, "idx_status", "idx_sys_houses_status"},
+	{&rental.SysHouseType{}, "idx_name", "idx_sys_house_types_name"},
+	{&rental.SysHouseType{}, "idx_code", "idx_sys_house_types_code"},
+	{&rental.SysHouseType{}, "idx_building_id", "idx_sys_house_types_building_id"},
+	{&rental.SysHouseType{}, "idx_status", "idx_sys_house_types_status"},
+	{&rental.SysHouseType{}, "idx_is_hot", "idx_sys_house_types_is_hot"},
+	{&rental.SysLandlord{}, "idx_name", "idx_sys_landlords_name"},
+	{&rental.SysLandlord{}, "idx_phone", "idx_sys_landlords_phone"},
+	{&rental.SysLandlord{}, "idx_id_card", "idx_sys_landlords_id_card"},
+	{&rental.SysLandlord{}, "idx_email", "idx_sys_landlords_email"},
+	{&rental.SysLandlord{}, "idx_type", "idx_sys_landlords_type"},
+	{&rental.SysLandlord{}, "idx_status", "idx_sys_landlords_status"},
+	{&rental.SysLandlord{}, "idx_is_vip", "idx_sys_landlords_is_vip"},
+	{&rental.SysLandlord{}, "idx_is_blacklisted", "idx_sys_landlords_is_blacklisted"},
+	{&rental.SysTenant{}, "idx_name", "idx_sys_tenants_name"},
+	{&rental.SysTenant{}, "idx_phone", "idx_sys_tenants_phone"},
+	{&rental.SysTenant{}, "idx_id_card", "idx_sys_tenants_id_card"},
+	{&rental.SysTenant{}, "idx_email", "idx_sys_tenants_email"},
+	{&rental.SysTenant{}, "idx_type", "idx_sys_tenants_type"},
+	{&rental.SysTenant{}, "idx_status", "idx_sys_tenants_status"},
+	{&rental.SysTenant{}, "idx_is_vip", "idx_sys_tenants_is_vip"},
+	{&rental.SysTenant{}, "idx_is_blacklisted", "idx_sys_tenants_is_blacklisted"},
+	{&rental.SysContract{}, "idx_type", "idx_sys_contracts_type"},
+	{&rental.SysContract{}, "idx_status", "idx_sys_contracts_status"},
+	{&rental.SysDistrict{}, "idx_city_code", "idx_sys_districts_city_code"},
+	{&rental.SysBusinessArea{}, "idx_city_code", "idx_sys_business_areas_city_code"},
+	{&system.SysRole{}, "idx_name", "idx_sys_role_name"},
+	{&system.SysUser{}, "idx_email", "idx_sys_user_email"},
+	{&system.SysUser{}, "idx_phone", "idx_sys_user_phone"},
+	{&system.SysDept{}, "idx_parent_id", "idx_sys_dept_parent_id"},
+	{&system.SysMenu{}, "idx_parent_id", "idx_sys_menu_parent_id"},
+}
+
+// migrate_1761200000000 迁移函数
+// 重命名多个表共用的索引名，新建的数据库已经使用新索引名，不需要处理
+func migrate_1761200000000(db *gorm.DB, version string) error {
+	for _, r := range indexRenames {
+		if err := renameIndex(db, r.model, r.oldName, r.newName); err != nil {
+			return err
+		}
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "重命名多个表共用的索引名",
+		Status:  "completed",
+	}).Error
+}
+
+// rollback_1761200000000 回滚函数
+// 恢复原索引名（只在 MySQL 上可以恢复，其他数据库原索引名会冲突）
+func rollback_1761200000000(db *gorm.DB, version string) error {
+	if db.Dialector.Name() != "mysql" {
+		return nil
+	}
+	for _, r := range indexRenames {
+		if err := renameIndex(db, r.model, r.newName, r.oldName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameIndex 表和原索引存在、新索引不存在时重命名索引
+func renameIndex(db *gorm.DB, model interface{}, oldName, newName string) error {
+	m := db.Migrator()
+	if !m.HasTable(model) || !m.HasIndex(model, oldName) || m.HasIndex(model, newName) {
+		return nil
+	}
+	return m.RenameIndex(model, oldName, newName)
+}
@@ -0,0 +1,35 @@
+package version
+
+import (
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/image"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1761300000000", migrate_1761300000000)
+	migration.Migrate.SetDown("1761300000000", rollback_1761300000000)
+}
+
+// migrate_1761300000000 迁移函数
+// 创建浏览器直传记录表
+func migrate_1761300000000(db *gorm.DB, version string) error {
+	if err := db.AutoMigrate(&image.SysDirectUpload{}); err != nil {
+		return err
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "创建浏览器直传记录表",
+		Status:  "completed",
+	}).Error
+}
+
+// rollback_1761300000000 回滚函数
+// 删除浏览器直传记录表
+func rollback_1761300000000(db *gorm.DB, version string) error {
+	return db.Migrator().DropTable(&image.SysDirectUpload{})
+}
@@ -0,0 +1,81 @@
+package version
+
+import (
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/system"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1761400000000", migrate_1761400000000)
+	migration.Migrate.SetDown("1761400000000", rollback_1761400000000)
+}
+
+// changes_1761400000000 迁移创建的按钮权限，回滚时只删除迁移创建的
+type changes_1761400000000 struct {
+	CreatedMenu bool `json:"created_menu"`
+}
+
+// migrate_1761400000000 迁移函数
+// 添加POI字典维护按钮权限
+func migrate_1761400000000(db *gorm.DB, version string) error {
+	var done changes_1761400000000
+
+	// 挂在楼盘管理菜单下；楼盘管理菜单还不存在（尚未导入种子数据）时由 seed 创建
+	var parent system.SysMenu
+	if err := db.Where("permission = ?", "rental:building:view").Limit(1).Find(&parent).Error; err != nil {
+		return err
+	}
+	if parent.ID != 0 {
+		var count int64
+		if err := db.Model(&system.SysMenu{}).Where("permission = ?", "rental:poi:edit").Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			poiMenu := system.SysMenu{
+				Name:       "PoiEdit",
+				Title:      "周边POI维护",
+				Permission: "rental:poi:edit",
+				ParentID:   parent.ID,
+				Type:       "F",
+				Sort:       2,
+				Visible:    "1",
+				MenuType:   "3",
+				Status:     "0",
+				Perms:      "rental:poi:edit",
+			}
+			if err := db.Create(&poiMenu).Error; err != nil {
+				return err
+			}
+			done.CreatedMenu = true
+		}
+	}
+
+	changes, err := migration.Changes(done)
+	if err != nil {
+		return err
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "添加POI字典维护权限",
+		Status:  "completed",
+		Changes: changes,
+	}).Error
+}
+
+// rollback_1761400000000 回滚函数
+// 删除迁移创建的POI字典维护权限，由 seed 创建的保留
+func rollback_1761400000000(db *gorm.DB, version string) error {
+	var done changes_1761400000000
+	if _, err := migration.LoadChanges(db, version, &done); err != nil {
+		return err
+	}
+	if !done.CreatedMenu {
+		return nil
+	}
+	return db.Where("permission = ?", "rental:poi:edit").Delete(&system.SysMenu{}).Error
+}
@@ -0,0 +1,58 @@
+package version
+
+import (
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/rental"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migration.Migrate.SetVersion("1761500000000", migrate_1761500000000)
+	migration.Migrate.SetDown("1761500000000", rollback_1761500000000)
+}
+
+// changes_1761500000000 迁移补加的字段，回滚时只删除这些字段
+type changes_1761500000000 struct {
+	AddedColumn bool `json:"added_column"`
+}
+
+// migrate_1761500000000 迁移函数
+// 户型表增加保姆间数字段（户型接口和列表查询一直在读写该字段，此前的迁移没有创建）
+// 新建的数据库中已由初始迁移创建，只记录本迁移实际补加的字段
+func migrate_1761500000000(db *gorm.DB, version string) error {
+	var done changes_1761500000000
+	if !db.Migrator().HasColumn(&rental.SysHouseType{}, "MaidRooms") {
+		if err := db.Migrator().AddColumn(&rental.SysHouseType{}, "MaidRooms"); err != nil {
+			return err
+		}
+		done.AddedColumn = true
+	}
+
+	changes, err := migration.Changes(done)
+	if err != nil {
+		return err
+	}
+
+	// 记录迁移完成
+	return db.Create(&base.Migration{
+		Version: version,
+		Name:    "户型表增加保姆间数字段",
+		Status:  "completed",
+		Changes: changes,
+	}).Error
+}
+
+// rollback_1761500000000 回滚函数
+// 只删除本迁移补加的保姆间数字段
+func rollback_1761500000000(db *gorm.DB, version string) error {
+	var done changes_1761500000000
+	if _, err := migration.LoadChanges(db, version, &done); err != nil {
+		return err
+	}
+	if !done.AddedColumn {
+		return nil
+	}
+	return db.Migrator().DropColumn(&rental.SysHouseType{}, "MaidRooms")
+}
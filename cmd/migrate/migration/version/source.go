@@ -0,0 +1,16 @@
+package version
+
+import (
+	"embed"
+
+	"rentPro/rentpro-admin/cmd/migrate/migration"
+)
+
+// source 迁移文件源码，用于计算迁移函数的校验和
+//
+//go:embed *_migrate.go
+var source embed.FS
+
+func init() {
+	migration.Migrate.SetSource(source)
+}
@@ -9,32 +9,28 @@ import (
 	"strconv"
 	"time"
 
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 
 	"rentPro/rentpro-admin/cmd/migrate/migration"
 	_ "rentPro/rentpro-admin/cmd/migrate/migration/version"
+	"rentPro/rentpro-admin/common/config"
 	"rentPro/rentpro-admin/common/database"
 	"rentPro/rentpro-admin/common/global"
 	"rentPro/rentpro-admin/common/models/base"
 )
 
-// 配置数据结构，用于解析 settings.yml
-type Config struct {
-	Settings struct {
-		Database struct {
-			Driver string `yaml:"driver"`
-			Source string `yaml:"source"`
-		} `yaml:"database"`
-	} `yaml:"settings"`
-}
-
 var (
 	configYml   string
 	generate    bool
 	goAdmin     bool
 	host        string
 	showVersion bool
+	upTo        string
+	force       bool
+	lockTimeout time.Duration
 
 	// StartCmd 定义了 migrate 子命令
 	// 用于执行数据库迁移操作，支持以下功能：
@@ -44,6 +40,11 @@ var (
 	// 命令注册：通过 rootCmd.AddCommand(migrate.StartCmd) 注册到根命令
 	// 使用方式：
 	//   - rentpro-admin migrate -c config/settings.yml  : 执行数据库迁移
+	//   - rentpro-admin migrate status                  : 查看各版本执行状态
+	//   - rentpro-admin migrate up --to <版本>           : 执行到指定版本
+	//   - rentpro-admin migrate down [n]                : 回滚最近 n 个迁移（默认 1）
+	//   - rentpro-admin migrate redo                    : 回滚并重新执行最近的迁移
+	//   - rentpro-admin migrate --force                 : 已执行的迁移被修改时仍然继续
 	//   - rentpro-admin migrate -g                      : 生成迁移文件
 	//   - rentpro-admin migrate -v                      : 显示版本信息
 	// 版本信息来源：common/global/adm.go 中的 Version 常量
@@ -65,6 +66,61 @@ var (
 			return run()
 		},
 	}
+
+	// statusCmd 查看迁移状态
+	statusCmd = &cobra.Command{
+		Use:     "status",
+		Short:   "查看迁移执行状态",
+		Example: "rentpro-admin migrate status -c config/settings.yml",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStatus()
+		},
+	}
+
+	// upCmd 执行迁移
+	upCmd = &cobra.Command{
+		Use:     "up",
+		Short:   "执行未执行的迁移",
+		Example: "rentpro-admin migrate up --to 1760900000000",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigration(func(m *migration.Migration) error {
+				return m.Up(upTo)
+			}, nil)
+		},
+	}
+
+	// downCmd 回滚迁移
+	downCmd = &cobra.Command{
+		Use:     "down [n]",
+		Short:   "回滚最近执行的 n 个迁移（默认 1）",
+		Example: "rentpro-admin migrate down 2",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n := 1
+			if len(args) == 1 {
+				var err error
+				if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+					return fmt.Errorf("回滚数量必须是正整数: %s", args[0])
+				}
+			}
+			return runMigration(func(m *migration.Migration) error {
+				return m.Down(n)
+			}, nil)
+		},
+	}
+
+	// redoCmd 重做最近的迁移
+	redoCmd = &cobra.Command{
+		Use:     "redo",
+		Short:   "回滚并重新执行最近的迁移",
+		Example: "rentpro-admin migrate redo",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// 最近的版本会重新执行，允许修改
+			return runMigration(func(m *migration.Migration) error {
+				return m.Redo()
+			}, lastApplied)
+		},
+	}
 )
 
 // init 初始化命令标志
@@ -84,6 +140,16 @@ func init() {
 
 	// 多租户主机选择标志
 	StartCmd.PersistentFlags().StringVarP(&host, "domain", "d", "*", "选择租户主机域名")
+
+	// 已执行的迁移被修改时仍然继续，并接受修改后的校验和
+	StartCmd.PersistentFlags().BoolVar(&force, "force", false, "已执行的迁移被修改时仍然继续")
+
+	// 等待其他实例释放迁移锁的最长时间
+	StartCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", 5*time.Minute, "等待迁移锁的最长时间")
+
+	// 子命令：status、up、down、redo
+	upCmd.Flags().StringVar(&upTo, "to", "", "只执行到该版本（包含）")
+	StartCmd.AddCommand(statusCmd, upCmd, downCmd, redoCmd)
 }
 
 // run 执行数据库迁移的核心逻辑
@@ -101,13 +167,13 @@ func run() error {
 		fmt.Printf("配置文件: %s\n", configYml)
 
 		// 读取和解析配置文件
-		config, err := loadConfig(configYml)
+		cfg, err := config.Load(configYml)
 		if err != nil {
 			return fmt.Errorf("加载配置文件失败: %v", err)
 		}
 
 		// 执行数据库初始化和迁移
-		err = initDB(config)
+		err = initDB(cfg)
 		if err != nil {
 			return fmt.Errorf("数据库初始化失败: %v", err)
 		}
@@ -125,33 +191,10 @@ func run() error {
 	return nil
 }
 
-// loadConfig 加载和解析配置文件
-func loadConfig(configPath string) (*Config, error) {
-	// 检查文件是否存在
-	if !fileExists(configPath) {
-		return nil, fmt.Errorf("配置文件不存在: %s", configPath)
-	}
-
-	// 读取配置文件
-	configData, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %v", err)
-	}
-
-	// 解析 YAML 配置
-	var config Config
-	err = yaml.Unmarshal(configData, &config)
-	if err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %v", err)
-	}
-
-	return &config, nil
-}
-
 // initDB 执行数据库初始化和迁移
-func initDB(config *Config) error {
-	fmt.Printf("数据库类型: %s\n", config.Settings.Database.Driver)
-	fmt.Printf("数据库连接: %s\n", maskSensitiveInfo(config.Settings.Database.Source))
+func initDB(cfg *config.Config) error {
+	fmt.Printf("数据库类型: %s\n", cfg.Settings.Database.Driver)
+	fmt.Printf("数据库连接: %s\n", config.Mask(cfg.Settings.Database.Source))
 
 	// 1. 初始化数据库连接
 	fmt.Println("初始化数据库连接...")
@@ -171,36 +214,186 @@ func initDB(config *Config) error {
 
 // migrateModel 执行模型迁移
 func migrateModel() error {
+	fmt.Println("执行数据库迁移...")
+	if err := prepareMigration(); err != nil {
+		return err
+	}
+
+	fmt.Println("- 执行业务表迁移...")
+
+	// 执行所有注册的迁移
+	err := guarded(migration.Migrate, func(m *migration.Migration) error {
+		return m.Migrate()
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("✅ 数据库迁移执行完成")
+	return nil
+}
+
+// Apply 在 database.DB 上执行所有未执行的迁移
+// 供 api --migrate 在启动时调用（如 SQLite 内存数据库，每次启动都是空库）
+func Apply() error {
+	return migrateModel()
+}
+
+// ApplyTo 在指定的数据库连接上执行迁移，直到 version（包含，为空时执行全部）
+// 供 db verify 在临时库中创建与备份相同版本的表结构
+func ApplyTo(db *gorm.DB, version string) error {
+	if err := prepareOn(db); err != nil {
+		return err
+	}
+	return guarded(migration.Migrate, func(m *migration.Migration) error {
+		return m.Up(version)
+	}, nil)
+}
+
+// prepareMigration 创建迁移记录表并设置迁移管理器的数据库连接
+func prepareMigration() error {
 	// 获取数据库实例
 	if database.DB == nil {
 		return fmt.Errorf("数据库连接未初始化")
 	}
 
-	db := database.DB
+	fmt.Println("- 创建迁移记录表...")
+	return prepareOn(database.DB.Debug())
+}
 
+// prepareOn 在 db 上创建迁移记录表，并设置为迁移管理器的数据库连接
+func prepareOn(db *gorm.DB) error {
 	// 设置 MySQL 表选项
-	if global.Driver == "mysql" {
+	if db.Dialector.Name() == "mysql" {
 		db = db.Set("gorm:table_options", "ENGINE=InnoDB CHARSET=utf8mb4")
 	}
 
-	fmt.Println("执行数据库迁移...")
-	fmt.Println("- 创建迁移记录表...")
-
-	// 自动迁移 Migration 模型
-	err := db.Debug().AutoMigrate(&base.Migration{})
+	// 自动迁移 Migration、MigrationLock 模型
+	err := db.AutoMigrate(&base.Migration{}, &base.MigrationLock{})
 	if err != nil {
 		return fmt.Errorf("迁移 Migration 模型失败: %v", err)
 	}
 
-	fmt.Println("- 执行业务表迁移...")
-
 	// 设置迁移管理器的数据库连接
-	migration.Migrate.SetDb(db.Debug())
+	migration.Migrate.SetDb(db)
+	return nil
+}
 
-	// 执行所有注册的迁移
-	migration.Migrate.Migrate()
+// connect 加载配置并连接数据库，供子命令使用
+func connect() error {
+	if configYml == "" {
+		return fmt.Errorf("请指定配置文件路径，使用 -c 参数")
+	}
+	cfg, err := config.Load(configYml)
+	if err != nil {
+		return fmt.Errorf("加载配置文件失败: %v", err)
+	}
+	fmt.Printf("数据库类型: %s\n", cfg.Settings.Database.Driver)
+	database.Setup()
+	return prepareMigration()
+}
 
-	fmt.Println("✅ 数据库迁移执行完成")
+// runMigration 连接数据库后执行 up、down、redo 操作，完成后显示状态
+func runMigration(fn func(m *migration.Migration) error, skip func(m *migration.Migration) ([]string, error)) error {
+	fmt.Printf("=== rentpro-admin 数据库迁移工具 v%s ===\n", global.Version)
+	if err := connect(); err != nil {
+		return err
+	}
+	if err := guarded(migration.Migrate, fn, skip); err != nil {
+		return err
+	}
+	fmt.Println("✅ 操作完成")
+	return printStatus(migration.Migrate)
+}
+
+// guarded 持有迁移锁、校验已执行迁移的校验和后执行操作
+// skip 返回不需要校验的版本
+func guarded(m *migration.Migration, fn func(m *migration.Migration) error, skip func(m *migration.Migration) ([]string, error)) error {
+	fmt.Println("- 获取迁移锁...")
+	unlock, err := m.Lock(lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var skipped []string
+	if skip != nil {
+		if skipped, err = skip(m); err != nil {
+			return err
+		}
+	}
+	if err := m.Verify(force, skipped...); err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+// lastApplied 最近执行的版本
+func lastApplied(m *migration.Migration) ([]string, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if statuses[i].Applied {
+			return []string{statuses[i].Version}, nil
+		}
+	}
+	return nil, nil
+}
+
+// runStatus 显示各版本执行状态
+func runStatus() error {
+	if err := connect(); err != nil {
+		return err
+	}
+	// 查询状态时不输出 SQL 日志
+	db := migration.Migrate.GetDb()
+	migration.Migrate.SetDb(db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Silent)}))
+	return printStatus(migration.Migrate)
+}
+
+// printStatus 打印迁移状态表
+func printStatus(m *migration.Migration) error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	modified := 0
+	fmt.Printf("\n%-15s %-8s %-20s %-6s %-8s %s\n", "版本", "状态", "执行时间", "可回滚", "校验", "名称")
+	for _, s := range statuses {
+		state, appliedAt := "未执行", "-"
+		if s.Applied {
+			state = "已执行"
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		} else {
+			pending++
+		}
+		if s.Missing {
+			state = "缺失"
+		}
+		reversible := "否"
+		if s.Reversible {
+			reversible = "是"
+		}
+		checksum := "-"
+		switch {
+		case s.Modified():
+			checksum = "已修改"
+			modified++
+		case s.Applied && s.StoredChecksum != "" && s.Checksum != "":
+			checksum = "一致"
+		case s.Applied && s.Checksum != "":
+			checksum = "未记录"
+		}
+		fmt.Printf("%-15s %-8s %-20s %-6s %-8s %s\n", s.Version, state, appliedAt, reversible, checksum, s.Name)
+	}
+	fmt.Printf("\n共 %d 个版本，未执行 %d 个\n", len(statuses), pending)
+	if modified > 0 {
+		fmt.Printf("⚠️  %d 个已执行的迁移被修改，执行迁移时需要 --force\n", modified)
+	}
 	return nil
 }
 
@@ -253,6 +446,7 @@ import (
 
 func init() {
 	migration.Migrate.SetVersion("%s", migrate_%s)
+	migration.Migrate.SetDown("%s", rollback_%s)
 }
 
 // migrate_%s 迁移函数
@@ -271,7 +465,15 @@ func migrate_%s(db *gorm.DB, version string) error {
 		Status:  "completed",
 	}).Error
 }
-`, packageName, timestamp, timestamp, timestamp, timestamp)
+
+// rollback_%s 回滚函数，撤销 migrate_%s 的修改（sys_migration 记录由迁移管理器删除）
+func rollback_%s(db *gorm.DB, version string) error {
+	// TODO: 在这里实现回滚逻辑，无法回滚时删除 SetDown 注册
+	// 例如：
+	// return db.Migrator().DropTable(&models.YourModel{})
+	return nil
+}
+`, packageName, timestamp, timestamp, timestamp, timestamp, timestamp, timestamp, timestamp, timestamp, timestamp)
 }
 
 // fileExists 检查文件或目录是否存在
@@ -280,14 +482,6 @@ func fileExists(path string) bool {
 	return !os.IsNotExist(err)
 }
 
-// maskSensitiveInfo 隐藏敏感信息，用于安全显示
-func maskSensitiveInfo(info string) string {
-	if len(info) <= 8 {
-		return "***"
-	}
-	return info[:4] + "***" + info[len(info)-4:]
-}
-
 // GetMigrateVersion 获取迁移工具版本信息
 func GetMigrateVersion() string {
 	return global.Version
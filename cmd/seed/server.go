@@ -0,0 +1,145 @@
+// Package seed 提供初始化数据相关的命令行功能
+// 从 YAML/JSON 数据集按自然键写入部门、岗位、角色、菜单、角色菜单、用户、城市区域商圈
+package seed
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/global"
+	"rentPro/rentpro-admin/common/seed"
+)
+
+var (
+	configYml   string
+	dir         string
+	env         string
+	only        []string
+	dryRun      bool
+	update      bool
+	verbose     bool
+	showVersion bool
+
+	// StartCmd 定义了 seed 子命令
+	// 命令注册：通过 rootCmd.AddCommand(seed.StartCmd) 注册到根命令
+	// 使用方式：
+	//   - rentpro-admin seed -c config/settings.yml   : 按 application.mode 加载 base 和环境数据集并写入
+	//   - rentpro-admin seed --env prod               : 指定环境
+	//   - rentpro-admin seed --only menus,role_menus  : 只写入指定数据集
+	//   - rentpro-admin seed --dry-run                : 只显示差异，不写入
+	//   - rentpro-admin seed --update                 : 同时更新已存在的角色、用户
+	//   - rentpro-admin seed -v                       : 显示版本信息
+	// 版本信息来源：common/global/adm.go 中的 Version 常量
+	StartCmd = &cobra.Command{
+		Use:   "seed",
+		Short: "写入初始化数据",
+		Long: `从数据集目录加载 base 和环境数据集（YAML/JSON），按依赖顺序写入：
+depts（部门）→ posts（岗位）→ roles（角色）→ menus（菜单）→ role_menus（角色菜单）→ users（用户）→ regions（城市、区域、商圈）。
+记录按自然键匹配：不存在时创建，存在时更新与数据集不一致的字段，已删除的记录不恢复；
+角色、用户只创建，指定 --update 时才更新已存在的记录；用户密码只在创建时设置，角色菜单只添加不删除。
+需要先执行 rentpro-admin migrate 创建表结构。`,
+		Example: "rentpro-admin seed -c config/settings.yml --env dev --dry-run",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if showVersion {
+				fmt.Printf("rentpro-admin seed version: %s\n", global.Version)
+				return nil
+			}
+			return run()
+		},
+	}
+)
+
+// init 初始化命令标志
+func init() {
+	StartCmd.PersistentFlags().BoolVarP(&showVersion, "version", "v", false, "显示版本信息")
+	StartCmd.PersistentFlags().StringVarP(&configYml, "config", "c", "config/settings.yml", "指定配置文件路径")
+	StartCmd.Flags().StringVar(&dir, "dir", "config/seed", "数据集目录")
+	StartCmd.Flags().StringVar(&env, "env", "", "环境数据集（dev、test、prod），默认为配置文件中的 application.mode")
+	StartCmd.Flags().StringSliceVar(&only, "only", nil, "只写入指定数据集，可选: "+strings.Join(seed.Datasets, ", "))
+	StartCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只显示差异，不写入")
+	StartCmd.Flags().BoolVar(&update, "update", false, "更新已存在的角色、用户中与数据集不一致的字段（默认只创建）")
+	StartCmd.Flags().BoolVar(&verbose, "verbose", false, "同时列出没有变化的记录")
+}
+
+// run 加载数据集并写入
+func run() error {
+	fmt.Printf("=== rentpro-admin 初始化数据 v%s ===\n", global.Version)
+
+	cfg, err := config.Load(configYml)
+	if err != nil {
+		return fmt.Errorf("加载配置文件失败: %v", err)
+	}
+	if env == "" {
+		env = cfg.Settings.Application.Mode
+	}
+
+	ds, files, err := seed.Load(dir, env)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("📋 环境: %s，数据集文件:\n", env)
+	for _, file := range files {
+		fmt.Printf("  - %s\n", file)
+	}
+
+	database.Setup()
+
+	// 逐条比对会产生大量查询，只输出警告和错误；按自然键查询不到记录是正常情况
+	db := database.DB.Session(&gorm.Session{Logger: logger.New(
+		log.New(os.Stdout, "\r\n", log.LstdFlags),
+		logger.Config{
+			SlowThreshold:             time.Second,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+			Colorful:                  true,
+		},
+	)})
+	report, err := seed.Run(db, ds, seed.Options{Only: only, DryRun: dryRun, Update: update})
+	if err != nil {
+		return err
+	}
+	printReport(report)
+	return nil
+}
+
+// printReport 打印写入结果
+func printReport(report *seed.Report) {
+	fmt.Println()
+	for _, change := range report.Changes {
+		switch change.Action {
+		case seed.ActionCreate:
+			fmt.Printf("+ [%s] %s\n", change.Dataset, change.Key)
+		case seed.ActionUpdate:
+			fmt.Printf("~ [%s] %s\n", change.Dataset, change.Key)
+			for _, field := range change.Fields {
+				fmt.Printf("      %s: %v → %v\n", field.Field, field.Old, field.New)
+			}
+		case seed.ActionSkipped:
+			fmt.Printf("- [%s] %s：%s\n", change.Dataset, change.Key, change.Reason)
+			for _, field := range change.Fields {
+				fmt.Printf("      %s: %v → %v\n", field.Field, field.Old, field.New)
+			}
+		default:
+			if verbose {
+				fmt.Printf("  [%s] %s\n", change.Dataset, change.Key)
+			}
+		}
+	}
+
+	created, updated := report.Count(seed.ActionCreate), report.Count(seed.ActionUpdate)
+	unchanged, skipped := report.Count(seed.ActionUnchanged), report.Count(seed.ActionSkipped)
+	if report.DryRun {
+		fmt.Printf("\n🔍 dry-run：将创建 %d 条、更新 %d 条、跳过 %d 条，%d 条无变化，未做任何修改\n", created, updated, skipped, unchanged)
+		return
+	}
+	fmt.Printf("\n✅ 写入完成：创建 %d 条、更新 %d 条、跳过 %d 条，%d 条无变化\n", created, updated, skipped, unchanged)
+}
@@ -0,0 +1,231 @@
+// Package storage 提供文件存储维护相关的命令行功能
+// 用于比对存储中的文件和图片记录，清理孤立文件、丢失文件和悬空记录
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/global"
+	"rentPro/rentpro-admin/common/initialize"
+	"rentPro/rentpro-admin/common/utils"
+)
+
+var (
+	configYml   string
+	prefix      string
+	quarantine  bool
+	remove      bool
+	dryRun      bool
+	assumeYes   bool
+	minAge      time.Duration
+	quarantineP string
+	output      string
+	batchSize   int
+	showVersion bool
+
+	// StartCmd 定义了 storage 子命令
+	// 命令注册：通过 rootCmd.AddCommand(storage.StartCmd) 注册到根命令
+	// 使用方式：
+	//   - rentpro-admin storage reconcile -c config/settings.yml : 生成对账报告
+	//   - rentpro-admin storage reconcile --quarantine           : 隔离孤立文件和悬空记录的文件
+	//   - rentpro-admin storage reconcile --delete --dry-run     : 预览删除
+	//   - rentpro-admin storage -v                               : 显示版本信息
+	// 版本信息来源：common/global/adm.go 中的 Version 常量
+	StartCmd = &cobra.Command{
+		Use:     "storage",
+		Short:   "文件存储维护工具",
+		Long:    `rentpro-admin 文件存储维护工具，用于比对存储中的文件和图片记录并清理`,
+		Example: "rentpro-admin storage reconcile -c config/settings.yml",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if showVersion {
+				fmt.Printf("rentpro-admin storage version: %s\n", global.Version)
+				return nil
+			}
+			return cmd.Help()
+		},
+	}
+
+	// reconcileCmd 比对存储文件和图片记录
+	reconcileCmd = &cobra.Command{
+		Use:   "reconcile",
+		Short: "比对存储文件和图片记录",
+		Long: `列出存储中的文件并与 sys_images 记录比对，报告没有记录引用的孤立文件、文件不存在的记录、所属楼盘/户型/房屋已删除的记录。
+默认只生成报告；--quarantine 将文件移动到隔离目录，--delete 直接删除文件，两种方式都会软删除问题记录。`,
+		Example: "rentpro-admin storage reconcile -c config/settings.yml --quarantine --dry-run",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReconcile()
+		},
+	}
+)
+
+// init 初始化命令标志
+func init() {
+	StartCmd.PersistentFlags().BoolVarP(&showVersion, "version", "v", false, "显示版本信息")
+	StartCmd.PersistentFlags().StringVarP(&configYml, "config", "c", "config/settings.yml", "指定配置文件路径")
+
+	reconcileCmd.Flags().StringVar(&prefix, "prefix", "", "只检查该前缀下的文件和记录")
+	reconcileCmd.Flags().BoolVar(&quarantine, "quarantine", false, "将孤立文件、悬空记录的文件移动到隔离目录")
+	reconcileCmd.Flags().BoolVar(&remove, "delete", false, "删除孤立文件、悬空记录的文件")
+	reconcileCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只显示将要执行的操作，不做修改")
+	reconcileCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "跳过确认")
+	reconcileCmd.Flags().DurationVar(&minAge, "min-age", 24*time.Hour, "上传时间在此之内的孤立文件不处理（直传尚未回调建档）")
+	reconcileCmd.Flags().StringVar(&quarantineP, "quarantine-prefix", "quarantine/", "隔离目录")
+	reconcileCmd.Flags().StringVarP(&output, "output", "o", "", "对账报告（JSON）输出路径，默认 storage-reconcile-{时间}.json")
+	reconcileCmd.Flags().IntVar(&batchSize, "batch", 1000, "每页列出的文件数、每批查询的记录数")
+	StartCmd.AddCommand(reconcileCmd)
+}
+
+// runReconcile 执行存储对账
+func runReconcile() error {
+	fmt.Printf("=== rentpro-admin 存储对账 v%s ===\n", global.Version)
+
+	if quarantine && remove {
+		return fmt.Errorf("--quarantine 和 --delete 不能同时使用")
+	}
+	opts := &utils.ReconcileOptions{
+		Prefix:           prefix,
+		Action:           utils.ReconcileActionReport,
+		MinAge:           minAge,
+		QuarantinePrefix: quarantineP,
+		BatchSize:        batchSize,
+	}
+	if quarantine {
+		opts.Action = utils.ReconcileActionQuarantine
+	} else if remove {
+		opts.Action = utils.ReconcileActionDelete
+	}
+	if opts.Action == utils.ReconcileActionQuarantine && strings.Trim(quarantineP, "/") == "" {
+		return fmt.Errorf("隔离目录不能为空")
+	}
+
+	cfg, err := config.Load(configYml)
+	if err != nil {
+		return fmt.Errorf("加载配置文件失败: %v", err)
+	}
+
+	database.Setup()
+
+	if err := initialize.InitStorage(cfg.Settings.Storage, cfg.Settings.Application.Mode); err != nil {
+		return fmt.Errorf("初始化文件存储失败: %v", err)
+	}
+	if err := utils.InitImageManager(); err != nil {
+		return err
+	}
+	imageManager := utils.GetImageManager()
+
+	// dev 模式下七牛云初始化失败会回退到本地存储，此时比对会把所有记录当作丢失文件
+	driver := cfg.Settings.Storage.Driver
+	if driver == "" {
+		driver = "qiniu"
+	}
+	if actual := imageManager.Storage().Driver(); actual != driver {
+		return fmt.Errorf("存储驱动 %s 初始化失败（当前为 %s），停止对账", driver, actual)
+	}
+
+	fmt.Printf("📋 存储驱动: %s，前缀: %q\n", driver, prefix)
+	report, err := imageManager.ScanStorage(opts)
+	if err != nil {
+		return err
+	}
+	report.DryRun = dryRun
+	printReport(report)
+
+	if opts.Action != utils.ReconcileActionReport && report.HasIssues() {
+		switch {
+		case dryRun:
+			fmt.Printf("\n🔍 dry-run：以上问题将被%s，未做任何修改\n", actionName(opts.Action))
+		case !assumeYes && !confirm(fmt.Sprintf("\n确认%s以上文件并软删除问题记录？输入 yes 继续: ", actionName(opts.Action))):
+			fmt.Println("已取消")
+			report.DryRun = true
+		default:
+			imageManager.ApplyReconcile(report, opts)
+			printResult(report)
+		}
+	}
+
+	return writeReport(report)
+}
+
+// printReport 打印对账结果
+func printReport(report *utils.ReconcileReport) {
+	fmt.Printf("\n共检查 %d 个文件、%d 条图片记录\n", report.ObjectCount, report.ImageCount)
+	fmt.Printf("  孤立文件（没有记录引用）: %d\n", len(report.OrphanObjects))
+	for _, obj := range report.OrphanObjects {
+		fmt.Printf("    - %s (%d 字节, %s)\n", obj.Key, obj.Size, obj.PutTime.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("  丢失文件（记录指向的文件不存在）: %d\n", len(report.MissingObjects))
+	for _, img := range report.MissingObjects {
+		fmt.Printf("    - #%d %s [%s:%d]\n", img.ID, img.Key, img.Module, img.ModuleID)
+	}
+	fmt.Printf("  悬空记录（所属对象已删除）: %d\n", len(report.DanglingImages))
+	for _, img := range report.DanglingImages {
+		fmt.Printf("    - #%d %s [%s:%d] %s\n", img.ID, img.Key, img.Module, img.ModuleID, img.Reason)
+	}
+}
+
+// printResult 打印处理结果
+func printResult(report *utils.ReconcileReport) {
+	done, skipped, failed := 0, 0, 0
+	count := func(action, errMsg, key string) {
+		switch {
+		case errMsg != "":
+			failed++
+			fmt.Printf("❌ %s: %s\n", key, errMsg)
+		case action == "skipped":
+			skipped++
+		default:
+			done++
+		}
+	}
+	for _, obj := range report.OrphanObjects {
+		count(obj.Action, obj.Error, obj.Key)
+	}
+	for _, img := range report.MissingObjects {
+		count(img.Action, img.Error, img.Key)
+	}
+	for _, img := range report.DanglingImages {
+		count(img.Action, img.Error, img.Key)
+	}
+	fmt.Printf("\n处理完成：成功 %d，跳过 %d（上传时间过近），失败 %d\n", done, skipped, failed)
+}
+
+// writeReport 写入 JSON 对账报告
+func writeReport(report *utils.ReconcileReport) error {
+	path := output
+	if path == "" {
+		path = fmt.Sprintf("storage-reconcile-%s.json", report.StartedAt.Format("20060102-150405"))
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成对账报告失败: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入对账报告失败: %v", err)
+	}
+	fmt.Printf("📄 对账报告: %s\n", path)
+	return nil
+}
+
+// confirm 读取用户确认
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line) == "yes"
+}
+
+// actionName 处理方式名称
+func actionName(action string) string {
+	if action == utils.ReconcileActionQuarantine {
+		return "隔离"
+	}
+	return "删除"
+}
@@ -0,0 +1,415 @@
+// Package backup 提供数据库逻辑备份与恢复
+// 备份文件为 gzip 压缩的 JSON Lines：第一行为 Header（表结构版本、各表列和行数），
+// 之后按 Header 中表的顺序，每行一条记录（JSON 数组，与列顺序对应）。
+// 格式与数据库驱动无关，恢复时只要求目标库的迁移版本与备份一致
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"rentPro/rentpro-admin/common/models/base"
+	"rentPro/rentpro-admin/common/models/image"
+)
+
+// Format 备份文件格式标识
+const Format = "rentpro-backup"
+
+// FormatVersion 备份文件格式版本，格式不兼容时递增
+const FormatVersion = 1
+
+// FilePrefix、FileSuffix 备份文件名为 backup_20060102_150405.jsonl.gz
+const (
+	FilePrefix = "backup_"
+	FileSuffix = ".jsonl.gz"
+)
+
+// excludedTables 不备份的表：迁移记录决定表结构版本，由迁移维护
+var excludedTables = map[string]bool{
+	base.Migration{}.TableName():     true,
+	base.MigrationLock{}.TableName(): true,
+}
+
+// Header 备份文件头
+type Header struct {
+	Format        string    `json:"format"`
+	FormatVersion int       `json:"format_version"`
+	Driver        string    `json:"driver"`
+	SchemaVersion string    `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Images        bool      `json:"images"`
+	Tables        []Table   `json:"tables"`
+}
+
+// Table 备份中的一张表
+type Table struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	// TimeColumns 时间类型的列，值保存为 RFC3339 字符串，恢复时转换回时间
+	TimeColumns []string `json:"time_columns,omitempty"`
+	Rows        int64    `json:"rows"`
+}
+
+// Rows 备份中的总行数
+func (h *Header) Rows() int64 {
+	var total int64
+	for _, t := range h.Tables {
+		total += t.Rows
+	}
+	return total
+}
+
+// Options 备份选项
+type Options struct {
+	// Images 是否包含图片清单（sys_images）
+	Images bool
+}
+
+// FileName 备份文件名
+func FileName(t time.Time) string {
+	return FilePrefix + t.Format("20060102_150405") + FileSuffix
+}
+
+// WriteFile 将数据库备份到 dir 目录下的新文件，返回文件路径和文件头
+// 先写入临时文件，完成后重命名，中途失败不会留下不完整的备份
+func WriteFile(db *gorm.DB, dir string, opts Options) (string, *Header, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", nil, fmt.Errorf("创建备份目录失败: %v", err)
+	}
+
+	path := filepath.Join(dir, FileName(time.Now()))
+	tmp, err := os.CreateTemp(dir, ".backup-*.tmp")
+	if err != nil {
+		return "", nil, fmt.Errorf("创建备份文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	header, err := Dump(db, tmp, opts)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("写入备份文件失败: %v", closeErr)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", nil, fmt.Errorf("保存备份文件失败: %v", err)
+	}
+	return path, header, nil
+}
+
+// Dump 在一个只读事务中导出所有表，保证各表数据一致
+func Dump(db *gorm.DB, w io.Writer, opts Options) (*Header, error) {
+	header := &Header{
+		Format:        Format,
+		FormatVersion: FormatVersion,
+		Driver:        db.Dialector.Name(),
+		CreatedAt:     time.Now(),
+		Images:        opts.Images,
+	}
+
+	gz := gzip.NewWriter(w)
+	buf := bufio.NewWriter(gz)
+	enc := json.NewEncoder(buf)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		version, err := SchemaVersion(tx)
+		if err != nil {
+			return err
+		}
+		header.SchemaVersion = version
+
+		tables, err := managedTables(tx, opts)
+		if err != nil {
+			return err
+		}
+		for _, name := range tables {
+			table, err := describe(tx, name)
+			if err != nil {
+				return err
+			}
+			header.Tables = append(header.Tables, *table)
+		}
+		if err := enc.Encode(header); err != nil {
+			return fmt.Errorf("写入备份文件头失败: %v", err)
+		}
+
+		for _, table := range header.Tables {
+			if err := dumpTable(tx, enc, table); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, snapshotOptions(db))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := buf.Flush(); err != nil {
+		return nil, fmt.Errorf("写入备份文件失败: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("写入备份文件失败: %v", err)
+	}
+	return header, nil
+}
+
+// SchemaVersion 已执行的最新迁移版本
+func SchemaVersion(db *gorm.DB) (string, error) {
+	if !db.Migrator().HasTable(&base.Migration{}) {
+		return "", fmt.Errorf("数据库未执行迁移（缺少 %s 表）", base.Migration{}.TableName())
+	}
+	var version sql.NullString
+	if err := db.Model(&base.Migration{}).Select("MAX(version)").Scan(&version).Error; err != nil {
+		return "", fmt.Errorf("查询迁移版本失败: %v", err)
+	}
+	if !version.Valid {
+		return "", fmt.Errorf("数据库未执行迁移（%s 表为空）", base.Migration{}.TableName())
+	}
+	return version.String, nil
+}
+
+// snapshotOptions 备份事务选项
+// MySQL、PostgreSQL 使用可重复读的只读事务；SQLite 事务本身是一致的快照，驱动不支持设置隔离级别
+func snapshotOptions(db *gorm.DB) *sql.TxOptions {
+	if db.Dialector.Name() == "sqlite" {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+}
+
+// managedTables 需要备份的表，按外键依赖排序（被引用的表在前）
+func managedTables(db *gorm.DB, opts Options) ([]string, error) {
+	all, err := db.Migrator().GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("查询数据表失败: %v", err)
+	}
+
+	images := image.SysImage{}.TableName()
+	var tables []string
+	for _, name := range all {
+		if excludedTables[name] || strings.HasPrefix(name, "sqlite_") {
+			continue
+		}
+		if name == images && !opts.Images {
+			continue
+		}
+		tables = append(tables, name)
+	}
+
+	refs, err := foreignKeys(db, tables)
+	if err != nil {
+		return nil, err
+	}
+	return sortByDependency(tables, refs), nil
+}
+
+// describe 读取表的列和行数
+func describe(db *gorm.DB, name string) (*Table, error) {
+	rows, err := db.Table(name).Where("1 = 0").Rows()
+	if err != nil {
+		return nil, fmt.Errorf("读取表 %s 结构失败: %v", name, err)
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("读取表 %s 结构失败: %v", name, err)
+	}
+	table := &Table{Name: name}
+	for _, t := range types {
+		table.Columns = append(table.Columns, t.Name())
+		if isTimeType(t.DatabaseTypeName()) {
+			table.TimeColumns = append(table.TimeColumns, t.Name())
+		}
+	}
+
+	if err := db.Table(name).Count(&table.Rows).Error; err != nil {
+		return nil, fmt.Errorf("统计表 %s 行数失败: %v", name, err)
+	}
+	return table, nil
+}
+
+// isTimeType 是否为日期时间类型（TIME 只有时分秒，按字符串处理）
+func isTimeType(databaseType string) bool {
+	t := strings.ToUpper(databaseType)
+	return strings.Contains(t, "DATE") || strings.Contains(t, "TIMESTAMP")
+}
+
+// dumpTable 按主键顺序导出一张表
+func dumpTable(db *gorm.DB, enc *json.Encoder, table Table) error {
+	query := db.Table(table.Name).Clauses(clause.Select{Columns: columnsOf(table)})
+	if contains(table.Columns, "id") {
+		query = query.Order("id")
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		return fmt.Errorf("导出表 %s 失败: %v", table.Name, err)
+	}
+	defer rows.Close()
+
+	values := make([]interface{}, len(table.Columns))
+	pointers := make([]interface{}, len(table.Columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	var count int64
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return fmt.Errorf("导出表 %s 失败: %v", table.Name, err)
+		}
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			row[i] = encodeValue(v)
+		}
+		if err := enc.Encode(row); err != nil {
+			return fmt.Errorf("写入备份文件失败: %v", err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("导出表 %s 失败: %v", table.Name, err)
+	}
+	if count != table.Rows {
+		return fmt.Errorf("导出表 %s 的行数 %d 与统计的 %d 不一致", table.Name, count, table.Rows)
+	}
+	return nil
+}
+
+// encodeValue 将驱动返回的值转换为可以写入 JSON 的值
+func encodeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, int64, float64, string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case driver.Valuer:
+		value, err := v.Value()
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return encodeValue(value)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+// columnsOf 表的列，已加引号
+func columnsOf(table Table) []clause.Column {
+	columns := make([]clause.Column, len(table.Columns))
+	for i, name := range table.Columns {
+		columns[i] = clause.Column{Name: name}
+	}
+	return columns
+}
+
+// foreignKeys 各表引用的其他表
+func foreignKeys(db *gorm.DB, tables []string) (map[string][]string, error) {
+	refs := make(map[string][]string)
+	var edges []struct {
+		Name       string
+		Referenced string
+	}
+
+	var err error
+	switch db.Dialector.Name() {
+	case "mysql":
+		err = db.Raw(`SELECT TABLE_NAME AS name, REFERENCED_TABLE_NAME AS referenced
+			FROM information_schema.KEY_COLUMN_USAGE
+			WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL`).Scan(&edges).Error
+	case "postgres":
+		err = db.Raw(`SELECT c.conrelid::regclass::text AS name, c.confrelid::regclass::text AS referenced
+			FROM pg_constraint c
+			WHERE c.contype = 'f' AND c.connamespace = current_schema()::regnamespace`).Scan(&edges).Error
+	case "sqlite":
+		for _, name := range tables {
+			var referenced []string
+			if err = db.Raw(`SELECT DISTINCT "table" FROM pragma_foreign_key_list(?)`, name).Scan(&referenced).Error; err != nil {
+				break
+			}
+			for _, r := range referenced {
+				edges = append(edges, struct {
+					Name       string
+					Referenced string
+				}{name, r})
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询外键失败: %v", err)
+	}
+
+	for _, e := range edges {
+		if e.Name != e.Referenced {
+			refs[e.Name] = append(refs[e.Name], e.Referenced)
+		}
+	}
+	return refs, nil
+}
+
+// sortByDependency 按依赖排序：被引用的表在前，同一层按表名排序；存在循环引用时剩余的表按表名追加
+func sortByDependency(tables []string, refs map[string][]string) []string {
+	sort.Strings(tables)
+	done := make(map[string]bool, len(tables))
+	sorted := make([]string, 0, len(tables))
+
+	for len(sorted) < len(tables) {
+		progressed := false
+		for _, name := range tables {
+			if done[name] {
+				continue
+			}
+			ready := true
+			for _, r := range refs[name] {
+				if !done[r] && contains(tables, r) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[name] = true
+				sorted = append(sorted, name)
+				progressed = true
+			}
+		}
+		if !progressed {
+			for _, name := range tables {
+				if !done[name] {
+					done[name] = true
+					sorted = append(sorted, name)
+				}
+			}
+		}
+	}
+	return sorted
+}
+
+// contains 列表中是否包含 s
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Retention 备份保留策略
+type Retention struct {
+	// Keep 保留最近的备份个数，0 表示不按个数清理
+	Keep int
+	// MaxAge 超过该时间的备份被清理，0 表示不按时间清理
+	MaxAge time.Duration
+}
+
+// File 备份目录中的一个备份文件
+type File struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// List 列出目录中的备份文件，按文件名（即备份时间）从新到旧排序
+func List(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取备份目录失败: %v", err)
+	}
+
+	var files []File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, FilePrefix) || !strings.HasSuffix(name, FileSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("读取备份文件信息失败: %v", err)
+		}
+		files = append(files, File{Path: filepath.Join(dir, name), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path > files[j].Path
+	})
+	return files, nil
+}
+
+// Prune 按保留策略清理备份，最新的备份总是保留；dryRun 时只返回将要删除的文件
+func Prune(dir string, retention Retention, dryRun bool) ([]File, error) {
+	files, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []File
+	for i, file := range files {
+		if i == 0 {
+			continue
+		}
+		expired := retention.MaxAge > 0 && time.Since(file.ModTime) > retention.MaxAge
+		if (retention.Keep > 0 && i >= retention.Keep) || expired {
+			if !dryRun {
+				if err := os.Remove(file.Path); err != nil {
+					return removed, fmt.Errorf("删除备份 %s 失败: %v", file.Path, err)
+				}
+			}
+			removed = append(removed, file)
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,239 @@
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxBatchValues 每条 INSERT 语句最多绑定的参数个数（SQLite、PostgreSQL 都有上限）
+const maxBatchValues = 10000
+
+// RestoreOptions 恢复选项
+type RestoreOptions struct {
+	// Force 目标库的迁移版本与备份不一致时仍然恢复
+	Force bool
+}
+
+// Reader 备份文件读取器
+type Reader struct {
+	Header *Header
+
+	file *os.File
+	gz   *gzip.Reader
+	dec  *json.Decoder
+}
+
+// Open 打开备份文件并读取文件头
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开备份文件失败: %v", err)
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("备份文件不是 gzip 格式: %v", err)
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(gz))
+	dec.UseNumber()
+	r := &Reader{file: file, gz: gz, dec: dec}
+
+	var header Header
+	if err := dec.Decode(&header); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("读取备份文件头失败: %v", err)
+	}
+	if header.Format != Format {
+		r.Close()
+		return nil, fmt.Errorf("不是 rentpro-admin 备份文件: %s", path)
+	}
+	if header.FormatVersion > FormatVersion {
+		r.Close()
+		return nil, fmt.Errorf("备份文件格式版本 %d 高于当前支持的 %d，请升级 rentpro-admin", header.FormatVersion, FormatVersion)
+	}
+	r.Header = &header
+	return r, nil
+}
+
+// Close 关闭备份文件
+func (r *Reader) Close() error {
+	r.gz.Close()
+	return r.file.Close()
+}
+
+// next 读取一行记录，按列类型还原值
+func (r *Reader) next(table Table, timeColumns map[int]bool) ([]interface{}, error) {
+	var row []interface{}
+	if err := r.dec.Decode(&row); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("备份文件不完整：表 %s 缺少数据", table.Name)
+		}
+		return nil, fmt.Errorf("读取表 %s 的数据失败: %v", table.Name, err)
+	}
+	if len(row) != len(table.Columns) {
+		return nil, fmt.Errorf("表 %s 的数据有 %d 列，应为 %d 列", table.Name, len(row), len(table.Columns))
+	}
+	for i, v := range row {
+		row[i] = decodeValue(v, timeColumns[i])
+	}
+	return row, nil
+}
+
+// decodeValue 将 JSON 中的值还原为写入数据库的值
+func decodeValue(v interface{}, isTime bool) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case string:
+		if isTime {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// CheckSchema 检查目标库的迁移版本与备份一致
+func CheckSchema(db *gorm.DB, header *Header, force bool) error {
+	if header.Driver != db.Dialector.Name() {
+		return fmt.Errorf("备份来自 %s 数据库，不能恢复到 %s 数据库", header.Driver, db.Dialector.Name())
+	}
+
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version == header.SchemaVersion {
+		return nil
+	}
+
+	var hint string
+	if version < header.SchemaVersion {
+		hint = fmt.Sprintf("请先执行 rentpro-admin migrate up --to %s", header.SchemaVersion)
+	} else {
+		hint = fmt.Sprintf("请先执行 rentpro-admin migrate down 回滚到 %s", header.SchemaVersion)
+	}
+	if force {
+		fmt.Printf("⚠️  目标库迁移版本 %s 与备份 %s 不一致，--force 继续恢复\n", version, header.SchemaVersion)
+		return nil
+	}
+	return fmt.Errorf("目标库迁移版本 %s 与备份 %s 不一致，%s（或使用 --force）", version, header.SchemaVersion, hint)
+}
+
+// Restore 在一个事务中恢复备份：先检查迁移版本，再清空备份中的各表并写入数据，失败时整体回滚
+// 备份中不包含的表（如未包含图片清单时的 sys_images）保持不变
+func Restore(db *gorm.DB, path string, opts RestoreOptions) (*Header, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if err := CheckSchema(db, r.Header, opts.Force); err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range r.Header.Tables {
+			if !tx.Migrator().HasTable(table.Name) {
+				return fmt.Errorf("目标库缺少表 %s", table.Name)
+			}
+		}
+
+		// 先删除引用方再删除被引用方
+		for i := len(r.Header.Tables) - 1; i >= 0; i-- {
+			name := r.Header.Tables[i].Name
+			if err := tx.Exec("DELETE FROM ?", clause.Table{Name: name}).Error; err != nil {
+				return fmt.Errorf("清空表 %s 失败: %v", name, err)
+			}
+		}
+
+		for _, table := range r.Header.Tables {
+			if err := restoreTable(tx, r, table); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 备份文件在所有表之后不应有多余内容
+	var extra json.RawMessage
+	if err := r.dec.Decode(&extra); err != io.EOF {
+		return nil, fmt.Errorf("备份文件在数据之后还有多余内容")
+	}
+	return r.Header, nil
+}
+
+// restoreTable 分批写入一张表，PostgreSQL 写入后重置自增序列
+func restoreTable(tx *gorm.DB, r *Reader, table Table) error {
+	timeColumns := make(map[int]bool)
+	for i, name := range table.Columns {
+		if contains(table.TimeColumns, name) {
+			timeColumns[i] = true
+		}
+	}
+
+	batchSize := maxBatchValues / len(table.Columns)
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	columns := columnsOf(table)
+	batch := make([][]interface{}, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		values := clause.Values{Columns: columns, Values: batch}
+		if err := tx.Exec("INSERT INTO ? ?", clause.Table{Name: table.Name}, values).Error; err != nil {
+			return fmt.Errorf("写入表 %s 失败: %v", table.Name, err)
+		}
+		batch = make([][]interface{}, 0, batchSize)
+		return nil
+	}
+
+	for i := int64(0); i < table.Rows; i++ {
+		row, err := r.next(table, timeColumns)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, row)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if tx.Dialector.Name() == "postgres" && contains(table.Columns, "id") && table.Rows > 0 {
+		err := tx.Exec("SELECT setval(pg_get_serial_sequence(?, 'id'), MAX(id)) FROM ?", table.Name, clause.Table{Name: table.Name}).Error
+		if err != nil {
+			return fmt.Errorf("重置表 %s 的自增序列失败: %v", table.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TableCheck 验证时一张表的行数比对
+type TableCheck struct {
+	Name     string
+	Expected int64
+	Restored int64
+}
+
+// OK 恢复后的行数与备份一致
+func (c TableCheck) OK() bool {
+	return c.Expected == c.Restored
+}
+
+// VerifyReport 备份验证结果
+type VerifyReport struct {
+	Header *Header
+	Tables []TableCheck
+}
+
+// OK 所有表的行数都一致
+func (r *VerifyReport) OK() bool {
+	for _, t := range r.Tables {
+		if !t.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify 将备份恢复到临时库并比对各表行数，不影响当前数据库
+// open 创建临时库，migrate 在临时库上执行迁移到备份的版本
+func Verify(path string, open func(name string) (*gorm.DB, func() error, error), migrate func(db *gorm.DB, version string) error) (*VerifyReport, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := r.Header
+	r.Close()
+
+	name := "rentpro_verify_" + time.Now().Format("20060102150405")
+	scratch, drop, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := drop(); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+	}()
+
+	if err := migrate(scratch, header.SchemaVersion); err != nil {
+		return nil, fmt.Errorf("临时库执行迁移失败: %v", err)
+	}
+	if _, err := Restore(scratch, path, RestoreOptions{}); err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{Header: header}
+	for _, table := range header.Tables {
+		check := TableCheck{Name: table.Name, Expected: table.Rows}
+		if err := scratch.Table(table.Name).Count(&check.Restored).Error; err != nil {
+			return nil, fmt.Errorf("统计表 %s 行数失败: %v", table.Name, err)
+		}
+		report.Tables = append(report.Tables, check)
+	}
+	return report, nil
+}
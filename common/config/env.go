@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// envPrefix 环境变量前缀
+// 配置路径去掉 settings，各级按 yaml 名称大写后用下划线连接，如 settings.database.source → RENTPRO_DATABASE_SOURCE
+const envPrefix = "RENTPRO_"
+
+// Field 一个配置项
+type Field struct {
+	Path   string // 配置路径，如 database.source
+	Env    string // 对应的环境变量名
+	Secret bool   // 是否为敏感信息
+	value  reflect.Value
+}
+
+// Value 配置项的值
+func (f Field) Value() string {
+	if f.value.Kind() == reflect.Ptr {
+		if f.value.IsNil() {
+			return ""
+		}
+		return fmt.Sprint(f.value.Elem().Interface())
+	}
+	return fmt.Sprint(f.value.Interface())
+}
+
+// Fields 可以通过环境变量覆盖的配置项（字符串、数字、布尔值），按结构体定义顺序
+func (c *Config) Fields() []Field {
+	var fields []Field
+	walk(reflect.ValueOf(&c.Settings).Elem(), nil, false, func(f Field) {
+		fields = append(fields, f)
+	})
+	return fields
+}
+
+// EnvName 配置路径对应的环境变量名
+func EnvName(path string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// applyEnv 使用 RENTPRO_* 环境变量覆盖配置项
+// 敏感配置项还可以用 RENTPRO_*_FILE 指定保存值的文件（Docker/Kubernetes secret 挂载）
+func (c *Config) applyEnv() error {
+	for _, f := range c.Fields() {
+		env := f.Env
+		value, ok := os.LookupEnv(env)
+		if !ok && f.Secret {
+			if path, found := os.LookupEnv(env + "_FILE"); found {
+				env, value, ok = env+"_FILE", filePrefix+path, true
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, value); err != nil {
+			return fmt.Errorf("环境变量 %s 的值无效: %v", env, err)
+		}
+		c.Env[f.Path] = env
+	}
+	return nil
+}
+
+// resolveSecrets 解析敏感配置项中的密钥引用，记录每项的来源
+// 生产模式下明文保存在配置文件中的密钥给出提示
+func (c *Config) resolveSecrets(dir string) error {
+	resolver := NewResolver(dir)
+	resolve := func(path string, value *string) error {
+		resolved, source, err := resolver.Resolve(*value)
+		if err != nil {
+			return fmt.Errorf("解析 settings.%s 失败: %v", path, err)
+		}
+		if env, ok := c.Env[path]; ok {
+			if source == SourcePlain {
+				source = "环境变量 " + env
+			} else {
+				source = "环境变量 " + env + " → " + source
+			}
+		}
+		if source == SourcePlain && c.Settings.Application.Mode == "prod" {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("settings.%s 以明文保存在配置文件中，生产环境建议使用环境变量、文件或加密密钥文件", path))
+		}
+		*value = resolved
+		c.Secrets = append(c.Secrets, Secret{Path: path, Source: source})
+		return nil
+	}
+
+	for _, f := range c.Fields() {
+		if !f.Secret || f.value.Kind() != reflect.String {
+			continue
+		}
+		value := f.value.String()
+		if err := resolve(f.Path, &value); err != nil {
+			return err
+		}
+		f.value.SetString(value)
+	}
+	for i := range c.Settings.Database.Replicas {
+		if err := resolve(fmt.Sprintf("database.replicas[%d].source", i), &c.Settings.Database.Replicas[i].Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walk 遍历结构体中的标量配置项，跳过 map、切片、inline 节点和 env:"-" 的项
+func walk(v reflect.Value, path []string, secret bool, fn func(Field)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" || sf.Tag.Get("env") == "-" {
+			continue
+		}
+
+		fieldPath := append(append([]string{}, path...), name)
+		fieldSecret := secret || sf.Tag.Get("secret") == "true"
+		field := v.Field(i)
+
+		kind := sf.Type.Kind()
+		if kind == reflect.Ptr {
+			kind = sf.Type.Elem().Kind()
+		}
+		switch kind {
+		case reflect.Struct:
+			walk(field, fieldPath, fieldSecret, fn)
+		case reflect.String, reflect.Int, reflect.Int64, reflect.Bool:
+			joined := strings.Join(fieldPath, ".")
+			fn(Field{Path: joined, Env: EnvName(joined), Secret: fieldSecret, value: field})
+		}
+	}
+}
+
+// setValue 将字符串转换为配置项的类型并赋值
+func setValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q 不是整数", value)
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%q 不是布尔值（true/false）", value)
+		}
+		field.SetBool(b)
+	}
+	return nil
+}
+
+// Hidden 显示配置时替代敏感配置项的值
+const Hidden = "<已隐藏>"
+
+// Mask 隐藏敏感信息，只保留首尾各 4 个字符
+func Mask(info string) string {
+	if info == "" {
+		return ""
+	}
+	if len(info) <= 8 {
+		return "***"
+	}
+	return info[:4] + "***" + info[len(info)-4:]
+}
+
+// Redacted 隐藏敏感配置项后的配置副本，用于显示；已配置的敏感项显示为 Hidden
+func (c *Config) Redacted() Settings {
+	hide := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		return Hidden
+	}
+	redacted := *c
+	redacted.Settings.Database.Replicas = append([]ReplicaConfig(nil), c.Settings.Database.Replicas...)
+	for i := range redacted.Settings.Database.Replicas {
+		redacted.Settings.Database.Replicas[i].Source = hide(redacted.Settings.Database.Replicas[i].Source)
+	}
+	for _, f := range redacted.Fields() {
+		if f.Secret && f.value.Kind() == reflect.String {
+			f.value.SetString(hide(f.value.String()))
+		}
+	}
+	redacted.Settings.Other = nil
+	return redacted.Settings
+}
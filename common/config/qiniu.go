@@ -3,7 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
 
 	"gopkg.in/yaml.v3"
 )
@@ -19,6 +19,9 @@ type QiniuConfig struct {
 	UseCdnDomains bool                  `yaml:"use_cdn_domains"` // 是否使用CDN域名
 	Upload        QiniuUploadConfig     `yaml:"upload"`          // 上传配置
 	ImageStyles   map[string]ImageStyle `yaml:"image_styles"`    // 图片样式配置
+
+	// Secrets access_key、secret_key 的来源，不包含值
+	Secrets []Secret `yaml:"-"`
 }
 
 // QiniuUploadConfig 上传配置
@@ -112,26 +115,18 @@ func (m *QiniuConfigManager) LoadConfig() error {
 		return fmt.Errorf("解析七牛云配置失败: %v", err)
 	}
 
-	// 处理环境变量替换
-	config.AccessKey = m.expandEnvVar(config.AccessKey)
-	config.SecretKey = m.expandEnvVar(config.SecretKey)
-	config.Domain = m.expandEnvVar(config.Domain)
+	// 解析密钥引用（环境变量、文件、加密密钥文件），域名只展开环境变量
+	secrets, err := resolveKeys(filepath.Dir(m.configPath), "qiniu", &config.AccessKey, &config.SecretKey)
+	if err != nil {
+		return err
+	}
+	config.Secrets = secrets
+	config.Domain = os.ExpandEnv(config.Domain)
 
 	m.config = &config
 	return nil
 }
 
-// expandEnvVar 展开环境变量
-func (m *QiniuConfigManager) expandEnvVar(value string) string {
-	if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") {
-		envName := value[2 : len(value)-1]
-		if envValue := os.Getenv(envName); envValue != "" {
-			return envValue
-		}
-	}
-	return value
-}
-
 // GetConfig 获取配置
 func (m *QiniuConfigManager) GetConfig() *QiniuConfig {
 	return m.config
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// S3Config S3兼容对象存储配置（AWS S3、MinIO 等）
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`   // 服务地址，如 s3.amazonaws.com、127.0.0.1:9000（不含协议）
+	Region    string `yaml:"region"`     // 区域
+	AccessKey string `yaml:"access_key"` // Access Key
+	SecretKey string `yaml:"secret_key"` // Secret Key
+	Bucket    string `yaml:"bucket"`     // 存储桶名称
+	UseSSL    bool   `yaml:"use_ssl"`    // 是否使用HTTPS
+	PathStyle bool   `yaml:"path_style"` // 是否使用路径风格访问（MinIO 需开启）
+	PublicURL string `yaml:"public_url"` // 公开访问URL前缀（CDN或自定义域名），为空时按 endpoint 和 bucket 生成
+
+	// Secrets access_key、secret_key 的来源，不包含值
+	Secrets []Secret `yaml:"-"`
+}
+
+// s3ConfigFile s3.yml 文件结构，环境配置整体覆盖默认配置
+type s3ConfigFile struct {
+	S3          S3Config `yaml:"s3"`
+	Development struct {
+		S3 *S3Config `yaml:"s3"`
+	} `yaml:"development"`
+	Production struct {
+		S3 *S3Config `yaml:"s3"`
+	} `yaml:"production"`
+}
+
+// LoadS3Config 加载S3配置文件
+// env 为运行模式（settings.application.mode）：dev 使用 development 节点，prod 使用 production 节点，
+// 没有对应节点时使用默认配置；prod 模式必须配置 production 节点，不回退到默认配置（本地 MinIO）
+func LoadS3Config(configPath string, env string) (*S3Config, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var file s3ConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析YAML配置失败: %v", err)
+	}
+
+	cfg := &file.S3
+	switch env {
+	case "dev", "development":
+		if file.Development.S3 != nil {
+			cfg = file.Development.S3
+		}
+	case "prod", "production":
+		if file.Production.S3 == nil {
+			return nil, fmt.Errorf("%s 缺少 production 节点，prod 模式不使用默认配置", configPath)
+		}
+		cfg = file.Production.S3
+	}
+
+	// 处理环境变量替换，密钥还可以引用文件或加密密钥文件
+	cfg.Endpoint = os.ExpandEnv(cfg.Endpoint)
+	cfg.PublicURL = os.ExpandEnv(cfg.PublicURL)
+	secrets, err := resolveKeys(filepath.Dir(configPath), "s3", &cfg.AccessKey, &cfg.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Secrets = secrets
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("S3配置验证失败: %v", err)
+	}
+	return cfg, nil
+}
+
+// Validate 验证配置
+func (c *S3Config) Validate() error {
+	if c.Endpoint == "" {
+		return fmt.Errorf("服务地址未配置")
+	}
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return fmt.Errorf("Access Key 或 Secret Key 未配置")
+	}
+	if c.Bucket == "" {
+		return fmt.Errorf("存储桶名称未配置")
+	}
+	return nil
+}
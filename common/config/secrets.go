@@ -0,0 +1,298 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 密钥引用：敏感配置项（数据库连接字符串、七牛云/S3 密钥等）可以不在配置文件中保存明文，而是引用外部密钥
+//
+//	${VAR}                         环境变量，可以嵌在值中，如 root:${DB_PASSWORD}@tcp(db:3306)/rentpro_admin
+//	file:/run/secrets/db_source    文件内容（Docker/Kubernetes secret 挂载），去掉末尾的换行
+//	secret:db_source               加密密钥文件 secrets.enc.yml 中的项，使用主密钥解密
+const (
+	filePrefix   = "file:"
+	secretPrefix = "secret:"
+
+	// SecretsFile 加密密钥文件名，与配置文件在同一目录
+	SecretsFile = "secrets.enc.yml"
+	// MasterKeyFile 主密钥文件名，与配置文件在同一目录，不能提交到版本库
+	MasterKeyFile = "master.key"
+)
+
+// 密钥来源，用于显示
+const (
+	SourcePlain = "配置文件（明文）"
+	SourceEmpty = "未配置"
+)
+
+var (
+	envRef     = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	secretName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+)
+
+// Resolver 解析密钥引用，加密密钥文件在第一次使用时加载
+type Resolver struct {
+	dir   string
+	store *SecretStore
+}
+
+// NewResolver 创建密钥解析器，dir 为加密密钥文件和主密钥文件所在目录
+func NewResolver(dir string) *Resolver {
+	return &Resolver{dir: dir}
+}
+
+// Resolve 解析配置值中的密钥引用，返回实际的值和来源说明
+func (r *Resolver) Resolve(value string) (string, string, error) {
+	switch {
+	case value == "":
+		return "", SourceEmpty, nil
+	case strings.HasPrefix(value, filePrefix):
+		path := strings.TrimSpace(strings.TrimPrefix(value, filePrefix))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("读取密钥文件失败: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), "文件 " + path, nil
+	case strings.HasPrefix(value, secretPrefix):
+		name := strings.TrimSpace(strings.TrimPrefix(value, secretPrefix))
+		if r.store == nil {
+			store, err := OpenSecretStore(r.dir)
+			if err != nil {
+				return "", "", err
+			}
+			r.store = store
+		}
+		secret, err := r.store.Get(name)
+		if err != nil {
+			return "", "", err
+		}
+		return secret, fmt.Sprintf("加密文件 %s（%s）", r.store.Path(), name), nil
+	}
+
+	names := envRef.FindAllStringSubmatch(value, -1)
+	if len(names) == 0 {
+		return value, SourcePlain, nil
+	}
+	var vars []string
+	for _, m := range names {
+		if _, ok := os.LookupEnv(m[1]); ok {
+			vars = append(vars, m[1])
+		} else {
+			vars = append(vars, m[1]+"（未设置）")
+		}
+	}
+	resolved := envRef.ReplaceAllStringFunc(value, func(ref string) string {
+		return os.Getenv(ref[2 : len(ref)-1])
+	})
+	return resolved, "环境变量 " + strings.Join(vars, ", "), nil
+}
+
+// SecretStore 加密密钥文件
+// 每个值单独以 AES-256-GCM 加密（密钥名作为附加数据），文件中只有密钥名是明文，可以提交到版本库
+type SecretStore struct {
+	path string
+	dir  string
+	key  []byte
+
+	file secretsFile
+}
+
+// secretsFile 加密密钥文件结构
+type secretsFile struct {
+	Version int               `yaml:"version"`
+	Secrets map[string]string `yaml:"secrets"`
+}
+
+// OpenSecretStore 打开目录中的加密密钥文件，文件不存在时为空
+func OpenSecretStore(dir string) (*SecretStore, error) {
+	s := &SecretStore{
+		path: filepath.Join(dir, SecretsFile),
+		dir:  dir,
+		file: secretsFile{Version: 1, Secrets: make(map[string]string)},
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("读取加密密钥文件失败: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &s.file); err != nil {
+		return nil, fmt.Errorf("解析加密密钥文件 %s 失败: %v", s.path, err)
+	}
+	if s.file.Secrets == nil {
+		s.file.Secrets = make(map[string]string)
+	}
+	return s, nil
+}
+
+// Path 加密密钥文件路径
+func (s *SecretStore) Path() string {
+	return s.path
+}
+
+// Names 文件中的密钥名，按名称排序
+func (s *SecretStore) Names() []string {
+	names := make([]string, 0, len(s.file.Secrets))
+	for name := range s.file.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get 解密一个密钥
+func (s *SecretStore) Get(name string) (string, error) {
+	sealed, ok := s.file.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("加密密钥文件 %s 中没有 %s", s.path, name)
+	}
+	aead, err := s.cipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("密钥 %s 格式错误", name)
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("解密密钥 %s 失败，主密钥不正确或文件已被修改", name)
+	}
+	return string(plain), nil
+}
+
+// Set 加密并保存一个密钥（需要调用 Save 写入文件）
+func (s *SecretStore) Set(name, value string) error {
+	if !secretName.MatchString(name) {
+		return fmt.Errorf("密钥名 %q 无效，只能包含小写字母、数字、下划线、点和连字符", name)
+	}
+	aead, err := s.cipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("生成随机数失败: %v", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	s.file.Secrets[name] = base64.StdEncoding.EncodeToString(sealed)
+	return nil
+}
+
+// Delete 删除一个密钥（需要调用 Save 写入文件）
+func (s *SecretStore) Delete(name string) bool {
+	if _, ok := s.file.Secrets[name]; !ok {
+		return false
+	}
+	delete(s.file.Secrets, name)
+	return true
+}
+
+// Save 写入加密密钥文件
+func (s *SecretStore) Save() error {
+	data, err := yaml.Marshal(&s.file)
+	if err != nil {
+		return fmt.Errorf("序列化加密密钥文件失败: %v", err)
+	}
+	header := "# rentpro-admin 加密密钥文件，使用 rentpro-admin config secrets 管理，请勿手工修改\n" +
+		"# 值使用主密钥（RENTPRO_MASTER_KEY 或 " + MasterKeyFile + "）以 AES-256-GCM 加密，可以提交到版本库\n"
+	if err := os.WriteFile(s.path, append([]byte(header), data...), 0644); err != nil {
+		return fmt.Errorf("写入加密密钥文件失败: %v", err)
+	}
+	return nil
+}
+
+// cipher 使用主密钥创建加密器
+func (s *SecretStore) cipher() (cipher.AEAD, error) {
+	if s.key == nil {
+		key, err := MasterKey(s.dir)
+		if err != nil {
+			return nil, err
+		}
+		s.key = key
+	}
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// MasterKey 读取主密钥（base64 编码的 32 字节）
+// 依次查找环境变量 RENTPRO_MASTER_KEY、RENTPRO_MASTER_KEY_FILE 指定的文件、目录中的 master.key
+func MasterKey(dir string) ([]byte, error) {
+	encoded, source := os.Getenv(envPrefix+"MASTER_KEY"), "环境变量 "+envPrefix+"MASTER_KEY"
+	if encoded == "" {
+		path := os.Getenv(envPrefix + "MASTER_KEY_FILE")
+		if path == "" {
+			path = filepath.Join(dir, MasterKeyFile)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("未找到主密钥：请设置环境变量 %sMASTER_KEY 或创建 %s（rentpro-admin config secrets init）", envPrefix, path)
+			}
+			return nil, fmt.Errorf("读取主密钥失败: %v", err)
+		}
+		encoded, source = string(data), "文件 "+path
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("主密钥（%s）无效，应为 base64 编码的 32 字节", source)
+	}
+	return key, nil
+}
+
+// GenerateMasterKey 生成主密钥并写入文件（权限 0600），文件已存在时报错
+func GenerateMasterKey(path string) error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("生成主密钥失败: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("主密钥文件 %s 已存在", path)
+		}
+		return fmt.Errorf("创建主密钥文件失败: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		return fmt.Errorf("写入主密钥文件失败: %v", err)
+	}
+	return nil
+}
+
+// resolveKeys 解析对象存储配置（qiniu.yml、s3.yml）中 access_key、secret_key 的密钥引用
+func resolveKeys(dir, section string, accessKey, secretKey *string) ([]Secret, error) {
+	resolver := NewResolver(dir)
+	var secrets []Secret
+	for _, item := range []struct {
+		name  string
+		value *string
+	}{
+		{"access_key", accessKey},
+		{"secret_key", secretKey},
+	} {
+		resolved, source, err := resolver.Resolve(*item.value)
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s.%s 失败: %v", section, item.name, err)
+		}
+		*item.value = resolved
+		secrets = append(secrets, Secret{Path: section + "." + item.name, Source: source})
+	}
+	return secrets, nil
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath 默认配置文件路径
+const DefaultPath = "config/settings.yml"
+
+// Config 配置文件（settings.yml）
+// 加载顺序：settings.yml → 同目录的 settings.{mode}.yml → RENTPRO_* 环境变量 → 密钥引用 → 默认值，最后校验
+type Config struct {
+	Settings Settings `yaml:"settings"`
+
+	// Files 实际加载的配置文件，按加载顺序
+	Files []string `yaml:"-"`
+	// Env 被环境变量覆盖的配置项（配置路径 → 环境变量名）
+	Env map[string]string `yaml:"-"`
+	// Warnings 加载时的提示，如已更名的配置项
+	Warnings []string `yaml:"-"`
+	// Secrets 敏感配置项及其来源，按结构体定义顺序
+	Secrets []Secret `yaml:"-"`
+}
+
+// Secret 敏感配置项的来源，不包含值
+type Secret struct {
+	Path   string // 配置路径，如 database.source
+	Source string // 来源说明，如 环境变量 RENTPRO_DATABASE_SOURCE
+}
+
+// Settings settings.yml 中的 settings 节点
+type Settings struct {
+	Application ApplicationConfig `yaml:"application"`
+	Logger      LoggerConfig      `yaml:"logger"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Storage     StorageConfig     `yaml:"storage"`
+
+	// Other 尚未使用的配置节点（qiniu、gen、cache 等），不做校验
+	Other map[string]interface{} `yaml:",inline"`
+}
+
+// ApplicationConfig 应用配置
+type ApplicationConfig struct {
+	Mode         string `yaml:"mode"`         // 运行模式：dev、test、prod
+	Host         string `yaml:"host"`         // 监听地址
+	Name         string `yaml:"name"`         // 应用名称
+	Port         int    `yaml:"port"`         // 端口号
+	ReadTimeout  int    `yaml:"readtimeout"`  // 读取超时（秒）
+	WriteTimeout int    `yaml:"writetimeout"` // 写入超时（秒），0 为不限制
+	EnabledDP    bool   `yaml:"enabledp"`     // 数据权限功能开关
+
+	// LegacyWriteTimeout 旧配置项 writertimeout（拼写错误，从未生效），加载时提示并迁移到 writetimeout
+	LegacyWriteTimeout int `yaml:"writertimeout,omitempty" env:"-"`
+}
+
+// LoggerConfig 日志配置
+type LoggerConfig struct {
+	Path      string `yaml:"path"`      // 日志存放路径
+	Stdout    string `yaml:"stdout"`    // 日志输出：file 文件，default 命令行
+	Level     string `yaml:"level"`     // 日志等级
+	EnabledDB bool   `yaml:"enableddb"` // 数据库日志开关
+}
+
+// JWTConfig JWT 配置
+type JWTConfig struct {
+	Secret  string `yaml:"secret" secret:"true"` // token 密钥
+	Timeout int    `yaml:"timeout"`              // token 过期时间（秒）
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	// Driver 数据库类型：mysql、sqlite3、postgres
+	Driver string `yaml:"driver"`
+	// Source 数据库连接字符串，sqlite3 为数据库文件路径或 :memory:
+	Source string `yaml:"source" secret:"true"`
+	// Replicas 只读副本，驱动与主库相同；只在 api 命令中启用
+	Replicas []ReplicaConfig `yaml:"replicas"`
+	// HealthInterval 副本健康检查间隔（秒），默认 10
+	HealthInterval int `yaml:"healthinterval"`
+	// StickyWindow 写入后同一客户端读主库的时间（秒），未配置时为 5，配置为 0 时只有写请求本身读主库
+	StickyWindow *int `yaml:"stickywindow"`
+}
+
+// ReplicaConfig 只读副本配置
+type ReplicaConfig struct {
+	// Source 副本连接字符串，格式与主库相同
+	Source string `yaml:"source" secret:"true"`
+}
+
+// 可选值，校验时使用
+var (
+	modes         = []string{"dev", "test", "prod"}
+	drivers       = []string{"mysql", "sqlite3", "postgres"}
+	logLevels     = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+	storageDrives = []string{"qiniu", "s3", "local"}
+)
+
+// current 当前命令加载的配置
+var current *Config
+
+// Current 当前配置，命令未调用 Load 时加载默认配置文件（加载失败时退出）
+func Current() *Config {
+	if current == nil {
+		cfg, err := Load(DefaultPath)
+		if err != nil {
+			log.Fatalf("加载配置失败: %v", err)
+		}
+		current = cfg
+	}
+	return current
+}
+
+// Load 加载配置文件并设置为当前配置，输出加载提示
+func Load(path string) (*Config, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range cfg.Warnings {
+		log.Printf("⚠️  %s", warning)
+	}
+	current = cfg
+	return cfg, nil
+}
+
+// Read 加载并校验配置文件，不修改当前配置
+func Read(path string) (*Config, error) {
+	if path == "" {
+		path = DefaultPath
+	}
+	cfg := &Config{Env: make(map[string]string)}
+	if err := cfg.decodeFile(path); err != nil {
+		return nil, err
+	}
+
+	// 环境配置文件：settings.yml → settings.prod.yml，模式可以由 RENTPRO_APPLICATION_MODE 指定
+	mode := cfg.Settings.Application.Mode
+	if env := os.Getenv(envPrefix + "APPLICATION_MODE"); env != "" {
+		mode = env
+	}
+	if mode != "" {
+		ext := filepath.Ext(path)
+		layer := strings.TrimSuffix(path, ext) + "." + mode + ext
+		if _, err := os.Stat(layer); err == nil {
+			if err := cfg.decodeFile(layer); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.resolveSecrets(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	cfg.migrateLegacy()
+	cfg.setDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// decodeFile 解析一个配置文件，覆盖已加载的配置项
+// 已知的配置节点中出现未定义的配置项时报错（通常是拼写错误）
+func (c *Config) decodeFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("配置文件不存在: %s", path)
+		}
+		return fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %v", path, err)
+	}
+	c.Files = append(c.Files, path)
+	return nil
+}
+
+// migrateLegacy 兼容已更名的配置项
+func (c *Config) migrateLegacy() {
+	app := &c.Settings.Application
+	if app.LegacyWriteTimeout != 0 {
+		if app.WriteTimeout == 0 {
+			app.WriteTimeout = app.LegacyWriteTimeout
+		}
+		c.Warnings = append(c.Warnings, "settings.application.writertimeout 已更名为 writetimeout，请修改配置文件")
+		app.LegacyWriteTimeout = 0
+	}
+}
+
+// setDefaults 未配置的项使用默认值
+func (c *Config) setDefaults() {
+	s := &c.Settings
+	if s.Application.Mode == "" {
+		s.Application.Mode = "dev"
+	}
+	if s.Application.Host == "" {
+		s.Application.Host = "0.0.0.0"
+	}
+	if s.Application.Port == 0 {
+		s.Application.Port = 8000
+	}
+	if s.Logger.Level == "" {
+		s.Logger.Level = "info"
+	}
+	if s.JWT.Timeout == 0 {
+		s.JWT.Timeout = 3600
+	}
+	if s.Database.HealthInterval == 0 {
+		s.Database.HealthInterval = 10
+	}
+	if s.Database.StickyWindow == nil {
+		window := 5
+		s.Database.StickyWindow = &window
+	}
+}
+
+// Validate 校验配置项，返回所有错误
+func (c *Config) Validate() error {
+	s := c.Settings
+	var errs []string
+	check := func(ok bool, path, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf("  - settings.%s: %s", path, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(oneOf(s.Application.Mode, modes), "application.mode", "不支持的运行模式 %q，可选 %s", s.Application.Mode, strings.Join(modes, ", "))
+	check(s.Application.Port > 0 && s.Application.Port <= 65535, "application.port", "端口号 %d 不在 1-65535 之间", s.Application.Port)
+	check(s.Application.ReadTimeout >= 0, "application.readtimeout", "不能为负数")
+	check(s.Application.WriteTimeout >= 0, "application.writetimeout", "不能为负数")
+	check(oneOf(s.Logger.Level, logLevels), "logger.level", "不支持的日志等级 %q，可选 %s", s.Logger.Level, strings.Join(logLevels, ", "))
+	check(s.JWT.Timeout > 0, "jwt.timeout", "必须大于 0")
+
+	check(s.Database.Driver != "", "database.driver", "不能为空，可选 %s", strings.Join(drivers, ", "))
+	check(s.Database.Driver == "" || oneOf(s.Database.Driver, drivers), "database.driver", "不支持的数据库类型 %q，可选 %s", s.Database.Driver, strings.Join(drivers, ", "))
+	check(s.Database.Source != "", "database.source", "不能为空（可以通过环境变量 RENTPRO_DATABASE_SOURCE 设置）")
+	for i, r := range s.Database.Replicas {
+		check(r.Source != "", fmt.Sprintf("database.replicas[%d].source", i), "不能为空")
+	}
+	check(s.Database.HealthInterval > 0, "database.healthinterval", "必须大于 0")
+	check(s.Database.StickyWindow == nil || *s.Database.StickyWindow >= 0, "database.stickywindow", "不能为负数")
+
+	check(s.Storage.Driver == "" || oneOf(s.Storage.Driver, storageDrives), "storage.driver", "不支持的存储驱动 %q，可选 %s", s.Storage.Driver, strings.Join(storageDrives, ", "))
+	check(s.Storage.DirectUpload.Expires >= 0, "storage.direct_upload.expires", "不能为负数")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("配置校验失败:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// oneOf 值是否在可选值中
+func oneOf(value string, options []string) bool {
+	for _, option := range options {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
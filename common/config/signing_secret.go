@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// weakSecrets 仓库和示例中出现过的签名密钥，任何模式下都不能使用
+var weakSecrets = []string{
+	"go-admin", "rentpro-admin-secret-key", "rentpro-direct-upload",
+	"secret", "changeme", "jwt-secret",
+}
+
+// minSecretLength 签名密钥的最小长度（HMAC-SHA256 建议不少于 32 字节）
+const minSecretLength = 32
+
+// SecretCheck 签名密钥检查结果
+type SecretCheck struct {
+	// Errors 不能使用的原因：未配置、使用默认密钥，dev 以外的模式还包括强度不足
+	Errors []string
+	// Warnings dev 模式下的强度不足
+	Warnings []string
+}
+
+// CheckJWTSecret 检查 JWT 密钥（settings.jwt.secret），规则见 checkSecret
+func CheckJWTSecret(secret, mode string) SecretCheck {
+	return checkSecret(secret, mode, "RENTPRO_JWT_SECRET")
+}
+
+// CheckDirectUploadSecret 检查浏览器直传凭证签名密钥（settings.storage.direct_upload.secret），规则见 checkSecret
+func CheckDirectUploadSecret(secret, mode string) SecretCheck {
+	return checkSecret(secret, mode, "RENTPRO_STORAGE_DIRECT_UPLOAD_SECRET")
+}
+
+// checkSecret 检查签名密钥：为空或为已知的默认值时任何模式都不能使用；
+// 长度不足、字符种类过少时 dev 以外的模式不能使用，dev 模式为警告。api 启动和 doctor 使用同一规则
+func checkSecret(secret, mode, env string) SecretCheck {
+	var check SecretCheck
+	if secret == "" {
+		check.Errors = append(check.Errors, fmt.Sprintf("未配置（可以通过环境变量 %s 设置）", env))
+		return check
+	}
+	for _, weak := range weakSecrets {
+		if strings.EqualFold(secret, weak) {
+			check.Errors = append(check.Errors, "使用的是默认密钥，任何人都可以伪造签名")
+			return check
+		}
+	}
+
+	var weak []string
+	if len(secret) < minSecretLength {
+		weak = append(weak, fmt.Sprintf("长度 %d，应不少于 %d 个字符", len(secret), minSecretLength))
+	}
+	if distinct(secret) < 10 {
+		weak = append(weak, "字符种类过少")
+	}
+	if mode == "dev" {
+		check.Warnings = weak
+	} else {
+		check.Errors = weak
+	}
+	return check
+}
+
+// distinct 不同字符的个数
+func distinct(s string) int {
+	seen := make(map[rune]bool)
+	for _, r := range s {
+		seen[r] = true
+	}
+	return len(seen)
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// StorageConfig 文件存储配置（settings.yml 中的 settings.storage）
+type StorageConfig struct {
+	Driver string             `yaml:"driver"` // 存储驱动：qiniu 七牛云，s3 S3兼容存储，local 本地磁盘
+	Local  LocalStorageConfig `yaml:"local"`  // 本地磁盘驱动配置
+	Upload QiniuUploadConfig  `yaml:"upload"` // 上传限制，未配置时七牛云驱动沿用 qiniu.yml 的 upload
+	Image  ImageCheckConfig   `yaml:"image"`  // 图片内容检查
+
+	Derivatives  DerivativesConfig  `yaml:"derivatives"`   // 服务端生成的缩略图等衍生图
+	Watermark    WatermarkConfig    `yaml:"watermark"`     // 衍生图水印
+	DirectUpload DirectUploadConfig `yaml:"direct_upload"` // 浏览器直传
+}
+
+// WatermarkConfig 衍生图水印配置，水印策略在 sys_watermark_policies 中按分类、模块配置
+type WatermarkConfig struct {
+	// Font 文字水印字体文件（TTF/OTF），为空使用内置字体（仅支持拉丁字符，中文水印需配置中文字体）
+	Font string `yaml:"font"`
+}
+
+// DirectUploadConfig 浏览器直传配置
+type DirectUploadConfig struct {
+	// CallbackURL 存储服务上传回调地址（需公网可访问的完整URL，如 https://admin.example.com/api/v1/images/upload-callback），
+	// 为空时客户端上传完成后自行调用回调接口
+	CallbackURL string `yaml:"callback_url"`
+	// Expires 上传凭证有效期（秒）
+	Expires int `yaml:"expires"`
+	// Secret 上传凭证签名密钥，api 启动时按 CheckDirectUploadSecret 检查，多实例部署需配置相同的密钥
+	Secret string `yaml:"secret" secret:"true"`
+}
+
+// DerivativesConfig 衍生图配置
+type DerivativesConfig struct {
+	// Enabled 是否由服务端生成衍生图，未配置时七牛云驱动使用图片样式URL，其他驱动由服务端生成
+	Enabled *bool `yaml:"enabled"`
+	// Sizes 衍生图规格，thumbnail、medium、large 对应图片的缩略图、中图、大图URL
+	Sizes map[string]DerivativeSize `yaml:"sizes"`
+}
+
+// DerivativeSize 衍生图规格
+type DerivativeSize struct {
+	Width   int    `yaml:"width"`   // 宽度
+	Height  int    `yaml:"height"`  // 高度
+	Quality int    `yaml:"quality"` // 编码质量 1-100
+	Format  string `yaml:"format"`  // 输出格式：jpeg、webp
+	Mode    string `yaml:"mode"`    // 缩放方式：fit 等比缩小不裁剪，fill 居中裁剪为指定尺寸
+}
+
+// defaultDerivativeSizes 默认衍生图规格，尺寸与 qiniu.yml 的图片样式一致
+var defaultDerivativeSizes = map[string]DerivativeSize{
+	"thumbnail": {Width: 200, Height: 150, Quality: 85, Format: "jpeg", Mode: "fill"},
+	"medium":    {Width: 800, Height: 600, Quality: 85, Format: "jpeg", Mode: "fit"},
+	"large":     {Width: 1200, Height: 900, Quality: 90, Format: "jpeg", Mode: "fit"},
+}
+
+// ImageCheckConfig 上传图片内容检查配置
+type ImageCheckConfig struct {
+	MinWidth  int   `yaml:"min_width"`  // 最小宽度（像素），0 为不限制
+	MinHeight int   `yaml:"min_height"` // 最小高度（像素），0 为不限制
+	MaxPixels int64 `yaml:"max_pixels"` // 最大像素数（宽×高），防止解压炸弹，0 使用默认 5000 万
+}
+
+// LocalStorageConfig 本地磁盘驱动配置
+type LocalStorageConfig struct {
+	Root       string `yaml:"root"`                      // 文件存放目录
+	BaseURL    string `yaml:"base_url"`                  // 访问URL前缀，可以是路径（/uploads）或完整地址
+	SignSecret string `yaml:"sign_secret" secret:"true"` // 签名URL密钥
+	Private    bool   `yaml:"private"`                   // 私有模式，只能通过签名URL访问
+}
+
+// 默认上传限制
+const (
+	defaultMaxFileSize   = 5 * 1024 * 1024
+	defaultLocalRoot     = "./uploads"
+	defaultLocalURL      = "/uploads"
+	defaultDirectExpires = 600
+)
+
+var defaultAllowedTypes = []string{"image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp"}
+
+// SetDefaults 补全未配置的项
+func (c *StorageConfig) SetDefaults() {
+	if c.Driver == "" {
+		c.Driver = "qiniu"
+	}
+	if c.Local.Root == "" {
+		c.Local.Root = defaultLocalRoot
+	}
+	if c.Local.BaseURL == "" {
+		c.Local.BaseURL = defaultLocalURL
+	}
+	c.Local.BaseURL = strings.TrimRight(c.Local.BaseURL, "/")
+	if c.Upload.MaxFileSize <= 0 {
+		c.Upload.MaxFileSize = defaultMaxFileSize
+	}
+	if len(c.Upload.AllowedTypes) == 0 {
+		c.Upload.AllowedTypes = defaultAllowedTypes
+	}
+	if c.Derivatives.Enabled == nil {
+		enabled := c.Driver != "qiniu"
+		c.Derivatives.Enabled = &enabled
+	}
+	if c.DirectUpload.Expires <= 0 {
+		c.DirectUpload.Expires = defaultDirectExpires
+	}
+	if len(c.Derivatives.Sizes) == 0 {
+		c.Derivatives.Sizes = make(map[string]DerivativeSize, len(defaultDerivativeSizes))
+		for name, size := range defaultDerivativeSizes {
+			c.Derivatives.Sizes[name] = size
+		}
+	}
+	for name, size := range c.Derivatives.Sizes {
+		if size.Quality <= 0 || size.Quality > 100 {
+			size.Quality = 85
+		}
+		if size.Format == "" {
+			size.Format = "jpeg"
+		}
+		if size.Mode == "" {
+			size.Mode = "fit"
+		}
+		c.Derivatives.Sizes[name] = size
+	}
+}
+
+// DerivativesEnabled 是否由服务端生成衍生图
+func (c *StorageConfig) DerivativesEnabled() bool {
+	return c.Derivatives.Enabled != nil && *c.Derivatives.Enabled
+}
+
+// MountPath 本地文件静态服务挂载路径，取 BaseURL 的路径部分
+func (c LocalStorageConfig) MountPath() string {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || u.Path == "" {
+		return defaultLocalURL
+	}
+	return u.Path
+}
+
+// 全局存储配置
+var StorageConfigInstance *StorageConfig
+
+// GetStorageConfig 获取全局存储配置，未初始化时返回默认配置
+func GetStorageConfig() *StorageConfig {
+	if StorageConfigInstance == nil {
+		cfg := &StorageConfig{}
+		cfg.SetDefaults()
+		return cfg
+	}
+	return StorageConfigInstance
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// LastInsertID 查询当前连接最近一次插入的自增ID
+// 原生 INSERT 语句之后调用，必须与 INSERT 使用同一个事务（同一连接）
+func LastInsertID(tx *gorm.DB) (uint64, error) {
+	var query string
+	switch tx.Dialector.Name() {
+	case "mysql":
+		query = "SELECT LAST_INSERT_ID()"
+	case "sqlite":
+		query = "SELECT last_insert_rowid()"
+	case "postgres":
+		query = "SELECT lastval()"
+	default:
+		return 0, fmt.Errorf("不支持的数据库类型: %s", tx.Dialector.Name())
+	}
+
+	var id uint64
+	if err := tx.Raw(query).Scan(&id).Error; err != nil {
+		return 0, fmt.Errorf("查询插入ID失败: %v", err)
+	}
+	return id, nil
+}
+
+// Like 模糊匹配运算符
+// MySQL 默认排序规则和 SQLite（ASCII 范围）的 LIKE 不区分大小写，PostgreSQL 使用 ILIKE 保持一致
+func Like(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "ILIKE"
+	}
+	return "LIKE"
+}
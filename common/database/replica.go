@@ -0,0 +1,233 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// ContextPrimary 上下文中存在该键（值为 true）时，查询使用主库
+// 键为 string 类型，gin.Context 通过 c.Set 设置后可以直接作为查询上下文
+const ContextPrimary = "database:primary"
+
+const (
+	defaultHealthInterval = 10 * time.Second
+	defaultStickyWindow   = 5 * time.Second
+	healthCheckTimeout    = 3 * time.Second
+)
+
+var (
+	replicas     *replicaSet
+	stickyWindow time.Duration
+)
+
+// replicaSet 只读副本及其健康状态
+type replicaSet struct {
+	primary gorm.ConnPool
+	pools   []*replicaPool
+	next    uint64
+}
+
+// replicaPool 一个只读副本的连接
+type replicaPool struct {
+	index   int
+	source  string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// SetupReplicas 按配置启用只读副本：查询路由到健康的副本，写入和事务使用主库，
+// 所有副本不可用时查询回退到主库。需要在 Setup 之后调用；没有配置副本时不做任何处理
+func SetupReplicas() error {
+	if DB == nil || current == nil {
+		return fmt.Errorf("数据库连接未初始化")
+	}
+	cfg := current
+	if len(cfg.Replicas) == 0 {
+		return nil
+	}
+
+	primary, err := DB.DB()
+	if err != nil {
+		return fmt.Errorf("获取主库连接失败: %v", err)
+	}
+
+	set := &replicaSet{primary: primary}
+	dialectors := make([]gorm.Dialector, 0, len(cfg.Replicas)+1)
+	for i, replica := range cfg.Replicas {
+		conn, err := openReplica(cfg.Driver, replica.Source)
+		if err != nil {
+			return fmt.Errorf("连接只读副本 %d 失败: %v", i+1, err)
+		}
+		pool := &replicaPool{index: i + 1, source: replica.Source, db: conn}
+		set.pools = append(set.pools, pool)
+		dialectors = append(dialectors, connDialector(cfg.Driver, conn))
+		log.Printf("只读副本 %d: %s", pool.index, maskSensitiveInfo(replica.Source))
+	}
+	// 主库也加入读连接池，副本全部不可用时由 Resolve 选择
+	// （只有一个读连接时 dbresolver 不调用 Policy，无法回退）
+	dialectors = append(dialectors, connDialector(cfg.Driver, primary))
+
+	err = DB.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   set,
+	}))
+	if err != nil {
+		return fmt.Errorf("注册读写分离失败: %v", err)
+	}
+
+	// 写入后读主库：查询上下文中带有 ContextPrimary 时改用主库
+	// （dbresolver.Write 会重新执行 gorm:db_resolver，因此只需在执行 SQL 前注册）
+	callbacks := DB.Callback()
+	for _, err := range []error{
+		callbacks.Query().Before("gorm:query").Register("rentpro:read_primary", readPrimary),
+		callbacks.Row().Before("gorm:row").Register("rentpro:read_primary", readPrimary),
+		callbacks.Raw().Before("gorm:raw").Register("rentpro:read_primary", readPrimary),
+	} {
+		if err != nil {
+			return fmt.Errorf("注册读主库回调失败: %v", err)
+		}
+	}
+
+	set.check()
+	go set.watch(duration(cfg.HealthInterval, defaultHealthInterval))
+
+	replicas = set
+	stickyWindow = defaultStickyWindow
+	if cfg.StickyWindow != nil {
+		// 0 表示不启用，写入后只有写请求本身读主库
+		stickyWindow = time.Duration(*cfg.StickyWindow) * time.Second
+	}
+	log.Printf("✅ 读写分离已启用：%d 个只读副本", len(set.pools))
+	return nil
+}
+
+// HasReplicas 是否启用了只读副本
+func HasReplicas() bool {
+	return replicas != nil
+}
+
+// StickyWindow 写入后同一客户端读主库的时间
+func StickyWindow() time.Duration {
+	return stickyWindow
+}
+
+// Request 处理请求时使用的数据库连接，携带请求上下文
+// 写入后读主库依赖上下文中的 ContextPrimary 标记（见 middleware.ReadYourWrites），
+// 请求内的查询、写入和事务都应通过它访问数据库，不直接使用 DB
+func Request(ctx context.Context) *gorm.DB {
+	return DB.WithContext(ctx)
+}
+
+// WithPrimary 返回查询使用主库的上下文
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ContextPrimary, true)
+}
+
+// readPrimary 查询上下文要求读主库时，切换到主库
+func readPrimary(db *gorm.DB) {
+	if ctx := db.Statement.Context; ctx != nil && ctx.Value(ContextPrimary) == true {
+		dbresolver.Write.ModifyStatement(db.Statement)
+	}
+}
+
+// Resolve 实现 dbresolver.Policy：轮询选择健康的副本，全部不可用时使用主库
+func (s *replicaSet) Resolve(pools []gorm.ConnPool) gorm.ConnPool {
+	n := len(s.pools)
+	start := int(atomic.AddUint64(&s.next, 1))
+	for i := 0; i < n; i++ {
+		pool := s.pools[(start+i)%n]
+		if pool.healthy.Load() {
+			return pool.db
+		}
+	}
+	return s.primary
+}
+
+// watch 定期检查副本健康状态
+func (s *replicaSet) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.check()
+	}
+}
+
+// check 并发检查所有副本，状态变化时记录日志
+func (s *replicaSet) check() {
+	var wg sync.WaitGroup
+	for _, pool := range s.pools {
+		wg.Add(1)
+		go func(pool *replicaPool) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			defer cancel()
+
+			err := pool.db.PingContext(ctx)
+			healthy := err == nil
+			if pool.healthy.Swap(healthy) == healthy {
+				return
+			}
+			if healthy {
+				log.Printf("✅ 只读副本 %d 可用", pool.index)
+			} else {
+				log.Printf("⚠️  只读副本 %d 不可用，查询改用其他副本或主库: %v", pool.index, err)
+			}
+		}(pool)
+	}
+	wg.Wait()
+}
+
+// openReplica 打开副本连接（不立即连接，由健康检查检测）
+func openReplica(driver, source string) (*sql.DB, error) {
+	var conn *sql.DB
+	var err error
+	switch driver {
+	case "mysql":
+		conn, err = sql.Open("mysql", source)
+	case "postgres":
+		conn, err = sql.Open("pgx", source)
+	case "sqlite3":
+		conn, err = sql.Open(sqlite.DriverName, sqliteDSN(source))
+	default:
+		return nil, fmt.Errorf("不支持的数据库类型: %s", driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetMaxIdleConns(10)
+	conn.SetMaxOpenConns(100)
+	conn.SetConnMaxLifetime(time.Hour)
+	return conn, nil
+}
+
+// connDialector 使用已打开的连接创建 Dialector，供 dbresolver 注册
+func connDialector(driver string, conn *sql.DB) gorm.Dialector {
+	switch driver {
+	case "mysql":
+		return mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true})
+	case "postgres":
+		return postgres.New(postgres.Config{Conn: conn})
+	default:
+		return &sqlite.Dialector{Conn: conn}
+	}
+}
+
+// duration 秒数配置转为时间，未配置时使用默认值
+func duration(seconds int, fallback time.Duration) time.Duration {
+	if seconds <= 0 {
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// OpenScratch 使用当前数据库配置创建一个临时的空库并返回其连接，用于验证备份等场景：
+// MySQL 创建数据库、PostgreSQL 创建 schema、SQLite 创建临时文件。
+// 返回的 drop 函数关闭连接并删除临时库
+func OpenScratch(name string) (scratch *gorm.DB, drop func() error, err error) {
+	if DB == nil || current == nil {
+		return nil, nil, fmt.Errorf("数据库连接未初始化")
+	}
+
+	gormConfig := &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+		Logger: logger.Default.LogMode(logger.Silent),
+	}
+	source := current.Source
+
+	var cleanup func() error
+	switch current.Driver {
+	case "mysql":
+		cfg, parseErr := gomysql.ParseDSN(source)
+		if parseErr != nil {
+			return nil, nil, fmt.Errorf("解析连接字符串失败: %v", parseErr)
+		}
+		cfg.DBName = name
+		if err := DB.Exec(fmt.Sprintf("CREATE DATABASE `%s` CHARACTER SET utf8mb4", name)).Error; err != nil {
+			return nil, nil, fmt.Errorf("创建临时数据库失败: %v", err)
+		}
+		cleanup = func() error {
+			return DB.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", name)).Error
+		}
+		scratch, err = gorm.Open(mysql.Open(cfg.FormatDSN()), gormConfig)
+	case "postgres":
+		if err := DB.Exec(fmt.Sprintf(`CREATE SCHEMA "%s"`, name)).Error; err != nil {
+			return nil, nil, fmt.Errorf("创建临时 schema 失败: %v", err)
+		}
+		cleanup = func() error {
+			return DB.Exec(fmt.Sprintf(`DROP SCHEMA IF EXISTS "%s" CASCADE`, name)).Error
+		}
+		scratch, err = gorm.Open(postgres.Open(postgresSearchPath(source, name)), gormConfig)
+	case "sqlite3":
+		file, tempErr := os.CreateTemp("", name+"-*.db")
+		if tempErr != nil {
+			return nil, nil, fmt.Errorf("创建临时数据库文件失败: %v", tempErr)
+		}
+		file.Close()
+		path := file.Name()
+		cleanup = func() error {
+			for _, suffix := range []string{"-wal", "-shm"} {
+				os.Remove(path + suffix)
+			}
+			return os.Remove(path)
+		}
+		scratch, err = gorm.Open(sqlite.Open(sqliteDSN(path)), gormConfig)
+	default:
+		return nil, nil, fmt.Errorf("不支持的数据库类型: %s", current.Driver)
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("连接临时库失败: %v", err)
+	}
+
+	drop = func() error {
+		if sqlDB, err := scratch.DB(); err == nil {
+			sqlDB.Close()
+		}
+		if err := cleanup(); err != nil {
+			return fmt.Errorf("删除临时库 %s 失败: %v", name, err)
+		}
+		return nil
+	}
+	return scratch, drop, nil
+}
+
+// postgresSearchPath 为 PostgreSQL 连接字符串指定 search_path，支持 URL 和 key=value 两种格式
+func postgresSearchPath(source, schemaName string) string {
+	if strings.HasPrefix(source, "postgres://") || strings.HasPrefix(source, "postgresql://") {
+		separator := "?"
+		if strings.Contains(source, "?") {
+			separator = "&"
+		}
+		return source + separator + "search_path=" + schemaName
+	}
+	return source + " search_path=" + schemaName
+}
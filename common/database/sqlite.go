@@ -0,0 +1,37 @@
+package database
+
+import "strings"
+
+// sqlitePragmas 连接 SQLite 时默认设置的参数
+// busy_timeout：并发写入时等待锁而不是立即返回 SQLITE_BUSY
+// foreign_keys：启用外键约束，与 MySQL 行为一致
+var sqlitePragmas = []string{"busy_timeout(5000)", "foreign_keys(1)"}
+
+// sqliteDSN 为 SQLite 连接字符串补充默认参数
+// 连接字符串中已经指定 _pragma 时不做修改；数据库文件使用 WAL 模式，读写互不阻塞
+func sqliteDSN(source string) string {
+	if strings.Contains(source, "_pragma=") {
+		return source
+	}
+
+	pragmas := append([]string{}, sqlitePragmas...)
+	if !isSQLiteMemory(source) {
+		pragmas = append(pragmas, "journal_mode(WAL)")
+	}
+
+	params := make([]string, 0, len(pragmas))
+	for _, pragma := range pragmas {
+		params = append(params, "_pragma="+pragma)
+	}
+
+	separator := "?"
+	if strings.Contains(source, "?") {
+		separator = "&"
+	}
+	return source + separator + strings.Join(params, "&")
+}
+
+// isSQLiteMemory 连接字符串是否为内存数据库
+func isSQLiteMemory(source string) bool {
+	return strings.HasPrefix(source, ":memory:") || strings.Contains(source, "mode=memory")
+}
@@ -0,0 +1,121 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	webpenc "github.com/gen2brain/webp"
+	"golang.org/x/image/draw"
+)
+
+// 缩放方式
+const (
+	ModeFit  = "fit"  // 等比缩小到尺寸以内，不裁剪
+	ModeFill = "fill" // 等比缩放后居中裁剪为指定尺寸
+)
+
+// Derivative 衍生图规格
+type Derivative struct {
+	Width   int    // 宽度
+	Height  int    // 高度
+	Quality int    // 编码质量 1-100
+	Format  string // 输出格式：jpeg、webp
+	Mode    string // 缩放方式：fit、fill
+
+	Watermark *Watermark // 缩放后叠加的水印，为空不加
+}
+
+// Extension 输出文件扩展名
+func (d Derivative) Extension() string {
+	if d.Format == FormatWebP {
+		return ".webp"
+	}
+	return ".jpg"
+}
+
+// MimeType 输出MIME类型
+func (d Derivative) MimeType() string {
+	if d.Format == FormatWebP {
+		return mimeTypes[FormatWebP]
+	}
+	return mimeTypes[FormatJPEG]
+}
+
+// Generate 按规格生成衍生图，小于目标尺寸的图片不放大
+func Generate(src image.Image, d Derivative) ([]byte, error) {
+	img := resize(src, d.Width, d.Height, d.Mode)
+	if d.Watermark != nil {
+		var err error
+		if img, err = d.Watermark.Apply(img); err != nil {
+			return nil, fmt.Errorf("添加水印失败: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	switch d.Format {
+	case FormatWebP:
+		if err := webpenc.Encode(&buf, img, webpenc.Options{Quality: d.Quality}); err != nil {
+			return nil, fmt.Errorf("WebP编码失败: %v", err)
+		}
+	case FormatJPEG, "":
+		if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: d.Quality}); err != nil {
+			return nil, fmt.Errorf("JPEG编码失败: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的输出格式: %s", d.Format)
+	}
+	return buf.Bytes(), nil
+}
+
+// resize 缩放图片
+func resize(src image.Image, width, height int, mode string) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if width <= 0 || height <= 0 {
+		return src
+	}
+
+	crop := b
+	if mode == ModeFill {
+		// 按目标宽高比居中裁剪
+		if sw*height > sh*width {
+			cw := sh * width / height
+			crop = image.Rect(b.Min.X+(sw-cw)/2, b.Min.Y, b.Min.X+(sw-cw)/2+cw, b.Max.Y)
+		} else {
+			ch := sw * height / width
+			crop = image.Rect(b.Min.X, b.Min.Y+(sh-ch)/2, b.Max.X, b.Min.Y+(sh-ch)/2+ch)
+		}
+		sw, sh = crop.Dx(), crop.Dy()
+	}
+
+	// 等比缩小到目标尺寸以内
+	dw, dh := sw, sh
+	if dw > width {
+		dw, dh = width, sh*width/sw
+	}
+	if dh > height {
+		dw, dh = sw*height/sh, height
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
+
+// flatten 透明图片铺白底（JPEG 不支持透明）
+func flatten(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
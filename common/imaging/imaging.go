@@ -0,0 +1,223 @@
+// Package imaging 提供上传图片的服务端检查和处理
+// 按文件头识别真实格式、读取尺寸、拒绝解压炸弹和分辨率过低的图片，
+// 按 EXIF 方向自动旋转，并去除 GPS 等 EXIF/XMP 元数据
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/webp"
+)
+
+// 支持的图片格式
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+)
+
+// mimeTypes 格式对应的MIME类型
+var mimeTypes = map[string]string{
+	FormatJPEG: "image/jpeg",
+	FormatPNG:  "image/png",
+	FormatGIF:  "image/gif",
+	FormatWebP: "image/webp",
+}
+
+// DefaultMaxPixels 默认最大像素数（约 5000 万像素），防止解压炸弹
+const DefaultMaxPixels = 50_000_000
+
+// 旋转后重新编码的 JPEG 质量
+const rotateQuality = 92
+
+// Limits 图片限制
+type Limits struct {
+	MinWidth  int   // 最小宽度，0 为不限制
+	MinHeight int   // 最小高度，0 为不限制
+	MaxPixels int64 // 最大像素数（宽×高），0 使用 DefaultMaxPixels
+}
+
+// Result 处理结果
+type Result struct {
+	Data     []byte // 处理后的文件内容
+	Format   string // 真实格式
+	MimeType string // 真实MIME类型
+	Width    int    // 宽度（按 EXIF 方向旋转后）
+	Height   int    // 高度（按 EXIF 方向旋转后）
+	Rotated  bool   // 是否按 EXIF 方向旋转并重新编码
+}
+
+// DetectFormat 按文件头（magic bytes）识别图片格式
+func DetectFormat(data []byte) (string, error) {
+	switch {
+	case len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF:
+		return FormatJPEG, nil
+	case len(data) >= 8 && bytes.Equal(data[:8], pngSignature):
+		return FormatPNG, nil
+	case len(data) >= 6 && (string(data[:6]) == "GIF87a" || string(data[:6]) == "GIF89a"):
+		return FormatGIF, nil
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return FormatWebP, nil
+	}
+	return "", fmt.Errorf("不支持的图片格式")
+}
+
+// MimeType 格式对应的MIME类型
+func MimeType(format string) string {
+	return mimeTypes[format]
+}
+
+// Process 检查并处理上传的图片
+// 先只解析文件头中的尺寸，超过像素上限直接拒绝，不做完整解码；
+// 完整解码验证图片内容，JPEG 按 EXIF 方向旋转后重新编码，其他情况无损去除元数据
+func Process(data []byte, limits Limits) (*Result, error) {
+	format, err := DetectFormat(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := decodeConfig(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析图片: %v", err)
+	}
+	maxPixels := limits.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("无效的图片尺寸: %dx%d", cfg.Width, cfg.Height)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return nil, fmt.Errorf("图片像素过大: %dx%d，最多 %d 像素", cfg.Width, cfg.Height, maxPixels)
+	}
+
+	orientation := 1
+	if format == FormatJPEG {
+		orientation = jpegOrientation(data)
+	}
+	width, height := cfg.Width, cfg.Height
+	if orientation >= 5 {
+		width, height = height, width
+	}
+	if width < limits.MinWidth || height < limits.MinHeight {
+		return nil, fmt.Errorf("图片分辨率过低: %dx%d，至少需要 %dx%d", width, height, limits.MinWidth, limits.MinHeight)
+	}
+
+	img, err := decode(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("图片内容已损坏: %v", err)
+	}
+
+	result := &Result{
+		Format:   format,
+		MimeType: mimeTypes[format],
+		Width:    width,
+		Height:   height,
+	}
+
+	if orientation > 1 {
+		// 重新编码不会保留任何元数据
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, orient(img, orientation), &jpeg.Options{Quality: rotateQuality}); err != nil {
+			return nil, fmt.Errorf("图片旋转失败: %v", err)
+		}
+		result.Data = buf.Bytes()
+		result.Rotated = true
+		return result, nil
+	}
+
+	stripped, err := stripMetadata(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("去除图片元数据失败: %v", err)
+	}
+	result.Data = stripped
+	return result, nil
+}
+
+// decodeConfig 只读取文件头中的尺寸
+func decodeConfig(format string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case FormatJPEG:
+		return jpeg.DecodeConfig(r)
+	case FormatPNG:
+		return png.DecodeConfig(r)
+	case FormatGIF:
+		return gif.DecodeConfig(r)
+	default:
+		return webp.DecodeConfig(r)
+	}
+}
+
+// decode 完整解码图片（GIF 为第一帧）
+func decode(format string, data []byte) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case FormatJPEG:
+		return jpeg.Decode(r)
+	case FormatPNG:
+		return png.Decode(r)
+	case FormatGIF:
+		return gif.Decode(r)
+	default:
+		return webp.Decode(r)
+	}
+}
+
+// Decode 解码图片，供生成缩略图等使用
+func Decode(data []byte) (image.Image, error) {
+	format, err := DetectFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	return decode(format, data)
+}
+
+// orient 按 EXIF 方向（2-8）变换图片
+func orient(src image.Image, orientation int) image.Image {
+	b := src.Bounds()
+	in := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(in, in.Bounds(), src, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 水平翻转
+				sx, sy = w-1-x, y
+			case 3: // 旋转180度
+				sx, sy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				sx, sy = x, h-1-y
+			case 5: // 沿左上-右下对角线翻转
+				sx, sy = y, x
+			case 6: // 顺时针旋转90度
+				sx, sy = y, h-1-x
+			case 7: // 沿右上-左下对角线翻转
+				sx, sy = w-1-y, h-1-x
+			case 8: // 逆时针旋转90度
+				sx, sy = w-1-y, x
+			default:
+				sx, sy = x, y
+			}
+			si := sy*in.Stride + sx*4
+			di := y*out.Stride + x*4
+			copy(out.Pix[di:di+4], in.Pix[si:si+4])
+		}
+	}
+	return out
+}
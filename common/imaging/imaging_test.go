@@ -0,0 +1,116 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// TestOrient 按 EXIF 方向变换图片
+// 原图 3x2，像素依次为
+//
+//	a b c
+//	d e f
+func TestOrient(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i, label := range "abcdef" {
+		src.SetNRGBA(i%3, i/3, color.NRGBA{R: uint8(label), A: 255})
+	}
+
+	cases := []struct {
+		orientation int
+		want        []string // 变换后每行的像素
+	}{
+		{1, []string{"abc", "def"}},
+		{2, []string{"cba", "fed"}},     // 水平翻转
+		{3, []string{"fed", "cba"}},     // 旋转180度
+		{4, []string{"def", "abc"}},     // 垂直翻转
+		{5, []string{"ad", "be", "cf"}}, // 沿左上-右下对角线翻转
+		{6, []string{"da", "eb", "fc"}}, // 顺时针旋转90度
+		{7, []string{"fc", "eb", "da"}}, // 沿右上-左下对角线翻转
+		{8, []string{"cf", "be", "ad"}}, // 逆时针旋转90度
+	}
+	for _, tc := range cases {
+		out := orient(src, tc.orientation)
+		b := out.Bounds()
+		if b.Dx() != len(tc.want[0]) || b.Dy() != len(tc.want) {
+			t.Errorf("方向 %d: 尺寸 %dx%d，应为 %dx%d", tc.orientation, b.Dx(), b.Dy(), len(tc.want[0]), len(tc.want))
+			continue
+		}
+		for y, row := range tc.want {
+			got := make([]byte, b.Dx())
+			for x := range got {
+				r, _, _, _ := out.At(x, y).RGBA()
+				got[x] = byte(r >> 8)
+			}
+			if string(got) != row {
+				t.Errorf("方向 %d 第 %d 行: 得到 %q，应为 %q", tc.orientation, y, got, row)
+			}
+		}
+	}
+}
+
+// TestProcessOrientation 带 EXIF 方向的 JPEG：方向 5-8 交换宽高，2-8 旋转后重新编码，结果都不包含元数据
+func TestProcessOrientation(t *testing.T) {
+	for orientation := 1; orientation <= 8; orientation++ {
+		tiff := exifTIFF(binary.BigEndian, orientation)
+		data := testJPEG(t, 16, 8, tiff)
+
+		result, err := Process(data, Limits{})
+		if err != nil {
+			t.Fatalf("方向 %d: %v", orientation, err)
+		}
+		wantW, wantH := 16, 8
+		if orientation >= 5 {
+			wantW, wantH = 8, 16
+		}
+		if result.Width != wantW || result.Height != wantH {
+			t.Errorf("方向 %d: 尺寸 %dx%d，应为 %dx%d", orientation, result.Width, result.Height, wantW, wantH)
+		}
+		if result.Rotated != (orientation > 1) {
+			t.Errorf("方向 %d: Rotated 为 %v", orientation, result.Rotated)
+		}
+		if result.Format != FormatJPEG || result.MimeType != "image/jpeg" {
+			t.Errorf("方向 %d: 格式 %s %s", orientation, result.Format, result.MimeType)
+		}
+		assertNoMetadata(t, result.Data, tiff)
+
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(result.Data))
+		if err != nil {
+			t.Fatalf("方向 %d: 处理后无法解码: %v", orientation, err)
+		}
+		if cfg.Width != wantW || cfg.Height != wantH {
+			t.Errorf("方向 %d: 输出尺寸 %dx%d，应为 %dx%d", orientation, cfg.Width, cfg.Height, wantW, wantH)
+		}
+		if got := jpegOrientation(result.Data); got != 1 {
+			t.Errorf("方向 %d: 输出仍带方向 %d", orientation, got)
+		}
+	}
+}
+
+// TestProcessLimits 旋转后的尺寸参与最小分辨率检查，超过像素上限时拒绝
+func TestProcessLimits(t *testing.T) {
+	// 16x8 方向 6 旋转后为 8x16
+	data := testJPEG(t, 16, 8, exifTIFF(binary.LittleEndian, 6))
+	cases := []struct {
+		name   string
+		limits Limits
+		ok     bool
+	}{
+		{"不限制", Limits{}, true},
+		{"按旋转后的高度", Limits{MinWidth: 8, MinHeight: 16}, true},
+		{"按原始宽度", Limits{MinWidth: 16}, false},
+		{"像素上限", Limits{MaxPixels: 100}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Process(data, tc.limits)
+			if (err == nil) != tc.ok {
+				t.Errorf("错误为 %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+// stripMetadata 无损去除元数据，图片数据原样保留
+func stripMetadata(format string, data []byte) ([]byte, error) {
+	switch format {
+	case FormatJPEG:
+		return stripJPEG(data)
+	case FormatPNG:
+		return stripPNG(data)
+	case FormatWebP:
+		return stripWebP(data)
+	default:
+		// GIF 没有 EXIF
+		return data, nil
+	}
+}
+
+// JPEG 段标记
+const (
+	jpegSOS  = 0xDA // 扫描开始，之后为压缩数据
+	jpegAPP1 = 0xE1 // EXIF、XMP
+	jpegAPPD = 0xED // IPTC（Photoshop）
+	jpegCOM  = 0xFE // 注释
+)
+
+// jpegSegments 遍历 SOS 之前的 JPEG 段，fn 返回 false 时停止
+// start、end 为段（含标记）在 data 中的位置
+func jpegSegments(data []byte, fn func(marker byte, start, end int) bool) (int, error) {
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 0, fmt.Errorf("无效的JPEG段")
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// 填充字节
+			pos++
+			continue
+		}
+		if marker == jpegSOS {
+			return pos, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return 0, fmt.Errorf("无效的JPEG段长度")
+		}
+		if !fn(marker, pos, end) {
+			return pos, nil
+		}
+		pos = end
+	}
+	return 0, fmt.Errorf("JPEG数据不完整")
+}
+
+// stripJPEG 去除 EXIF、XMP、IPTC 和注释段，保留 ICC 色彩配置等其他段
+func stripJPEG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	sos, err := jpegSegments(data, func(marker byte, start, end int) bool {
+		switch marker {
+		case jpegAPP1, jpegAPPD, jpegCOM:
+		default:
+			out = append(out, data[start:end]...)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, data[sos:]...), nil
+}
+
+// jpegOrientation 读取 EXIF 方向，没有或无法解析时返回 1
+func jpegOrientation(data []byte) int {
+	orientation := 1
+	jpegSegments(data, func(marker byte, start, end int) bool {
+		payload := data[start+4 : end]
+		if marker != jpegAPP1 || !bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			return true
+		}
+		if o := exifOrientation(payload[6:]); o >= 1 && o <= 8 {
+			orientation = o
+		}
+		return false
+	})
+	return orientation
+}
+
+// exifOrientation 从 TIFF 结构的 IFD0 中读取方向标签（0x0112）
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+	return 0
+}
+
+// pngMetadataChunks 需要去除的 PNG 元数据块
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNG 去除 EXIF 和文本块
+func stripPNG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, fmt.Errorf("无效的PNG数据块")
+		}
+		if !pngMetadataChunks[string(data[pos+4:pos+8])] {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return out, nil
+}
+
+// WebP VP8X 标志位
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+// stripWebP 去除 EXIF、XMP 块并更新 VP8X 标志和 RIFF 大小
+func stripWebP(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+	pos := 12
+	for pos+8 <= len(data) {
+		fourcc := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + size + size%2
+		if end > len(data) {
+			if pos+8+size != len(data) {
+				return nil, fmt.Errorf("无效的WebP数据块")
+			}
+			end = len(data)
+		}
+		switch fourcc {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, data[pos:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
@@ -0,0 +1,411 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// 测试用的元数据内容，去除后输出中不应再出现
+// xmpPayload 长度为奇数，WebP 块需要填充字节
+var (
+	xmpPayload  = []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>GPS 39.9042N 116.40745E</x:xmpmeta>")
+	iptcPayload = []byte("Photoshop 3.0\x008BIM\x04\x04\x00\x00\x00\x00\x00\x00")
+	comment     = []byte("shot at home")
+	iccPayload  = []byte("ICC_PROFILE\x00\x01\x01color profile")
+)
+
+// vp8lChunk 1x1 无损 WebP 图像数据块（VP8L，13 字节数据加 1 字节填充）
+var vp8lChunk = []byte{
+	'V', 'P', '8', 'L', 0x0D, 0x00, 0x00, 0x00,
+	0x2F, 0x00, 0x00, 0x00, 0x10, 0x07, 0x10, 0x11, 0x11, 0x88, 0x88, 0xFE, 0x07, 0x00,
+}
+
+// exifTIFF 构造 TIFF 结构的 EXIF 数据：IFD0 包含方向标签（orientation 为 0 时不写）和 GPS IFD 指针，
+// GPS IFD 包含纬度
+func exifTIFF(order binary.ByteOrder, orientation int) []byte {
+	type entry struct {
+		tag, typ uint16
+		count    uint32
+		value    []byte // 4 字节以内的值
+	}
+	u16 := func(v uint16) []byte { b := make([]byte, 4); order.PutUint16(b, v); return b }
+	u32 := func(v uint32) []byte { b := make([]byte, 4); order.PutUint32(b, v); return b }
+	writeIFD := func(buf *bytes.Buffer, entries []entry) {
+		binary.Write(buf, order, uint16(len(entries)))
+		for _, e := range entries {
+			binary.Write(buf, order, e.tag)
+			binary.Write(buf, order, e.typ)
+			binary.Write(buf, order, e.count)
+			buf.Write(e.value)
+		}
+		binary.Write(buf, order, uint32(0))
+	}
+
+	var ifd0 []entry
+	if orientation > 0 {
+		ifd0 = append(ifd0, entry{0x0112, 3, 1, u16(uint16(orientation))})
+	}
+	// GPS IFD 紧跟在 IFD0 之后
+	gpsOffset := 8 + 2 + (len(ifd0)+1)*12 + 4
+	ifd0 = append(ifd0, entry{0x8825, 4, 1, u32(uint32(gpsOffset))})
+	// 纬度的 3 个分数紧跟在 GPS IFD 之后
+	latOffset := gpsOffset + 2 + 2*12 + 4
+	gps := []entry{
+		{0x0001, 2, 2, []byte{'N', 0, 0, 0}},
+		{0x0002, 5, 3, u32(uint32(latOffset))},
+	}
+
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II")
+	} else {
+		buf.WriteString("MM")
+	}
+	binary.Write(&buf, order, uint16(42))
+	binary.Write(&buf, order, uint32(8))
+	writeIFD(&buf, ifd0)
+	writeIFD(&buf, gps)
+	for _, v := range []uint32{39, 1, 54, 1, 2712, 100} {
+		binary.Write(&buf, order, v)
+	}
+	return buf.Bytes()
+}
+
+// jpegSegment 构造 JPEG 段
+func jpegSegment(marker byte, payload []byte) []byte {
+	seg := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// testImage 每个像素颜色不同的测试图片
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 40), G: uint8(y * 40), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// testJPEG 带 EXIF（含 GPS）、XMP、IPTC、注释和 ICC 段的 JPEG
+func testJPEG(t *testing.T, w, h int, tiff []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(w, h), nil); err != nil {
+		t.Fatalf("生成JPEG失败: %v", err)
+	}
+	encoded := buf.Bytes()
+
+	out := append([]byte{}, encoded[:2]...)
+	out = append(out, jpegSegment(jpegAPP1, append([]byte("Exif\x00\x00"), tiff...))...)
+	out = append(out, jpegSegment(jpegAPP1, xmpPayload)...)
+	out = append(out, jpegSegment(0xE2, iccPayload)...)
+	out = append(out, jpegSegment(jpegAPPD, iptcPayload)...)
+	out = append(out, jpegSegment(jpegCOM, comment)...)
+	return append(out, encoded[2:]...)
+}
+
+// pngChunk 构造 PNG 数据块
+func pngChunk(typ string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], typ)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// testPNG 在 IHDR 之后插入 eXIf、tEXt、zTXt、iTXt、tIME 块的 PNG
+func testPNG(t *testing.T, tiff []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(4, 3)); err != nil {
+		t.Fatalf("生成PNG失败: %v", err)
+	}
+	encoded := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + 13
+
+	out := append([]byte{}, encoded[:ihdrEnd]...)
+	out = append(out, pngChunk("eXIf", tiff)...)
+	out = append(out, pngChunk("tEXt", append([]byte("Comment\x00"), comment...))...)
+	out = append(out, pngChunk("zTXt", []byte("Author\x00\x00\x78\x9c"))...)
+	out = append(out, pngChunk("iTXt", append([]byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"), xmpPayload...))...)
+	out = append(out, pngChunk("tIME", []byte{0x07, 0xEA, 10, 19, 8, 0, 0})...)
+	return append(out, encoded[ihdrEnd:]...)
+}
+
+// webpChunk 构造 WebP 数据块，奇数长度补一个填充字节
+func webpChunk(fourcc string, data []byte) []byte {
+	chunk := make([]byte, 8, 9+len(data))
+	copy(chunk, fourcc)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(data)))
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// testWebP VP8X 扩展格式的 1x1 WebP，带 EXIF 和 XMP 块
+func testWebP(tiff []byte) []byte {
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagEXIF | webpFlagXMP
+	// 画布宽高减 1，各 24 位，1x1 时为 0
+
+	body := []byte("WEBP")
+	body = append(body, webpChunk("VP8X", vp8x)...)
+	body = append(body, vp8lChunk...)
+	body = append(body, webpChunk("EXIF", tiff)...)
+	body = append(body, webpChunk("XMP ", xmpPayload)...)
+
+	out := []byte("RIFF\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(body)))
+	return append(out, body...)
+}
+
+// assertNoMetadata 输出中不应包含任何元数据内容
+func assertNoMetadata(t *testing.T, out, tiff []byte) {
+	t.Helper()
+	for name, payload := range map[string][]byte{
+		"EXIF": tiff,
+		"XMP":  xmpPayload,
+		"IPTC": iptcPayload,
+		"注释":   comment,
+	} {
+		if bytes.Contains(out, payload) {
+			t.Errorf("%s 未去除", name)
+		}
+	}
+	if bytes.Contains(out, []byte("Exif\x00\x00")) {
+		t.Error("EXIF 标识未去除")
+	}
+}
+
+// TestStripJPEG 去除 JPEG 的 EXIF（含 GPS）、XMP、IPTC 和注释段，保留 ICC 和图像数据
+func TestStripJPEG(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			tiff := exifTIFF(order, 1)
+			data := testJPEG(t, 4, 3, tiff)
+
+			out, err := stripJPEG(data)
+			if err != nil {
+				t.Fatalf("去除元数据失败: %v", err)
+			}
+			assertNoMetadata(t, out, tiff)
+			if !bytes.Contains(out, iccPayload) {
+				t.Error("ICC 段不应去除")
+			}
+			img, err := jpeg.Decode(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("去除元数据后无法解码: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 3 {
+				t.Errorf("尺寸 %dx%d，应为 4x3", b.Dx(), b.Dy())
+			}
+		})
+	}
+}
+
+// TestStripJPEGMalformed 段结构错误时返回错误
+func TestStripJPEGMalformed(t *testing.T) {
+	soi := []byte{0xFF, 0xD8}
+	sos := []byte{0xFF, jpegSOS, 0x00, 0x02}
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"只有文件头", soi},
+		{"没有SOS", append(append([]byte{}, soi...), jpegSegment(jpegCOM, comment)...)},
+		{"段标记错误", append(append([]byte{}, soi...), 0x00, jpegCOM, 0x00, 0x04, 'a', 'b')},
+		{"段长度小于2", append(append([]byte{}, soi...), append([]byte{0xFF, jpegAPP1, 0x00, 0x01}, sos...)...)},
+		{"段长度超出数据", append(append([]byte{}, soi...), 0xFF, jpegAPP1, 0xFF, 0xFF, 'E', 'x')},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := stripJPEG(tc.data); err == nil {
+				t.Error("应返回错误")
+			}
+			if o := jpegOrientation(tc.data); o != 1 {
+				t.Errorf("方向 %d，应为 1", o)
+			}
+		})
+	}
+
+	// 段之间的填充字节
+	padded := append(append([]byte{}, soi...), 0xFF, 0xFF)
+	padded = append(padded, jpegSegment(jpegCOM, comment)...)
+	padded = append(padded, sos...)
+	out, err := stripJPEG(padded)
+	if err != nil {
+		t.Fatalf("填充字节: %v", err)
+	}
+	if bytes.Contains(out, comment) {
+		t.Error("填充字节之后的注释段未去除")
+	}
+}
+
+// TestJPEGOrientation 读取 JPEG 的 EXIF 方向
+func TestJPEGOrientation(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for orientation := 0; orientation <= 9; orientation++ {
+			want := orientation
+			if orientation < 1 || orientation > 8 {
+				// 没有方向标签或取值无效时按 1 处理
+				want = 1
+			}
+			data := testJPEG(t, 4, 3, exifTIFF(order, orientation))
+			if got := jpegOrientation(data); got != want {
+				t.Errorf("%s 方向 %d: 得到 %d，应为 %d", order, orientation, got, want)
+			}
+		}
+	}
+}
+
+// TestExifOrientation 解析 TIFF 结构中的方向标签，数据不完整时不越界
+func TestExifOrientation(t *testing.T) {
+	valid := exifTIFF(binary.BigEndian, 6)
+	cases := []struct {
+		name string
+		tiff []byte
+		want int
+	}{
+		{"小端", exifTIFF(binary.LittleEndian, 3), 3},
+		{"大端", valid, 6},
+		{"没有方向标签", exifTIFF(binary.LittleEndian, 0), 0},
+		{"空数据", nil, 0},
+		{"字节序错误", append([]byte("XX"), valid[2:]...), 0},
+		{"IFD偏移超出数据", append(append([]byte{}, valid[:4]...), 0x7F, 0xFF, 0xFF, 0xFF), 0},
+		{"IFD条目数超出数据", append(append([]byte{}, valid[:8]...), 0xFF, 0xFF), 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exifOrientation(tc.tiff); got != tc.want {
+				t.Errorf("得到 %d，应为 %d", got, tc.want)
+			}
+		})
+	}
+
+	// 截断到任意长度都不能越界
+	for n := 0; n <= len(valid); n++ {
+		exifOrientation(valid[:n])
+	}
+}
+
+// TestStripPNG 去除 PNG 的 eXIf 和文本块，保留图像数据
+func TestStripPNG(t *testing.T) {
+	tiff := exifTIFF(binary.BigEndian, 1)
+	data := testPNG(t, tiff)
+
+	out, err := stripPNG(data)
+	if err != nil {
+		t.Fatalf("去除元数据失败: %v", err)
+	}
+	assertNoMetadata(t, out, tiff)
+	for _, typ := range []string{"eXIf", "tEXt", "zTXt", "iTXt", "tIME"} {
+		if bytes.Contains(out, []byte(typ)) {
+			t.Errorf("%s 块未去除", typ)
+		}
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("去除元数据后无法解码: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 3 {
+		t.Errorf("尺寸 %dx%d，应为 4x3", b.Dx(), b.Dy())
+	}
+}
+
+// TestStripPNGMalformed 数据块长度超出数据时返回错误
+func TestStripPNGMalformed(t *testing.T) {
+	data := append([]byte{}, pngSignature...)
+	data = append(data, 0xFF, 0xFF, 0xFF, 0xF0, 't', 'E', 'X', 't', 0, 0, 0, 0)
+	if _, err := stripPNG(data); err == nil {
+		t.Error("应返回错误")
+	}
+}
+
+// TestStripWebP 去除 WebP 的 EXIF、XMP 块，清除 VP8X 标志并更新 RIFF 大小
+func TestStripWebP(t *testing.T) {
+	tiff := exifTIFF(binary.LittleEndian, 1)
+	data := testWebP(tiff)
+	if _, err := webp.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("测试图片无法解码: %v", err)
+	}
+
+	out, err := stripWebP(data)
+	if err != nil {
+		t.Fatalf("去除元数据失败: %v", err)
+	}
+	assertNoMetadata(t, out, tiff)
+	if bytes.Contains(out, []byte("EXIF")) || bytes.Contains(out, []byte("XMP ")) {
+		t.Error("EXIF、XMP 块未去除")
+	}
+	if flags := out[20]; flags&(webpFlagEXIF|webpFlagXMP) != 0 {
+		t.Errorf("VP8X 标志 %#x 未清除", flags)
+	}
+	if size := binary.LittleEndian.Uint32(out[4:8]); int(size) != len(out)-8 {
+		t.Errorf("RIFF 大小 %d，应为 %d", size, len(out)-8)
+	}
+	if _, err := webp.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("去除元数据后无法解码: %v", err)
+	}
+}
+
+// TestStripWebPMalformed 数据块长度超出数据时返回错误，最后一个块缺少填充字节时接受
+func TestStripWebPMalformed(t *testing.T) {
+	data := testWebP(exifTIFF(binary.LittleEndian, 1))
+	broken := append([]byte{}, data...)
+	// VP8X 块长度改为超出数据
+	binary.LittleEndian.PutUint32(broken[16:20], 0xFFFFFF)
+	if _, err := stripWebP(broken); err == nil {
+		t.Error("应返回错误")
+	}
+
+	// XMP 为最后一个块且长度为奇数，去掉填充字节
+	if len(xmpPayload)%2 == 0 {
+		t.Fatal("xmpPayload 长度应为奇数")
+	}
+	unpadded := data[:len(data)-1]
+	out, err := stripWebP(unpadded)
+	if err != nil {
+		t.Fatalf("最后一个块缺少填充字节: %v", err)
+	}
+	if bytes.Contains(out, xmpPayload) {
+		t.Error("XMP 未去除")
+	}
+}
+
+// TestTruncated 任意截断的图片都不能导致越界，只能返回错误
+func TestTruncated(t *testing.T) {
+	tiff := exifTIFF(binary.BigEndian, 6)
+	samples := map[string][]byte{
+		FormatJPEG: testJPEG(t, 4, 3, tiff),
+		FormatPNG:  testPNG(t, tiff),
+		FormatWebP: testWebP(tiff),
+	}
+	for format, data := range samples {
+		t.Run(format, func(t *testing.T) {
+			for n := 0; n <= len(data); n++ {
+				part := data[:n]
+				// 与 Process 相同，只处理能识别格式的数据
+				if detected, err := DetectFormat(part); err == nil {
+					stripMetadata(detected, part)
+					if detected == FormatJPEG {
+						jpegOrientation(part)
+					}
+				}
+				Process(part, Limits{})
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// 水印位置
+const (
+	PositionTopLeft     = "top_left"
+	PositionTopRight    = "top_right"
+	PositionBottomLeft  = "bottom_left"
+	PositionBottomRight = "bottom_right"
+	PositionCenter      = "center"
+	PositionTile        = "tile" // 平铺整张图片
+)
+
+// Watermark 水印，Logo 不为空时使用 Logo，否则使用文字
+type Watermark struct {
+	Text     string         // 文字内容
+	Font     *opentype.Font // 文字字体，为空使用内置字体（仅支持拉丁字符）
+	Color    color.Color    // 文字颜色
+	Logo     image.Image    // Logo 图片
+	Position string         // 位置
+	Opacity  float64        // 不透明度 0-1
+	Scale    float64        // 水印宽度占图片宽度的比例
+	Margin   float64        // 边距占图片宽度的比例
+}
+
+var (
+	defaultFont     *opentype.Font
+	defaultFontErr  error
+	defaultFontOnce sync.Once
+)
+
+// DefaultFont 内置水印字体（Go Bold）
+func DefaultFont() (*opentype.Font, error) {
+	defaultFontOnce.Do(func() {
+		defaultFont, defaultFontErr = opentype.Parse(gobold.TTF)
+	})
+	return defaultFont, defaultFontErr
+}
+
+// ParseFont 解析 TrueType/OpenType 字体文件内容
+func ParseFont(data []byte) (*opentype.Font, error) {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析字体失败: %v", err)
+	}
+	return f, nil
+}
+
+// Apply 在图片上叠加水印，返回新图片
+func (w *Watermark) Apply(src image.Image) (image.Image, error) {
+	b := src.Bounds()
+	width := int(math.Round(float64(b.Dx()) * w.Scale))
+	if width < 1 {
+		return src, nil
+	}
+	mark, err := w.render(width)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+
+	opacity := w.Opacity
+	if opacity <= 0 || opacity > 1 {
+		opacity = 1
+	}
+	mask := image.NewUniform(color.Alpha{A: uint8(math.Round(opacity * 255))})
+	margin := int(math.Round(float64(b.Dx()) * w.Margin))
+	for _, pt := range placements(dst.Bounds().Size(), mark.Bounds().Size(), w.Position, margin) {
+		r := image.Rectangle{Min: pt, Max: pt.Add(mark.Bounds().Size())}
+		draw.DrawMask(dst, r, mark, mark.Bounds().Min, mask, image.Point{}, draw.Over)
+	}
+	return dst, nil
+}
+
+// render 按目标宽度生成水印图层
+func (w *Watermark) render(width int) (image.Image, error) {
+	if w.Logo != nil {
+		lb := w.Logo.Bounds()
+		height := lb.Dy() * width / lb.Dx()
+		if height < 1 {
+			height = 1
+		}
+		dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), w.Logo, lb, draw.Src, nil)
+		return dst, nil
+	}
+
+	if w.Text == "" {
+		return nil, fmt.Errorf("水印文字为空")
+	}
+	f := w.Font
+	if f == nil {
+		var err error
+		if f, err = DefaultFont(); err != nil {
+			return nil, err
+		}
+	}
+
+	// 按 100 号字测量文字宽度，换算出铺满目标宽度的字号
+	const probeSize = 100
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: probeSize, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, fmt.Errorf("加载字体失败: %v", err)
+	}
+	advance := font.MeasureString(face, w.Text).Ceil()
+	face.Close()
+	if advance <= 0 {
+		return nil, fmt.Errorf("水印文字无法显示")
+	}
+	face, err = opentype.NewFace(f, &opentype.FaceOptions{Size: probeSize * float64(width) / float64(advance), DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, fmt.Errorf("加载字体失败: %v", err)
+	}
+	defer face.Close()
+
+	metrics := face.Metrics()
+	textWidth := font.MeasureString(face, w.Text).Ceil()
+	height := (metrics.Ascent + metrics.Descent).Ceil()
+	if textWidth < 1 || height < 1 {
+		return image.NewNRGBA(image.Rect(0, 0, 1, 1)), nil
+	}
+
+	textColor := w.Color
+	if textColor == nil {
+		textColor = color.White
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, textWidth, height))
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(textColor),
+		Face: face,
+		Dot:  fixed.Point26_6{X: 0, Y: metrics.Ascent},
+	}
+	drawer.DrawString(w.Text)
+	return dst, nil
+}
+
+// placements 水印左上角位置，平铺时返回多个
+func placements(canvas, mark image.Point, position string, margin int) []image.Point {
+	switch position {
+	case PositionTopLeft:
+		return []image.Point{{X: margin, Y: margin}}
+	case PositionTopRight:
+		return []image.Point{{X: canvas.X - mark.X - margin, Y: margin}}
+	case PositionBottomLeft:
+		return []image.Point{{X: margin, Y: canvas.Y - mark.Y - margin}}
+	case PositionCenter:
+		return []image.Point{{X: (canvas.X - mark.X) / 2, Y: (canvas.Y - mark.Y) / 2}}
+	case PositionTile:
+		// 水印之间留出一个水印大小的间隔，奇数行错开半个间隔
+		stepX, stepY := mark.X*2, mark.Y*3
+		if stepX < 1 || stepY < 1 {
+			return nil
+		}
+		var points []image.Point
+		for row, y := 0, margin; y < canvas.Y; row, y = row+1, y+stepY {
+			offset := 0
+			if row%2 == 1 {
+				offset = mark.X
+			}
+			for x := margin - offset; x < canvas.X; x += stepX {
+				points = append(points, image.Point{X: x, Y: y})
+			}
+		}
+		return points
+	default:
+		return []image.Point{{X: canvas.X - mark.X - margin, Y: canvas.Y - mark.Y - margin}}
+	}
+}
@@ -39,6 +39,9 @@ func InitQiniu(env string) error {
 	// 输出配置信息（脱敏）
 	qiniuConfig := config.GetQiniuConfig()
 	if qiniuConfig != nil {
+		for _, secret := range qiniuConfig.Secrets {
+			log.Printf("%s 来源: %s", secret.Path, secret.Source)
+		}
 		log.Printf("存储空间: %s", qiniuConfig.Bucket)
 		log.Printf("访问域名: %s", qiniuConfig.Domain)
 		log.Printf("存储区域: %s", qiniuConfig.Zone)
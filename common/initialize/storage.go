@@ -0,0 +1,93 @@
+package initialize
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/storage"
+	"rentPro/rentpro-admin/common/utils"
+)
+
+// InitStorage 按配置初始化文件存储驱动
+// driver 为 qiniu 时初始化七牛云，失败时只有 dev 模式回退到本地磁盘存储，其他模式返回错误；
+// driver 为 s3 时按 config/s3.yml 连接S3兼容存储，失败直接返回错误；driver 为 local 时直接使用本地磁盘
+func InitStorage(cfg config.StorageConfig, env string) error {
+	if cfg.Driver == "" {
+		cfg.Driver = storage.DriverQiniu
+	}
+
+	switch cfg.Driver {
+	case storage.DriverQiniu:
+		if err := InitQiniu(env); err != nil {
+			// 非开发环境不回退，避免文件悄悄写到本机磁盘
+			if env != "dev" {
+				return fmt.Errorf("七牛云服务初始化失败: %v", err)
+			}
+			log.Printf("⚠️  七牛云服务初始化失败: %v", err)
+			log.Println("dev 模式，将使用本地文件存储")
+			cfg.Driver = storage.DriverLocal
+			break
+		}
+		// 未单独配置上传限制时沿用 qiniu.yml 的配置
+		if qiniuConfig := config.GetQiniuConfig(); qiniuConfig != nil {
+			if cfg.Upload.MaxFileSize <= 0 {
+				cfg.Upload.MaxFileSize = qiniuConfig.Upload.MaxFileSize
+			}
+			if len(cfg.Upload.AllowedTypes) == 0 {
+				cfg.Upload.AllowedTypes = qiniuConfig.Upload.AllowedTypes
+			}
+			if cfg.Upload.UploadDir == "" {
+				cfg.Upload.UploadDir = qiniuConfig.Upload.UploadDir
+			}
+		}
+		storage.SetDefault(utils.GetQiniuService())
+	case storage.DriverS3:
+		if err := initS3(env); err != nil {
+			return err
+		}
+	case storage.DriverLocal:
+	default:
+		return fmt.Errorf("不支持的存储驱动: %s", cfg.Driver)
+	}
+
+	cfg.SetDefaults()
+	config.StorageConfigInstance = &cfg
+
+	if cfg.Driver == storage.DriverLocal {
+		local, err := storage.NewLocal(cfg.Local.Root, cfg.Local.BaseURL, cfg.Local.SignSecret, cfg.Local.Private)
+		if err != nil {
+			return fmt.Errorf("初始化本地存储失败: %v", err)
+		}
+		storage.SetDefault(local)
+		log.Printf("✅ 本地文件存储初始化成功，目录: %s，访问地址: %s", cfg.Local.Root, cfg.Local.BaseURL)
+	}
+
+	return nil
+}
+
+// initS3 初始化S3兼容存储并设置为全局存储
+func initS3(env string) error {
+	log.Println("开始初始化S3存储...")
+
+	s3Config, err := config.LoadS3Config(filepath.Join("config", "s3.yml"), env)
+	if err != nil {
+		return fmt.Errorf("初始化S3配置失败: %v", err)
+	}
+
+	s3, err := storage.NewS3(s3Config)
+	if err != nil {
+		return err
+	}
+	if err := s3.CheckBucket(); err != nil {
+		return fmt.Errorf("初始化S3存储失败: %v", err)
+	}
+
+	storage.SetDefault(s3)
+	log.Println("✅ S3存储初始化成功！")
+	log.Printf("服务地址: %s", s3Config.Endpoint)
+	log.Printf("存储桶: %s", s3Config.Bucket)
+	log.Printf("路径风格访问: %v", s3Config.PathStyle)
+	return nil
+}
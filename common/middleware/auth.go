@@ -0,0 +1,162 @@
+// Package middleware 提供 API 服务使用的 Gin 中间件
+// 包括 JWT 认证、菜单权限校验以及数据权限范围计算
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/models/system"
+	"rentPro/rentpro-admin/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ContextUserID 上下文中保存用户ID的键（uint64，与图片路由保持一致）
+	ContextUserID = "user_id"
+	// ContextUsername 上下文中保存用户名的键
+	ContextUsername = "username"
+	// contextCurrentUser 上下文中缓存当前用户（含角色和菜单）的键
+	contextCurrentUser = "current_user"
+)
+
+// jwtConfig 签发和校验 token 使用的配置，api 启动时由 SetJWTConfig 设置为 settings.jwt
+// 没有默认密钥：未设置时签发和校验 token 都会失败
+var jwtConfig utils.JWTConfig
+
+// SetJWTConfig 设置签发和校验 token 使用的密钥和过期时间（秒）
+func SetJWTConfig(secret string, timeout int) {
+	jwtConfig = utils.JWTConfig{Secret: secret, Timeout: int64(timeout)}
+}
+
+// NewJWT 使用当前配置创建 JWT 实例，登录、刷新等接口与认证中间件使用同一配置
+func NewJWT() *utils.JWT {
+	return utils.NewJWT(jwtConfig)
+}
+
+// JWTAuth JWT 认证中间件
+// 校验 Authorization: Bearer <token>，并将用户ID和用户名写入上下文
+func JWTAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "未提供认证信息",
+			})
+			return
+		}
+
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if tokenString == authHeader {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "认证格式错误",
+			})
+			return
+		}
+
+		claims, err := NewJWT().ParseToken(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "token无效",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		if claims.ExpiresAt != nil && time.Now().Unix() > claims.ExpiresAt.Unix() {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "token已过期",
+			})
+			return
+		}
+
+		c.Set(ContextUserID, uint64(claims.UserID))
+		c.Set(ContextUsername, claims.Username)
+		c.Next()
+	}
+}
+
+// OptionalJWTAuth 可选的 JWT 认证中间件
+// 携带有效 token 时与 JWTAuth 一样将用户ID和用户名写入上下文；未携带或 token 无效时按未登录处理，不中断请求
+// 用于对外展示与管理端共用的查询接口，由接口根据是否登录决定可见范围
+func OptionalJWTAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if tokenString != "" && tokenString != authHeader {
+			claims, err := NewJWT().ParseToken(tokenString)
+			if err == nil && (claims.ExpiresAt == nil || time.Now().Unix() <= claims.ExpiresAt.Unix()) {
+				c.Set(ContextUserID, uint64(claims.UserID))
+				c.Set(ContextUsername, claims.Username)
+			}
+		}
+		c.Next()
+	}
+}
+
+// GetCurrentUser 获取当前登录用户（预加载角色及角色菜单）
+// 结果会缓存在请求上下文中，同一请求内多次调用只查询一次数据库
+func GetCurrentUser(c *gin.Context) (*system.SysUser, error) {
+	if cached, ok := c.Get(contextCurrentUser); ok {
+		return cached.(*system.SysUser), nil
+	}
+
+	userID, _ := c.Get(ContextUserID)
+	var user system.SysUser
+	err := database.Request(c).Preload("Role").Preload("Role.Menus").
+		Where("id = ?", userID).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	c.Set(contextCurrentUser, &user)
+	return &user, nil
+}
+
+// IsAdminUser 判断用户是否为管理员（用户标记或管理员角色）
+func IsAdminUser(user *system.SysUser) bool {
+	if user.IsAdmin {
+		return true
+	}
+	return user.Role != nil && user.Role.IsAdmin()
+}
+
+// HasPermission 判断当前用户是否拥有指定的权限标识
+func HasPermission(c *gin.Context, permission string) bool {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return false
+	}
+	if IsAdminUser(user) {
+		return true
+	}
+	if user.Role == nil || !user.Role.IsActive() {
+		return false
+	}
+	return user.Role.HasPermission(permission)
+}
+
+// RequirePermission 权限校验中间件，需在 JWTAuth 之后使用
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "没有操作权限",
+				"data": gin.H{
+					"permission": permission,
+				},
+			})
+			return
+		}
+		c.Next()
+	}
+}
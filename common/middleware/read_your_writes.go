@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"rentPro/rentpro-admin/common/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadPrimaryHeader 请求头带有该字段时，本次请求的查询使用主库
+const ReadPrimaryHeader = "X-Read-Primary"
+
+// readPrimaryCookie 写入后读主库的截止时间（Unix 秒）
+const readPrimaryCookie = "rentpro_read_primary"
+
+// ReadYourWrites 启用只读副本时保证写入后能读到自己的数据：
+// 写请求及其后 StickyWindow 内同一客户端的请求都读主库（StickyWindow 为 0 时只有写请求本身），
+// 客户端也可以通过 X-Read-Primary 请求头单次指定读主库。
+// 查询需要使用 database.Request(c) 才会生效
+func ReadYourWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !database.HasReplicas() {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			c.Set(database.ContextPrimary, true)
+			if window := database.StickyWindow(); window > 0 {
+				until := time.Now().Add(window).Unix()
+				c.SetCookie(readPrimaryCookie, strconv.FormatInt(until, 10), int(window/time.Second), "/", "", false, true)
+			}
+		default:
+			if c.GetHeader(ReadPrimaryHeader) != "" || stickyToPrimary(c) {
+				c.Set(database.ContextPrimary, true)
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// stickyToPrimary 客户端最近一次写入是否仍在读主库的时间内
+func stickyToPrimary(c *gin.Context) bool {
+	value, err := c.Cookie(readPrimaryCookie)
+	if err != nil {
+		return false
+	}
+	until, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Now().Unix() < until
+}
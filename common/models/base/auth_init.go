@@ -1,8 +1,8 @@
 package base
 
 import (
-	"fmt"
 	"rentPro/rentpro-admin/common/models/system"
+	"rentPro/rentpro-admin/common/seed"
 
 	"gorm.io/gorm"
 )
@@ -28,37 +28,23 @@ func InitAuthTables(db *gorm.DB) error {
 	return nil
 }
 
-// InitDefaultData 初始化默认数据（从SQL文件读取）
-func InitDefaultData(db *gorm.DB) error {
-	// 创建SQL文件加载器
-	sqlLoader := NewSQLFileLoader("config/sql/data")
-
-	// 按照依赖关系顺序加载数据文件
-	dataFiles := []string{
-		"sys_dept.sql",      // 部门数据
-		"sys_post.sql",      // 岗位数据
-		"sys_role.sql",      // 角色数据
-		"sys_menu.sql",      // 菜单数据
-		"sys_user.sql",      // 用户数据
-		"sys_role_menu.sql", // 角色菜单关联数据
-	}
-
-	// 依次加载并执行SQL文件
-	for _, filename := range dataFiles {
-		if err := sqlLoader.LoadAndExecuteSQL(db, filename); err != nil {
-			return fmt.Errorf("加载数据文件 %s 失败: %v", filename, err)
-		}
+// InitDefaultData 初始化默认数据（从数据集 config/seed 读取）
+// env 为环境数据集（dev、test、prod），为空时只写入 base 基础数据
+func InitDefaultData(db *gorm.DB, env string) error {
+	ds, _, err := seed.Load("config/seed", env)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	_, err = seed.Run(db, ds, seed.Options{})
+	return err
 }
 
 // ================================================================
-// 以下函数已弃用，改为从SQL文件读取数据，保留作为备份参考
+// 以下函数已弃用，改为从数据集 config/seed 读取数据，保留作为备份参考
 // ================================================================
 
-// initDefaultDepts 初始化默认部门（已弃用，改为从SQL文件读取）
-// Deprecated: 请使用SQL文件 config/sql/data/sys_dept.sql 替代
+// initDefaultDepts 初始化默认部门（已弃用，改为从数据集读取）
+// Deprecated: 请使用数据集 config/seed/base/10-system.yml 替代
 func initDefaultDepts(db *gorm.DB) error {
 	depts := []system.SysDept{
 		{
@@ -109,8 +95,8 @@ func initDefaultDepts(db *gorm.DB) error {
 	return nil
 }
 
-// initDefaultPosts 初始化默认岗位（已弃用，改为从SQL文件读取）
-// Deprecated: 请使用SQL文件 config/sql/data/sys_post.sql 替代
+// initDefaultPosts 初始化默认岗位（已弃用，改为从数据集读取）
+// Deprecated: 请使用数据集 config/seed/base/10-system.yml 替代
 func initDefaultPosts(db *gorm.DB) error {
 	posts := []system.SysPost{
 		{
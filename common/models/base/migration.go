@@ -11,6 +11,8 @@ type Migration struct {
 	Version   string    `gorm:"size:191;not null;unique;index:idx_version" json:"version" comment:"迁移版本号"`
 	Name      string    `gorm:"size:255" json:"name" comment:"迁移名称"`
 	Status    string    `gorm:"size:20;default:'completed'" json:"status" comment:"迁移状态(pending,running,completed,failed)"`
+	Checksum  string    `gorm:"size:64" json:"checksum" comment:"迁移函数校验和"`
+	Changes   string    `gorm:"type:text" json:"changes" comment:"迁移实际执行的修改(JSON)，回滚时按此撤销"`
 	CreatedAt time.Time `json:"created_at" comment:"创建时间"`
 	UpdatedAt time.Time `json:"updated_at" comment:"更新时间"`
 }
@@ -19,3 +21,17 @@ type Migration struct {
 func (Migration) TableName() string {
 	return "sys_migration"
 }
+
+// MigrationLock 迁移锁，同一时间只允许一个进程执行迁移
+// 表中最多一行（ID 固定为 1），持有者定期延长 ExpiresAt，进程异常退出后锁在过期后自动失效
+type MigrationLock struct {
+	ID        uint      `gorm:"primarykey;autoIncrement:false" json:"id"`
+	Owner     string    `gorm:"size:255;not null" json:"owner" comment:"持有者（主机名:进程号）"`
+	LockedAt  time.Time `gorm:"not null" json:"locked_at" comment:"加锁时间"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at" comment:"过期时间"`
+}
+
+// TableName 设置表名
+func (MigrationLock) TableName() string {
+	return "sys_migration_lock"
+}
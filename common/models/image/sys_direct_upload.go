@@ -0,0 +1,26 @@
+package image
+
+import "time"
+
+// 直传记录状态
+const (
+	DirectUploadPending   = "pending"   // 正在检查上传的文件
+	DirectUploadCompleted = "completed" // 已创建图片记录
+)
+
+// SysDirectUpload 浏览器直传记录
+// 按存储Key唯一，保证一个上传凭证只能完成一次；完成后记录创建的图片，重复回调、去重后重试返回同一条记录
+type SysDirectUpload struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Key       string    `json:"key" gorm:"size:500;not null;uniqueIndex:idx_sys_direct_uploads_key;comment:存储Key"`
+	UserID    uint64    `json:"userId" gorm:"not null;comment:申请上传的用户"`
+	ImageID   uint64    `json:"imageId" gorm:"comment:创建的图片记录ID"`
+	Status    string    `json:"status" gorm:"size:20;not null;comment:状态(pending/completed)"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (SysDirectUpload) TableName() string {
+	return "sys_direct_uploads"
+}
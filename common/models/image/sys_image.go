@@ -31,7 +31,7 @@ type SysImage struct {
 	// 图片属性
 	Width  int    `json:"width" gorm:"comment:图片宽度"`
 	Height int    `json:"height" gorm:"comment:图片高度"`
-	Hash   string `json:"hash" gorm:"size:100;comment:文件Hash"`
+	Hash   string `json:"hash" gorm:"size:100;index;comment:文件内容Hash(SHA-256)"`
 
 	// 状态控制
 	IsPublic  bool   `json:"isPublic" gorm:"default:true;comment:是否公开访问"`
@@ -59,9 +59,10 @@ type SysImageCategory struct {
 	Name         string    `json:"name" gorm:"size:100;not null;comment:分类名称"`
 	Description  string    `json:"description" gorm:"size:200;comment:分类描述"`
 	MaxSize      int64     `json:"maxSize" gorm:"comment:最大文件大小"`
-	AllowedTypes []string  `json:"allowedTypes" gorm:"type:json;comment:允许的文件类型"`
+	AllowedTypes []string  `json:"allowedTypes" gorm:"type:json;serializer:json;comment:允许的文件类型"`
 	MaxCount     int       `json:"maxCount" gorm:"default:10;comment:最大上传数量"`
 	IsRequired   bool      `json:"isRequired" gorm:"default:false;comment:是否必填"`
+	Modules      []string  `json:"modules" gorm:"type:json;serializer:json;comment:适用模块(为空适用所有模块)"`
 	Status       string    `json:"status" gorm:"size:20;default:'active';comment:状态"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
@@ -72,6 +73,37 @@ func (SysImageCategory) TableName() string {
 	return "sys_image_categories"
 }
 
+// ImageCategoryCreateRequest 创建图片分类请求
+type ImageCategoryCreateRequest struct {
+	Code         string   `json:"code" binding:"required,max=50"`  // 分类编码
+	Name         string   `json:"name" binding:"required,max=100"` // 分类名称
+	Description  string   `json:"description" binding:"max=200"`   // 分类描述
+	MaxSize      int64    `json:"maxSize" binding:"min=0"`         // 最大文件大小，0 使用全局上传配置
+	AllowedTypes []string `json:"allowedTypes"`                    // 允许的文件类型，为空使用全局上传配置
+	MaxCount     int      `json:"maxCount" binding:"min=0"`        // 每个关联对象的最大图片数，0 为不限制
+	IsRequired   bool     `json:"isRequired"`                      // 是否必填
+	Modules      []string `json:"modules"`                         // 适用模块
+}
+
+// ImageCategoryUpdateRequest 更新图片分类请求，未提交的字段不修改
+type ImageCategoryUpdateRequest struct {
+	Name         *string   `json:"name" binding:"omitempty,max=100"`
+	Description  *string   `json:"description" binding:"omitempty,max=200"`
+	MaxSize      *int64    `json:"maxSize" binding:"omitempty,min=0"`
+	AllowedTypes *[]string `json:"allowedTypes"`
+	MaxCount     *int      `json:"maxCount" binding:"omitempty,min=0"`
+	IsRequired   *bool     `json:"isRequired"`
+	Modules      *[]string `json:"modules"`
+	Status       *string   `json:"status" binding:"omitempty,oneof=active inactive"`
+}
+
+// ImageWarning 图片完整性提示（必填分类缺少图片）
+type ImageWarning struct {
+	Category string `json:"category"` // 分类编码
+	Name     string `json:"name"`     // 分类名称
+	Message  string `json:"message"`  // 提示信息
+}
+
 // ImageUploadRequest 图片上传请求
 type ImageUploadRequest struct {
 	Category string `json:"category" binding:"required"` // 图片分类
@@ -81,14 +113,20 @@ type ImageUploadRequest struct {
 	IsPublic bool   `json:"isPublic"`                    // 是否公开访问
 }
 
+// DirectUploadRequest 浏览器直传获取上传凭证请求
+type DirectUploadRequest struct {
+	ImageUploadRequest
+	FileName string `json:"fileName" binding:"required,max=200"` // 原始文件名
+}
+
 // ImageUpdateRequest 图片更新请求
 type ImageUpdateRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Category    string `json:"category"`
-	IsMain      bool   `json:"isMain"`
+	IsMain      *bool  `json:"isMain"` // 不传不修改；设为主图时取消图库内其他主图
 	IsPublic    bool   `json:"isPublic"`
-	SortOrder   int    `json:"sortOrder"`
+	SortOrder   *int   `json:"sortOrder"` // 不传不修改；图库内的位置（从 1 开始），其他图片依次后移
 	Status      string `json:"status"`
 }
 
@@ -97,26 +135,6 @@ type ImageBatchDeleteRequest struct {
 	IDs []uint64 `json:"ids" binding:"required"`
 }
 
-// ImageListRequest 图片列表请求
-type ImageListRequest struct {
-	Category string `form:"category"`
-	Module   string `form:"module"`
-	ModuleID uint64 `form:"moduleId"`
-	Status   string `form:"status"`
-	IsMain   *bool  `form:"isMain"`
-	IsPublic *bool  `form:"isPublic"`
-	Page     int    `form:"page,default=1"`
-	PageSize int    `form:"pageSize,default=10"`
-	OrderBy  string `form:"orderBy,default=created_at"`
-	OrderDir string `form:"orderDir,default=desc"`
-}
-
-// ImageListResponse 图片列表响应
-type ImageListResponse struct {
-	Total int64       `json:"total"`
-	List  []*SysImage `json:"list"`
-}
-
 // ImageStats 图片统计信息
 type ImageStats struct {
 	TotalImages   int64            `json:"totalImages"`
@@ -0,0 +1,50 @@
+package image
+
+import "time"
+
+// SysWatermarkPolicy 图片水印策略
+// 按图片分类、模块匹配，生成衍生图时叠加水印，原图不加水印
+type SysWatermarkPolicy struct {
+	ID               uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name             string    `json:"name" gorm:"size:100;not null;comment:策略名称"`
+	Category         string    `json:"category" gorm:"size:50;index;comment:适用图片分类(为空适用所有分类)"`
+	Module           string    `json:"module" gorm:"size:50;index;comment:适用模块(为空适用所有模块)"`
+	Type             string    `json:"type" gorm:"size:20;not null;comment:水印类型(text/logo)"`
+	Text             string    `json:"text" gorm:"size:100;comment:水印文字"`
+	Color            string    `json:"color" gorm:"size:20;comment:文字颜色(#RRGGBB)"`
+	LogoKey          string    `json:"logoKey" gorm:"size:500;comment:Logo图片存储Key"`
+	Position         string    `json:"position" gorm:"size:20;not null;comment:位置(top_left/top_right/bottom_left/bottom_right/center/tile)"`
+	Opacity          float64   `json:"opacity" gorm:"type:decimal(3,2);not null;comment:不透明度(0-1)"`
+	Scale            float64   `json:"scale" gorm:"type:decimal(3,2);not null;comment:水印宽度占图片宽度的比例"`
+	Margin           float64   `json:"margin" gorm:"type:decimal(3,2);comment:边距占图片宽度的比例"`
+	Sizes            []string  `json:"sizes" gorm:"type:json;serializer:json;comment:适用的衍生图规格(为空适用所有规格)"`
+	ExemptFloorPlans bool      `json:"exemptFloorPlans" gorm:"comment:户型图不加水印"`
+	Status           string    `json:"status" gorm:"size:20;default:'active';index;comment:状态(active/inactive)"`
+	CreatedBy        uint64    `json:"createdBy" gorm:"comment:创建者ID"`
+	UpdatedBy        uint64    `json:"updatedBy" gorm:"comment:更新者ID"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (SysWatermarkPolicy) TableName() string {
+	return "sys_watermark_policies"
+}
+
+// WatermarkPolicyRequest 创建、更新水印策略请求（更新时整体替换）
+type WatermarkPolicyRequest struct {
+	Name             string   `json:"name" binding:"required,max=100"`                                                           // 策略名称
+	Category         string   `json:"category" binding:"max=50"`                                                                 // 适用图片分类，为空适用所有分类
+	Module           string   `json:"module" binding:"max=50"`                                                                   // 适用模块，为空适用所有模块
+	Type             string   `json:"type" binding:"required,oneof=text logo"`                                                   // 水印类型
+	Text             string   `json:"text" binding:"max=100"`                                                                    // 水印文字，文字水印必填
+	Color            string   `json:"color" binding:"omitempty,hexcolor"`                                                        // 文字颜色，默认白色
+	LogoKey          string   `json:"logoKey" binding:"max=500"`                                                                 // Logo图片存储Key，Logo水印必填
+	Position         string   `json:"position" binding:"required,oneof=top_left top_right bottom_left bottom_right center tile"` // 位置
+	Opacity          float64  `json:"opacity" binding:"gt=0,lte=1"`                                                              // 不透明度
+	Scale            float64  `json:"scale" binding:"gt=0,lte=1"`                                                                // 水印宽度占图片宽度的比例
+	Margin           float64  `json:"margin" binding:"gte=0,lte=0.5"`                                                            // 边距占图片宽度的比例
+	Sizes            []string `json:"sizes"`                                                                                     // 适用的衍生图规格，为空适用所有规格
+	ExemptFloorPlans bool     `json:"exemptFloorPlans"`                                                                          // 户型图不加水印
+	Status           string   `json:"status" binding:"omitempty,oneof=active inactive"`                                          // 状态，默认 active
+}
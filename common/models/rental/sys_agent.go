@@ -10,10 +10,10 @@ type SysAgent struct {
 	ID uint `json:"id" gorm:"primaryKey;autoIncrement" comment:"主键ID"`
 
 	// 基础信息
-	Name    string `json:"name" gorm:"size:100;not null;index:idx_name" comment:"经纪人姓名"`
-	Phone   string `json:"phone" gorm:"size:20;not null;uniqueIndex:idx_phone" comment:"联系电话"`
-	IDCard  string `json:"idCard" gorm:"size:18;uniqueIndex:idx_id_card" comment:"身份证号"`
-	Email   string `json:"email" gorm:"size:100;uniqueIndex:idx_email" comment:"邮箱"`
+	Name    string `json:"name" gorm:"size:100;not null;index:idx_sys_agents_name" comment:"经纪人姓名"`
+	Phone   string `json:"phone" gorm:"size:20;not null;uniqueIndex:idx_sys_agents_phone" comment:"联系电话"`
+	IDCard  string `json:"idCard" gorm:"size:18;uniqueIndex:idx_sys_agents_id_card" comment:"身份证号"`
+	Email   string `json:"email" gorm:"size:100;uniqueIndex:idx_sys_agents_email" comment:"邮箱"`
 	Address string `json:"address" gorm:"size:500" comment:"联系地址"`
 
 	// 所属公司
@@ -22,7 +22,7 @@ type SysAgent struct {
 
 	// 证书信息
 	CertificationNumber string     `json:"certificationNumber" gorm:"size:50;uniqueIndex:idx_cert_number" comment:"从业资格证书编号"`
-	CertificationDate   *time.Time `json:"certificationDate" gorm:"comment:"资格证书获得日期"`
+	CertificationDate   *time.Time `json:"certificationDate" comment:"资格证书获得日期"`
 	CertificationImage  string     `json:"certificationImage" gorm:"size:500" comment:"资格证书图片URL"`
 
 	// 专业信息
@@ -35,7 +35,7 @@ type SysAgent struct {
 	AverageRating   float64 `json:"averageRating" gorm:"type:decimal(3,2);default:0;index:idx_avg_rating" comment:"平均评分"`
 
 	// 状态信息
-	Status string `json:"status" gorm:"size:20;not null;default:'active';index:idx_status" comment:"状态(active:正常, inactive:停用, suspended:暂停)"`
+	Status string `json:"status" gorm:"size:20;not null;default:'active';index:idx_sys_agents_status" comment:"状态(active:正常, inactive:停用, suspended:暂停)"`
 
 	// 备注
 	Notes string `json:"notes" gorm:"type:text" comment:"备注信息"`
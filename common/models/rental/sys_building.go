@@ -10,7 +10,7 @@ type SysBuildings struct {
 	ID uint `json:"id" gorm:"primaryKey;autoIncrement" comment:"主键ID"`
 
 	// 基础信息
-	Name            string `json:"name" gorm:"size:100;not null;index:idx_name" comment:"楼盘名称"`
+	Name            string `json:"name" gorm:"size:100;not null;index:idx_sys_buildings_name" comment:"楼盘名称"`
 	Developer       string `json:"developer" gorm:"size:100" comment:"开发商"`
 	DetailedAddress string `json:"detailedAddress" gorm:"size:500;not null;column:detailed_address" comment:"详细地址"`
 	City            string `json:"city" gorm:"size:50;not null" comment:"城市"`
@@ -19,6 +19,10 @@ type SysBuildings struct {
 	SubDistrict     string `json:"subDistrict" gorm:"size:50" comment:"街道"`
 	PropertyType    string `json:"propertyType" gorm:"size:50" comment:"物业类型(住宅/商业/办公等)"`
 
+	// 位置信息（用于计算周边POI距离）
+	Longitude float64 `json:"longitude" gorm:"type:decimal(10,6);default:0" comment:"经度"`
+	Latitude  float64 `json:"latitude" gorm:"type:decimal(10,6);default:0" comment:"纬度"`
+
 	PropertyCompany string `json:"propertyCompany" gorm:"size:100" comment:"物业公司"`
 	Description     string `json:"description" gorm:"type:text" comment:"楼盘描述"`
 
@@ -29,8 +33,8 @@ type SysBuildings struct {
 	RentDealsCount int `json:"rentDealsCount" gorm:"default:0" comment:"在租成交数"`
 
 	// 状态信息
-	Status string `json:"status" gorm:"size:20;not null;default:'active';index:idx_status" comment:"状态(active:在租/售, inactive:停用, pending:审核中)"`
-	IsHot  bool   `json:"isHot" gorm:"default:false;index:idx_is_hot" comment:"是否顶豪楼盘"`
+	Status string `json:"status" gorm:"size:20;not null;default:'active';index:idx_sys_buildings_status" comment:"状态(active:在租/售, inactive:停用, pending:审核中, rejected:已驳回)"`
+	IsHot  bool   `json:"isHot" gorm:"default:false;index:idx_sys_buildings_is_hot" comment:"是否顶豪楼盘"`
 
 	// 管理信息
 	CreatedBy string `json:"createdBy" gorm:"size:50" comment:"创建人"`
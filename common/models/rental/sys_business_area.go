@@ -6,7 +6,7 @@ type SysBusinessArea struct {
 	Code       string `json:"code" gorm:"type:varchar(20);uniqueIndex;not null;comment:商圈代码"`
 	Name       string `json:"name" gorm:"type:varchar(100);not null;comment:商圈名称"`
 	DistrictID uint64 `json:"district_id" gorm:"index:idx_district_id;comment:区域ID"`
-	CityCode   string `json:"city_code" gorm:"type:varchar(20);not null;index:idx_city_code;comment:城市代码"`
+	CityCode   string `json:"city_code" gorm:"type:varchar(20);not null;index:idx_sys_business_areas_city_code;comment:城市代码"`
 	Sort       int64  `json:"sort" gorm:"default:0;comment:排序"`
 	Status     string `json:"status" gorm:"type:varchar(20);default:active;comment:状态"`
 
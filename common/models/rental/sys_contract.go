@@ -12,7 +12,7 @@ type SysContract struct {
 	// 合同基本信息
 	ContractNumber string `json:"contractNumber" gorm:"size:50;not null;uniqueIndex:idx_contract_number" comment:"合同编号"`
 	Title          string `json:"title" gorm:"size:200;not null" comment:"合同标题"`
-	Type           string `json:"type" gorm:"size:20;not null;index:idx_type" comment:"合同类型(rent:租赁, sale:买卖)"`
+	Type           string `json:"type" gorm:"size:20;not null;index:idx_sys_contracts_type" comment:"合同类型(rent:租赁, sale:买卖)"`
 
 	// 关联信息
 	PropertyID   uint   `json:"propertyId" gorm:"not null;index:idx_property_id" comment:"房源ID"`
@@ -38,7 +38,7 @@ type SysContract struct {
 	NextPaymentDate *time.Time `json:"nextPaymentDate" comment:"下次支付日期"`
 
 	// 合同状态
-	Status string `json:"status" gorm:"size:20;not null;default:'pending';index:idx_status" comment:"合同状态(pending:待生效, active:生效中, expired:已过期, terminated:已终止, cancelled:已取消)"`
+	Status string `json:"status" gorm:"size:20;not null;default:'pending';index:idx_sys_contracts_status" comment:"合同状态(pending:待生效, active:生效中, expired:已过期, terminated:已终止, cancelled:已取消)"`
 
 	// 房屋信息
 	Address string  `json:"address" gorm:"size:500" comment:"房屋地址"`
@@ -5,7 +5,7 @@ type SysDistrict struct {
 	ID       uint64 `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
 	Code     string `json:"code" gorm:"type:varchar(20);uniqueIndex;not null;comment:区域代码"`
 	Name     string `json:"name" gorm:"type:varchar(50);not null;comment:区域名称"`
-	CityCode string `json:"city_code" gorm:"type:varchar(20);not null;index:idx_city_code;comment:城市代码"`
+	CityCode string `json:"city_code" gorm:"type:varchar(20);not null;index:idx_sys_districts_city_code;comment:城市代码"`
 	CityID   uint64 `json:"city_id" gorm:"index:idx_city_id;comment:城市ID"`
 	Sort     int64  `json:"sort" gorm:"default:0;comment:排序"`
 	Status   string `json:"status" gorm:"type:varchar(20);default:active;comment:状态"`
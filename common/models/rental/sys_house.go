@@ -11,11 +11,11 @@ type SysHouse struct {
 	ID uint `json:"id" gorm:"primaryKey;autoIncrement" comment:"主键ID"`
 
 	// 基础信息
-	Name string `json:"name" gorm:"size:100;not null;index:idx_name" comment:"房屋名称"`
-	Code string `json:"code" gorm:"size:50;not null;uniqueIndex:idx_code" comment:"房屋编码"`
+	Name string `json:"name" gorm:"size:100;not null;index:idx_sys_houses_name" comment:"房屋名称"`
+	Code string `json:"code" gorm:"size:50;not null;uniqueIndex:idx_sys_houses_code" comment:"房屋编码"`
 
 	// 关联关系
-	BuildingID  uint         `json:"buildingId" gorm:"not null;index:idx_building_id" comment:"所属楼盘ID"`
+	BuildingID  uint         `json:"buildingId" gorm:"not null;index:idx_sys_houses_building_id" comment:"所属楼盘ID"`
 	Building    SysBuildings `json:"building,omitempty" gorm:"foreignKey:BuildingID" comment:"所属楼盘"`
 	HouseTypeID uint         `json:"houseTypeId" gorm:"not null;index:idx_house_type_id" comment:"所属户型ID"`
 	HouseType   SysHouseType `json:"houseType,omitempty" gorm:"foreignKey:HouseTypeID" comment:"所属户型"`
@@ -43,19 +43,19 @@ type SysHouse struct {
 	PriceAdjustmentReason string  `json:"priceAdjustmentReason" gorm:"size:200" comment:"价格调整原因"`
 
 	// 状态信息
-	Status     string `json:"status" gorm:"size:20;not null;default:'available';index:idx_status" comment:"状态(available:可租/售, rented:已租, sold:已售, maintenance:维护中, inactive:停用)"`
+	Status     string `json:"status" gorm:"size:20;not null;default:'available';index:idx_sys_houses_status" comment:"状态(available:可租/售, rented:已租, sold:已售, maintenance:维护中, inactive:停用)"`
 	SaleStatus string `json:"saleStatus" gorm:"size:20;default:'available'" comment:"销售状态(available:可售, sold:已售, reserved:已预订)"`
 	RentStatus string `json:"rentStatus" gorm:"size:20;default:'available'" comment:"租赁状态(available:可租, rented:已租, reserved:已预订)"`
 
 	// 图片信息
 	MainImage string   `json:"mainImage" gorm:"size:500" comment:"主图URL"`
-	ImageUrls []string `json:"imageUrls" gorm:"type:json" comment:"图片URL列表"`
+	ImageUrls []string `json:"imageUrls" gorm:"type:json;serializer:json" comment:"图片URL列表"`
 
 	// 特色标签
-	Tags []string `json:"tags" gorm:"type:json" comment:"特色标签(南北通透/精装修/地铁房等)"`
+	Tags []string `json:"tags" gorm:"type:json;serializer:json" comment:"特色标签(南北通透/精装修/地铁房等)"`
 
 	// 配套设施
-	Facilities []string `json:"facilities" gorm:"type:json" comment:"配套设施(空调/暖气/家具/家电等)"`
+	Facilities []string `json:"facilities" gorm:"type:json;serializer:json" comment:"配套设施(空调/暖气/家具/家电等)"`
 
 	// 备注
 	Description string `json:"description" gorm:"type:text" comment:"房屋描述"`
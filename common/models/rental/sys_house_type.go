@@ -23,12 +23,12 @@ type SysHouseType struct {
 	ID uint `json:"id" gorm:"primaryKey;autoIncrement" comment:"主键ID"`
 
 	// 基础信息
-	Name        string `json:"name" gorm:"size:100;not null;index:idx_name" comment:"户型名称"`
-	Code        string `json:"code" gorm:"size:50;not null;uniqueIndex:idx_code" comment:"户型编码"`
+	Name        string `json:"name" gorm:"size:100;not null;index:idx_sys_house_types_name" comment:"户型名称"`
+	Code        string `json:"code" gorm:"size:50;not null;uniqueIndex:idx_sys_house_types_code" comment:"户型编码"`
 	Description string `json:"description" gorm:"type:text" comment:"户型描述"`
 
 	// 楼盘关联
-	BuildingID uint         `json:"building_id" gorm:"not null;index:idx_building_id" comment:"所属楼盘ID"`
+	BuildingID uint         `json:"building_id" gorm:"not null;index:idx_sys_house_types_building_id" comment:"所属楼盘ID"`
 	Building   SysBuildings `json:"building,omitempty" gorm:"foreignKey:BuildingID" comment:"所属楼盘"`
 
 	// 户型标准规格
@@ -37,6 +37,7 @@ type SysHouseType struct {
 	Halls        int     `json:"halls" gorm:"not null;default:1" comment:"客厅数"`
 	Bathrooms    int     `json:"bathrooms" gorm:"not null;default:1" comment:"卫生间数"`
 	Balconies    int     `json:"balconies" gorm:"default:0" comment:"阳台数"`
+	MaidRooms    int     `json:"maidRooms" gorm:"default:0" comment:"保姆间数"`
 	FloorHeight  float64 `json:"floorHeight" gorm:"type:decimal(4,2)" comment:"标准层高(米)"`
 
 	// 标准朝向和景观
@@ -57,16 +58,16 @@ type SysHouseType struct {
 	ReservedStock  int `json:"reservedStock" gorm:"default:0" comment:"已预订库存"`
 
 	// 户型状态
-	Status string `json:"status" gorm:"size:20;not null;default:'active';index:idx_status" comment:"状态(active:在售/租, inactive:停用, pending:审核中)"`
-	IsHot  bool   `json:"isHot" gorm:"default:false;index:idx_is_hot" comment:"是否热门户型"`
+	Status string `json:"status" gorm:"size:20;not null;default:'active';index:idx_sys_house_types_status" comment:"状态(active:在售/租, inactive:停用, pending:审核中, rejected:已驳回)"`
+	IsHot  bool   `json:"isHot" gorm:"default:false;index:idx_sys_house_types_is_hot" comment:"是否热门户型"`
 
 	// 户型展示图片
 	MainImage    string   `json:"mainImage" gorm:"size:500" comment:"主图URL"`
 	FloorPlanUrl string   `json:"floorPlanUrl" gorm:"size:500" comment:"户型图URL"`
-	ImageUrls    []string `json:"imageUrls" gorm:"type:json" comment:"图片URL列表"`
+	ImageUrls    []string `json:"imageUrls" gorm:"type:json;serializer:json" comment:"图片URL列表"`
 
 	// 特色标签
-	Tags []string `json:"tags" gorm:"type:json" comment:"特色标签(南北通透/精装修/地铁房等)"`
+	Tags []string `json:"tags" gorm:"type:json;serializer:json" comment:"特色标签(南北通透/精装修/地铁房等)"`
 
 	// 管理信息
 	CreatedBy string `json:"createdBy" gorm:"size:50" comment:"创建人"`
@@ -0,0 +1,114 @@
+package rental
+
+import (
+	"math"
+	"time"
+)
+
+// POI 分类
+const (
+	PoiCategorySubway   = "subway"   // 地铁站
+	PoiCategoryBus      = "bus"      // 公交站
+	PoiCategorySchool   = "school"   // 学校
+	PoiCategoryHospital = "hospital" // 医院
+	PoiCategoryMall     = "mall"     // 商场
+	PoiCategoryPark     = "park"     // 公园
+)
+
+// PoiCategories POI 分类及名称
+var PoiCategories = map[string]string{
+	PoiCategorySubway:   "地铁站",
+	PoiCategoryBus:      "公交站",
+	PoiCategorySchool:   "学校",
+	PoiCategoryHospital: "医院",
+	PoiCategoryMall:     "商场",
+	PoiCategoryPark:     "公园",
+}
+
+// SysPoi 周边兴趣点字典模型
+// 按城市维护，地铁站的 Line 为所属线路，换乘站按线路分别登记
+type SysPoi struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement" comment:"主键ID"`
+	CityCode  string    `json:"cityCode" gorm:"size:10;not null;index:idx_poi_city_category" comment:"城市代码"`
+	Category  string    `json:"category" gorm:"size:20;not null;index:idx_poi_city_category" comment:"分类(subway/bus/school/hospital/mall/park)"`
+	Name      string    `json:"name" gorm:"size:100;not null" comment:"名称"`
+	Line      string    `json:"line" gorm:"size:50;index:idx_poi_line" comment:"线路(地铁、公交)"`
+	Address   string    `json:"address" gorm:"size:255" comment:"地址"`
+	Longitude float64   `json:"longitude" gorm:"type:decimal(10,6);default:0" comment:"经度"`
+	Latitude  float64   `json:"latitude" gorm:"type:decimal(10,6);default:0" comment:"纬度"`
+	Sort      int       `json:"sort" gorm:"default:0" comment:"排序"`
+	Status    string    `json:"status" gorm:"size:20;default:'active'" comment:"状态"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime" comment:"创建时间"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime" comment:"更新时间"`
+}
+
+// TableName 设置表名
+func (SysPoi) TableName() string {
+	return "sys_pois"
+}
+
+// HasLocation 是否有坐标
+func (p *SysPoi) HasLocation() bool {
+	return p.Longitude != 0 || p.Latitude != 0
+}
+
+// SysBuildingPoi 楼盘周边POI关联模型
+type SysBuildingPoi struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement" comment:"主键ID"`
+	BuildingID  uint64    `json:"buildingId" gorm:"not null;uniqueIndex:uk_building_poi" comment:"楼盘ID"`
+	PoiID       uint64    `json:"poiId" gorm:"not null;uniqueIndex:uk_building_poi;index:idx_poi_id" comment:"POI ID"`
+	Distance    int       `json:"distance" gorm:"not null;default:0;index:idx_distance" comment:"距离(米)"`
+	WalkMinutes int       `json:"walkMinutes" gorm:"default:0" comment:"步行时间(分钟)"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime" comment:"创建时间"`
+}
+
+// TableName 设置表名
+func (SysBuildingPoi) TableName() string {
+	return "sys_building_pois"
+}
+
+// Amenities 楼盘配套设施编码及名称
+var Amenities = map[string]string{
+	"parking":      "停车场",
+	"ev_charging":  "充电桩",
+	"elevator":     "电梯",
+	"gym":          "健身房",
+	"pool":         "游泳池",
+	"clubhouse":    "会所",
+	"playground":   "儿童游乐场",
+	"garden":       "园林绿化",
+	"concierge":    "礼宾服务",
+	"security_24h": "24小时安保",
+}
+
+// SysBuildingAmenity 楼盘配套设施模型
+type SysBuildingAmenity struct {
+	ID         uint64    `json:"id" gorm:"primaryKey;autoIncrement" comment:"主键ID"`
+	BuildingID uint64    `json:"buildingId" gorm:"not null;uniqueIndex:uk_building_amenity" comment:"楼盘ID"`
+	Code       string    `json:"code" gorm:"size:50;not null;uniqueIndex:uk_building_amenity;index:idx_amenity_code" comment:"设施编码"`
+	Remark     string    `json:"remark" gorm:"size:255" comment:"说明(如车位数量)"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"autoCreateTime" comment:"创建时间"`
+}
+
+// TableName 设置表名
+func (SysBuildingAmenity) TableName() string {
+	return "sys_building_amenities"
+}
+
+// earthRadius 地球平均半径(米)
+const earthRadius = 6371000
+
+// Distance 计算两个经纬度之间的球面距离(米)
+func Distance(lng1, lat1, lng2, lat2 float64) int {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return int(math.Round(2 * earthRadius * math.Asin(math.Sqrt(a))))
+}
+
+// WalkMinutes 按步行速度 80 米/分钟估算步行时间
+func WalkMinutes(distance int) int {
+	return int(math.Ceil(float64(distance) / 80))
+}
@@ -0,0 +1,45 @@
+package rental
+
+import (
+	"time"
+)
+
+// 楼盘、户型的发布状态
+const (
+	StatusActive   = "active"   // 已审核通过，对外展示
+	StatusInactive = "inactive" // 停用
+	StatusPending  = "pending"  // 审核中
+	StatusRejected = "rejected" // 审核驳回，退回编辑人修改
+)
+
+// 审核对象类型
+const (
+	ReviewTargetBuilding  = "building"
+	ReviewTargetHouseType = "house_type"
+)
+
+// 审核操作
+const (
+	ReviewActionSubmit  = "submit"  // 新建或编辑后提交审核
+	ReviewActionApprove = "approve" // 审核通过
+	ReviewActionReject  = "reject"  // 审核驳回
+)
+
+// SysReviewRecord 审核记录模型
+// 记录楼盘、户型每次提交审核和审核结果
+type SysReviewRecord struct {
+	ID         uint64    `json:"id" gorm:"primaryKey;autoIncrement" comment:"主键ID"`
+	TargetType string    `json:"targetType" gorm:"size:20;not null;index:idx_review_target" comment:"审核对象类型(building/house_type)"`
+	TargetID   uint64    `json:"targetId" gorm:"not null;index:idx_review_target" comment:"审核对象ID"`
+	Action     string    `json:"action" gorm:"size:20;not null" comment:"操作(submit/approve/reject)"`
+	FromStatus string    `json:"fromStatus" gorm:"size:20" comment:"操作前状态"`
+	ToStatus   string    `json:"toStatus" gorm:"size:20" comment:"操作后状态"`
+	Comment    string    `json:"comment" gorm:"size:500" comment:"审核意见"`
+	Operator   string    `json:"operator" gorm:"size:50" comment:"操作人"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"autoCreateTime" comment:"操作时间"`
+}
+
+// TableName 设置表名
+func (SysReviewRecord) TableName() string {
+	return "sys_review_records"
+}
@@ -10,10 +10,10 @@ type SysTenant struct {
 	ID uint `json:"id" gorm:"primaryKey;autoIncrement" comment:"主键ID"`
 
 	// 基础信息
-	Name             string `json:"name" gorm:"size:100;not null;index:idx_name" comment:"租户姓名"`
-	Phone            string `json:"phone" gorm:"size:20;not null;uniqueIndex:idx_phone" comment:"联系电话"`
-	IDCard           string `json:"idCard" gorm:"size:18;uniqueIndex:idx_id_card" comment:"身份证号"`
-	Email            string `json:"email" gorm:"size:100;uniqueIndex:idx_email" comment:"邮箱"`
+	Name             string `json:"name" gorm:"size:100;not null;index:idx_sys_tenants_name" comment:"租户姓名"`
+	Phone            string `json:"phone" gorm:"size:20;not null;uniqueIndex:idx_sys_tenants_phone" comment:"联系电话"`
+	IDCard           string `json:"idCard" gorm:"size:18;uniqueIndex:idx_sys_tenants_id_card" comment:"身份证号"`
+	Email            string `json:"email" gorm:"size:100;uniqueIndex:idx_sys_tenants_email" comment:"邮箱"`
 	Address          string `json:"address" gorm:"size:500" comment:"联系地址"`
 	EmergencyContact string `json:"emergencyContact" gorm:"size:100" comment:"紧急联系人"`
 	EmergencyPhone   string `json:"emergencyPhone" gorm:"size:20" comment:"紧急联系电话"`
@@ -24,8 +24,8 @@ type SysTenant struct {
 	BusinessLicense string `json:"businessLicense" gorm:"size:100" comment:"营业执照号"`
 
 	// 租户类型和状态
-	Type   string `json:"type" gorm:"size:20;not null;default:'individual';index:idx_type" comment:"租户类型(individual:个人, company:企业)"`
-	Status string `json:"status" gorm:"size:20;not null;default:'active';index:idx_status" comment:"状态(active:正常, inactive:停用, blacklisted:黑名单)"`
+	Type   string `json:"type" gorm:"size:20;not null;default:'individual';index:idx_sys_tenants_type" comment:"租户类型(individual:个人, company:企业)"`
+	Status string `json:"status" gorm:"size:20;not null;default:'active';index:idx_sys_tenants_status" comment:"状态(active:正常, inactive:停用, blacklisted:黑名单)"`
 
 	// 统计信息
 	ContractCount int     `json:"contractCount" gorm:"default:0;index:idx_contract_count" comment:"合同数量"`
@@ -34,8 +34,8 @@ type SysTenant struct {
 
 	// 信用信息
 	CreditScore   int  `json:"creditScore" gorm:"default:100" comment:"信用评分(0-100)"`
-	IsVIP         bool `json:"isVIP" gorm:"default:false;index:idx_is_vip" comment:"是否VIP客户"`
-	IsBlacklisted bool `json:"isBlacklisted" gorm:"default:false;index:idx_is_blacklisted" comment:"是否黑名单"`
+	IsVIP         bool `json:"isVIP" gorm:"default:false;index:idx_sys_tenants_is_vip" comment:"是否VIP客户"`
+	IsBlacklisted bool `json:"isBlacklisted" gorm:"default:false;index:idx_sys_tenants_is_blacklisted" comment:"是否黑名单"`
 
 	// 备注
 	Notes string `json:"notes" gorm:"type:text" comment:"备注信息"`
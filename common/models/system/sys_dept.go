@@ -10,7 +10,7 @@ import (
 // 管理组织架构和部门信息
 type SysDept struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
-	ParentID  uint           `gorm:"default:0;index:idx_parent_id" json:"parent_id" comment:"父部门ID"`
+	ParentID  uint           `gorm:"default:0;index:idx_sys_dept_parent_id" json:"parent_id" comment:"父部门ID"`
 	DeptPath  string         `gorm:"size:255" json:"dept_path" comment:"部门路径"`
 	DeptName  string         `gorm:"size:128;not null" json:"dept_name" comment:"部门名称"`
 	Sort      int            `gorm:"default:1" json:"sort" comment:"排序"`
@@ -17,7 +17,7 @@ type SysMenu struct {
 	Redirect   string         `gorm:"size:128" json:"redirect" comment:"重定向地址"`
 	Component  string         `gorm:"size:128" json:"component" comment:"组件路径"`
 	Permission string         `gorm:"size:255" json:"permission" comment:"权限标识"`
-	ParentID   uint           `gorm:"default:0;index:idx_parent_id" json:"parent_id" comment:"父菜单ID"`
+	ParentID   uint           `gorm:"default:0;index:idx_sys_menu_parent_id" json:"parent_id" comment:"父菜单ID"`
 	Type       string         `gorm:"size:1;default:'M'" json:"type" comment:"菜单类型 M:菜单 C:目录 F:按钮"`
 	Sort       int            `gorm:"default:1" json:"sort" comment:"排序"`
 	Visible    string         `gorm:"size:1;default:'0'" json:"visible" comment:"是否显示 0:显示 1:隐藏"`
@@ -10,7 +10,7 @@ import (
 // 定义系统中的角色和权限组
 type SysRole struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
-	Name      string         `gorm:"size:128;not null;unique;index:idx_name" json:"name" comment:"角色名称"`
+	Name      string         `gorm:"size:128;not null;unique;index:idx_sys_role_name" json:"name" comment:"角色名称"`
 	Key       string         `gorm:"size:128;not null;unique;index:idx_key" json:"key" comment:"角色标识"`
 	Status    int            `gorm:"default:1" json:"status" comment:"状态 1:启用 2:禁用"`
 	Sort      int            `gorm:"default:1" json:"sort" comment:"排序"`
@@ -15,8 +15,8 @@ type SysUser struct {
 	Password    string         `gorm:"size:128;not null" json:"-" comment:"密码"`
 	NickName    string         `gorm:"size:128" json:"nick_name" comment:"昵称"`
 	Avatar      string         `gorm:"size:255" json:"avatar" comment:"头像"`
-	Email       string         `gorm:"size:128;index:idx_email" json:"email" comment:"邮箱"`
-	Phone       string         `gorm:"size:32;index:idx_phone" json:"phone" comment:"手机号"`
+	Email       string         `gorm:"size:128;index:idx_sys_user_email" json:"email" comment:"邮箱"`
+	Phone       string         `gorm:"size:32;index:idx_sys_user_phone" json:"phone" comment:"手机号"`
 	Status      int            `gorm:"default:1" json:"status" comment:"状态 1:启用 2:禁用"`
 	IsAdmin     bool           `gorm:"default:false" json:"is_admin" comment:"是否为管理员"`
 	Remark      string         `gorm:"size:255" json:"remark" comment:"备注"`
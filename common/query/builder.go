@@ -0,0 +1,473 @@
+// Package query 提供列表接口共用的查询构建器
+// 由一份列表定义同时生成数据查询和总数查询，统一处理筛选、排序和分页参数
+//
+// 支持的查询参数：
+//   - 筛选：field=value 使用字段默认操作符，field_op=value 指定操作符
+//     操作符：eq, ne, like, gt, gte, lt, lte, in（逗号分隔）, between（逗号分隔的两个值）
+//     例如：price_gte=3000、area_between=50,90、status_in=active,pending
+//   - 排序：sort=field,-field（"-" 表示降序），只允许定义中声明为可排序的字段
+//   - 分页：page、pageSize（兼容 page_size）；传 cursor 参数时使用游标分页，见 cursor.go
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FieldType 字段类型，决定查询参数的转换方式
+type FieldType int
+
+const (
+	String FieldType = iota
+	Int
+	Float
+	Bool
+	Time
+)
+
+// 支持的筛选操作符
+const (
+	OpEq      = "eq"
+	OpNe      = "ne"
+	OpLike    = "like"
+	OpGt      = "gt"
+	OpGte     = "gte"
+	OpLt      = "lt"
+	OpLte     = "lte"
+	OpIn      = "in"
+	OpBetween = "between"
+)
+
+var knownOps = map[string]bool{
+	OpEq: true, OpNe: true, OpLike: true, OpGt: true, OpGte: true,
+	OpLt: true, OpLte: true, OpIn: true, OpBetween: true,
+}
+
+// 常用操作符组合
+var (
+	// TextOps 文本模糊匹配字段
+	TextOps = []string{OpLike, OpEq, OpIn}
+	// EnumOps 枚举/编码字段
+	EnumOps = []string{OpEq, OpNe, OpIn}
+	// RangeOps 数值和时间范围字段
+	RangeOps = []string{OpEq, OpGt, OpGte, OpLt, OpLte, OpBetween, OpIn}
+)
+
+// timeLayouts 时间参数支持的格式
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// Field 列表字段定义
+type Field struct {
+	// Column SQL 列表达式，如 "b.name"
+	Column string
+	// Type 字段类型
+	Type FieldType
+	// Ops 允许的筛选操作符，第一个为 field=value 时使用的默认操作符；为空表示不可筛选
+	Ops []string
+	// Sortable 是否允许排序
+	Sortable bool
+}
+
+// Definition 列表查询定义
+type Definition struct {
+	// Table 主表（可带别名），如 "sys_buildings b"
+	Table string
+	// Joins 关联语句
+	Joins []string
+	// Select 数据查询的列，为空时使用 "*"
+	Select string
+	// Key 主键列，作为排序的最终条件保证结果稳定，如 "b.id"
+	Key string
+	// Fields 可筛选/排序字段，key 为接口参数名
+	Fields map[string]Field
+	// DefaultSort 默认排序，格式同 sort 参数
+	DefaultSort string
+	// DefaultPageSize 默认每页条数
+	DefaultPageSize int
+	// MaxPageSize 每页条数上限
+	MaxPageSize int
+}
+
+// Condition 已解析的筛选条件
+type Condition struct {
+	Field  string
+	Op     string
+	Values []interface{}
+}
+
+// Sort 已解析的排序条件
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Params 已解析的列表查询参数
+type Params struct {
+	Page       int
+	PageSize   int
+	Conditions []Condition
+	Sorts      []Sort
+	// CursorMode 是否为游标分页
+	CursorMode bool
+	// after 游标分页的起始位置，第一页为 nil
+	after *cursor
+}
+
+// Page 统一的分页结果
+type Page struct {
+	List     interface{} `json:"list"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"pageSize"`
+	// 以下字段仅用于游标分页
+	CursorMode bool   `json:"-"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+// JSON 以统一的分页格式输出响应
+// 游标分页不统计总数，返回 nextCursor 和 hasMore
+func (p *Page) JSON(c *gin.Context, message string) {
+	p.JSONCompat(c, message, nil)
+}
+
+// JSONCompat 以统一的分页格式输出响应，并附加接口迁移前使用的字段（如 size、page_size），
+// 保证已有调用方不受影响；legacy 中的字段覆盖统一格式中的同名字段
+// 游标分页是新增的分页方式，不附加旧字段
+func (p *Page) JSONCompat(c *gin.Context, message string, legacy gin.H) {
+	if p.CursorMode {
+		c.JSON(200, gin.H{
+			"code":       200,
+			"message":    message,
+			"data":       p.List,
+			"pageSize":   p.PageSize,
+			"nextCursor": p.NextCursor,
+			"hasMore":    p.HasMore,
+		})
+		return
+	}
+	body := gin.H{
+		"code":     200,
+		"message":  message,
+		"data":     p.List,
+		"total":    p.Total,
+		"page":     p.Page,
+		"pageSize": p.PageSize,
+	}
+	for key, value := range legacy {
+		body[key] = value
+	}
+	c.JSON(200, body)
+}
+
+// Parse 从请求中解析列表参数
+func (d *Definition) Parse(c *gin.Context) (*Params, error) {
+	return d.ParseValues(c.Request.URL.Query())
+}
+
+// ParseValues 从查询参数中解析列表参数，未声明的参数会被忽略
+func (d *Definition) ParseValues(values url.Values) (*Params, error) {
+	params := &Params{
+		Page:     1,
+		PageSize: d.defaultPageSize(),
+	}
+
+	if page, err := strconv.Atoi(values.Get("page")); err == nil && page > 0 {
+		params.Page = page
+	}
+	pageSize := values.Get("pageSize")
+	if pageSize == "" {
+		pageSize = values.Get("page_size")
+	}
+	if size, err := strconv.Atoi(pageSize); err == nil && size > 0 {
+		params.PageSize = size
+	}
+	if max := d.maxPageSize(); params.PageSize > max {
+		params.PageSize = max
+	}
+
+	// 按参数名排序，保证生成的SQL稳定
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		raw := strings.TrimSpace(values.Get(key))
+		if raw == "" {
+			continue
+		}
+
+		name, op, ok := d.resolveKey(key)
+		if !ok {
+			continue
+		}
+
+		field := d.Fields[name]
+		if !contains(field.Ops, op) {
+			return nil, fmt.Errorf("字段 %s 不支持 %s 筛选", name, op)
+		}
+
+		args, err := convertValues(field.Type, op, raw)
+		if err != nil {
+			return nil, fmt.Errorf("参数 %s 格式错误: %v", key, err)
+		}
+		params.Conditions = append(params.Conditions, Condition{Field: name, Op: op, Values: args})
+	}
+
+	sortValue := values.Get("sort")
+	if sortValue == "" {
+		sortValue = d.DefaultSort
+	}
+	sorts, err := d.parseSort(sortValue)
+	if err != nil {
+		return nil, err
+	}
+	params.Sorts = sorts
+
+	if values.Has("cursor") {
+		if err := d.parseCursor(params, values.Get("cursor")); err != nil {
+			return nil, err
+		}
+	}
+
+	return params, nil
+}
+
+// resolveKey 将参数名解析为字段名和操作符
+func (d *Definition) resolveKey(key string) (string, string, bool) {
+	if field, ok := d.Fields[key]; ok {
+		if len(field.Ops) == 0 {
+			return "", "", false
+		}
+		return key, field.Ops[0], true
+	}
+
+	idx := strings.LastIndex(key, "_")
+	if idx <= 0 {
+		return "", "", false
+	}
+	name, op := key[:idx], key[idx+1:]
+	if _, ok := d.Fields[name]; !ok || !knownOps[op] {
+		return "", "", false
+	}
+	return name, op, true
+}
+
+// parseSort 解析 sort=field,-field
+func (d *Definition) parseSort(value string) ([]Sort, error) {
+	var sorts []Sort
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+")
+		field, ok := d.Fields[name]
+		if !ok || !field.Sortable {
+			return nil, fmt.Errorf("不支持按 %s 排序", name)
+		}
+		sorts = append(sorts, Sort{Field: name, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// Build 生成带主表、关联和筛选条件的基础查询，可同时用于数据和总数查询
+func (d *Definition) Build(db *gorm.DB, params *Params) *gorm.DB {
+	tx := db.Table(d.Table)
+	for _, join := range d.Joins {
+		tx = tx.Joins(join)
+	}
+	for _, cond := range params.Conditions {
+		clause, args := cond.clause(d.Fields[cond.Field].Column, db.Dialector.Name())
+		tx = tx.Where(clause, args...)
+	}
+	return tx.Session(&gorm.Session{})
+}
+
+// OrderBy 生成 ORDER BY 子句，末尾追加主键保证顺序稳定
+// 排序字段的 NULL 统一为升序在前、降序在后（MySQL 的默认规则），其他数据库显式指定 NULLS FIRST/LAST
+func (d *Definition) OrderBy(params *Params, dialect string) string {
+	parts := make([]string, 0, len(params.Sorts)+1)
+	for _, s := range params.Sorts {
+		parts = append(parts, d.Fields[s.Field].Column+direction(s.Desc)+nullsOrder(s.Desc, dialect))
+	}
+	if d.Key != "" {
+		parts = append(parts, d.Key+" ASC")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Find 执行总数查询和分页数据查询，结果写入 dest
+// db 可预先携带额外条件（如软删除、数据权限、路径参数）
+func (d *Definition) Find(db *gorm.DB, params *Params, dest interface{}) (*Page, error) {
+	if params.CursorMode {
+		next, err := d.findKeyset(db, params, params.after, dest)
+		if err != nil {
+			return nil, err
+		}
+		page := &Page{List: dest, PageSize: params.PageSize, CursorMode: true}
+		if next != nil {
+			page.NextCursor = next.encode()
+			page.HasMore = true
+		}
+		return page, nil
+	}
+
+	base := d.Build(db, params)
+
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询总数失败: %v", err)
+	}
+
+	selectColumns := d.Select
+	if selectColumns == "" {
+		selectColumns = "*"
+	}
+
+	tx := base.Select(selectColumns)
+	if order := d.OrderBy(params, db.Dialector.Name()); order != "" {
+		tx = tx.Order(order)
+	}
+	err := tx.Limit(params.PageSize).
+		Offset((params.Page - 1) * params.PageSize).
+		Scan(dest).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询列表失败: %v", err)
+	}
+
+	return &Page{
+		List:     dest,
+		Total:    total,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+	}, nil
+}
+
+// clause 生成单个筛选条件的SQL片段
+// PostgreSQL 的 LIKE 区分大小写，模糊匹配使用 ILIKE，与 MySQL 行为一致
+func (c Condition) clause(column, dialect string) (string, []interface{}) {
+	switch c.Op {
+	case OpNe:
+		return column + " <> ?", c.Values
+	case OpLike:
+		if dialect == "postgres" {
+			return column + " ILIKE ?", []interface{}{fmt.Sprintf("%%%v%%", c.Values[0])}
+		}
+		return column + " LIKE ?", []interface{}{fmt.Sprintf("%%%v%%", c.Values[0])}
+	case OpGt:
+		return column + " > ?", c.Values
+	case OpGte:
+		return column + " >= ?", c.Values
+	case OpLt:
+		return column + " < ?", c.Values
+	case OpLte:
+		return column + " <= ?", c.Values
+	case OpIn:
+		return column + " IN ?", []interface{}{c.Values}
+	case OpBetween:
+		return column + " BETWEEN ? AND ?", c.Values
+	default:
+		return column + " = ?", c.Values
+	}
+}
+
+// convertValues 按字段类型和操作符转换参数值
+func convertValues(fieldType FieldType, op, raw string) ([]interface{}, error) {
+	parts := []string{raw}
+	if op == OpIn || op == OpBetween {
+		parts = strings.Split(raw, ",")
+	}
+	if op == OpBetween && len(parts) != 2 {
+		return nil, fmt.Errorf("between 需要两个以逗号分隔的值")
+	}
+
+	values := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if op == OpLike {
+			values = append(values, part)
+			continue
+		}
+		value, err := convertValue(fieldType, part)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// convertValue 转换单个参数值
+func convertValue(fieldType FieldType, raw string) (interface{}, error) {
+	switch fieldType {
+	case Int:
+		return strconv.ParseInt(raw, 10, 64)
+	case Float:
+		return strconv.ParseFloat(raw, 64)
+	case Bool:
+		return strconv.ParseBool(raw)
+	case Time:
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("无法解析时间: %s", raw)
+	default:
+		return raw, nil
+	}
+}
+
+func (d *Definition) defaultPageSize() int {
+	if d.DefaultPageSize > 0 {
+		return d.DefaultPageSize
+	}
+	return 10
+}
+
+func (d *Definition) maxPageSize() int {
+	if d.MaxPageSize > 0 {
+		return d.MaxPageSize
+	}
+	return 100
+}
+
+func direction(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+	return " ASC"
+}
+
+// nullsOrder NULL 的排序位置：升序在前、降序在后
+// MySQL 默认如此且不支持 NULLS FIRST/LAST 语法；PostgreSQL 默认相反，SQLite 3.30 起支持该语法
+func nullsOrder(desc bool, dialect string) string {
+	switch {
+	case dialect == "mysql":
+		return ""
+	case desc:
+		return " NULLS LAST"
+	default:
+		return " NULLS FIRST"
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,286 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 游标分页（keyset）
+//
+// 请求携带 cursor 参数（首页传空值 cursor=）即进入游标模式：
+// 按排序字段和主键的值定位下一页，不使用 OFFSET，也不统计总数，
+// 翻页过程中插入新数据不会导致重复或遗漏。
+// 游标为不透明字符串，编码了排序规则、最后一行的排序字段值和主键。
+//
+// 排序字段的 NULL 值升序时在前、降序时在后，与 OrderBy 生成的排序一致（见 nullsOrder）。
+
+// cursor 游标内容
+type cursor struct {
+	// Sort 生成游标时的排序规则，排序变化后旧游标失效
+	Sort string `json:"s"`
+	// Values 最后一行的排序字段值，NULL 为 nil
+	Values []*string `json:"v"`
+	// Key 最后一行的主键
+	Key string `json:"k"`
+}
+
+// StreamBatchSize 流式输出时每批查询的条数
+const StreamBatchSize = 500
+
+// cursorTimeLayout 游标中时间值的编码格式
+const cursorTimeLayout = time.RFC3339Nano
+
+// encode 编码为不透明字符串
+func (cur *cursor) encode() string {
+	data, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 解析游标字符串，空字符串表示第一页
+func decodeCursor(value string) (*cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("无效的游标")
+	}
+	var cur cursor
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return nil, fmt.Errorf("无效的游标")
+	}
+	return &cur, nil
+}
+
+// sortSignature 排序规则签名，用于校验游标与当前排序是否一致
+func sortSignature(sorts []Sort) string {
+	parts := make([]string, 0, len(sorts))
+	for _, s := range sorts {
+		if s.Desc {
+			parts = append(parts, "-"+s.Field)
+		} else {
+			parts = append(parts, s.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseCursor 解析游标参数并校验与排序规则匹配
+func (d *Definition) parseCursor(params *Params, value string) error {
+	if d.Key == "" {
+		return fmt.Errorf("该列表不支持游标分页")
+	}
+	cur, err := decodeCursor(value)
+	if err != nil {
+		return err
+	}
+	if cur != nil && (cur.Sort != sortSignature(params.Sorts) || len(cur.Values) != len(params.Sorts)) {
+		return fmt.Errorf("游标与排序条件不匹配")
+	}
+	params.CursorMode = true
+	params.after = cur
+	return nil
+}
+
+// afterClause 生成定位到游标之后的条件
+// 展开为 (a > ?) OR (a = ? AND b < ?) OR ... OR (a = ? AND b = ? AND key > ?)
+func (d *Definition) afterClause(params *Params, cur *cursor) (string, []interface{}, error) {
+	var (
+		terms  []string
+		args   []interface{}
+		equals []string
+		eqArgs []interface{}
+	)
+
+	for i, s := range params.Sorts {
+		field := d.Fields[s.Field]
+		var value interface{}
+		if cur.Values[i] != nil {
+			v, err := convertValue(field.Type, *cur.Values[i])
+			if err != nil {
+				return "", nil, fmt.Errorf("无效的游标")
+			}
+			value = v
+		}
+
+		next, nextArgs := nextClause(field.Column, s.Desc, value)
+		terms = append(terms, joinTerm(equals, next))
+		args = append(append(args, eqArgs...), nextArgs...)
+
+		if value == nil {
+			equals = append(equals, field.Column+" IS NULL")
+		} else {
+			equals = append(equals, field.Column+" = ?")
+			eqArgs = append(eqArgs, value)
+		}
+	}
+
+	key, err := convertValue(Int, cur.Key)
+	if err != nil {
+		return "", nil, fmt.Errorf("无效的游标")
+	}
+	terms = append(terms, joinTerm(equals, d.Key+" > ?"))
+	args = append(append(args, eqArgs...), key)
+
+	return "(" + strings.Join(terms, " OR ") + ")", args, nil
+}
+
+// nextClause 单个排序字段上“排在游标值之后”的条件
+func nextClause(column string, desc bool, value interface{}) (string, []interface{}) {
+	switch {
+	case value == nil && desc:
+		// 降序时 NULL 已在末尾，之后没有更大的值
+		return "1 = 0", nil
+	case value == nil:
+		return column + " IS NOT NULL", nil
+	case desc:
+		return "(" + column + " < ? OR " + column + " IS NULL)", []interface{}{value}
+	default:
+		return column + " > ?", []interface{}{value}
+	}
+}
+
+func joinTerm(equals []string, last string) string {
+	return "(" + strings.Join(append(append([]string{}, equals...), last), " AND ") + ")"
+}
+
+// findKeyset 按游标查询一页数据
+// 先按排序条件查出本页主键和排序值，再按主键查询完整数据，返回下一页游标（没有更多数据时为 nil）
+func (d *Definition) findKeyset(db *gorm.DB, params *Params, after *cursor, dest interface{}) (*cursor, error) {
+	tx := d.Build(db, params)
+	if after != nil {
+		clause, args, err := d.afterClause(params, after)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(clause, args...)
+	}
+
+	columns := make([]string, 0, len(params.Sorts)+1)
+	for i, s := range params.Sorts {
+		columns = append(columns, fmt.Sprintf("%s AS cursor_sort_%d", d.Fields[s.Field].Column, i))
+	}
+	columns = append(columns, d.Key+" AS cursor_key")
+
+	var keys []map[string]interface{}
+	err := tx.Select(strings.Join(columns, ", ")).
+		Order(d.OrderBy(params, db.Dialector.Name())).
+		Limit(params.PageSize + 1).
+		Scan(&keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询列表失败: %v", err)
+	}
+
+	var next *cursor
+	if len(keys) > params.PageSize {
+		keys = keys[:params.PageSize]
+		last := keys[len(keys)-1]
+		next = &cursor{
+			Sort: sortSignature(params.Sorts),
+			Key:  cursorString(last["cursor_key"]),
+		}
+		for i := range params.Sorts {
+			value := last[fmt.Sprintf("cursor_sort_%d", i)]
+			if value == nil {
+				next.Values = append(next.Values, nil)
+				continue
+			}
+			s := cursorString(value)
+			next.Values = append(next.Values, &s)
+		}
+	}
+
+	ids := make([]interface{}, 0, len(keys))
+	for _, row := range keys {
+		ids = append(ids, row["cursor_key"])
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	selectColumns := d.Select
+	if selectColumns == "" {
+		selectColumns = "*"
+	}
+	err = d.Build(db, params).
+		Select(selectColumns).
+		Where(d.Key+" IN ?", ids).
+		Order(d.OrderBy(params, db.Dialector.Name())).
+		Scan(dest).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询列表失败: %v", err)
+	}
+
+	return next, nil
+}
+
+// cursorString 将数据库返回的值转换为游标中的字符串
+func cursorString(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(cursorTimeLayout)
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Each 按游标分批遍历全部匹配数据，忽略分页参数
+// newDest 返回每批数据的接收切片指针，fn 处理每批数据
+func (d *Definition) Each(db *gorm.DB, params *Params, batchSize int, newDest func() interface{}, fn func(rows interface{}) error) error {
+	if d.Key == "" {
+		return fmt.Errorf("该列表不支持分批遍历")
+	}
+
+	batch := *params
+	batch.PageSize = batchSize
+
+	var after *cursor
+	for {
+		dest := newDest()
+		next, err := d.findKeyset(db, &batch, after, dest)
+		if err != nil {
+			return err
+		}
+		if err := fn(dest); err != nil {
+			return err
+		}
+		if next == nil {
+			return nil
+		}
+		after = next
+	}
+}
+
+// WriteNDJSON 以 NDJSON（每行一个 JSON 对象）格式流式输出
+// each 通过 emit 逐批输出数据；输出过程中出错时追加一行 {"error": "..."}
+func WriteNDJSON(c *gin.Context, each func(emit func(rows interface{}) error) error) {
+	c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(200)
+
+	encoder := json.NewEncoder(c.Writer)
+	emit := func(rows interface{}) error {
+		list := reflect.Indirect(reflect.ValueOf(rows))
+		for i := 0; i < list.Len(); i++ {
+			if err := encoder.Encode(list.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	if err := each(emit); err != nil {
+		encoder.Encode(gin.H{"error": err.Error()})
+		c.Writer.Flush()
+	}
+}
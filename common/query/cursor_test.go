@@ -0,0 +1,180 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// cursorItem 排序字段可为 NULL 的测试数据
+type cursorItem struct {
+	ID    uint
+	Score *int
+	Name  string
+}
+
+func (cursorItem) TableName() string {
+	return "cursor_items"
+}
+
+var cursorItemDefinition = &Definition{
+	Table: "cursor_items",
+	Key:   "id",
+	Fields: map[string]Field{
+		"score": {Column: "score", Type: Int, Sortable: true},
+		"name":  {Column: "name", Type: String, Sortable: true},
+	},
+	DefaultSort: "score",
+}
+
+// TestCursorNullsSQLite SQLite 上排序字段含 NULL 时的游标分页
+func TestCursorNullsSQLite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cursor.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开 SQLite 失败: %v", err)
+	}
+	testCursorNulls(t, db)
+}
+
+// TestCursorNullsPostgres PostgreSQL 上排序字段含 NULL 时的游标分页（PostgreSQL 默认 NULL 排在升序末尾）
+// 设置 RENTPRO_TEST_POSTGRES_DSN 时执行，如 host=localhost user=postgres dbname=rentpro_test sslmode=disable
+func TestCursorNullsPostgres(t *testing.T) {
+	dsn := os.Getenv("RENTPRO_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("未设置 RENTPRO_TEST_POSTGRES_DSN")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("连接 PostgreSQL 失败: %v", err)
+	}
+	testCursorNulls(t, db)
+}
+
+// testCursorNulls 各种排序下，游标逐页遍历和普通分页的结果都与预期顺序一致（NULL 升序在前、降序在后，不重复不遗漏）
+func testCursorNulls(t *testing.T, db *gorm.DB) {
+	migrator := db.Migrator()
+	if err := migrator.DropTable(&cursorItem{}); err != nil {
+		t.Fatalf("删除测试表失败: %v", err)
+	}
+	if err := migrator.AutoMigrate(&cursorItem{}); err != nil {
+		t.Fatalf("创建测试表失败: %v", err)
+	}
+	t.Cleanup(func() { migrator.DropTable(&cursorItem{}) })
+
+	scores := []interface{}{nil, 3, 1, nil, 3, 2, nil, 1, nil, 2}
+	var items []cursorItem
+	for i, score := range scores {
+		item := cursorItem{Name: fmt.Sprintf("n%d", i%3)}
+		if score != nil {
+			v := score.(int)
+			item.Score = &v
+		}
+		items = append(items, item)
+	}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+
+	for _, sortValue := range []string{"score", "-score", "score,-name", "-score,name"} {
+		want := expectedOrder(items, sortValue)
+
+		params, err := cursorItemDefinition.ParseValues(url.Values{"sort": {sortValue}, "pageSize": {"100"}})
+		if err != nil {
+			t.Fatalf("sort=%s 解析参数失败: %v", sortValue, err)
+		}
+		var rows []cursorItem
+		if _, err := cursorItemDefinition.Find(db, params, &rows); err != nil {
+			t.Fatalf("sort=%s 分页查询失败: %v", sortValue, err)
+		}
+		if got := itemIDs(rows); !reflect.DeepEqual(got, want) {
+			t.Errorf("sort=%s 分页顺序 %v，预期 %v", sortValue, got, want)
+		}
+
+		for _, pageSize := range []string{"1", "2", "3"} {
+			var got []uint
+			cursor := ""
+			for page := 0; ; page++ {
+				if page > len(items) {
+					t.Fatalf("sort=%s pageSize=%s 游标没有结束", sortValue, pageSize)
+				}
+				params, err := cursorItemDefinition.ParseValues(url.Values{"sort": {sortValue}, "pageSize": {pageSize}, "cursor": {cursor}})
+				if err != nil {
+					t.Fatalf("sort=%s 解析游标失败: %v", sortValue, err)
+				}
+				var rows []cursorItem
+				result, err := cursorItemDefinition.Find(db, params, &rows)
+				if err != nil {
+					t.Fatalf("sort=%s 游标查询失败: %v", sortValue, err)
+				}
+				got = append(got, itemIDs(rows)...)
+				if !result.HasMore {
+					break
+				}
+				cursor = result.NextCursor
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("sort=%s pageSize=%s 游标顺序 %v，预期 %v", sortValue, pageSize, got, want)
+			}
+		}
+	}
+}
+
+// expectedOrder 按排序规则计算预期的主键顺序：NULL 升序在前、降序在后，最后按主键升序
+func expectedOrder(items []cursorItem, sortValue string) []uint {
+	sorted := append([]cursorItem{}, items...)
+	fields := strings.Split(sortValue, ",")
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, field := range fields {
+			desc := strings.HasPrefix(field, "-")
+			var c int
+			if strings.TrimPrefix(field, "-") == "score" {
+				c = compareScore(sorted[i].Score, sorted[j].Score)
+			} else {
+				c = strings.Compare(sorted[i].Name, sorted[j].Name)
+			}
+			if desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return itemIDs(sorted)
+}
+
+// compareScore NULL 小于任何值
+func compareScore(a, b *int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case *a < *b:
+		return -1
+	case *a > *b:
+		return 1
+	}
+	return 0
+}
+
+func itemIDs(items []cursorItem) []uint {
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
@@ -0,0 +1,389 @@
+// Package seed 提供声明式的初始化数据（种子数据）
+// 从 YAML/JSON 数据集读取部门、岗位、角色、菜单、角色菜单、用户、城市区域商圈，
+// 按自然键（部门路径、岗位编码、角色标识、菜单名称、用户名、区域编码）依赖顺序写入数据库：
+// 记录不存在时创建，存在时更新与数据集不一致的字段。
+//
+// 数据集目录结构（默认 config/seed）：
+//   - base/ 所有环境都加载的基础数据
+//   - {环境}/ 按环境追加的数据，如 dev/ 演示数据、prod/ 生产数据
+//
+// 同一目录内按文件名顺序加载，环境目录在 base 之后加载；
+// 自然键相同的记录以后加载的为准，角色菜单取并集。
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BaseEnv 所有环境都加载的数据集目录
+const BaseEnv = "base"
+
+// AllMenus 角色菜单中表示全部菜单
+const AllMenus = "*"
+
+// Dataset 数据集
+type Dataset struct {
+	Depts         []Dept              `yaml:"depts" json:"depts"`
+	Posts         []Post              `yaml:"posts" json:"posts"`
+	Roles         []Role              `yaml:"roles" json:"roles"`
+	Menus         []Menu              `yaml:"menus" json:"menus"`
+	RoleMenus     map[string][]string `yaml:"role_menus" json:"role_menus"` // 角色标识 → 菜单名称，"*" 为全部菜单
+	Users         []User              `yaml:"users" json:"users"`
+	Cities        []City              `yaml:"cities" json:"cities"`
+	Districts     []District          `yaml:"districts" json:"districts"`
+	BusinessAreas []BusinessArea      `yaml:"business_areas" json:"business_areas"`
+}
+
+// Dept 部门，自然键为部门路径（上级部门名称/部门名称）
+type Dept struct {
+	Path   string `yaml:"path" json:"path"` // 如 "RentPro科技/技术部"
+	Sort   int    `yaml:"sort" json:"sort"`
+	Leader string `yaml:"leader" json:"leader"`
+	Phone  string `yaml:"phone" json:"phone"`
+	Email  string `yaml:"email" json:"email"`
+	Status string `yaml:"status" json:"status"` // 0:正常 1:停用，默认 0
+}
+
+// Post 岗位，自然键为岗位编码
+type Post struct {
+	Code   string `yaml:"code" json:"code"`
+	Name   string `yaml:"name" json:"name"`
+	Sort   int    `yaml:"sort" json:"sort"`
+	Status string `yaml:"status" json:"status"` // 0:正常 1:停用，默认 0
+	Remark string `yaml:"remark" json:"remark"`
+}
+
+// Role 角色，自然键为角色标识
+type Role struct {
+	Key       string `yaml:"key" json:"key"`
+	Name      string `yaml:"name" json:"name"`
+	Status    int    `yaml:"status" json:"status"` // 1:启用 2:禁用，默认 1
+	Sort      int    `yaml:"sort" json:"sort"`
+	Flag      string `yaml:"flag" json:"flag"`
+	Remark    string `yaml:"remark" json:"remark"`
+	Admin     bool   `yaml:"admin" json:"admin"`
+	DataScope string `yaml:"data_scope" json:"data_scope"` // 默认 1
+	Params    string `yaml:"params" json:"params"`
+}
+
+// Menu 菜单，自然键为菜单名称
+type Menu struct {
+	Name       string `yaml:"name" json:"name"`
+	Parent     string `yaml:"parent" json:"parent"` // 上级菜单名称，为空时是顶级菜单
+	Title      string `yaml:"title" json:"title"`
+	Icon       string `yaml:"icon" json:"icon"`
+	Path       string `yaml:"path" json:"path"`
+	Redirect   string `yaml:"redirect" json:"redirect"`
+	Component  string `yaml:"component" json:"component"`
+	Permission string `yaml:"permission" json:"permission"`
+	Type       string `yaml:"type" json:"type"` // M:菜单 C:目录 F:按钮，默认 M
+	Sort       int    `yaml:"sort" json:"sort"`
+	Visible    string `yaml:"visible" json:"visible"`     // 0:显示 1:隐藏，默认 0
+	IsFrame    string `yaml:"is_frame" json:"is_frame"`   // 0:是 1:否，默认 1
+	IsCache    string `yaml:"is_cache" json:"is_cache"`   // 0:缓存 1:不缓存，默认 0
+	MenuType   string `yaml:"menu_type" json:"menu_type"` // 1:左侧菜单 2:顶部菜单 3:按钮
+	Status     string `yaml:"status" json:"status"`       // 0:正常 1:停用，默认 0
+	Perms      string `yaml:"perms" json:"perms"`
+}
+
+// User 用户，自然键为用户名
+// 密码只在创建用户时使用，已存在的用户不修改密码
+type User struct {
+	Username    string `yaml:"username" json:"username"`
+	Password    string `yaml:"password" json:"password"`         // 初始密码
+	PasswordEnv string `yaml:"password_env" json:"password_env"` // 从环境变量读取初始密码，优先于 password
+	NickName    string `yaml:"nick_name" json:"nick_name"`
+	Avatar      string `yaml:"avatar" json:"avatar"`
+	Email       string `yaml:"email" json:"email"`
+	Phone       string `yaml:"phone" json:"phone"`
+	Status      int    `yaml:"status" json:"status"` // 1:启用 2:禁用，默认 1
+	IsAdmin     bool   `yaml:"is_admin" json:"is_admin"`
+	Remark      string `yaml:"remark" json:"remark"`
+	Dept        string `yaml:"dept" json:"dept"` // 部门路径
+	Post        string `yaml:"post" json:"post"` // 岗位编码
+	Role        string `yaml:"role" json:"role"` // 角色标识
+}
+
+// City 城市，自然键为城市代码
+type City struct {
+	Code   string `yaml:"code" json:"code"`
+	Name   string `yaml:"name" json:"name"`
+	Sort   int64  `yaml:"sort" json:"sort"`
+	Status string `yaml:"status" json:"status"` // 默认 active
+}
+
+// District 区域，自然键为区域代码
+type District struct {
+	Code   string `yaml:"code" json:"code"`
+	Name   string `yaml:"name" json:"name"`
+	City   string `yaml:"city" json:"city"` // 城市代码
+	Sort   int64  `yaml:"sort" json:"sort"`
+	Status string `yaml:"status" json:"status"` // 默认 active
+}
+
+// BusinessArea 商圈，自然键为商圈代码
+type BusinessArea struct {
+	Code     string `yaml:"code" json:"code"`
+	Name     string `yaml:"name" json:"name"`
+	District string `yaml:"district" json:"district"` // 区域代码
+	Sort     int64  `yaml:"sort" json:"sort"`
+	Status   string `yaml:"status" json:"status"` // 默认 active
+}
+
+// Load 加载数据集：先加载 dir/base，再加载 dir/{env}
+// 返回合并后的数据集和加载的文件列表
+func Load(dir, env string) (*Dataset, []string, error) {
+	envs := []string{BaseEnv}
+	if env != "" && env != BaseEnv {
+		envs = append(envs, env)
+	}
+
+	merged := &Dataset{}
+	var loaded []string
+	for i, name := range envs {
+		files, err := datasetFiles(filepath.Join(dir, name))
+		if err != nil {
+			// 环境目录可以不存在
+			if i > 0 && os.IsNotExist(err) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("读取数据集目录失败: %v", err)
+		}
+		for _, file := range files {
+			ds, err := loadFile(file)
+			if err != nil {
+				return nil, nil, err
+			}
+			merged.merge(ds)
+			loaded = append(loaded, file)
+		}
+	}
+
+	if err := merged.normalize(); err != nil {
+		return nil, nil, err
+	}
+	return merged, loaded, nil
+}
+
+// datasetFiles 目录中的数据集文件，按文件名排序
+func datasetFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yml", ".yaml", ".json":
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadFile 解析一个数据集文件
+func loadFile(file string) (*Dataset, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取数据集 %s 失败: %v", file, err)
+	}
+	ds := &Dataset{}
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		err = json.Unmarshal(data, ds)
+	} else {
+		err = yaml.Unmarshal(data, ds)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析数据集 %s 失败: %v", file, err)
+	}
+	return ds, nil
+}
+
+// merge 合并后加载的数据集，自然键相同的记录替换原记录（保留原位置），角色菜单取并集
+func (d *Dataset) merge(o *Dataset) {
+	d.Depts = mergeItems(d.Depts, o.Depts, func(v Dept) string { return v.Path })
+	d.Posts = mergeItems(d.Posts, o.Posts, func(v Post) string { return v.Code })
+	d.Roles = mergeItems(d.Roles, o.Roles, func(v Role) string { return v.Key })
+	d.Menus = mergeItems(d.Menus, o.Menus, func(v Menu) string { return v.Name })
+	d.Users = mergeItems(d.Users, o.Users, func(v User) string { return v.Username })
+	d.Cities = mergeItems(d.Cities, o.Cities, func(v City) string { return v.Code })
+	d.Districts = mergeItems(d.Districts, o.Districts, func(v District) string { return v.Code })
+	d.BusinessAreas = mergeItems(d.BusinessAreas, o.BusinessAreas, func(v BusinessArea) string { return v.Code })
+
+	if len(o.RoleMenus) > 0 && d.RoleMenus == nil {
+		d.RoleMenus = make(map[string][]string)
+	}
+	for role, menus := range o.RoleMenus {
+		for _, menu := range menus {
+			if !contains(d.RoleMenus[role], menu) {
+				d.RoleMenus[role] = append(d.RoleMenus[role], menu)
+			}
+		}
+	}
+}
+
+// mergeItems 按自然键合并记录
+func mergeItems[T any](items, more []T, key func(T) string) []T {
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		index[key(item)] = i
+	}
+	for _, item := range more {
+		if i, ok := index[key(item)]; ok {
+			items[i] = item
+			continue
+		}
+		index[key(item)] = len(items)
+		items = append(items, item)
+	}
+	return items
+}
+
+// normalize 检查自然键并填充默认值
+func (d *Dataset) normalize() error {
+	for i := range d.Depts {
+		dept := &d.Depts[i]
+		dept.Path = strings.Trim(dept.Path, "/")
+		if dept.Path == "" {
+			return fmt.Errorf("部门缺少 path")
+		}
+		dept.Status = withDefault(dept.Status, "0")
+	}
+	// 上级部门在前
+	sort.SliceStable(d.Depts, func(i, j int) bool {
+		return strings.Count(d.Depts[i].Path, "/") < strings.Count(d.Depts[j].Path, "/")
+	})
+
+	for i := range d.Posts {
+		if d.Posts[i].Code == "" || d.Posts[i].Name == "" {
+			return fmt.Errorf("岗位缺少 code 或 name")
+		}
+		d.Posts[i].Status = withDefault(d.Posts[i].Status, "0")
+	}
+
+	for i := range d.Roles {
+		role := &d.Roles[i]
+		if role.Key == "" || role.Name == "" {
+			return fmt.Errorf("角色缺少 key 或 name")
+		}
+		if role.Status == 0 {
+			role.Status = 1
+		}
+		role.DataScope = withDefault(role.DataScope, "1")
+	}
+
+	for i := range d.Menus {
+		menu := &d.Menus[i]
+		if menu.Name == "" {
+			return fmt.Errorf("菜单缺少 name")
+		}
+		menu.Type = withDefault(menu.Type, "M")
+		menu.Visible = withDefault(menu.Visible, "0")
+		menu.IsFrame = withDefault(menu.IsFrame, "1")
+		menu.IsCache = withDefault(menu.IsCache, "0")
+		menu.Status = withDefault(menu.Status, "0")
+	}
+	menus, err := sortMenus(d.Menus)
+	if err != nil {
+		return err
+	}
+	d.Menus = menus
+
+	for i := range d.Users {
+		user := &d.Users[i]
+		if user.Username == "" {
+			return fmt.Errorf("用户缺少 username")
+		}
+		if user.Status == 0 {
+			user.Status = 1
+		}
+	}
+
+	for i := range d.Cities {
+		if d.Cities[i].Code == "" || d.Cities[i].Name == "" {
+			return fmt.Errorf("城市缺少 code 或 name")
+		}
+		d.Cities[i].Status = withDefault(d.Cities[i].Status, "active")
+	}
+	for i := range d.Districts {
+		if d.Districts[i].Code == "" || d.Districts[i].Name == "" || d.Districts[i].City == "" {
+			return fmt.Errorf("区域缺少 code、name 或 city")
+		}
+		d.Districts[i].Status = withDefault(d.Districts[i].Status, "active")
+	}
+	for i := range d.BusinessAreas {
+		if d.BusinessAreas[i].Code == "" || d.BusinessAreas[i].Name == "" || d.BusinessAreas[i].District == "" {
+			return fmt.Errorf("商圈缺少 code、name 或 district")
+		}
+		d.BusinessAreas[i].Status = withDefault(d.BusinessAreas[i].Status, "active")
+	}
+	return nil
+}
+
+// sortMenus 调整菜单顺序，上级菜单在前
+// 上级菜单不在数据集中时视为数据库中已存在的菜单
+func sortMenus(menus []Menu) ([]Menu, error) {
+	inDataset := make(map[string]bool, len(menus))
+	for _, menu := range menus {
+		inDataset[menu.Name] = true
+	}
+
+	sorted := make([]Menu, 0, len(menus))
+	placed := make(map[string]bool, len(menus))
+	for len(sorted) < len(menus) {
+		progress := false
+		for _, menu := range menus {
+			if placed[menu.Name] {
+				continue
+			}
+			if menu.Parent == "" || !inDataset[menu.Parent] || placed[menu.Parent] {
+				sorted = append(sorted, menu)
+				placed[menu.Name] = true
+				progress = true
+			}
+		}
+		if !progress {
+			return nil, fmt.Errorf("菜单上级关系存在循环")
+		}
+	}
+	return sorted, nil
+}
+
+// withDefault 值为空时使用默认值
+func withDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// contains 列表中是否包含该值
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys map 的键，按字典序排列
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,85 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"rentPro/rentpro-admin/common/models/rental"
+)
+
+// seedRegions 写入城市、区域、商圈，自然键均为编码
+func (s *Seeder) seedRegions(ds *Dataset) error {
+	for _, item := range ds.Cities {
+		city := &rental.SysCity{
+			Code:   item.Code,
+			Name:   item.Name,
+			Sort:   item.Sort,
+			Status: item.Status,
+		}
+		where := map[string]interface{}{"code": item.Code}
+		if err := s.upsert(DatasetRegions, "城市 "+item.Code, city, where, []string{"name", "sort", "status"}); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range ds.Districts {
+		if s.isDeleted(DatasetRegions, "城市 "+item.City) {
+			s.skipDeleted(DatasetRegions, "区域 "+item.Code, "城市已删除")
+			continue
+		}
+		var city rental.SysCity
+		err := s.tx.Where("code = ?", item.City).Take(&city).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("区域 %s 的城市不存在: %s", item.Code, item.City)
+		}
+		if err != nil {
+			return fmt.Errorf("查询城市 %s 失败: %v", item.City, err)
+		}
+
+		district := &rental.SysDistrict{
+			Code:     item.Code,
+			Name:     item.Name,
+			CityCode: city.Code,
+			CityID:   city.ID,
+			Sort:     item.Sort,
+			Status:   item.Status,
+		}
+		where := map[string]interface{}{"code": item.Code}
+		columns := []string{"name", "city_code", "city_id", "sort", "status"}
+		if err := s.upsert(DatasetRegions, "区域 "+item.Code, district, where, columns); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range ds.BusinessAreas {
+		if s.isDeleted(DatasetRegions, "区域 "+item.District) {
+			s.skipDeleted(DatasetRegions, "商圈 "+item.Code, "区域已删除")
+			continue
+		}
+		var district rental.SysDistrict
+		err := s.tx.Where("code = ?", item.District).Take(&district).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("商圈 %s 的区域不存在: %s", item.Code, item.District)
+		}
+		if err != nil {
+			return fmt.Errorf("查询区域 %s 失败: %v", item.District, err)
+		}
+
+		area := &rental.SysBusinessArea{
+			Code:       item.Code,
+			Name:       item.Name,
+			DistrictID: district.ID,
+			CityCode:   district.CityCode,
+			Sort:       item.Sort,
+			Status:     item.Status,
+		}
+		where := map[string]interface{}{"code": item.Code}
+		columns := []string{"name", "district_id", "city_code", "sort", "status"}
+		if err := s.upsert(DatasetRegions, "商圈 "+item.Code, area, where, columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// 数据集名称，按依赖顺序排列
+const (
+	DatasetDepts     = "depts"
+	DatasetPosts     = "posts"
+	DatasetRoles     = "roles"
+	DatasetMenus     = "menus"
+	DatasetRoleMenus = "role_menus"
+	DatasetUsers     = "users"
+	DatasetRegions   = "regions" // 城市、区域、商圈
+)
+
+// Datasets 所有数据集，按依赖顺序排列
+var Datasets = []string{DatasetDepts, DatasetPosts, DatasetRoles, DatasetMenus, DatasetRoleMenus, DatasetUsers, DatasetRegions}
+
+// createOnly 默认只创建不更新的数据集：角色、用户记录权限和账号状态，后台修改后不应被覆盖，
+// 指定 Options.Update 时才更新
+var createOnly = []string{DatasetRoles, DatasetUsers}
+
+// 记录变更类型
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+	ActionSkipped   = "skipped" // 已软删除、上级已删除，或只创建的数据集中与数据集不一致的记录
+)
+
+// FieldChange 字段变更
+type FieldChange struct {
+	Field string
+	Old   interface{}
+	New   interface{}
+}
+
+// Change 一条记录的变更
+type Change struct {
+	Dataset string
+	Key     string // 自然键
+	Action  string
+	Fields  []FieldChange // 更新的字段（跳过时为不一致的字段）
+	Reason  string        // 跳过原因
+}
+
+// Report 写入结果
+type Report struct {
+	DryRun  bool
+	Changes []Change
+}
+
+// Count 某类变更的记录数
+func (r *Report) Count(action string) int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Options 写入选项
+type Options struct {
+	Only   []string // 只写入这些数据集，为空写入全部
+	DryRun bool     // 只比对不写入
+	Update bool     // 更新角色、用户中与数据集不一致的字段（默认只创建）
+}
+
+// errDryRun 预览结束后回滚事务
+var errDryRun = errors.New("dry run")
+
+// Seeder 数据集写入器
+type Seeder struct {
+	tx      *gorm.DB
+	report  *Report
+	update  bool
+	deleted map[string]bool // 已软删除或因上级删除而跳过的记录，数据集:自然键
+}
+
+// Run 按依赖顺序写入数据集
+// 所有数据集在一个事务中写入，任何错误都整体回滚；预览时同样执行写入，结束后回滚，
+// 因此预览结果与实际写入一致（包括同一次写入中新建的上级部门、菜单等引用）
+func Run(db *gorm.DB, ds *Dataset, opts Options) (*Report, error) {
+	for _, name := range opts.Only {
+		if !contains(Datasets, name) {
+			return nil, fmt.Errorf("未知的数据集: %s，可选: %s", name, strings.Join(Datasets, ", "))
+		}
+	}
+
+	report := &Report{DryRun: opts.DryRun}
+	steps := []struct {
+		name string
+		fn   func(s *Seeder, ds *Dataset) error
+	}{
+		{DatasetDepts, (*Seeder).seedDepts},
+		{DatasetPosts, (*Seeder).seedPosts},
+		{DatasetRoles, (*Seeder).seedRoles},
+		{DatasetMenus, (*Seeder).seedMenus},
+		{DatasetRoleMenus, (*Seeder).seedRoleMenus},
+		{DatasetUsers, (*Seeder).seedUsers},
+		{DatasetRegions, (*Seeder).seedRegions},
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		s := &Seeder{tx: tx, report: report, update: opts.Update, deleted: make(map[string]bool)}
+		for _, step := range steps {
+			if len(opts.Only) > 0 && !contains(opts.Only, step.name) {
+				continue
+			}
+			if err := step.fn(s, ds); err != nil {
+				return fmt.Errorf("写入 %s 失败: %v", step.name, err)
+			}
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return report, nil
+}
+
+// upsert 按自然键 where 查询记录（包括已软删除的记录）
+// 不存在时创建 model；存在时比对 columns 中的字段，更新不一致的字段（只创建的数据集未指定 Options.Update 时跳过）；
+// 已软删除的记录跳过，不恢复、不更新
+// 执行后 model 的主键为数据库中记录的主键
+func (s *Seeder) upsert(dataset, key string, model interface{}, where map[string]interface{}, columns []string) error {
+	stmt := &gorm.Statement{DB: s.tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	sch := stmt.Schema
+	ctx := context.Background()
+	value := reflect.ValueOf(model).Elem()
+
+	existing := reflect.New(sch.ModelType)
+	err := s.tx.Unscoped().Where(where).Take(existing.Interface()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := s.tx.Create(model).Error; err != nil {
+			return fmt.Errorf("创建 %s 失败: %v", key, err)
+		}
+		// 有列默认值的字段为零值时，创建会写入列默认值，需要单独更新
+		var zero []string
+		for _, column := range columns {
+			field := sch.LookUpField(column)
+			if _, isZero := field.ValueOf(ctx, value); isZero && field.HasDefaultValue {
+				zero = append(zero, field.DBName)
+			}
+		}
+		if len(zero) > 0 {
+			if err := s.tx.Model(model).Select(zero).Updates(model).Error; err != nil {
+				return fmt.Errorf("创建 %s 失败: %v", key, err)
+			}
+		}
+		s.report.Changes = append(s.report.Changes, Change{Dataset: dataset, Key: key, Action: ActionCreate})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询 %s 失败: %v", key, err)
+	}
+
+	pk := sch.PrioritizedPrimaryField
+	id, _ := pk.ValueOf(ctx, existing.Elem())
+	if err := pk.Set(ctx, value, id); err != nil {
+		return err
+	}
+
+	if deleted := deletedAt(ctx, sch, existing.Elem()); deleted != nil {
+		s.skipDeleted(dataset, key, "已于 "+deleted.Time.Format("2006-01-02 15:04:05")+" 删除，不恢复")
+		return nil
+	}
+
+	change := Change{Dataset: dataset, Key: key, Action: ActionUnchanged}
+	var changed []string
+	for _, column := range columns {
+		field := sch.LookUpField(column)
+		oldValue, _ := field.ValueOf(ctx, existing.Elem())
+		newValue, _ := field.ValueOf(ctx, value)
+		if fmt.Sprint(oldValue) != fmt.Sprint(newValue) {
+			changed = append(changed, field.DBName)
+			change.Fields = append(change.Fields, FieldChange{Field: field.DBName, Old: oldValue, New: newValue})
+		}
+	}
+
+	if len(changed) > 0 && !s.updates(dataset) {
+		change.Action = ActionSkipped
+		change.Reason = "已存在，使用 --update 更新"
+	} else if len(changed) > 0 {
+		change.Action = ActionUpdate
+		if err := s.tx.Model(model).Select(changed).Updates(model).Error; err != nil {
+			return fmt.Errorf("更新 %s 失败: %v", key, err)
+		}
+	}
+	s.report.Changes = append(s.report.Changes, change)
+	return nil
+}
+
+// updates 数据集中已存在的记录是否更新
+func (s *Seeder) updates(dataset string) bool {
+	return s.update || !contains(createOnly, dataset)
+}
+
+// skipDeleted 跳过已软删除或上级已删除的记录，下级记录同样跳过
+func (s *Seeder) skipDeleted(dataset, key, reason string) {
+	s.deleted[dataset+":"+key] = true
+	s.report.Changes = append(s.report.Changes, Change{Dataset: dataset, Key: key, Action: ActionSkipped, Reason: reason})
+}
+
+// isDeleted 记录是否已软删除或因上级删除而跳过
+func (s *Seeder) isDeleted(dataset, key string) bool {
+	return s.deleted[dataset+":"+key]
+}
+
+// deletedAt 已软删除记录的删除时间，未删除或模型不支持软删除时返回 nil
+func deletedAt(ctx context.Context, sch *schema.Schema, value reflect.Value) *gorm.DeletedAt {
+	field := sch.LookUpField("deleted_at")
+	if field == nil {
+		return nil
+	}
+	v, _ := field.ValueOf(ctx, value)
+	if deleted, ok := v.(gorm.DeletedAt); ok && deleted.Valid {
+		return &deleted
+	}
+	return nil
+}
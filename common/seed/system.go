@@ -0,0 +1,336 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"rentPro/rentpro-admin/common/models/system"
+)
+
+// seedDepts 写入部门，自然键为（上级部门, 部门名称），部门路径 dept_path 按上级部门生成
+func (s *Seeder) seedDepts(ds *Dataset) error {
+	for _, item := range ds.Depts {
+		parentID, parentPath := uint(0), "0"
+		name := item.Path
+		if i := strings.LastIndex(item.Path, "/"); i >= 0 {
+			if s.isDeleted(DatasetDepts, item.Path[:i]) {
+				s.skipDeleted(DatasetDepts, item.Path, "上级部门已删除")
+				continue
+			}
+			parent, err := s.findDept(item.Path[:i])
+			if err != nil {
+				return err
+			}
+			parentID, parentPath = parent.ID, parent.DeptPath
+			name = item.Path[i+1:]
+		}
+
+		dept := &system.SysDept{
+			ParentID: parentID,
+			DeptName: name,
+			Sort:     item.Sort,
+			Leader:   item.Leader,
+			Phone:    item.Phone,
+			Email:    item.Email,
+			Status:   item.Status,
+		}
+		// 已存在的部门先取ID生成部门路径，新建的部门创建后补写
+		var existing system.SysDept
+		err := s.tx.Unscoped().Where("parent_id = ? AND dept_name = ?", parentID, name).Take(&existing).Error
+		if err == nil {
+			dept.DeptPath = deptPath(parentPath, existing.ID)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("查询部门 %s 失败: %v", item.Path, err)
+		}
+
+		where := map[string]interface{}{"parent_id": parentID, "dept_name": name}
+		columns := []string{"dept_path", "sort", "leader", "phone", "email", "status"}
+		if err := s.upsert(DatasetDepts, item.Path, dept, where, columns); err != nil {
+			return err
+		}
+		if dept.DeptPath == "" {
+			err := s.tx.Model(dept).Update("dept_path", deptPath(parentPath, dept.ID)).Error
+			if err != nil {
+				return fmt.Errorf("更新部门 %s 路径失败: %v", item.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// deptPath 部门路径：上级部门路径,部门ID
+func deptPath(parentPath string, id uint) string {
+	return parentPath + "," + strconv.FormatUint(uint64(id), 10)
+}
+
+// findDept 按部门路径查询部门
+func (s *Seeder) findDept(path string) (*system.SysDept, error) {
+	var dept *system.SysDept
+	parentID := uint(0)
+	for _, name := range strings.Split(path, "/") {
+		// 每级使用新的变量，主键不为零时 Take 会附加主键条件
+		dept = &system.SysDept{}
+		err := s.tx.Where("parent_id = ? AND dept_name = ?", parentID, name).Take(dept).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("部门不存在: %s", path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("查询部门 %s 失败: %v", path, err)
+		}
+		parentID = dept.ID
+	}
+	return dept, nil
+}
+
+// seedPosts 写入岗位，自然键为岗位编码
+func (s *Seeder) seedPosts(ds *Dataset) error {
+	for _, item := range ds.Posts {
+		post := &system.SysPost{
+			PostCode: item.Code,
+			PostName: item.Name,
+			Sort:     item.Sort,
+			Status:   item.Status,
+			Remark:   item.Remark,
+		}
+		where := map[string]interface{}{"post_code": item.Code}
+		columns := []string{"post_name", "sort", "status", "remark"}
+		if err := s.upsert(DatasetPosts, item.Code, post, where, columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedRoles 写入角色，自然键为角色标识
+func (s *Seeder) seedRoles(ds *Dataset) error {
+	for _, item := range ds.Roles {
+		role := &system.SysRole{
+			Name:      item.Name,
+			Key:       item.Key,
+			Status:    item.Status,
+			Sort:      item.Sort,
+			Flag:      item.Flag,
+			Remark:    item.Remark,
+			Admin:     item.Admin,
+			DataScope: item.DataScope,
+			Params:    item.Params,
+		}
+		where := map[string]interface{}{"key": item.Key}
+		columns := []string{"name", "status", "sort", "flag", "remark", "admin", "data_scope", "params"}
+		if err := s.upsert(DatasetRoles, item.Key, role, where, columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedMenus 写入菜单，自然键为菜单名称
+func (s *Seeder) seedMenus(ds *Dataset) error {
+	for _, item := range ds.Menus {
+		var parentID uint
+		if item.Parent != "" {
+			if s.isDeleted(DatasetMenus, item.Parent) {
+				s.skipDeleted(DatasetMenus, item.Name, "上级菜单已删除")
+				continue
+			}
+			parent, err := s.findMenu(item.Parent)
+			if err != nil {
+				return err
+			}
+			parentID = parent.ID
+		}
+
+		menu := &system.SysMenu{
+			Name:       item.Name,
+			Title:      item.Title,
+			Icon:       item.Icon,
+			Path:       item.Path,
+			Redirect:   item.Redirect,
+			Component:  item.Component,
+			Permission: item.Permission,
+			ParentID:   parentID,
+			Type:       item.Type,
+			Sort:       item.Sort,
+			Visible:    item.Visible,
+			IsFrame:    item.IsFrame,
+			IsCache:    item.IsCache,
+			MenuType:   item.MenuType,
+			Status:     item.Status,
+			Perms:      item.Perms,
+		}
+		where := map[string]interface{}{"name": item.Name}
+		columns := []string{"title", "icon", "path", "redirect", "component", "permission", "parent_id",
+			"type", "sort", "visible", "is_frame", "is_cache", "menu_type", "status", "perms"}
+		if err := s.upsert(DatasetMenus, item.Name, menu, where, columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findMenu 按菜单名称查询菜单
+func (s *Seeder) findMenu(name string) (*system.SysMenu, error) {
+	var menu system.SysMenu
+	err := s.tx.Where("name = ?", name).Take(&menu).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("菜单不存在: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询菜单 %s 失败: %v", name, err)
+	}
+	return &menu, nil
+}
+
+// seedRoleMenus 写入角色菜单关联，只添加缺少的关联，不删除数据集以外的关联（可能是后台分配的权限）
+// 已删除的角色、菜单跳过
+func (s *Seeder) seedRoleMenus(ds *Dataset) error {
+	for _, roleKey := range sortedKeys(ds.RoleMenus) {
+		if s.isDeleted(DatasetRoles, roleKey) {
+			continue
+		}
+		role, err := s.findRole(roleKey)
+		if err != nil {
+			return err
+		}
+
+		var menus []system.SysMenu
+		names := ds.RoleMenus[roleKey]
+		if contains(names, AllMenus) {
+			if err := s.tx.Order("id ASC").Find(&menus).Error; err != nil {
+				return fmt.Errorf("查询菜单失败: %v", err)
+			}
+		} else {
+			for _, name := range names {
+				if s.isDeleted(DatasetMenus, name) {
+					continue
+				}
+				menu, err := s.findMenu(name)
+				if err != nil {
+					return err
+				}
+				menus = append(menus, *menu)
+			}
+		}
+
+		var assigned []uint
+		err = s.tx.Table("sys_role_menu").Where("sys_role_id = ?", role.ID).Pluck("sys_menu_id", &assigned).Error
+		if err != nil {
+			return fmt.Errorf("查询角色 %s 菜单失败: %v", roleKey, err)
+		}
+		has := make(map[uint]bool, len(assigned))
+		for _, id := range assigned {
+			has[id] = true
+		}
+
+		for _, menu := range menus {
+			change := Change{Dataset: DatasetRoleMenus, Key: roleKey + " → " + menu.Name, Action: ActionUnchanged}
+			if !has[menu.ID] {
+				err := s.tx.Table("sys_role_menu").Create(map[string]interface{}{
+					"sys_role_id": role.ID,
+					"sys_menu_id": menu.ID,
+				}).Error
+				if err != nil {
+					return fmt.Errorf("添加角色 %s 菜单 %s 失败: %v", roleKey, menu.Name, err)
+				}
+				has[menu.ID] = true
+				change.Action = ActionCreate
+			}
+			s.report.Changes = append(s.report.Changes, change)
+		}
+	}
+	return nil
+}
+
+// findRole 按角色标识查询角色
+func (s *Seeder) findRole(key string) (*system.SysRole, error) {
+	var role system.SysRole
+	err := s.tx.Where(map[string]interface{}{"key": key}).Take(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("角色不存在: %s", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询角色 %s 失败: %v", key, err)
+	}
+	return &role, nil
+}
+
+// seedUsers 写入用户，自然键为用户名；密码只在创建时设置
+// 已存在的用户未指定 Options.Update 时跳过，已删除的用户不恢复
+func (s *Seeder) seedUsers(ds *Dataset) error {
+	for _, item := range ds.Users {
+		var existing system.SysUser
+		err := s.tx.Unscoped().Where("username = ?", item.Username).Take(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("查询用户 %s 失败: %v", item.Username, err)
+		}
+		exists := err == nil
+		if exists && existing.DeletedAt.Valid {
+			s.skipDeleted(DatasetUsers, item.Username, "已于 "+existing.DeletedAt.Time.Format("2006-01-02 15:04:05")+" 删除，不恢复")
+			continue
+		}
+		if exists && !s.updates(DatasetUsers) {
+			// 不查询部门、岗位、角色，它们可能已在后台删除
+			s.report.Changes = append(s.report.Changes, Change{Dataset: DatasetUsers, Key: item.Username, Action: ActionUnchanged})
+			continue
+		}
+
+		user := &system.SysUser{
+			Username: item.Username,
+			NickName: item.NickName,
+			Avatar:   item.Avatar,
+			Email:    item.Email,
+			Phone:    item.Phone,
+			Status:   item.Status,
+			IsAdmin:  item.IsAdmin,
+			Remark:   item.Remark,
+		}
+		if item.Dept != "" {
+			dept, err := s.findDept(item.Dept)
+			if err != nil {
+				return err
+			}
+			user.DeptID = dept.ID
+		}
+		if item.Post != "" {
+			var post system.SysPost
+			err := s.tx.Where("post_code = ?", item.Post).Take(&post).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("岗位不存在: %s", item.Post)
+			}
+			if err != nil {
+				return fmt.Errorf("查询岗位 %s 失败: %v", item.Post, err)
+			}
+			user.PostID = post.ID
+		}
+		if item.Role != "" {
+			role, err := s.findRole(item.Role)
+			if err != nil {
+				return err
+			}
+			user.RoleID = role.ID
+		}
+
+		// 初始密码，已存在的用户不使用
+		user.Password = item.Password
+		if item.PasswordEnv != "" {
+			if password := os.Getenv(item.PasswordEnv); password != "" {
+				user.Password = password
+			}
+		}
+		if !exists && user.Password == "" {
+			return fmt.Errorf("用户 %s 缺少初始密码（password 或环境变量 password_env）", item.Username)
+		}
+
+		where := map[string]interface{}{"username": item.Username}
+		columns := []string{"nick_name", "avatar", "email", "phone", "status", "is_admin", "remark", "dept_id", "post_id", "role_id"}
+		if err := s.upsert(DatasetUsers, item.Username, user, where, columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
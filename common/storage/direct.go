@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"net/http"
+	"time"
+)
+
+// UploadPolicy 浏览器直传的限制条件
+type UploadPolicy struct {
+	MaxSize      int64         // 最大文件大小
+	MimeTypes    []string      // 允许的文件类型，驱动不支持时忽略（上传完成后服务端仍会检查）
+	Expires      time.Duration // 上传凭证有效期
+	CallbackURL  string        // 上传完成后存储服务回调的地址，为空时不回调
+	CallbackBody string        // 回调请求体（application/x-www-form-urlencoded）
+}
+
+// DirectUpload 浏览器直传参数
+// 客户端以 multipart/form-data 将 Fields 和文件（字段名 FileField）提交到 URL
+type DirectUpload struct {
+	Method         string            `json:"method"`         // 请求方法
+	URL            string            `json:"url"`            // 上传地址
+	Fields         map[string]string `json:"fields"`         // 需要一并提交的表单字段
+	FileField      string            `json:"fileField"`      // 文件字段名
+	ServerCallback bool              `json:"serverCallback"` // 存储服务是否会回调服务端，为 false 时客户端上传后需自行调用回调接口
+}
+
+// DirectUploader 支持浏览器直传的存储驱动
+type DirectUploader interface {
+	// DirectUpload 生成上传到指定 key 的直传参数
+	DirectUpload(key string, policy UploadPolicy) (*DirectUpload, error)
+}
+
+// CallbackVerifier 支持验证上传回调签名的存储驱动
+type CallbackVerifier interface {
+	// IsCallback 请求是否带有存储服务的回调签名
+	IsCallback(req *http.Request) bool
+	// VerifyCallback 验证回调签名
+	VerifyCallback(req *http.Request) (bool, error)
+}
@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSignature 签名URL的签名无效或已过期
+var ErrInvalidSignature = errors.New("签名无效或已过期")
+
+// Local 本地磁盘存储
+// 文件保存在 root 目录下，通过 API 服务的文件路由（默认 /uploads，见 Serve）访问，
+// 用于本地开发和无法访问云存储的离线测试环境
+type Local struct {
+	root       string
+	baseURL    string
+	signSecret string
+	// private 私有模式，只能通过签名URL访问（对应云存储的私有空间）
+	private bool
+}
+
+// NewLocal 创建本地磁盘存储
+// baseURL 为访问URL前缀，如 /uploads 或 http://localhost:8002/uploads；private 为 true 时必须配置 signSecret
+func NewLocal(root, baseURL, signSecret string, private bool) (*Local, error) {
+	if root == "" {
+		return nil, fmt.Errorf("本地存储目录不能为空")
+	}
+	if private && signSecret == "" {
+		return nil, fmt.Errorf("本地存储为私有模式时必须配置 sign_secret")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("创建本地存储目录失败: %v", err)
+	}
+	return &Local{
+		root:       root,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signSecret: signSecret,
+		private:    private,
+	}, nil
+}
+
+// Driver 驱动名称
+func (l *Local) Driver() string {
+	return DriverLocal
+}
+
+// path 将存储key转换为本地文件路径，拒绝跳出根目录的key
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || strings.HasSuffix(key, "/") {
+		return "", fmt.Errorf("无效的存储key: %s", key)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(clean[1:])), nil
+}
+
+// Put 上传文件，先写临时文件再重命名，避免读到写了一半的文件
+func (l *Local) Put(key string, r io.Reader, size int64, contentType string) (*Object, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha1.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("写入文件失败: %v", err)
+	}
+	if size >= 0 && written != size {
+		return nil, fmt.Errorf("文件大小不一致: 期望 %d bytes，实际 %d bytes", size, written)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return nil, fmt.Errorf("保存文件失败: %v", err)
+	}
+
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(p))
+	}
+	return &Object{
+		Key:      key,
+		Size:     written,
+		Hash:     hex.EncodeToString(hash.Sum(nil)),
+		MimeType: contentType,
+		PutTime:  time.Now(),
+	}, nil
+}
+
+// Get 读取文件内容
+func (l *Local) Get(key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return f, nil
+}
+
+// Delete 删除文件
+func (l *Local) Delete(key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("删除文件失败: %v", err)
+	}
+	return nil
+}
+
+// Stat 获取文件信息
+// 本地驱动不保存上传时的hash，Hash 为空
+func (l *Local) Stat(key string) (*Object, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+	if info.IsDir() {
+		return nil, ErrNotFound
+	}
+	return &Object{
+		Key:      key,
+		Size:     info.Size(),
+		MimeType: mime.TypeByExtension(filepath.Ext(p)),
+		PutTime:  info.ModTime(),
+	}, nil
+}
+
+// List 按前缀列出文件，按key字典序返回，marker 为上一页最后一个key
+func (l *Local) List(prefix, marker string, limit int) ([]Object, string, error) {
+	var keys []string
+	err := filepath.WalkDir(l.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) && key > marker {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("列出文件失败: %v", err)
+	}
+	sort.Strings(keys)
+
+	next := ""
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+		next = keys[limit-1]
+	}
+
+	objects := make([]Object, 0, len(keys))
+	for _, key := range keys {
+		obj, err := l.Stat(key)
+		if err != nil {
+			continue
+		}
+		objects = append(objects, *obj)
+	}
+	return objects, next, nil
+}
+
+// PublicURL 文件的访问URL
+func (l *Local) PublicURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return l.baseURL + "/" + strings.Join(segments, "/")
+}
+
+// SignedURL 带有效期签名的访问URL，由 Serve 校验签名和有效期
+func (l *Local) SignedURL(key string, expires time.Duration) (string, error) {
+	deadline := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	return fmt.Sprintf("%s?e=%s&token=%s", l.PublicURL(key), deadline, l.sign(key, deadline)), nil
+}
+
+// sign 计算签名URL的 token
+func (l *Local) sign(key, deadline string) string {
+	mac := hmac.New(sha256.New, []byte(l.signSecret))
+	mac.Write([]byte(key + ":" + deadline))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名URL的 e（过期时间）和 token 参数
+func (l *Local) Verify(key, deadline, token string) error {
+	expiresAt, err := strconv.ParseInt(deadline, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(token), []byte(l.sign(key, deadline))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Serve 提供本地文件的访问，key 为去掉访问URL前缀后的路径
+// 请求带签名参数时校验签名和有效期，私有模式下必须带有效签名；不提供目录和上传中的临时文件
+func (l *Local) Serve(w http.ResponseWriter, r *http.Request, key string) {
+	query := r.URL.Query()
+	if l.private || query.Has("e") || query.Has("token") {
+		if err := l.Verify(key, query.Get("e"), query.Get("token")); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	p, err := l.path(key)
+	if err != nil || strings.HasPrefix(path.Base(key), ".upload-") {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// StyleURL 本地驱动不做图片处理，返回原URL
+func (l *Local) StyleURL(url, style string) string {
+	return url
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"rentPro/rentpro-admin/common/config"
+)
+
+// S3 S3兼容对象存储（AWS S3、MinIO 等）
+type S3 struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+// NewS3 创建S3存储
+func NewS3(cfg *config.S3Config) (*S3, error) {
+	lookup := minio.BucketLookupDNS
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建S3客户端失败: %v", err)
+	}
+
+	publicURL := strings.TrimRight(cfg.PublicURL, "/")
+	if publicURL == "" {
+		scheme := "http"
+		if cfg.UseSSL {
+			scheme = "https"
+		}
+		if cfg.PathStyle {
+			publicURL = fmt.Sprintf("%s://%s/%s", scheme, cfg.Endpoint, cfg.Bucket)
+		} else {
+			publicURL = fmt.Sprintf("%s://%s.%s", scheme, cfg.Bucket, cfg.Endpoint)
+		}
+	}
+
+	return &S3{
+		client:    client,
+		bucket:    cfg.Bucket,
+		publicURL: publicURL,
+	}, nil
+}
+
+// Driver 驱动名称
+func (s *S3) Driver() string {
+	return DriverS3
+}
+
+// CheckBucket 检查存储桶是否存在，用于启动时验证配置
+func (s *S3) CheckBucket() error {
+	exists, err := s.client.BucketExists(context.Background(), s.bucket)
+	if err != nil {
+		return fmt.Errorf("连接S3失败: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("存储桶不存在: %s", s.bucket)
+	}
+	return nil
+}
+
+// Put 上传文件，key 原样使用
+func (s *S3) Put(key string, r io.Reader, size int64, contentType string) (*Object, error) {
+	info, err := s.client.PutObject(context.Background(), s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("上传到S3失败: %v", err)
+	}
+	return &Object{
+		Key:      key,
+		Size:     info.Size,
+		Hash:     info.ETag,
+		MimeType: contentType,
+		PutTime:  time.Now(),
+	}, nil
+}
+
+// Get 读取文件内容
+func (s *S3) Get(key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(context.Background(), s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	// GetObject 不会立即请求，通过 Stat 确认对象存在
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).StatusCode == 404 {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return obj, nil
+}
+
+// Delete 删除文件
+// S3 删除不存在的对象不会报错，先查询以保持与其他驱动一致的 ErrNotFound
+func (s *S3) Delete(key string) error {
+	if _, err := s.Stat(key); err != nil {
+		return err
+	}
+	if err := s.client.RemoveObject(context.Background(), s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("删除文件失败: %v", err)
+	}
+	return nil
+}
+
+// Stat 获取文件信息
+func (s *S3) Stat(key string) (*Object, error) {
+	info, err := s.client.StatObject(context.Background(), s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).StatusCode == 404 {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+	return &Object{
+		Key:      key,
+		Size:     info.Size,
+		Hash:     info.ETag,
+		MimeType: info.ContentType,
+		PutTime:  info.LastModified,
+	}, nil
+}
+
+// List 按前缀分页列出文件，marker 为上一页最后一个key
+func (s *S3) List(prefix, marker string, limit int) ([]Object, string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var objects []Object
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:     prefix,
+		StartAfter: marker,
+		Recursive:  true,
+	}) {
+		if info.Err != nil {
+			return nil, "", fmt.Errorf("列出文件失败: %v", info.Err)
+		}
+		if limit > 0 && len(objects) == limit {
+			// 还有更多数据，以本页最后一个key作为下一页位置
+			return objects, objects[len(objects)-1].Key, nil
+		}
+		objects = append(objects, Object{
+			Key:      info.Key,
+			Size:     info.Size,
+			Hash:     info.ETag,
+			MimeType: info.ContentType,
+			PutTime:  info.LastModified,
+		})
+	}
+	return objects, "", nil
+}
+
+// PublicURL 文件的公开访问URL（存储桶需配置公共读或通过CDN访问）
+func (s *S3) PublicURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return s.publicURL + "/" + strings.Join(segments, "/")
+}
+
+// SignedURL 预签名下载URL
+func (s *S3) SignedURL(key string, expires time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(context.Background(), s.bucket, key, expires, nil)
+	if err != nil {
+		return "", fmt.Errorf("生成预签名URL失败: %v", err)
+	}
+	return u.String(), nil
+}
+
+// StyleURL S3 不做图片处理，返回原URL
+func (s *S3) StyleURL(url, style string) string {
+	return url
+}
+
+// DirectUpload 生成预签名 POST 表单，浏览器直接上传到存储桶
+// S3 没有上传回调，客户端上传完成后需自行调用服务端回调接口
+func (s *S3) DirectUpload(key string, policy UploadPolicy) (*DirectUpload, error) {
+	postPolicy := minio.NewPostPolicy()
+	if err := postPolicy.SetBucket(s.bucket); err != nil {
+		return nil, err
+	}
+	if err := postPolicy.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := postPolicy.SetExpires(time.Now().UTC().Add(policy.Expires)); err != nil {
+		return nil, err
+	}
+	if policy.MaxSize > 0 {
+		if err := postPolicy.SetContentLengthRange(1, policy.MaxSize); err != nil {
+			return nil, err
+		}
+	}
+
+	u, fields, err := s.client.PresignedPostPolicy(context.Background(), postPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("生成直传签名失败: %v", err)
+	}
+	return &DirectUpload{
+		Method:    "POST",
+		URL:       u.String(),
+		Fields:    fields,
+		FileField: "file",
+	}, nil
+}
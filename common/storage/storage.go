@@ -0,0 +1,64 @@
+// Package storage 定义文件存储后端接口
+// 图片等文件的上传、删除、查询和访问URL生成都通过 Storage 接口完成，
+// 具体由配置选择的驱动实现（七牛云、本地磁盘等）
+package storage
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// 存储驱动名称
+const (
+	DriverQiniu = "qiniu" // 七牛云
+	DriverLocal = "local" // 本地磁盘
+	DriverS3    = "s3"    // S3兼容对象存储
+)
+
+// ErrNotFound 文件不存在
+var ErrNotFound = errors.New("文件不存在")
+
+// Object 存储中的文件信息
+type Object struct {
+	Key      string    `json:"key"`      // 存储key
+	Size     int64     `json:"size"`     // 文件大小
+	Hash     string    `json:"hash"`     // 文件hash（由驱动计算，不同驱动算法不同）
+	MimeType string    `json:"mimeType"` // 文件类型
+	PutTime  time.Time `json:"putTime"`  // 上传时间
+}
+
+// Storage 文件存储后端
+type Storage interface {
+	// Driver 驱动名称
+	Driver() string
+	// Put 上传文件，key 已存在时覆盖
+	Put(key string, r io.Reader, size int64, contentType string) (*Object, error)
+	// Get 读取文件内容，调用方负责关闭，文件不存在时返回 ErrNotFound
+	Get(key string) (io.ReadCloser, error)
+	// Delete 删除文件，文件不存在时返回 ErrNotFound
+	Delete(key string) error
+	// Stat 获取文件信息，文件不存在时返回 ErrNotFound
+	Stat(key string) (*Object, error)
+	// List 按前缀列出文件，marker 为上一页返回的位置，返回下一页位置（为空表示没有更多）
+	List(prefix, marker string, limit int) ([]Object, string, error)
+	// PublicURL 文件的公开访问URL
+	PublicURL(key string) string
+	// SignedURL 带有效期的私有访问URL
+	SignedURL(key string, expires time.Duration) (string, error)
+	// StyleURL 图片样式URL（缩略图等），驱动不支持的样式返回原URL
+	StyleURL(url, style string) string
+}
+
+// 全局存储实例
+var defaultStorage Storage
+
+// SetDefault 设置全局存储实例
+func SetDefault(s Storage) {
+	defaultStorage = s
+}
+
+// Default 获取全局存储实例，未初始化时返回 nil
+func Default() Storage {
+	return defaultStorage
+}
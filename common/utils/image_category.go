@@ -0,0 +1,384 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
+
+	"rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/models/image"
+)
+
+// 图片分类规则
+//
+// sys_image_categories 按分类配置上传限制：MaxSize 最大文件大小、AllowedTypes 允许的文件类型、
+// MaxCount 每个关联对象（模块 + 模块ID）该分类的最大图片数、IsRequired 是否必填。
+// 所有上传方式和修改图片分类都按图片分类校验；分类未配置时使用 default 分类，default 也不存在时只按全局上传配置校验。
+// 分类未配置大小、类型时使用全局上传配置（settings.storage.upload）。
+
+const (
+	// defaultCategory 未配置分类时使用的分类编码
+	defaultCategory = "default"
+	// floorPlanCategory 户型图分类编码
+	floorPlanCategory = "floor_plan"
+)
+
+// RejectedError 上传内容不符合要求（文件大小、类型、数量、图片内容等）
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return e.Reason
+}
+
+// rejectf 生成上传不符合要求的错误
+func rejectf(format string, args ...interface{}) error {
+	return &RejectedError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// IsRejected 是否为上传内容不符合要求的错误
+func IsRejected(err error) bool {
+	var rejected *RejectedError
+	return errors.As(err, &rejected)
+}
+
+// uploadRules 一个图片分类的上传限制
+type uploadRules struct {
+	category     string   // 分类编码
+	name         string   // 分类名称，用于提示
+	maxSize      int64    // 最大文件大小
+	allowedTypes []string // 允许的文件类型
+	maxCount     int      // 每个关联对象的最大图片数，0 为不限制
+}
+
+// categoryRules 获取分类的上传限制
+func (im *ImageManager) categoryRules(code string) (*uploadRules, error) {
+	cfg := config.GetStorageConfig()
+	rules := &uploadRules{
+		category:     code,
+		name:         code,
+		maxSize:      cfg.Upload.MaxFileSize,
+		allowedTypes: cfg.Upload.AllowedTypes,
+	}
+
+	var category image.SysImageCategory
+	err := im.db.Where("code = ?", code).First(&category).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) && code != defaultCategory {
+		err = im.db.Where("code = ?", defaultCategory).First(&category).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return rules, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询图片分类失败: %v", err)
+	}
+	if category.Status != "" && category.Status != "active" {
+		return nil, rejectf("图片分类「%s」已停用", category.Name)
+	}
+
+	if category.Code == code {
+		rules.name = category.Name
+	}
+	if category.MaxSize > 0 {
+		rules.maxSize = category.MaxSize
+	}
+	if len(category.AllowedTypes) > 0 {
+		rules.allowedTypes = category.AllowedTypes
+	}
+	rules.maxCount = category.MaxCount
+	return rules, nil
+}
+
+// checkSize 检查文件大小
+func (r *uploadRules) checkSize(size int64) error {
+	if size > r.maxSize {
+		return rejectf("文件大小超过分类「%s」的限制: 最大 %s", r.name, formatSize(r.maxSize))
+	}
+	return nil
+}
+
+// checkType 检查文件类型（按文件头识别的真实类型）
+func (r *uploadRules) checkType(mimeType string) error {
+	for _, allowed := range r.allowedTypes {
+		if strings.EqualFold(allowed, mimeType) {
+			return nil
+		}
+	}
+	return rejectf("分类「%s」不支持的文件类型: %s，允许: %s", r.name, mimeType, strings.Join(r.allowedTypes, ", "))
+}
+
+// checkCount 检查关联对象该分类的图片数量，adding 为本次新增数量
+// 查询时锁定所属对象和该分类已有的图片记录，在事务中调用时计数检查和写入串行执行，并发上传不会超出限制
+// 检查后随即写入，始终查询主库
+func (r *uploadRules) checkCount(db *gorm.DB, module string, moduleID uint64, adding int) error {
+	if r.maxCount <= 0 || moduleID == 0 {
+		return nil
+	}
+	db = db.Clauses(dbresolver.Write).Session(&gorm.Session{})
+	locking := clause.Locking{Strength: "UPDATE"}
+	if owner, ok := imageOwners[module]; ok {
+		var ownerIDs []uint64
+		if err := db.Table(owner.table).Clauses(locking).Where("id = ?", moduleID).Pluck("id", &ownerIDs).Error; err != nil {
+			return fmt.Errorf("查询%s失败: %v", owner.title, err)
+		}
+	}
+	var ids []uint64
+	err := db.Model(&image.SysImage{}).
+		Clauses(locking).
+		Where("module = ? AND module_id = ? AND category = ?", module, moduleID, r.category).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return fmt.Errorf("查询现有图片数量失败: %v", err)
+	}
+	if len(ids)+adding > r.maxCount {
+		return rejectf("分类「%s」最多上传%d张图片，当前已有%d张", r.name, r.maxCount, len(ids))
+	}
+	return nil
+}
+
+// checkImage 检查已有图片是否符合分类的大小、类型限制，用于修改图片分类
+func (r *uploadRules) checkImage(img *image.SysImage) error {
+	if err := r.checkSize(img.FileSize); err != nil {
+		return err
+	}
+	return r.checkType(img.MimeType)
+}
+
+// createImage 在一个事务中检查分类数量并创建图片记录，stored 为图片的存储文件（见 saveImage）
+func (im *ImageManager) createImage(rules *uploadRules, img *image.SysImage, stored *storedFile) error {
+	return im.saveImage(img, stored, func(tx *gorm.DB) error {
+		return insertImage(tx, rules, img)
+	})
+}
+
+// insertImage 在事务中检查分类数量、创建图片记录并加入图库
+// 未指定顺序时排在图库末尾；IsMain 为 true 时经过 setMainImage 设为主图，最后同步所属对象的封面和图片列表
+func insertImage(tx *gorm.DB, rules *uploadRules, img *image.SysImage) error {
+	if err := rules.checkCount(tx, img.Module, img.ModuleID, 1); err != nil {
+		return err
+	}
+
+	if img.SortOrder == 0 && img.ModuleID > 0 {
+		var maxOrder int
+		err := tx.Model(&image.SysImage{}).
+			Where("module = ? AND module_id = ?", img.Module, img.ModuleID).
+			Select("COALESCE(MAX(sort_order), 0)").
+			Scan(&maxOrder).Error
+		if err != nil {
+			return fmt.Errorf("查询图库失败: %v", err)
+		}
+		img.SortOrder = maxOrder + 1
+	}
+
+	isMain := img.IsMain
+	img.IsMain = false
+	if err := tx.Create(img).Error; err != nil {
+		return fmt.Errorf("保存到数据库失败: %v", err)
+	}
+	if isMain {
+		img.IsMain = true
+		return setMainImage(tx, img.Module, img.ModuleID, img.ID, img.CreatedBy)
+	}
+	return syncOwnerImages(tx, img.Module, img.ModuleID)
+}
+
+// formatSize 文件大小的可读格式
+func formatSize(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(size)/1024/1024)
+	case size >= 1024:
+		return fmt.Sprintf("%.1fKB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%dB", size)
+	}
+}
+
+// ListImageCategories 获取图片分类列表，status 为空时返回全部
+func ListImageCategories(ctx context.Context, status string) ([]image.SysImageCategory, error) {
+	var categories []image.SysImageCategory
+	query := database.Request(ctx).Order("id ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("查询图片分类失败: %v", err)
+	}
+	return categories, nil
+}
+
+// GetImageCategory 获取图片分类
+func GetImageCategory(ctx context.Context, id uint64) (*image.SysImageCategory, error) {
+	return getImageCategory(database.Request(ctx), id)
+}
+
+// getImageCategory 在 db 上查询图片分类
+func getImageCategory(db *gorm.DB, id uint64) (*image.SysImageCategory, error) {
+	var category image.SysImageCategory
+	if err := db.First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, rejectf("图片分类不存在")
+		}
+		return nil, fmt.Errorf("查询图片分类失败: %v", err)
+	}
+	return &category, nil
+}
+
+// CreateImageCategory 创建图片分类
+func CreateImageCategory(ctx context.Context, req *image.ImageCategoryCreateRequest) (*image.SysImageCategory, error) {
+	db := database.Request(ctx)
+	var count int64
+	if err := db.Clauses(dbresolver.Write).Model(&image.SysImageCategory{}).Where("code = ?", req.Code).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询图片分类失败: %v", err)
+	}
+	if count > 0 {
+		return nil, rejectf("分类编码已存在: %s", req.Code)
+	}
+
+	category := &image.SysImageCategory{
+		Code:         req.Code,
+		Name:         req.Name,
+		Description:  req.Description,
+		MaxSize:      req.MaxSize,
+		AllowedTypes: req.AllowedTypes,
+		MaxCount:     req.MaxCount,
+		IsRequired:   req.IsRequired,
+		Modules:      req.Modules,
+		Status:       "active",
+	}
+	if err := db.Create(category).Error; err != nil {
+		return nil, fmt.Errorf("创建图片分类失败: %v", err)
+	}
+	// MaxCount 有列默认值，0（不限制）需要单独写入
+	if req.MaxCount == 0 {
+		if err := db.Model(category).Update("max_count", 0).Error; err != nil {
+			return nil, fmt.Errorf("创建图片分类失败: %v", err)
+		}
+	}
+	return category, nil
+}
+
+// UpdateImageCategory 更新图片分类
+// 读取后整行写回，从主库读取
+func UpdateImageCategory(ctx context.Context, id uint64, req *image.ImageCategoryUpdateRequest) (*image.SysImageCategory, error) {
+	db := database.Request(ctx)
+	category, err := getImageCategory(db.Clauses(dbresolver.Write), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		category.Name = *req.Name
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+	if req.MaxSize != nil {
+		category.MaxSize = *req.MaxSize
+	}
+	if req.AllowedTypes != nil {
+		category.AllowedTypes = *req.AllowedTypes
+	}
+	if req.MaxCount != nil {
+		category.MaxCount = *req.MaxCount
+	}
+	if req.IsRequired != nil {
+		category.IsRequired = *req.IsRequired
+	}
+	if req.Modules != nil {
+		category.Modules = *req.Modules
+	}
+	if req.Status != nil {
+		if category.Code == defaultCategory && *req.Status != "active" {
+			return nil, rejectf("默认分类不能停用")
+		}
+		category.Status = *req.Status
+	}
+
+	if err := db.Save(category).Error; err != nil {
+		return nil, fmt.Errorf("更新图片分类失败: %v", err)
+	}
+	return category, nil
+}
+
+// DeleteImageCategory 删除图片分类，分类下还有图片时不允许删除
+func DeleteImageCategory(ctx context.Context, id uint64) error {
+	db := database.Request(ctx)
+	category, err := getImageCategory(db.Clauses(dbresolver.Write), id)
+	if err != nil {
+		return err
+	}
+	if category.Code == defaultCategory {
+		return rejectf("默认分类不能删除")
+	}
+
+	var count int64
+	if err := db.Clauses(dbresolver.Write).Model(&image.SysImage{}).Where("category = ?", category.Code).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询分类图片数量失败: %v", err)
+	}
+	if count > 0 {
+		return rejectf("分类「%s」下还有%d张图片，不能删除，可以停用该分类", category.Name, count)
+	}
+
+	if err := db.Delete(&image.SysImageCategory{}, id).Error; err != nil {
+		return fmt.Errorf("删除图片分类失败: %v", err)
+	}
+	return nil
+}
+
+// RequiredImageWarnings 检查关联对象的必填图片分类，返回缺少图片的分类提示
+// modules 为关联对象的图片所属模块（户型图片分布在 house_type、house_floor_plan 两个模块）
+func RequiredImageWarnings(ctx context.Context, modules []string, moduleID uint64) ([]image.ImageWarning, error) {
+	db := database.Request(ctx)
+	var categories []image.SysImageCategory
+	err := db.Where("is_required = ? AND status = ?", true, "active").
+		Order("id ASC").
+		Find(&categories).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询图片分类失败: %v", err)
+	}
+
+	warnings := make([]image.ImageWarning, 0)
+	for _, category := range categories {
+		if !categoryAppliesTo(&category, modules) {
+			continue
+		}
+		var count int64
+		err := db.Model(&image.SysImage{}).
+			Where("module IN ? AND module_id = ? AND category = ?", modules, moduleID, category.Code).
+			Count(&count).Error
+		if err != nil {
+			return nil, fmt.Errorf("查询图片数量失败: %v", err)
+		}
+		if count == 0 {
+			warnings = append(warnings, image.ImageWarning{
+				Category: category.Code,
+				Name:     category.Name,
+				Message:  fmt.Sprintf("缺少必填图片：%s", category.Name),
+			})
+		}
+	}
+	return warnings, nil
+}
+
+// categoryAppliesTo 分类是否适用于任一模块，未配置适用模块时适用所有模块
+func categoryAppliesTo(category *image.SysImageCategory, modules []string) bool {
+	if len(category.Modules) == 0 {
+		return true
+	}
+	for _, m := range category.Modules {
+		for _, module := range modules {
+			if m == module {
+				return true
+			}
+		}
+	}
+	return false
+}
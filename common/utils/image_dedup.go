@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
+
+	"rentPro/rentpro-admin/common/models/image"
+	"rentPro/rentpro-admin/common/storage"
+)
+
+// 图片内容去重
+//
+// 上传时计算文件内容（去除元数据后）的 SHA-256 写入 SysImage.Hash，已有相同内容（hash 和大小一致）的图片时
+// 不再上传，新记录直接引用已有的存储文件。多条记录可以指向同一个存储Key，
+// 删除记录时只有没有其他记录引用该Key才删除存储文件。
+//
+// 匹配到已有图片和写入新记录之间，被匹配的图片可能被删除并释放存储文件。写入新记录的事务先锁定
+// 引用该Key的图片记录（SELECT ... FOR UPDATE），没有未删除的记录时重新上传文件；删除图片的 UPDATE
+// 与该锁冲突，释放存储文件时同样以锁定读统计引用，能看到已提交的新记录，不会删除被新记录引用的文件。
+
+// errObjectReleased 复用的存储文件在写入新记录前已没有图片记录引用（已被释放）
+var errObjectReleased = errors.New("复用的存储文件已被删除")
+
+// storedFile storeFile 的结果，复用的存储文件在写入记录前被释放时用 file、key 重新上传
+type storedFile struct {
+	*UploadResult
+	reused bool        // 是否复用已有图片的存储文件
+	file   *uploadFile // 上传的文件
+	key    string      // 不复用时的存储Key
+}
+
+// apply 把存储文件的 Key、URL 和 hash 写入图片记录
+func (f *storedFile) apply(img *image.SysImage) {
+	img.Key = f.Key
+	img.URL = f.OriginalURL
+	img.ThumbnailURL = f.ThumbnailURL
+	img.MediumURL = f.MediumURL
+	img.LargeURL = f.LargeURL
+	img.Hash = f.Hash
+}
+
+// storeFile 上传文件，内容与已有图片相同时复用已有存储文件
+// 写入图片记录需使用 saveImage，在同一事务中确认复用的存储文件仍被引用
+func (im *ImageManager) storeFile(file *uploadFile, key string) (*storedFile, error) {
+	stored := &storedFile{file: file, key: key}
+	if existing := im.findByHash(file.hash, int64(len(file.data))); existing != nil {
+		stored.UploadResult = existingResult(existing)
+		stored.reused = true
+		return stored, nil
+	}
+
+	if err := im.uploadStored(stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// uploadStored 上传文件到 stored.key
+func (im *ImageManager) uploadStored(stored *storedFile) error {
+	result, err := im.putFile(stored.file, stored.key)
+	if err != nil {
+		return err
+	}
+	result.Hash = stored.file.hash
+	stored.UploadResult = result
+	stored.reused = false
+	return nil
+}
+
+// saveImage 在事务中执行 save 写入图片记录，img 的 Key、URL 已由 stored 设置
+// 复用已有存储文件时先锁定引用该Key的图片记录，已全部删除时重新上传文件、更新 img 后再执行一次
+func (im *ImageManager) saveImage(img *image.SysImage, stored *storedFile, save func(tx *gorm.DB) error) error {
+	err := im.db.Transaction(func(tx *gorm.DB) error {
+		if stored.reused {
+			if err := lockReferences(tx, stored.Key); err != nil {
+				return err
+			}
+		}
+		return save(tx)
+	})
+	if err != errObjectReleased {
+		return err
+	}
+
+	if err := im.uploadStored(stored); err != nil {
+		return fmt.Errorf("上传文件失败: %v", err)
+	}
+	stored.apply(img)
+	return im.db.Transaction(save)
+}
+
+// lockReferences 在事务中锁定引用存储Key的未删除图片记录，没有时返回 errObjectReleased
+func lockReferences(tx *gorm.DB, key string) error {
+	var ids []uint64
+	err := tx.Clauses(dbresolver.Write).Model(&image.SysImage{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: key}).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return fmt.Errorf("查询存储文件引用失败: %v", err)
+	}
+	if len(ids) == 0 {
+		return errObjectReleased
+	}
+	return nil
+}
+
+// existingResult 引用已有图片存储文件的上传结果
+func existingResult(existing *image.SysImage) *UploadResult {
+	return &UploadResult{
+		Key:          existing.Key,
+		Hash:         existing.Hash,
+		Size:         existing.FileSize,
+		OriginalURL:  existing.URL,
+		ThumbnailURL: existing.ThumbnailURL,
+		MediumURL:    existing.MediumURL,
+		LargeURL:     existing.LargeURL,
+		Styles: map[string]string{
+			"thumbnail": existing.ThumbnailURL,
+			"medium":    existing.MediumURL,
+			"large":     existing.LargeURL,
+		},
+	}
+}
+
+// findByHash 查找内容相同且存储文件仍存在的图片
+// 找到时复用该图片的存储文件，从主库查询，避免复用副本上已删除的记录
+func (im *ImageManager) findByHash(hash string, size int64) *image.SysImage {
+	var existing image.SysImage
+	err := im.db.Clauses(dbresolver.Write).Where("hash = ? AND file_size = ?", hash, size).
+		Order("id ASC").
+		First(&existing).Error
+	if err != nil {
+		return nil
+	}
+
+	// 存储文件已被删除时重新上传
+	if _, err := im.store.Stat(existing.Key); err != nil {
+		if err != storage.ErrNotFound {
+			fmt.Printf("⚠️  查询存储文件失败 [%s]: %v\n", existing.Key, err)
+		}
+		return nil
+	}
+	return &existing
+}
+
+// releaseObject 没有图片记录引用时删除存储文件及其衍生图
+// 需在删除（或未能创建）对应图片记录之后调用，失败只记录错误
+// 引用与 saveImage 相同以锁定读统计，并发写入的复用记录提交后才计数
+func (im *ImageManager) releaseObject(key string) {
+	err := im.db.Transaction(func(tx *gorm.DB) error {
+		return lockReferences(tx, key)
+	})
+	if err == nil {
+		return
+	}
+	if err != errObjectReleased {
+		fmt.Printf("%v [%s]\n", err, key)
+		return
+	}
+
+	if err := im.store.Delete(key); err != nil && err != storage.ErrNotFound {
+		fmt.Printf("删除存储文件失败 [%s]: %v\n", key, err)
+	}
+	im.deleteDerivatives(key)
+}
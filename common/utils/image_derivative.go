@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
+
+	"rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/imaging"
+	"rentPro/rentpro-admin/common/models/image"
+	"rentPro/rentpro-admin/common/storage"
+)
+
+// 衍生图
+//
+// 开启服务端生成（settings.storage.derivatives）时，上传原图后按配置的规格生成缩略图、中图、大图，
+// 以 {原图key去掉扩展名}_{规格名}.{jpg|webp} 存放在原图旁边，适用于所有存储驱动。
+// 未开启时沿用存储驱动的图片样式URL（七牛云 imageView2 样式），水印策略（image_watermark.go）也只在服务端生成时生效。
+
+// derivativeKey 衍生图的存储key
+func derivativeKey(key, name string, size config.DerivativeSize) string {
+	spec := imaging.Derivative{Format: size.Format}
+	return derivativePrefix(key) + name + spec.Extension()
+}
+
+// derivativePrefix 原图所有衍生图共同的key前缀，规格、格式配置变更前生成的衍生图也在这个前缀下
+func derivativePrefix(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_"
+}
+
+// isDerivativeOf 判断存储key是否为原图的衍生图（任意规格名，.jpg 或 .webp）
+func isDerivativeOf(key, original string) bool {
+	prefix := derivativePrefix(original)
+	if key == original || !strings.HasPrefix(key, prefix) {
+		return false
+	}
+	rest := key[len(prefix):]
+	ext := path.Ext(rest)
+	if ext != ".jpg" && ext != ".webp" {
+		return false
+	}
+	name := strings.TrimSuffix(rest, ext)
+	return name != "" && !strings.Contains(name, "/")
+}
+
+// styleURLs 生成各样式URL，服务端生成衍生图失败时使用原图URL
+func (im *ImageManager) styleURLs(key, originalURL string, data []byte, wm *derivativeWatermark) map[string]string {
+	cfg := config.GetStorageConfig()
+	if !cfg.DerivativesEnabled() {
+		styles := make(map[string]string)
+		for _, styleName := range []string{"thumbnail", "medium", "large"} {
+			styles[styleName] = im.store.StyleURL(originalURL, styleName)
+		}
+		return styles
+	}
+
+	styles, err := im.putDerivatives(key, data, wm)
+	if err != nil {
+		fmt.Printf("⚠️  生成衍生图失败 [%s]: %v\n", key, err)
+		styles = make(map[string]string)
+		for name := range cfg.Derivatives.Sizes {
+			styles[name] = originalURL
+		}
+	}
+	return styles
+}
+
+// putDerivatives 按配置的规格生成衍生图并上传，返回各规格的URL
+// wm 为图片使用的水印，为空不加水印；任一规格失败时删除本次已上传的衍生图
+func (im *ImageManager) putDerivatives(key string, data []byte, wm *derivativeWatermark) (map[string]string, error) {
+	src, err := imaging.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("解码原图失败: %v", err)
+	}
+
+	sizes := config.GetStorageConfig().Derivatives.Sizes
+	names := make([]string, 0, len(sizes))
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	styles := make(map[string]string, len(names))
+	var uploaded []string
+	for _, name := range names {
+		size := sizes[name]
+		spec := imaging.Derivative{
+			Width:   size.Width,
+			Height:  size.Height,
+			Quality: size.Quality,
+			Format:  size.Format,
+			Mode:    size.Mode,
+
+			Watermark: wm.forSize(name),
+		}
+		out, err := imaging.Generate(src, spec)
+		if err == nil {
+			dKey := derivativeKey(key, name, size)
+			_, err = im.store.Put(dKey, bytes.NewReader(out), int64(len(out)), spec.MimeType())
+			if err == nil {
+				uploaded = append(uploaded, dKey)
+				styles[name] = im.store.PublicURL(dKey)
+				continue
+			}
+		}
+
+		for _, dKey := range uploaded {
+			im.store.Delete(dKey)
+		}
+		return nil, fmt.Errorf("生成%s失败: %v", name, err)
+	}
+	return styles, nil
+}
+
+// deleteDerivatives 删除原图对应的衍生图
+// 按前缀列出存储文件删除，不依赖当前的衍生图配置，旧规格、旧格式生成的衍生图和关闭服务端生成前留下的衍生图一并删除；
+// 前缀下仍被图片记录引用的文件是其他原图，不删除
+func (im *ImageManager) deleteDerivatives(key string) {
+	prefix := derivativePrefix(key)
+	var keys []string
+	marker := ""
+	for {
+		objects, next, err := im.store.List(prefix, marker, 1000)
+		if err != nil {
+			fmt.Printf("列出衍生图失败 [%s]: %v\n", prefix, err)
+			return
+		}
+		for _, obj := range objects {
+			if isDerivativeOf(obj.Key, key) {
+				keys = append(keys, obj.Key)
+			}
+		}
+		if next == "" {
+			break
+		}
+		marker = next
+	}
+
+	for _, dKey := range keys {
+		var refs int64
+		err := im.db.Clauses(dbresolver.Write).Model(&image.SysImage{}).
+			Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: dKey}).
+			Count(&refs).Error
+		if err != nil {
+			fmt.Printf("查询存储文件引用失败 [%s]: %v\n", dKey, err)
+			continue
+		}
+		if refs > 0 {
+			continue
+		}
+		if err := im.store.Delete(dKey); err != nil && err != storage.ErrNotFound {
+			fmt.Printf("删除衍生图失败 [%s]: %v\n", dKey, err)
+		}
+	}
+}
+
+// BackfillResult 衍生图回填结果
+type BackfillResult struct {
+	Total     int // 处理的存储文件数
+	Generated int // 生成成功
+	Skipped   int // 已有衍生图跳过
+	Failed    int // 失败
+}
+
+// BackfillDerivatives 为已有图片生成衍生图并更新图片记录的缩略图、中图、大图URL
+// 按存储key处理，去重共享同一文件的记录一起更新；force 为 false 时跳过缩略图URL已是衍生图的文件
+// report 在每个文件处理后调用，err 为 nil 表示成功
+func (im *ImageManager) BackfillDerivatives(force bool, batchSize int, report func(key string, err error)) (*BackfillResult, error) {
+	if !config.GetStorageConfig().DerivativesEnabled() {
+		return nil, fmt.Errorf("未开启服务端生成衍生图（settings.storage.derivatives.enabled）")
+	}
+
+	// 水印策略在整个回填过程中只加载一次
+	set, err := im.loadWatermarks()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BackfillResult{}
+	done := make(map[string]bool)
+	var lastID uint64
+	for {
+		var images []image.SysImage
+		err := im.db.Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(batchSize).
+			Find(&images).Error
+		if err != nil {
+			return result, fmt.Errorf("查询图片失败: %v", err)
+		}
+		if len(images) == 0 {
+			return result, nil
+		}
+		lastID = images[len(images)-1].ID
+
+		for _, img := range images {
+			if done[img.Key] || strings.HasSuffix(img.Key, "/.folder") {
+				continue
+			}
+			done[img.Key] = true
+			result.Total++
+
+			if !force && img.ThumbnailURL != "" && img.ThumbnailURL != img.URL &&
+				img.ThumbnailURL == im.store.PublicURL(derivativeKey(img.Key, "thumbnail", config.GetStorageConfig().Derivatives.Sizes["thumbnail"])) {
+				result.Skipped++
+				continue
+			}
+
+			err := im.backfillOne(&img, set)
+			if err != nil {
+				result.Failed++
+			} else {
+				result.Generated++
+			}
+			if report != nil {
+				report(img.Key, err)
+			}
+		}
+	}
+}
+
+// backfillOne 读取原图生成衍生图，并更新引用该文件的所有图片记录
+// img 为引用该文件的最早的记录，按其分类、模块选择水印
+func (im *ImageManager) backfillOne(img *image.SysImage, set *watermarkSet) error {
+	key := img.Key
+	reader, err := im.store.Get(key)
+	if err != nil {
+		return fmt.Errorf("读取原图失败: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("读取原图失败: %v", err)
+	}
+
+	styles, err := im.putDerivatives(key, data, set.forImage(img.Category, img.Module))
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{}
+	for name, column := range map[string]string{"thumbnail": "thumbnail_url", "medium": "medium_url", "large": "large_url"} {
+		if url, ok := styles[name]; ok {
+			updates[column] = url
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return im.db.Model(&image.SysImage{}).
+		Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: key}).
+		UpdateColumns(updates).Error
+}
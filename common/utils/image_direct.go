@@ -0,0 +1,420 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
+
+	"rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/models/image"
+	"rentPro/rentpro-admin/common/storage"
+)
+
+// 浏览器直传
+//
+// 客户端先获取上传参数：存储Key按模块、分类确定，附带服务端签名的上传凭证 ticket，
+// 然后把文件直接上传到存储服务的暂存Key（{存储Key}.direct-upload），不经过 API 服务器中转。
+// 上传完成后由存储服务回调（七牛云，需回调签名）或登录用户携带 ticket 调用回调接口，服务端读取暂存文件，
+// 做与普通上传相同的检查（真实格式、尺寸、去除EXIF、去重、衍生图），通过后写入存储Key并创建图片记录，
+// 最后删除暂存文件。客户端只能写暂存Key，完成后再次上传（S3 预签名表单在有效期内可以重复使用）不会替换已检查的文件。
+// 一个上传凭证只能完成一次（sys_direct_uploads 按存储Key唯一），重复回调、去重后重试返回同一条图片记录。
+// 不支持直传的驱动（本地磁盘）由服务端的直传接口接收文件，流程相同。
+
+// directCompleteGrace 上传凭证过期后仍允许完成上传的时间，覆盖凭证到期前开始的上传
+const directCompleteGrace = time.Hour
+
+// directUploadSuffix 客户端直传写入的暂存Key后缀
+const directUploadSuffix = ".direct-upload"
+
+// DirectUploadTicket 直传凭证内容，签名后交给客户端，回调时原样带回
+type DirectUploadTicket struct {
+	Key      string `json:"k"`              // 存储Key
+	FileName string `json:"f"`              // 原始文件名
+	Category string `json:"c"`              // 图片分类
+	Module   string `json:"m"`              // 所属模块
+	ModuleID uint64 `json:"mi,omitempty"`   // 模块关联ID
+	IsMain   bool   `json:"main,omitempty"` // 是否为主图
+	IsPublic bool   `json:"pub,omitempty"`  // 是否公开访问
+	UserID   uint64 `json:"u"`              // 申请上传的用户
+	MaxSize  int64  `json:"s"`              // 最大文件大小
+	Expires  int64  `json:"e"`              // 过期时间（Unix秒）
+}
+
+// DirectUploadResult 直传参数
+type DirectUploadResult struct {
+	*storage.DirectUpload
+	Key       string    `json:"key"`       // 存储Key
+	Ticket    string    `json:"ticket"`    // 上传凭证，调用回调接口时提交
+	ExpiresAt time.Time `json:"expiresAt"` // 上传凭证过期时间
+}
+
+// directUploadSecret 上传凭证签名密钥，api 启动时已按 config.CheckDirectUploadSecret 检查，多实例部署使用相同的密钥
+func directUploadSecret() []byte {
+	return []byte(config.GetStorageConfig().DirectUpload.Secret)
+}
+
+// sign 计算凭证内容的签名
+func (t *DirectUploadTicket) sign(payload string) string {
+	mac := hmac.New(sha256.New, directUploadSecret())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Encode 编码并签名为 {内容}.{签名}
+func (t *DirectUploadTicket) Encode() string {
+	data, _ := json.Marshal(t)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + t.sign(payload)
+}
+
+// UploadKey 客户端直传写入的暂存Key
+func (t *DirectUploadTicket) UploadKey() string {
+	return t.Key + directUploadSuffix
+}
+
+// Expired 上传凭证是否已过期
+func (t *DirectUploadTicket) Expired() bool {
+	return time.Now().Unix() > t.Expires
+}
+
+// ParseDirectUploadTicket 验证签名并解析上传凭证
+func ParseDirectUploadTicket(value string) (*DirectUploadTicket, error) {
+	payload, signature, found := strings.Cut(value, ".")
+	if !found || len(directUploadSecret()) == 0 {
+		return nil, fmt.Errorf("上传凭证无效")
+	}
+	var ticket DirectUploadTicket
+	if !hmac.Equal([]byte(signature), []byte(ticket.sign(payload))) {
+		return nil, fmt.Errorf("上传凭证无效")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("上传凭证无效")
+	}
+	if err := json.Unmarshal(data, &ticket); err != nil || ticket.Key == "" {
+		return nil, fmt.Errorf("上传凭证无效")
+	}
+	return &ticket, nil
+}
+
+// CreateDirectUpload 生成浏览器直传参数
+// fallbackURL 为存储驱动不支持直传时的服务端接收地址
+func (im *ImageManager) CreateDirectUpload(req *image.DirectUploadRequest, userID uint64, fallbackURL string) (*DirectUploadResult, error) {
+	cfg := config.GetStorageConfig()
+
+	// 只保留文件名部分
+	fileName := path.Base(strings.ReplaceAll(req.FileName, "\\", "/"))
+	if fileName == "." || fileName == "/" {
+		return nil, rejectf("文件名无效")
+	}
+
+	// 按图片分类检查数量，上传凭证使用分类的大小、类型限制
+	rules, err := im.categoryRules(req.Category)
+	if err != nil {
+		return nil, err
+	}
+	if err := rules.checkCount(im.db, req.Module, req.ModuleID, 1); err != nil {
+		return nil, err
+	}
+
+	expires := time.Duration(cfg.DirectUpload.Expires) * time.Second
+	expiresAt := time.Now().Add(expires)
+	ticket := &DirectUploadTicket{
+		Key:      im.imageKey(req.Module, req.ModuleID, req.Category, fileName),
+		FileName: fileName,
+		Category: req.Category,
+		Module:   req.Module,
+		ModuleID: req.ModuleID,
+		IsMain:   req.IsMain,
+		IsPublic: req.IsPublic,
+		UserID:   userID,
+		MaxSize:  rules.maxSize,
+		Expires:  expiresAt.Unix(),
+	}
+	value := ticket.Encode()
+
+	var upload *storage.DirectUpload
+	if uploader, ok := im.store.(storage.DirectUploader); ok {
+		upload, err = uploader.DirectUpload(ticket.UploadKey(), storage.UploadPolicy{
+			MaxSize:      ticket.MaxSize,
+			MimeTypes:    rules.allowedTypes,
+			Expires:      expires,
+			CallbackURL:  cfg.DirectUpload.CallbackURL,
+			CallbackBody: "ticket=" + url.QueryEscape(value),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("生成直传参数失败: %v", err)
+		}
+	} else {
+		// 服务端接收文件后直接完成上传
+		upload = &storage.DirectUpload{
+			Method:         "POST",
+			URL:            fallbackURL,
+			Fields:         map[string]string{"ticket": value},
+			FileField:      "file",
+			ServerCallback: true,
+		}
+	}
+
+	return &DirectUploadResult{
+		DirectUpload: upload,
+		Key:          ticket.Key,
+		Ticket:       value,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// VerifyUploadCallback 请求带有存储服务回调签名时验证签名，返回是否为存储服务回调
+// 没有回调签名的请求（客户端调用）需要登录且与申请上传凭证的用户一致，由调用方检查
+func (im *ImageManager) VerifyUploadCallback(req *http.Request) (bool, error) {
+	verifier, ok := im.store.(storage.CallbackVerifier)
+	if !ok || !verifier.IsCallback(req) {
+		return false, nil
+	}
+	valid, err := verifier.VerifyCallback(req)
+	if err != nil {
+		return false, fmt.Errorf("验证回调签名失败: %v", err)
+	}
+	if !valid {
+		return false, fmt.Errorf("回调签名无效")
+	}
+	return true, nil
+}
+
+// ReceiveDirectUpload 存储驱动不支持直传时，由服务端接收文件写入暂存Key并完成上传
+// 凭证已使用或暂存Key已有文件时不写入；凭证已完成时返回已创建的记录
+// 存储驱动支持直传时客户端应直接上传到存储服务，不经过服务端接收
+func (im *ImageManager) ReceiveDirectUpload(ticket *DirectUploadTicket, file *multipart.FileHeader) (*image.SysImage, error) {
+	if _, ok := im.store.(storage.DirectUploader); ok {
+		return nil, rejectf("存储驱动支持直传，请直接上传到存储服务")
+	}
+	if ticket.Expired() {
+		return nil, rejectf("上传凭证已过期")
+	}
+	if file.Size > ticket.MaxSize {
+		return nil, rejectf("文件大小超过限制: 最大 %s", formatSize(ticket.MaxSize))
+	}
+
+	existing, err := im.claimDirectUpload(ticket)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	img, err := im.receiveDirectUpload(ticket, file)
+	if err != nil {
+		im.releaseDirectUpload(ticket)
+		return nil, err
+	}
+	return img, nil
+}
+
+// receiveDirectUpload 写入暂存Key后检查文件并创建图片记录
+func (im *ImageManager) receiveDirectUpload(ticket *DirectUploadTicket, file *multipart.FileHeader) (*image.SysImage, error) {
+	if _, err := im.store.Stat(ticket.UploadKey()); err == nil {
+		return nil, rejectf("上传凭证已使用")
+	} else if err != storage.ErrNotFound {
+		return nil, fmt.Errorf("查询存储文件失败: %v", err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取上传文件失败: %v", err)
+	}
+	defer src.Close()
+	if _, err := im.store.Put(ticket.UploadKey(), src, file.Size, file.Header.Get("Content-Type")); err != nil {
+		return nil, fmt.Errorf("上传文件失败: %v", err)
+	}
+
+	return im.completeDirectUpload(ticket)
+}
+
+// CompleteDirectUpload 检查已直传到暂存Key的文件并创建图片记录
+// 凭证已完成时返回已创建的记录（重复回调、去重后重试）；文件检查不通过时删除暂存文件，凭证可以重新上传后再完成
+func (im *ImageManager) CompleteDirectUpload(ticket *DirectUploadTicket) (*image.SysImage, error) {
+	if time.Now().Unix() > ticket.Expires+int64(directCompleteGrace/time.Second) {
+		return nil, rejectf("上传凭证已过期")
+	}
+
+	existing, err := im.claimDirectUpload(ticket)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		// 完成后再次上传到暂存Key的文件不会使用
+		im.discardUploaded(ticket.UploadKey())
+		return existing, nil
+	}
+
+	img, err := im.completeDirectUpload(ticket)
+	if err != nil {
+		im.releaseDirectUpload(ticket)
+		return nil, err
+	}
+	return img, nil
+}
+
+// completeDirectUpload 检查暂存文件，写入存储Key（内容重复时引用已有文件）并创建图片记录，最后删除暂存文件
+// 调用前需已占用上传凭证
+func (im *ImageManager) completeDirectUpload(ticket *DirectUploadTicket) (*image.SysImage, error) {
+	data, err := im.readUploaded(ticket)
+	if err != nil {
+		return nil, err
+	}
+	// 检查通过后文件已写入存储Key，检查不通过时也不再保留
+	defer im.discardUploaded(ticket.UploadKey())
+
+	// 按图片分类检查数量和文件内容
+	rules, err := im.categoryRules(ticket.Category)
+	if err != nil {
+		return nil, err
+	}
+	if err := rules.checkCount(im.db, ticket.Module, ticket.ModuleID, 1); err != nil {
+		return nil, err
+	}
+	prepared, err := im.prepareData(data, rules)
+	if err != nil {
+		return nil, err
+	}
+	prepared.module = ticket.Module
+
+	// 内容与已有图片相同时引用已有文件
+	uploadResult, err := im.storeFile(prepared, ticket.Key)
+	if err != nil {
+		return nil, fmt.Errorf("上传文件失败: %v", err)
+	}
+
+	img := &image.SysImage{
+		Name:         ticket.FileName,
+		FileName:     ticket.FileName,
+		FileSize:     int64(len(prepared.data)),
+		MimeType:     prepared.mimeType,
+		Extension:    im.getFileExtension(ticket.FileName),
+		Key:          uploadResult.Key,
+		URL:          uploadResult.OriginalURL,
+		ThumbnailURL: uploadResult.ThumbnailURL,
+		MediumURL:    uploadResult.MediumURL,
+		LargeURL:     uploadResult.LargeURL,
+		Hash:         uploadResult.Hash,
+		Width:        prepared.width,
+		Height:       prepared.height,
+		Category:     ticket.Category,
+		Module:       ticket.Module,
+		ModuleID:     ticket.ModuleID,
+		IsPublic:     ticket.IsPublic,
+		IsMain:       ticket.IsMain,
+		Status:       "active",
+		CreatedBy:    ticket.UserID,
+		UpdatedBy:    ticket.UserID,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	// 检查数量、保存和设置主图在同一事务中，并发完成上传不会超出分类的数量限制
+	err = im.saveImage(img, uploadResult, func(tx *gorm.DB) error {
+		if err := insertImage(tx, rules, img); err != nil {
+			return err
+		}
+		err := tx.Model(&image.SysDirectUpload{}).
+			Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: ticket.Key}).
+			Updates(map[string]interface{}{
+				"status":   image.DirectUploadCompleted,
+				"image_id": img.ID,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("保存到数据库失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		im.releaseObject(uploadResult.Key)
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// claimDirectUpload 占用上传凭证，保证一个凭证只完成一次
+// 凭证已完成时返回创建的图片记录；其他请求正在处理或凭证已被其他用户使用时返回错误
+func (im *ImageManager) claimDirectUpload(ticket *DirectUploadTicket) (*image.SysImage, error) {
+	record := &image.SysDirectUpload{
+		Key:    ticket.Key,
+		UserID: ticket.UserID,
+		Status: image.DirectUploadPending,
+	}
+	result := im.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, fmt.Errorf("占用上传凭证失败: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil, nil
+	}
+
+	var existing image.SysDirectUpload
+	err := im.db.Clauses(dbresolver.Write).
+		Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: ticket.Key}).
+		Take(&existing).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询上传凭证失败: %v", err)
+	}
+	if existing.Status != image.DirectUploadCompleted || existing.UserID != ticket.UserID {
+		return nil, rejectf("上传凭证正在使用或已使用")
+	}
+
+	var img image.SysImage
+	if err := im.db.Clauses(dbresolver.Write).Take(&img, existing.ImageID).Error; err != nil {
+		return nil, rejectf("上传凭证已使用")
+	}
+	return &img, nil
+}
+
+// releaseDirectUpload 完成上传失败时释放上传凭证，客户端可以重新上传后再完成
+func (im *ImageManager) releaseDirectUpload(ticket *DirectUploadTicket) {
+	err := im.db.Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: ticket.Key}).
+		Where("status = ?", image.DirectUploadPending).
+		Delete(&image.SysDirectUpload{}).Error
+	if err != nil {
+		fmt.Printf("释放上传凭证失败 [%s]: %v\n", ticket.Key, err)
+	}
+}
+
+// readUploaded 读取暂存文件内容，超过凭证允许的大小时删除文件
+func (im *ImageManager) readUploaded(ticket *DirectUploadTicket) ([]byte, error) {
+	rc, err := im.store.Get(ticket.UploadKey())
+	if err == storage.ErrNotFound {
+		return nil, rejectf("文件尚未上传")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, ticket.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取上传文件失败: %v", err)
+	}
+	if int64(len(data)) > ticket.MaxSize {
+		im.discardUploaded(ticket.UploadKey())
+		return nil, rejectf("文件大小超过限制: 最大 %s", formatSize(ticket.MaxSize))
+	}
+	return data, nil
+}
+
+// discardUploaded 删除暂存文件，失败只记录错误
+func (im *ImageManager) discardUploaded(key string) {
+	if err := im.store.Delete(key); err != nil && err != storage.ErrNotFound {
+		fmt.Printf("删除直传文件失败 [%s]: %v\n", key, err)
+	}
+}
@@ -0,0 +1,327 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"rentPro/rentpro-admin/common/models/image"
+)
+
+// 图库管理
+//
+// 同一模块 + 模块ID 的图片组成一个图库（如某个户型的户型图），SortOrder 为图库内的顺序，IsMain 为封面。
+// 所属对象表中冗余了封面URL和图片URL列表（如 sys_house_types.floor_plan_url、sys_houses.main_image），
+// 调整顺序、切换封面、移动图片、删除图片时在同一事务中同步更新。
+
+// imageOwner 图片所属对象
+type imageOwner struct {
+	title       string // 对象名称，用于提示
+	table       string // 对象表
+	coverColumn string // 封面URL列，为空表示没有
+	urlsColumn  string // 图片URL列表列（JSON），为空表示没有
+}
+
+// imageOwners 模块对应的所属对象
+var imageOwners = map[string]imageOwner{
+	"building":         {title: "楼盘", table: "sys_buildings"},
+	"house_type":       {title: "户型", table: "sys_house_types", coverColumn: "main_image", urlsColumn: "image_urls"},
+	"house_floor_plan": {title: "户型", table: "sys_house_types", coverColumn: "floor_plan_url"},
+	"house":            {title: "房屋", table: "sys_houses", coverColumn: "main_image", urlsColumn: "image_urls"},
+}
+
+// gallery 图库（模块 + 模块ID）
+type gallery struct {
+	module   string
+	moduleID uint64
+}
+
+// findOwner 查询模块对应的所属对象，检查对象是否存在
+func findOwner(tx *gorm.DB, module string, moduleID uint64) (*imageOwner, error) {
+	owner, ok := imageOwners[module]
+	if !ok {
+		return nil, rejectf("不支持的图片模块: %s", module)
+	}
+	var count int64
+	if err := tx.Table(owner.table).Where("id = ? AND deleted_at IS NULL", moduleID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询%s失败: %v", owner.title, err)
+	}
+	if count == 0 {
+		return nil, rejectf("%s不存在", owner.title)
+	}
+	return &owner, nil
+}
+
+// galleryImages 按图库顺序查询图片
+func galleryImages(tx *gorm.DB, module string, moduleID uint64) ([]image.SysImage, error) {
+	var images []image.SysImage
+	err := tx.Where("module = ? AND module_id = ?", module, moduleID).
+		Order("sort_order ASC, id ASC").
+		Find(&images).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询图库失败: %v", err)
+	}
+	return images, nil
+}
+
+// syncOwnerImages 按图库当前状态更新所属对象的封面URL和图片URL列表
+// 没有设置封面时使用第一张图片，图库为空时清空
+func syncOwnerImages(tx *gorm.DB, module string, moduleID uint64) error {
+	owner, ok := imageOwners[module]
+	if !ok || moduleID == 0 || (owner.coverColumn == "" && owner.urlsColumn == "") {
+		return nil
+	}
+
+	images, err := galleryImages(tx, module, moduleID)
+	if err != nil {
+		return err
+	}
+
+	cover := ""
+	urls := make([]string, 0, len(images))
+	for _, img := range images {
+		urls = append(urls, img.URL)
+		if img.IsMain && cover == "" {
+			cover = img.URL
+		}
+	}
+	if cover == "" && len(urls) > 0 {
+		cover = urls[0]
+	}
+
+	updates := make(map[string]interface{})
+	if owner.coverColumn != "" {
+		updates[owner.coverColumn] = cover
+	}
+	if owner.urlsColumn != "" {
+		data, _ := json.Marshal(urls)
+		updates[owner.urlsColumn] = string(data)
+	}
+	if err := tx.Table(owner.table).Where("id = ?", moduleID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新%s图片信息失败: %v", owner.title, err)
+	}
+	return nil
+}
+
+// syncGalleries 同步多个图库的所属对象，失败只记录错误
+func (im *ImageManager) syncGalleries(galleries map[gallery]bool) {
+	for g := range galleries {
+		if err := syncOwnerImages(im.db, g.module, g.moduleID); err != nil {
+			fmt.Printf("⚠️  同步图片所属对象失败 [%s:%d]: %v\n", g.module, g.moduleID, err)
+		}
+	}
+}
+
+// ReorderImages 调整图库顺序
+// ids 为新的顺序，未列出的图片保持原有相对顺序排在后面
+func (im *ImageManager) ReorderImages(module string, moduleID uint64, ids []uint64, userID uint64) ([]image.SysImage, error) {
+	var result []image.SysImage
+	err := im.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findOwner(tx, module, moduleID); err != nil {
+			return err
+		}
+		ordered, err := reorderGallery(tx, module, moduleID, ids, userID)
+		if err != nil {
+			return err
+		}
+		result = ordered
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// reorderGallery 在事务中按 ids 重新编排图库顺序（sort_order 从 1 连续编号），并同步所属对象
+func reorderGallery(tx *gorm.DB, module string, moduleID uint64, ids []uint64, userID uint64) ([]image.SysImage, error) {
+	images, err := galleryImages(tx, module, moduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint64]image.SysImage, len(images))
+	for _, img := range images {
+		byID[img.ID] = img
+	}
+	ordered := make([]image.SysImage, 0, len(images))
+	listed := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		img, ok := byID[id]
+		if !ok {
+			return nil, rejectf("图片%d不属于该图库", id)
+		}
+		if listed[id] {
+			return nil, rejectf("图片%d重复", id)
+		}
+		listed[id] = true
+		ordered = append(ordered, img)
+	}
+	for _, img := range images {
+		if !listed[img.ID] {
+			ordered = append(ordered, img)
+		}
+	}
+
+	now := time.Now()
+	for i := range ordered {
+		ordered[i].SortOrder = i + 1
+		err := tx.Model(&image.SysImage{}).Where("id = ?", ordered[i].ID).Updates(map[string]interface{}{
+			"sort_order": i + 1,
+			"updated_by": userID,
+			"updated_at": now,
+		}).Error
+		if err != nil {
+			return nil, fmt.Errorf("更新图片顺序失败: %v", err)
+		}
+	}
+
+	if err := syncOwnerImages(tx, module, moduleID); err != nil {
+		return nil, err
+	}
+	return ordered, nil
+}
+
+// moveInGallery 在事务中把图片移动到图库的第 position 位（从 1 开始，超出范围时排在最后）
+func moveInGallery(tx *gorm.DB, img *image.SysImage, position int, userID uint64) error {
+	images, err := galleryImages(tx, img.Module, img.ModuleID)
+	if err != nil {
+		return err
+	}
+	ids := make([]uint64, 0, len(images))
+	for _, other := range images {
+		if other.ID != img.ID {
+			ids = append(ids, other.ID)
+		}
+	}
+	index := position - 1
+	if index < 0 {
+		index = 0
+	}
+	if index > len(ids) {
+		index = len(ids)
+	}
+	ids = append(ids[:index], append([]uint64{img.ID}, ids[index:]...)...)
+	_, err = reorderGallery(tx, img.Module, img.ModuleID, ids, userID)
+	return err
+}
+
+// MoveImages 将图片移动到另一个对象的图库（如户型图移动到具体房屋）
+// category 不为空时同时修改分类；按目标分类检查数量限制，分类改变的图片还需符合目标分类的大小、类型限制，
+// 移动的图片排在目标图库末尾且不作为封面
+func (im *ImageManager) MoveImages(ids []uint64, module string, moduleID uint64, category string, userID uint64) ([]image.SysImage, error) {
+	var moved []image.SysImage
+	err := im.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findOwner(tx, module, moduleID); err != nil {
+			return err
+		}
+
+		seen := make(map[uint64]bool, len(ids))
+		for _, id := range ids {
+			if seen[id] {
+				return rejectf("图片ID重复: %d", id)
+			}
+			seen[id] = true
+		}
+
+		var images []image.SysImage
+		if err := tx.Where("id IN ?", ids).Order("sort_order ASC, id ASC").Find(&images).Error; err != nil {
+			return fmt.Errorf("查询图片失败: %v", err)
+		}
+		if len(images) != len(ids) {
+			return rejectf("部分图片不存在")
+		}
+
+		// 按目标分类检查数量，已在目标图库中的图片不计入新增；
+		// 与修改图片分类相同，分类改变的图片还需符合目标分类的大小、类型限制
+		adding := make(map[string]int)
+		sources := make(map[gallery]bool)
+		rulesByCode := make(map[string]*uploadRules)
+		for i := range images {
+			img := &images[i]
+			target := img.Category
+			if category != "" {
+				target = category
+			}
+			if img.Module != module || img.ModuleID != moduleID || img.Category != target {
+				adding[target]++
+			}
+			sources[gallery{module: img.Module, moduleID: img.ModuleID}] = true
+			if target == img.Category {
+				continue
+			}
+			rules, ok := rulesByCode[target]
+			if !ok {
+				var err error
+				if rules, err = im.categoryRules(target); err != nil {
+					return err
+				}
+				rulesByCode[target] = rules
+			}
+			if err := rules.checkImage(img); err != nil {
+				return err
+			}
+		}
+		for code, count := range adding {
+			rules, ok := rulesByCode[code]
+			if !ok {
+				var err error
+				if rules, err = im.categoryRules(code); err != nil {
+					return err
+				}
+			}
+			if err := rules.checkCount(tx, module, moduleID, count); err != nil {
+				return err
+			}
+		}
+
+		var maxOrder int
+		err := tx.Model(&image.SysImage{}).
+			Where("module = ? AND module_id = ?", module, moduleID).
+			Select("COALESCE(MAX(sort_order), 0)").
+			Scan(&maxOrder).Error
+		if err != nil {
+			return fmt.Errorf("查询图库失败: %v", err)
+		}
+
+		now := time.Now()
+		for i := range images {
+			img := &images[i]
+			if img.Module == module && img.ModuleID == moduleID && (category == "" || img.Category == category) {
+				continue
+			}
+			updates := map[string]interface{}{
+				"module":     module,
+				"module_id":  moduleID,
+				"is_main":    false,
+				"sort_order": maxOrder + i + 1,
+				"updated_by": userID,
+				"updated_at": now,
+			}
+			if category != "" {
+				updates["category"] = category
+				img.Category = category
+			}
+			if err := tx.Model(&image.SysImage{}).Where("id = ?", img.ID).Updates(updates).Error; err != nil {
+				return fmt.Errorf("移动图片失败: %v", err)
+			}
+			img.Module, img.ModuleID, img.IsMain, img.SortOrder = module, moduleID, false, maxOrder+i+1
+		}
+
+		sources[gallery{module: module, moduleID: moduleID}] = true
+		for g := range sources {
+			if err := syncOwnerImages(tx, g.module, g.moduleID); err != nil {
+				return err
+			}
+		}
+
+		moved = images
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
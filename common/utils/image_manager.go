@@ -1,90 +1,100 @@
 package utils
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"strings"
 	"time"
 
+	"rentPro/rentpro-admin/common/config"
 	"rentPro/rentpro-admin/common/database"
+	"rentPro/rentpro-admin/common/imaging"
 	"rentPro/rentpro-admin/common/models/image"
+	"rentPro/rentpro-admin/common/query"
+	"rentPro/rentpro-admin/common/storage"
 
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 // ImageManager 图片管理器
 type ImageManager struct {
-	qiniuService *QiniuService
-	db           *gorm.DB
+	store storage.Storage
+	db    *gorm.DB
+
+	shared     *ImageManager   // WithContext 返回的副本指向的全局实例，全局实例为 nil
+	regenerate regenerateQueue // 水印策略变更后等待重新生成衍生图的文件
 }
 
 // NewImageManager 创建图片管理器
+// 使用全局存储实例，未初始化存储时沿用已初始化的七牛云服务
 func NewImageManager() (*ImageManager, error) {
-	qiniuService := GetQiniuService()
-	if qiniuService == nil {
-		return nil, fmt.Errorf("七牛云服务未初始化")
+	store := storage.Default()
+	if store == nil {
+		if qiniuService := GetQiniuService(); qiniuService != nil {
+			store = qiniuService
+		}
+	}
+	if store == nil {
+		return nil, fmt.Errorf("文件存储未初始化")
 	}
 
 	return &ImageManager{
-		qiniuService: qiniuService,
-		db:           database.DB,
+		store: store,
+		db:    database.DB,
 	}, nil
 }
 
+// WithContext 返回使用请求上下文访问数据库的副本，处理请求时使用
+// 写入后读主库依赖请求上下文（见 database.Request）；后台任务仍由全局实例执行，不使用请求上下文
+func (im *ImageManager) WithContext(ctx context.Context) *ImageManager {
+	if im == nil {
+		return nil
+	}
+	return &ImageManager{store: im.store, db: im.db.WithContext(ctx), shared: im.root()}
+}
+
+// root 全局实例
+func (im *ImageManager) root() *ImageManager {
+	if im.shared != nil {
+		return im.shared
+	}
+	return im
+}
+
+// Storage 图片管理器使用的存储后端
+func (im *ImageManager) Storage() storage.Storage {
+	return im.store
+}
+
 // UploadImage 上传图片
 func (im *ImageManager) UploadImage(file *multipart.FileHeader, req *image.ImageUploadRequest, userID uint64) (*image.SysImage, error) {
-	// 验证文件
-	if err := im.qiniuService.ValidateFile(file); err != nil {
+	// 按图片分类检查数量并验证文件
+	rules, err := im.categoryRules(req.Category)
+	if err != nil {
+		return nil, err
+	}
+	if err := rules.checkCount(im.db, req.Module, req.ModuleID, 1); err != nil {
+		return nil, err
+	}
+	prepared, err := im.prepareFile(file, rules)
+	if err != nil {
 		return nil, err
 	}
+	prepared.module = req.Module
 
 	// 生成存储Key，支持楼盘文件夹结构
-	fileName := fmt.Sprintf("%s_%d_%s", req.Category, time.Now().UnixNano(), file.Filename)
-	var customKey string
-
-	// 如果是楼盘相关的图片，使用楼盘管理文件夹结构
-	if req.Module == "building" || req.Module == "house" {
-		if req.ModuleID > 0 {
-			// 获取楼盘信息以构建正确的路径
-			var building struct {
-				ID   uint64 `json:"id"`
-				Name string `json:"name"`
-				City string `json:"city"`
-			}
+	customKey := im.imageKey(req.Module, req.ModuleID, req.Category, file.Filename)
 
-			// 从数据库获取楼盘信息
-			if err := im.db.Table("sys_buildings").
-				Select("id, name, city").
-				Where("id = ?", req.ModuleID).
-				First(&building).Error; err == nil {
-
-				// 使用楼盘表中的城市名称
-				cityName := building.City
-
-				// 构建新的楼盘管理文件夹路径
-				safeCityName := im.sanitizeFolderName(cityName)
-				safeBuildingName := im.sanitizeFolderName(building.Name)
-				buildingFolderName := fmt.Sprintf("%d-%s", building.ID, safeBuildingName)
-
-				// 格式: 楼盘管理/{城市名}/{楼盘ID-楼盘名称}/{category}/{timestamp}_{filename}
-				customKey = fmt.Sprintf("楼盘管理/%s/%s/%s/%s", safeCityName, buildingFolderName, req.Category, fileName)
-			} else {
-				// 如果获取楼盘信息失败，使用备用路径
-				customKey = fmt.Sprintf("楼盘管理/未分类楼盘/%s/%s", req.Category, fileName)
-			}
-		} else {
-			// 如果没有指定楼盘ID，使用通用楼盘文件夹
-			customKey = fmt.Sprintf("楼盘管理/通用文件夹/%s/%s", req.Category, fileName)
-		}
-	} else {
-		// 其他模块使用原有逻辑
-		customKey = im.qiniuService.configManager.GetUploadKey(fileName)
-	}
-
-	// 上传到七牛云
-	uploadResult, err := im.qiniuService.UploadFile(file, customKey)
+	// 上传到存储
+	uploadResult, err := im.storeFile(prepared, customKey)
 	if err != nil {
-		return nil, fmt.Errorf("上传到七牛云失败: %v", err)
+		return nil, fmt.Errorf("上传文件失败: %v", err)
 	}
 
 	// 保存到数据库
@@ -92,14 +102,17 @@ func (im *ImageManager) UploadImage(file *multipart.FileHeader, req *image.Image
 		Name:         file.Filename,
 		Description:  "",
 		FileName:     file.Filename,
-		FileSize:     file.Size,
-		MimeType:     file.Header.Get("Content-Type"),
+		FileSize:     int64(len(prepared.data)),
+		MimeType:     prepared.mimeType,
 		Extension:    im.getFileExtension(file.Filename),
 		Key:          uploadResult.Key,
 		URL:          uploadResult.OriginalURL,
 		ThumbnailURL: uploadResult.ThumbnailURL,
 		MediumURL:    uploadResult.MediumURL,
 		LargeURL:     uploadResult.LargeURL,
+		Hash:         uploadResult.Hash,
+		Width:        prepared.width,
+		Height:       prepared.height,
 		Category:     req.Category,
 		Module:       req.Module,
 		ModuleID:     req.ModuleID,
@@ -113,10 +126,11 @@ func (im *ImageManager) UploadImage(file *multipart.FileHeader, req *image.Image
 		UpdatedAt:    time.Now(),
 	}
 
-	if err := im.db.Create(img).Error; err != nil {
+	// 上传期间其他请求可能已写入图片，检查数量和保存在同一事务中
+	if err := im.createImage(rules, img, uploadResult); err != nil {
 		// 如果数据库保存失败，删除已上传的文件
-		im.qiniuService.DeleteFile(uploadResult.Key)
-		return nil, fmt.Errorf("保存到数据库失败: %v", err)
+		im.releaseObject(uploadResult.Key)
+		return nil, err
 	}
 
 	return img, nil
@@ -151,6 +165,7 @@ func (im *ImageManager) GetImagesByModule(module string, moduleID uint64, catego
 }
 
 // UpdateImage 更新图片信息
+// 修改分类时按新分类检查数量、大小和类型；主图、顺序经过 setMainImage、moveInGallery 调整并同步所属对象
 func (im *ImageManager) UpdateImage(id uint64, req *image.ImageUpdateRequest, userID uint64) error {
 	updateData := map[string]interface{}{
 		"updated_by": userID,
@@ -169,112 +184,139 @@ func (im *ImageManager) UpdateImage(id uint64, req *image.ImageUpdateRequest, us
 	if req.Status != "" {
 		updateData["status"] = req.Status
 	}
-	updateData["is_main"] = req.IsMain
 	updateData["is_public"] = req.IsPublic
-	updateData["sort_order"] = req.SortOrder
 
-	if err := im.db.Model(&image.SysImage{}).Where("id = ?", id).Updates(updateData).Error; err != nil {
-		return fmt.Errorf("更新图片信息失败: %v", err)
-	}
+	return im.db.Transaction(func(tx *gorm.DB) error {
+		var img image.SysImage
+		if err := tx.Where("id = ?", id).First(&img).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("图片不存在")
+			}
+			return fmt.Errorf("查询图片失败: %v", err)
+		}
 
-	return nil
+		if req.Category != "" && req.Category != img.Category {
+			rules, err := im.categoryRules(req.Category)
+			if err != nil {
+				return err
+			}
+			if err := rules.checkImage(&img); err != nil {
+				return err
+			}
+			if err := rules.checkCount(tx, img.Module, img.ModuleID, 1); err != nil {
+				return err
+			}
+		}
+
+		// 取消主图后图库没有主图，所属对象的封面使用第一张图片
+		if req.IsMain != nil && !*req.IsMain {
+			updateData["is_main"] = false
+		}
+		if err := tx.Model(&image.SysImage{}).Where("id = ?", id).Updates(updateData).Error; err != nil {
+			return fmt.Errorf("更新图片信息失败: %v", err)
+		}
+
+		if req.SortOrder != nil && *req.SortOrder != img.SortOrder {
+			if err := moveInGallery(tx, &img, *req.SortOrder, userID); err != nil {
+				return err
+			}
+		}
+		if req.IsMain != nil && *req.IsMain {
+			return setMainImage(tx, img.Module, img.ModuleID, img.ID, userID)
+		}
+		return syncOwnerImages(tx, img.Module, img.ModuleID)
+	})
 }
 
 // DeleteImage 删除图片
 func (im *ImageManager) DeleteImage(id uint64, userID uint64) error {
 	var img image.SysImage
-	if err := im.db.Where("id = ?", id).First(&img).Error; err != nil {
+	if err := im.db.Clauses(dbresolver.Write).Where("id = ?", id).First(&img).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("图片不存在")
 		}
 		return fmt.Errorf("查询图片失败: %v", err)
 	}
 
-	// 从七牛云删除文件
-	if err := im.qiniuService.DeleteFile(img.Key); err != nil {
-		// 记录错误，但不阻止数据库删除
-		fmt.Printf("删除七牛云文件失败: %v\n", err)
-	}
-
 	// 从数据库删除记录
 	if err := im.db.Delete(&img).Error; err != nil {
 		return fmt.Errorf("删除图片记录失败: %v", err)
 	}
 
+	// 没有其他图片引用时删除存储文件，失败只记录错误
+	im.releaseObject(img.Key)
+
+	// 同步所属对象的封面和图片列表
+	im.syncGalleries(map[gallery]bool{{module: img.Module, moduleID: img.ModuleID}: true})
+
 	return nil
 }
 
 // BatchDeleteImages 批量删除图片
 func (im *ImageManager) BatchDeleteImages(ids []uint64, userID uint64) error {
 	var images []image.SysImage
-	if err := im.db.Where("id IN (?)", ids).Find(&images).Error; err != nil {
+	if err := im.db.Clauses(dbresolver.Write).Where("id IN (?)", ids).Find(&images).Error; err != nil {
 		return fmt.Errorf("查询图片失败: %v", err)
 	}
 
-	// 删除七牛云文件
-	for _, img := range images {
-		if err := im.qiniuService.DeleteFile(img.Key); err != nil {
-			fmt.Printf("删除七牛云文件失败 [%s]: %v\n", img.Key, err)
-		}
-	}
-
 	// 批量删除数据库记录
 	if err := im.db.Where("id IN (?)", ids).Delete(&image.SysImage{}).Error; err != nil {
 		return fmt.Errorf("批量删除图片记录失败: %v", err)
 	}
 
+	// 删除不再被引用的存储文件
+	released := make(map[string]bool)
+	galleries := make(map[gallery]bool)
+	for _, img := range images {
+		if !released[img.Key] {
+			released[img.Key] = true
+			im.releaseObject(img.Key)
+		}
+		galleries[gallery{module: img.Module, moduleID: img.ModuleID}] = true
+	}
+
+	// 同步所属对象的封面和图片列表
+	im.syncGalleries(galleries)
+
 	return nil
 }
 
+// ImageListQuery 图片列表查询定义
+var ImageListQuery = &query.Definition{
+	Table: "sys_images",
+	Key:   "id",
+	Fields: map[string]query.Field{
+		"name":      {Column: "name", Ops: query.TextOps, Sortable: true},
+		"category":  {Column: "category", Ops: query.EnumOps},
+		"module":    {Column: "module", Ops: query.EnumOps},
+		"moduleId":  {Column: "module_id", Type: query.Int, Ops: query.EnumOps},
+		"status":    {Column: "status", Ops: query.EnumOps},
+		"isMain":    {Column: "is_main", Type: query.Bool, Ops: []string{query.OpEq}, Sortable: true},
+		"isPublic":  {Column: "is_public", Type: query.Bool, Ops: []string{query.OpEq}},
+		"mimeType":  {Column: "mime_type", Ops: query.EnumOps},
+		"fileSize":  {Column: "file_size", Type: query.Int, Ops: query.RangeOps, Sortable: true},
+		"sortOrder": {Column: "sort_order", Type: query.Int, Ops: query.RangeOps, Sortable: true},
+		"createdAt": {Column: "created_at", Type: query.Time, Ops: query.RangeOps, Sortable: true},
+		"updatedAt": {Column: "updated_at", Type: query.Time, Ops: query.RangeOps, Sortable: true},
+	},
+	DefaultSort: "-createdAt",
+}
+
 // ListImages 获取图片列表
-func (im *ImageManager) ListImages(req *image.ImageListRequest) (*image.ImageListResponse, error) {
+func (im *ImageManager) ListImages(params *query.Params) (*query.Page, error) {
 	var images []*image.SysImage
-	var total int64
-
-	query := im.db.Model(&image.SysImage{})
-
-	// 构建查询条件
-	if req.Category != "" {
-		query = query.Where("category = ?", req.Category)
-	}
-	if req.Module != "" {
-		query = query.Where("module = ?", req.Module)
-	}
-	if req.ModuleID > 0 {
-		query = query.Where("module_id = ?", req.ModuleID)
-	}
-	if req.Status != "" {
-		query = query.Where("status = ?", req.Status)
-	}
-	if req.IsMain != nil {
-		query = query.Where("is_main = ?", *req.IsMain)
-	}
-	if req.IsPublic != nil {
-		query = query.Where("is_public = ?", *req.IsPublic)
-	}
-
-	// 获取总数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("获取总数失败: %v", err)
-	}
-
-	// 分页查询
-	offset := (req.Page - 1) * req.PageSize
-	orderBy := req.OrderBy
-	if req.OrderDir == "asc" {
-		orderBy += " ASC"
-	} else {
-		orderBy += " DESC"
-	}
-
-	if err := query.Offset(offset).Limit(req.PageSize).Order(orderBy).Find(&images).Error; err != nil {
-		return nil, fmt.Errorf("查询图片列表失败: %v", err)
+	page, err := ImageListQuery.Find(im.db.Where("deleted_at IS NULL"), params, &images)
+	if err != nil {
+		return nil, fmt.Errorf("获取图片列表失败: %v", err)
 	}
+	return page, nil
+}
 
-	return &image.ImageListResponse{
-		Total: total,
-		List:  images,
-	}, nil
+// EachImages 按游标分批遍历全部匹配的图片，用于流式导出
+func (im *ImageManager) EachImages(params *query.Params, fn func(rows interface{}) error) error {
+	return ImageListQuery.Each(im.db.Where("deleted_at IS NULL"), params, query.StreamBatchSize, func() interface{} {
+		return &[]*image.SysImage{}
+	}, fn)
 }
 
 // GetImageStats 获取图片统计信息
@@ -331,23 +373,85 @@ func (im *ImageManager) GetImageStats() (*image.ImageStats, error) {
 	return stats, nil
 }
 
-// SetMainImage 设置主图
+// SetMainImage 设置主图（图库封面），并同步所属对象的封面URL
+// 在一个事务中完成，图库内只有一张主图
 func (im *ImageManager) SetMainImage(module string, moduleID uint64, imageID uint64, userID uint64) error {
-	// 取消其他主图
-	if err := im.db.Model(&image.SysImage{}).Where("module = ? AND module_id = ? AND id != ?", module, moduleID, imageID).Update("is_main", false).Error; err != nil {
-		return fmt.Errorf("取消其他主图失败: %v", err)
+	return im.db.Transaction(func(tx *gorm.DB) error {
+		return setMainImage(tx, module, moduleID, imageID, userID)
+	})
+}
+
+// setMainImage 在事务中设置主图并同步所属对象，上传、修改图片设为主图都经过这里
+func setMainImage(tx *gorm.DB, module string, moduleID uint64, imageID uint64, userID uint64) error {
+	var img image.SysImage
+	err := tx.Where("id = ? AND module = ? AND module_id = ?", imageID, module, moduleID).First(&img).Error
+	if err == gorm.ErrRecordNotFound {
+		return rejectf("图片不存在或不属于该图库")
+	}
+	if err != nil {
+		return fmt.Errorf("查询图片失败: %v", err)
 	}
 
-	// 设置新主图
-	if err := im.db.Model(&image.SysImage{}).Where("id = ?", imageID).Updates(map[string]interface{}{
-		"is_main":    true,
-		"updated_by": userID,
-		"updated_at": time.Now(),
-	}).Error; err != nil {
+	// 单条语句切换主图，避免并发设置时出现多张主图
+	err = tx.Model(&image.SysImage{}).
+		Where("module = ? AND module_id = ?", module, moduleID).
+		Updates(map[string]interface{}{
+			"is_main":    gorm.Expr("id = ?", imageID),
+			"updated_by": userID,
+			"updated_at": time.Now(),
+		}).Error
+	if err != nil {
 		return fmt.Errorf("设置主图失败: %v", err)
 	}
 
-	return nil
+	return syncOwnerImages(tx, module, moduleID)
+}
+
+// imageKey 生成图片存储Key，楼盘、房屋图片使用楼盘管理文件夹结构
+func (im *ImageManager) imageKey(module string, moduleID uint64, category, originalName string) string {
+	fileName := fmt.Sprintf("%s_%d_%s", category, time.Now().UnixNano(), originalName)
+	var customKey string
+
+	// 如果是楼盘相关的图片，使用楼盘管理文件夹结构
+	if module == "building" || module == "house" {
+		if moduleID > 0 {
+			// 获取楼盘信息以构建正确的路径
+			var building struct {
+				ID   uint64 `json:"id"`
+				Name string `json:"name"`
+				City string `json:"city"`
+			}
+
+			// 从数据库获取楼盘信息
+			if err := im.db.Clauses(dbresolver.Write).Table("sys_buildings").
+				Select("id, name, city").
+				Where("id = ?", moduleID).
+				First(&building).Error; err == nil {
+
+				// 使用楼盘表中的城市名称
+				cityName := building.City
+
+				// 构建新的楼盘管理文件夹路径
+				safeCityName := im.sanitizeFolderName(cityName)
+				safeBuildingName := im.sanitizeFolderName(building.Name)
+				buildingFolderName := fmt.Sprintf("%d-%s", building.ID, safeBuildingName)
+
+				// 格式: 楼盘管理/{城市名}/{楼盘ID-楼盘名称}/{category}/{timestamp}_{filename}
+				customKey = fmt.Sprintf("楼盘管理/%s/%s/%s/%s", safeCityName, buildingFolderName, category, fileName)
+			} else {
+				// 如果获取楼盘信息失败，使用备用路径
+				customKey = fmt.Sprintf("楼盘管理/未分类楼盘/%s/%s", category, fileName)
+			}
+		} else {
+			// 如果没有指定楼盘ID，使用通用楼盘文件夹
+			customKey = fmt.Sprintf("楼盘管理/通用文件夹/%s/%s", category, fileName)
+		}
+	} else {
+		// 其他模块使用原有逻辑
+		customKey = im.uploadKey(fileName)
+	}
+
+	return customKey
 }
 
 // getFileExtension 获取文件扩展名
@@ -360,6 +464,117 @@ func (im *ImageManager) getFileExtension(filename string) string {
 	return ""
 }
 
+// uploadFile 经过检查和处理、待上传的图片
+type uploadFile struct {
+	data     []byte // 处理后的文件内容（已去除元数据、按EXIF方向旋转）
+	mimeType string // 按文件头识别的真实类型
+	width    int
+	height   int
+	hash     string // 处理后内容的 SHA-256
+	category string // 图片分类，用于选择水印
+	module   string // 所属模块，用于选择水印
+}
+
+// prepareFile 按图片分类的上传限制验证文件，并在服务端解码检查图片
+// 文件类型以文件头识别的真实格式为准，不信任客户端的 Content-Type
+func (im *ImageManager) prepareFile(file *multipart.FileHeader, rules *uploadRules) (*uploadFile, error) {
+	// 检查文件大小
+	if err := rules.checkSize(file.Size); err != nil {
+		return nil, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取上传文件失败: %v", err)
+	}
+	defer src.Close()
+	data, err := io.ReadAll(io.LimitReader(src, rules.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取上传文件失败: %v", err)
+	}
+	if err := rules.checkSize(int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	return im.prepareData(data, rules)
+}
+
+// prepareData 在服务端解码检查图片内容
+func (im *ImageManager) prepareData(data []byte, rules *uploadRules) (*uploadFile, error) {
+	cfg := config.GetStorageConfig()
+
+	// 解码检查：真实格式、尺寸、解压炸弹、最小分辨率，去除EXIF并自动旋转
+	result, err := imaging.Process(data, imaging.Limits{
+		MinWidth:  cfg.Image.MinWidth,
+		MinHeight: cfg.Image.MinHeight,
+		MaxPixels: cfg.Image.MaxPixels,
+	})
+	if err != nil {
+		return nil, &RejectedError{Reason: err.Error()}
+	}
+
+	// 检查文件类型
+	if err := rules.checkType(result.MimeType); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(result.Data)
+	return &uploadFile{
+		data:     result.Data,
+		mimeType: result.MimeType,
+		width:    result.Width,
+		height:   result.Height,
+		hash:     hex.EncodeToString(sum[:]),
+		category: rules.category,
+	}, nil
+}
+
+// uploadKey 生成非楼盘模块文件的存储Key，加上配置的上传目录前缀
+func (im *ImageManager) uploadKey(fileName string) string {
+	if dir := config.GetStorageConfig().Upload.UploadDir; dir != "" {
+		return fmt.Sprintf("%s/%s", dir, fileName)
+	}
+	return fileName
+}
+
+// putFile 上传文件到存储并生成各样式URL
+func (im *ImageManager) putFile(file *uploadFile, key string) (*UploadResult, error) {
+	obj, err := im.store.Put(key, bytes.NewReader(file.data), int64(len(file.data)), file.mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	result := im.storedResult(obj.Key, file)
+	result.Hash = obj.Hash
+	result.Size = obj.Size
+	return result, nil
+}
+
+// storedResult 为已在存储中的文件生成各样式URL（按配置生成衍生图）
+func (im *ImageManager) storedResult(key string, file *uploadFile) *UploadResult {
+	originalURL := im.store.PublicURL(key)
+	styles := im.styleURLs(key, originalURL, file.data, im.watermarkFor(file.category, file.module))
+
+	return &UploadResult{
+		Key:          key,
+		Hash:         file.hash,
+		Size:         int64(len(file.data)),
+		OriginalURL:  originalURL,
+		ThumbnailURL: styles["thumbnail"],
+		MediumURL:    styles["medium"],
+		LargeURL:     styles["large"],
+		Styles:       styles,
+	}
+}
+
+// putText 上传文本内容（文件夹标记文件等）
+func (im *ImageManager) putText(key string, content string) error {
+	if _, err := im.store.Put(key, strings.NewReader(content), int64(len(content)), "application/json"); err != nil {
+		return fmt.Errorf("上传文本内容失败: %v", err)
+	}
+	return nil
+}
+
 // 全局图片管理器实例
 var ImageManagerInstance *ImageManager
 
@@ -375,10 +590,19 @@ func InitImageManager() error {
 
 // UploadBuildingFloorPlan 上传楼盘户型图
 func (im *ImageManager) UploadBuildingFloorPlan(file *multipart.FileHeader, buildingID uint64, houseTypeID uint64, userID uint64) (*image.SysImage, error) {
-	// 验证文件
-	if err := im.qiniuService.ValidateFile(file); err != nil {
+	// 按户型图分类检查数量并验证文件
+	rules, err := im.categoryRules(floorPlanCategory)
+	if err != nil {
+		return nil, err
+	}
+	if err := rules.checkCount(im.db, "house_floor_plan", houseTypeID, 1); err != nil {
 		return nil, err
 	}
+	prepared, err := im.prepareFile(file, rules)
+	if err != nil {
+		return nil, err
+	}
+	prepared.module = "house_floor_plan"
 
 	// 获取楼盘信息（城市和名称）
 	var building struct {
@@ -386,7 +610,7 @@ func (im *ImageManager) UploadBuildingFloorPlan(file *multipart.FileHeader, buil
 		Name string `json:"name"`
 		City string `json:"city"`
 	}
-	result := im.db.Table("sys_buildings").Where("id = ? AND deleted_at IS NULL", buildingID).First(&building)
+	result := im.db.Clauses(dbresolver.Write).Table("sys_buildings").Where("id = ? AND deleted_at IS NULL", buildingID).First(&building)
 	if result.Error != nil {
 		return nil, fmt.Errorf("获取楼盘信息失败: %v", result.Error)
 	}
@@ -396,7 +620,7 @@ func (im *ImageManager) UploadBuildingFloorPlan(file *multipart.FileHeader, buil
 		Name         string  `json:"name"`
 		StandardArea float64 `json:"standard_area"`
 	}
-	result = im.db.Table("sys_house_types").Where("id = ? AND deleted_at IS NULL", houseTypeID).First(&houseType)
+	result = im.db.Clauses(dbresolver.Write).Table("sys_house_types").Where("id = ? AND deleted_at IS NULL", houseTypeID).First(&houseType)
 	if result.Error != nil {
 		return nil, fmt.Errorf("获取户型信息失败: %v", result.Error)
 	}
@@ -408,10 +632,10 @@ func (im *ImageManager) UploadBuildingFloorPlan(file *multipart.FileHeader, buil
 	houseTypeFolderName := fmt.Sprintf("%s-%.0f平米", sanitizedHouseTypeName, houseType.StandardArea)
 	customKey := fmt.Sprintf("楼盘管理/%s/%d-%s/building-images/%s/%s", building.City, building.ID, sanitizedBuildingName, houseTypeFolderName, fileName)
 
-	// 上传到七牛云
-	uploadResult, err := im.qiniuService.UploadFile(file, customKey)
+	// 上传到存储
+	uploadResult, err := im.storeFile(prepared, customKey)
 	if err != nil {
-		return nil, fmt.Errorf("上传到七牛云失败: %v", err)
+		return nil, fmt.Errorf("上传文件失败: %v", err)
 	}
 
 	// 保存到数据库
@@ -419,16 +643,19 @@ func (im *ImageManager) UploadBuildingFloorPlan(file *multipart.FileHeader, buil
 		Name:         file.Filename,
 		Description:  fmt.Sprintf("楼盘%d的户型图", buildingID),
 		FileName:     file.Filename,
-		FileSize:     file.Size,
-		MimeType:     file.Header.Get("Content-Type"),
+		FileSize:     int64(len(prepared.data)),
+		MimeType:     prepared.mimeType,
 		Extension:    im.getFileExtension(file.Filename),
 		Key:          uploadResult.Key,
 		URL:          uploadResult.OriginalURL,
 		ThumbnailURL: uploadResult.ThumbnailURL,
 		MediumURL:    uploadResult.MediumURL,
 		LargeURL:     uploadResult.LargeURL,
-		Category:     "floor_plan",
-		Module:       "house",
+		Hash:         uploadResult.Hash,
+		Width:        prepared.width,
+		Height:       prepared.height,
+		Category:     floorPlanCategory,
+		Module:       "house_floor_plan",
 		ModuleID:     houseTypeID, // 使用户型ID作为模块ID
 		IsPublic:     true,
 		IsMain:       false,
@@ -440,10 +667,11 @@ func (im *ImageManager) UploadBuildingFloorPlan(file *multipart.FileHeader, buil
 		UpdatedAt:    time.Now(),
 	}
 
-	if err := im.db.Create(img).Error; err != nil {
+	// 上传期间其他请求可能已写入图片，检查数量和保存在同一事务中
+	if err := im.createImage(rules, img, uploadResult); err != nil {
 		// 如果数据库保存失败，删除已上传的文件
-		im.qiniuService.DeleteFile(uploadResult.Key)
-		return nil, fmt.Errorf("保存到数据库失败: %v", err)
+		im.releaseObject(uploadResult.Key)
+		return nil, err
 	}
 
 	return img, nil
@@ -470,10 +698,10 @@ func (im *ImageManager) GetBuildingImages(buildingID uint64, category string) ([
 
 // GetBuildingFloorPlans 获取楼盘的所有户型图
 func (im *ImageManager) GetBuildingFloorPlans(buildingID uint64) ([]*image.SysImage, error) {
-	return im.GetBuildingImages(buildingID, "floor_plan")
+	return im.GetBuildingImages(buildingID, floorPlanCategory)
 }
 
-// CreateBuildingFolder 创建楼盘文件夹结构并在七牛云上创建相关目录
+// CreateBuildingFolder 创建楼盘文件夹结构并在存储上创建相关目录
 // 新的文件夹结构：楼盘管理/{城市名}/{楼盘ID-楼盘名称}/{子文件夹}/
 func (im *ImageManager) CreateBuildingFolder(buildingID uint64, buildingName string) error {
 	// 从数据库获取楼盘所在城市信息
@@ -515,9 +743,9 @@ func (im *ImageManager) CreateBuildingFolder(buildingID uint64, buildingName str
 		"documents":       "相关文档",
 	}
 
-	// 在七牛云上创建文件夹标记文件（使用新的楼盘管理结构）
+	// 在存储上创建文件夹标记文件（使用新的楼盘管理结构）
 	if err := im.createBuildingManagementFolderStructure(buildingID, buildingName, cityName, folderStructure); err != nil {
-		fmt.Printf("⚠️  七牛云文件夹创建失败: %v\n", err)
+		fmt.Printf("⚠️  存储文件夹创建失败: %v\n", err)
 		// 不阻止楼盘创建，只记录错误
 	}
 
@@ -543,8 +771,8 @@ func (im *ImageManager) CreateBuildingFolder(buildingID uint64, buildingName str
 // InitializeCityFolders 初始化所有城市的基础文件夹结构
 // 创建楼盘管理主文件夹，并根据数据库城市表创建所有城市文件夹
 func (im *ImageManager) InitializeCityFolders() error {
-	if im.qiniuService == nil {
-		return fmt.Errorf("七牛云服务未初始化")
+	if im.store == nil {
+		return fmt.Errorf("文件存储未初始化")
 	}
 
 	// 1. 创建楼盘管理主文件夹
@@ -558,7 +786,7 @@ func (im *ImageManager) InitializeCityFolders() error {
   "purpose": "楼盘管理系统的根目录文件夹"
 }`, time.Now().Format("2006-01-02 15:04:05"))
 
-	if err := im.qiniuService.UploadText(mainFolderKey, mainFolderContent); err != nil {
+	if err := im.putText(mainFolderKey, mainFolderContent); err != nil {
 		fmt.Printf("⚠️  创建楼盘管理主文件夹失败: %v\n", err)
 	} else {
 		fmt.Printf("📁 创建楼盘管理主文件夹: 楼盘管理/\n")
@@ -596,7 +824,7 @@ func (im *ImageManager) InitializeCityFolders() error {
   "purpose": "存储%s市的所有楼盘信息"
 }`, city.ID, city.Name, city.Code, safeCityName, time.Now().Format("2006-01-02 15:04:05"), city.Name)
 
-		if err := im.qiniuService.UploadText(cityFolderKey, cityFolderContent); err != nil {
+		if err := im.putText(cityFolderKey, cityFolderContent); err != nil {
 			fmt.Printf("⚠️  创建城市文件夹失败 %s: %v\n", city.Name, err)
 			continue
 		}
@@ -608,11 +836,11 @@ func (im *ImageManager) InitializeCityFolders() error {
 	return nil
 }
 
-// createBuildingManagementFolderStructure 在七牛云上创建楼盘管理文件夹结构
+// createBuildingManagementFolderStructure 在存储上创建楼盘管理文件夹结构
 // 新结构：楼盘管理/{城市名}/{楼盘ID-楼盘名称}/{子文件夹}/
 func (im *ImageManager) createBuildingManagementFolderStructure(buildingID uint64, buildingName, cityName string, folders map[string]string) error {
-	if im.qiniuService == nil {
-		return fmt.Errorf("七牛云服务未初始化")
+	if im.store == nil {
+		return fmt.Errorf("文件存储未初始化")
 	}
 
 	// 处理城市名称和楼盘名称，确保适合作为文件夹名称
@@ -620,7 +848,7 @@ func (im *ImageManager) createBuildingManagementFolderStructure(buildingID uint6
 	safeBuildingName := im.sanitizeFolderName(buildingName)
 	buildingFolderName := fmt.Sprintf("%d-%s", buildingID, safeBuildingName)
 
-	// 为每个文件夹创建一个标记文件（因为七牛云不支持空文件夹）
+	// 为每个文件夹创建一个标记文件（因为对象存储不支持空文件夹）
 	for folder, desc := range folders {
 		// 创建文件夹标记文件的key，使用楼盘管理/城市/楼盘/子文件夹的层级结构
 		folderKey := fmt.Sprintf("楼盘管理/%s/%s/%s/.folder", safeCityName, buildingFolderName, folder)
@@ -639,13 +867,13 @@ func (im *ImageManager) createBuildingManagementFolderStructure(buildingID uint6
   "purpose": "楼盘管理系统文件夹结构标记文件"
 }`, buildingID, buildingName, cityName, buildingFolderName, folder, desc, safeCityName, buildingFolderName, folder, time.Now().Format("2006-01-02 15:04:05"))
 
-		// 上传标记文件到七牛云
-		if err := im.qiniuService.UploadText(folderKey, content); err != nil {
+		// 上传标记文件到存储
+		if err := im.putText(folderKey, content); err != nil {
 			fmt.Printf("⚠️  创建文件夹标记失败 %s: %v\n", folder, err)
 			continue
 		}
 
-		fmt.Printf("📁 创建七牛云文件夹: 楼盘管理/%s/%s/%s/\n", safeCityName, buildingFolderName, folder)
+		fmt.Printf("📁 创建存储文件夹: 楼盘管理/%s/%s/%s/\n", safeCityName, buildingFolderName, folder)
 	}
 
 	return nil
@@ -703,7 +931,7 @@ func (im *ImageManager) recordBuildingFolderInfo(buildingID uint64, buildingName
 	return nil
 }
 
-// CreateHouseTypeFolder 为新创建的户型在七牛云创建文件夹
+// CreateHouseTypeFolder 为新创建的户型在存储上创建文件夹
 func (im *ImageManager) CreateHouseTypeFolder(buildingID uint64, houseTypeName string, standardArea float64) error {
 	// 获取楼盘信息
 	var building struct {
@@ -711,7 +939,7 @@ func (im *ImageManager) CreateHouseTypeFolder(buildingID uint64, houseTypeName s
 		Name string `json:"name"`
 		City string `json:"city"`
 	}
-	result := im.db.Table("sys_buildings").Where("id = ? AND deleted_at IS NULL", buildingID).First(&building)
+	result := im.db.Clauses(dbresolver.Write).Table("sys_buildings").Where("id = ? AND deleted_at IS NULL", buildingID).First(&building)
 	if result.Error != nil {
 		return fmt.Errorf("获取楼盘信息失败: %v", result.Error)
 	}
@@ -740,8 +968,8 @@ func (im *ImageManager) CreateHouseTypeFolder(buildingID uint64, houseTypeName s
   "purpose": "存储户型图片的文件夹"
 }`, building.ID, building.Name, building.City, houseTypeName, standardArea, houseTypeFolderName, folderPath, time.Now().Format("2006-01-02 15:04:05"))
 
-	// 上传标记文件到七牛云
-	if err := im.qiniuService.UploadText(folderKey, content); err != nil {
+	// 上传标记文件到存储
+	if err := im.putText(folderKey, content); err != nil {
 		return fmt.Errorf("创建户型文件夹失败: %v", err)
 	}
 
@@ -755,20 +983,22 @@ func (im *ImageManager) UploadHouseTypeFloorPlans(files []*multipart.FileHeader,
 		return nil, fmt.Errorf("没有上传文件")
 	}
 
-	if len(files) > 5 {
-		return nil, fmt.Errorf("最多只能上传5张户型图")
+	// 按户型图分类检查数量（已有图片加本次上传不超过分类的最大数量）
+	rules, err := im.categoryRules(floorPlanCategory)
+	if err != nil {
+		return nil, err
+	}
+	if err := rules.checkCount(im.db, "house_floor_plan", houseTypeID, len(files)); err != nil {
+		return nil, err
 	}
 
 	// 检查当前已有的图片数量
 	var existingCount int64
-	err := im.db.Table("sys_images").Where("module = 'house_floor_plan' AND module_id = ? AND deleted_at IS NULL", houseTypeID).Count(&existingCount).Error
+	err = im.db.Clauses(dbresolver.Write).Table("sys_images").Where("module = 'house_floor_plan' AND module_id = ? AND deleted_at IS NULL", houseTypeID).Count(&existingCount).Error
 	if err != nil {
 		return nil, fmt.Errorf("查询现有图片数量失败: %v", err)
 	}
 
-	// 用户需求：单次最多5张，总数不限
-	// 已在前端和API层面控制单次上传数量，此处不再限制总数
-
 	// 获取户型和楼盘信息
 	var houseType struct {
 		ID           uint64  `json:"id"`
@@ -776,7 +1006,7 @@ func (im *ImageManager) UploadHouseTypeFloorPlans(files []*multipart.FileHeader,
 		Name         string  `json:"name"`
 		StandardArea float64 `json:"standard_area"`
 	}
-	result := im.db.Table("sys_house_types").Where("id = ? AND deleted_at IS NULL", houseTypeID).First(&houseType)
+	result := im.db.Clauses(dbresolver.Write).Table("sys_house_types").Where("id = ? AND deleted_at IS NULL", houseTypeID).First(&houseType)
 	if result.Error != nil {
 		return nil, fmt.Errorf("获取户型信息失败: %v", result.Error)
 	}
@@ -786,30 +1016,25 @@ func (im *ImageManager) UploadHouseTypeFloorPlans(files []*multipart.FileHeader,
 		Name string `json:"name"`
 		City string `json:"city"`
 	}
-	result = im.db.Table("sys_buildings").Where("id = ? AND deleted_at IS NULL", houseType.BuildingID).First(&building)
+	result = im.db.Clauses(dbresolver.Write).Table("sys_buildings").Where("id = ? AND deleted_at IS NULL", houseType.BuildingID).First(&building)
 	if result.Error != nil {
 		return nil, fmt.Errorf("获取楼盘信息失败: %v", result.Error)
 	}
 
 	var uploadedImages []*image.SysImage
-	var sortOrder int
-
-	// 获取当前最大排序号
-	err = im.db.Raw("SELECT COALESCE(MAX(sort_order), 0) FROM sys_images WHERE module = 'house_floor_plan' AND module_id = ? AND deleted_at IS NULL", houseTypeID).Scan(&sortOrder).Error
-	if err != nil {
-		sortOrder = 0
-	}
 
-	// 依次上传每个文件
+	// 依次上传每个文件，保存时排在图库末尾，并同步户型的 floor_plan_url
 	for i, file := range files {
 		// 验证文件
-		if err := im.qiniuService.ValidateFile(file); err != nil {
+		prepared, err := im.prepareFile(file, rules)
+		if err != nil {
 			// 如果有文件上传失败，清理已上传的文件
 			for _, img := range uploadedImages {
 				im.DeleteImage(img.ID, userID)
 			}
 			return nil, err
 		}
+		prepared.module = "house_floor_plan"
 
 		// 生成存储Key
 		fileName := fmt.Sprintf("floor_plan_%d_%s", time.Now().UnixNano(), file.Filename)
@@ -818,14 +1043,14 @@ func (im *ImageManager) UploadHouseTypeFloorPlans(files []*multipart.FileHeader,
 		houseTypeFolderName := fmt.Sprintf("%s-%.0f平米", sanitizedHouseTypeName, houseType.StandardArea)
 		customKey := fmt.Sprintf("楼盘管理/%s/%d-%s/building-images/%s/%s", building.City, building.ID, sanitizedBuildingName, houseTypeFolderName, fileName)
 
-		// 上传到七牛云
-		uploadResult, err := im.qiniuService.UploadFile(file, customKey)
+		// 上传到存储
+		uploadResult, err := im.storeFile(prepared, customKey)
 		if err != nil {
 			// 如果上传失败，清理已上传的文件
 			for _, img := range uploadedImages {
 				im.DeleteImage(img.ID, userID)
 			}
-			return nil, fmt.Errorf("上传到七牛云失败: %v", err)
+			return nil, fmt.Errorf("上传文件失败: %v", err)
 		}
 
 		// 保存到数据库
@@ -833,45 +1058,39 @@ func (im *ImageManager) UploadHouseTypeFloorPlans(files []*multipart.FileHeader,
 			Name:         file.Filename,
 			Description:  fmt.Sprintf("户型%s的户型图", houseType.Name),
 			FileName:     file.Filename,
-			FileSize:     file.Size,
-			MimeType:     file.Header.Get("Content-Type"),
+			FileSize:     int64(len(prepared.data)),
+			MimeType:     prepared.mimeType,
 			Extension:    im.getFileExtension(file.Filename),
 			Key:          uploadResult.Key,
 			URL:          uploadResult.OriginalURL,
 			ThumbnailURL: uploadResult.ThumbnailURL,
 			MediumURL:    uploadResult.MediumURL,
 			LargeURL:     uploadResult.LargeURL,
-			Category:     "floor_plan",
+			Hash:         uploadResult.Hash,
+			Width:        prepared.width,
+			Height:       prepared.height,
+			Category:     floorPlanCategory,
 			Module:       "house_floor_plan",
 			ModuleID:     houseTypeID,
 			IsPublic:     true,
 			IsMain:       i == 0 && existingCount == 0, // 第一张图片且当前没有图片时设为主图
-			SortOrder:    sortOrder + i + 1,
 			Status:       "active",
 			CreatedBy:    userID,
 			UpdatedBy:    userID,
 		}
 
-		result := im.db.Create(img)
-		if result.Error != nil {
+		if err := im.createImage(rules, img, uploadResult); err != nil {
 			// 如果数据库保存失败，清理已上传的文件
+			im.releaseObject(uploadResult.Key)
 			for _, prevImg := range uploadedImages {
 				im.DeleteImage(prevImg.ID, userID)
 			}
-			return nil, fmt.Errorf("保存图片信息到数据库失败: %v", result.Error)
+			return nil, err
 		}
 
 		uploadedImages = append(uploadedImages, img)
 	}
 
-	// 如果这是第一批图片，更新户型表的 floor_plan_url
-	if existingCount == 0 && len(uploadedImages) > 0 {
-		err = im.db.Exec("UPDATE sys_house_types SET floor_plan_url = ? WHERE id = ?", uploadedImages[0].URL, houseTypeID).Error
-		if err != nil {
-			fmt.Printf("⚠️  更新户型表floor_plan_url失败: %v\n", err)
-		}
-	}
-
 	return uploadedImages, nil
 }
 
@@ -0,0 +1,311 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
+
+	"rentPro/rentpro-admin/common/models/image"
+	"rentPro/rentpro-admin/common/storage"
+)
+
+// 存储对账
+//
+// 比对存储中的文件和 sys_images 记录，找出三类问题：
+//   - 孤立文件：存储中存在但没有图片记录引用（衍生图按原图记录计为已引用）
+//   - 丢失文件：图片记录指向的文件在存储中不存在
+//   - 悬空记录：图片记录所属的楼盘、户型、房屋已删除
+// 处理方式为隔离（移动到隔离目录，可恢复）或删除；图片记录一律软删除。
+
+// 对账处理方式
+const (
+	ReconcileActionReport     = "report"     // 只生成报告
+	ReconcileActionQuarantine = "quarantine" // 隔离
+	ReconcileActionDelete     = "delete"     // 删除
+)
+
+// ReconcileOptions 对账选项
+type ReconcileOptions struct {
+	Prefix           string        // 只检查该前缀下的文件和记录，为空检查全部
+	Action           string        // 处理方式
+	MinAge           time.Duration // 上传时间在此之内的孤立文件不处理（直传上传后尚未回调建档）
+	QuarantinePrefix string        // 隔离目录，对账时跳过该目录下的文件
+	BatchSize        int           // 每页列出的文件数、每批查询的记录数
+}
+
+// ReconcileObject 对账发现的孤立文件
+type ReconcileObject struct {
+	storage.Object
+	Action string `json:"action,omitempty"` // 处理结果：quarantined/deleted/skipped
+	Target string `json:"target,omitempty"` // 隔离后的存储key
+	Error  string `json:"error,omitempty"`
+}
+
+// ReconcileImage 对账发现的问题图片记录
+type ReconcileImage struct {
+	ID       uint64 `json:"id"`
+	Key      string `json:"key"`
+	Category string `json:"category"`
+	Module   string `json:"module"`
+	ModuleID uint64 `json:"moduleId"`
+	Reason   string `json:"reason"`
+	Action   string `json:"action,omitempty"` // 处理结果：quarantined/deleted
+	Target   string `json:"target,omitempty"` // 文件隔离后的存储key
+	Error    string `json:"error,omitempty"`
+}
+
+// ReconcileReport 对账报告
+type ReconcileReport struct {
+	Driver         string            `json:"driver"`
+	Prefix         string            `json:"prefix"`
+	Action         string            `json:"action"`
+	DryRun         bool              `json:"dryRun"`
+	StartedAt      time.Time         `json:"startedAt"`
+	FinishedAt     time.Time         `json:"finishedAt"`
+	ObjectCount    int               `json:"objectCount"`    // 检查的文件数
+	ImageCount     int               `json:"imageCount"`     // 检查的图片记录数
+	OrphanObjects  []ReconcileObject `json:"orphanObjects"`  // 没有记录引用的文件
+	MissingObjects []ReconcileImage  `json:"missingObjects"` // 文件不存在的记录
+	DanglingImages []ReconcileImage  `json:"danglingImages"` // 所属对象已删除的记录
+}
+
+// HasIssues 是否发现问题
+func (r *ReconcileReport) HasIssues() bool {
+	return len(r.OrphanObjects) > 0 || len(r.MissingObjects) > 0 || len(r.DanglingImages) > 0
+}
+
+// ScanStorage 比对存储文件和图片记录，生成对账报告（不做任何修改）
+func (im *ImageManager) ScanStorage(opts *ReconcileOptions) (*ReconcileReport, error) {
+	report := &ReconcileReport{
+		Driver:         im.store.Driver(),
+		Prefix:         opts.Prefix,
+		Action:         opts.Action,
+		StartedAt:      time.Now(),
+		OrphanObjects:  make([]ReconcileObject, 0),
+		MissingObjects: make([]ReconcileImage, 0),
+		DanglingImages: make([]ReconcileImage, 0),
+	}
+
+	// 列出存储中的文件
+	objects := make(map[string]storage.Object)
+	var order []string
+	marker := ""
+	for {
+		page, next, err := im.store.List(opts.Prefix, marker, opts.BatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("列出存储文件失败: %v", err)
+		}
+		for _, obj := range page {
+			if opts.QuarantinePrefix != "" && strings.HasPrefix(obj.Key, opts.QuarantinePrefix) {
+				continue
+			}
+			// 文件夹占位文件（scripts/init_city_folders.go 创建）
+			if strings.HasSuffix(obj.Key, "/.folder") {
+				continue
+			}
+			objects[obj.Key] = obj
+			order = append(order, obj.Key)
+		}
+		if next == "" {
+			break
+		}
+		marker = next
+	}
+	report.ObjectCount = len(objects)
+
+	// 逐批检查图片记录
+	referenced := make(map[string]bool)
+	originals := make(map[string]string) // 衍生图前缀 → 原图key
+	owners := make(map[gallery]bool)
+	var lastID uint64
+	for {
+		var images []image.SysImage
+		err := im.db.Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(opts.BatchSize).
+			Find(&images).Error
+		if err != nil {
+			return nil, fmt.Errorf("查询图片失败: %v", err)
+		}
+		if len(images) == 0 {
+			break
+		}
+		lastID = images[len(images)-1].ID
+
+		for _, img := range images {
+			if !strings.HasPrefix(img.Key, opts.Prefix) {
+				continue
+			}
+			report.ImageCount++
+			referenced[img.Key] = true
+			originals[derivativePrefix(img.Key)] = img.Key
+
+			if _, ok := objects[img.Key]; !ok {
+				report.MissingObjects = append(report.MissingObjects, reconcileImage(&img, "存储文件不存在"))
+				continue
+			}
+
+			exists, err := im.ownerExists(owners, img.Module, img.ModuleID)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				reason := fmt.Sprintf("所属%s已删除", imageOwners[img.Module].title)
+				report.DanglingImages = append(report.DanglingImages, reconcileImage(&img, reason))
+			}
+		}
+	}
+
+	for _, key := range order {
+		if !referenced[key] && !isLiveDerivative(key, originals) {
+			report.OrphanObjects = append(report.OrphanObjects, ReconcileObject{Object: objects[key]})
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// ApplyReconcile 按报告处理孤立文件、丢失文件和悬空记录，处理结果写回报告
+// 孤立文件：隔离（移动到隔离目录）或删除，上传时间在 MinAge 之内的跳过；
+// 丢失文件的记录：软删除；悬空记录：软删除，没有其他记录引用的文件隔离或删除
+func (im *ImageManager) ApplyReconcile(report *ReconcileReport, opts *ReconcileOptions) {
+	if opts.Action != ReconcileActionQuarantine && opts.Action != ReconcileActionDelete {
+		return
+	}
+	quarantineDir := strings.TrimSuffix(opts.QuarantinePrefix, "/") + "/" + report.StartedAt.Format("20060102150405") + "/"
+
+	for i := range report.OrphanObjects {
+		item := &report.OrphanObjects[i]
+		if opts.MinAge > 0 && !item.PutTime.IsZero() && time.Since(item.PutTime) < opts.MinAge {
+			item.Action = "skipped"
+			continue
+		}
+		if opts.Action == ReconcileActionQuarantine {
+			item.Target = quarantineDir + item.Key
+			if err := im.moveObject(&item.Object, item.Target); err != nil {
+				item.Error = err.Error()
+				continue
+			}
+			item.Action = "quarantined"
+		} else {
+			if err := im.store.Delete(item.Key); err != nil && err != storage.ErrNotFound {
+				item.Error = err.Error()
+				continue
+			}
+			item.Action = "deleted"
+		}
+	}
+
+	galleries := make(map[gallery]bool)
+	for i := range report.MissingObjects {
+		item := &report.MissingObjects[i]
+		if err := im.db.Delete(&image.SysImage{}, item.ID).Error; err != nil {
+			item.Error = fmt.Sprintf("删除图片记录失败: %v", err)
+			continue
+		}
+		item.Action = "deleted"
+		galleries[gallery{module: item.Module, moduleID: item.ModuleID}] = true
+	}
+
+	for i := range report.DanglingImages {
+		item := &report.DanglingImages[i]
+		if err := im.db.Delete(&image.SysImage{}, item.ID).Error; err != nil {
+			item.Error = fmt.Sprintf("删除图片记录失败: %v", err)
+			continue
+		}
+		if opts.Action == ReconcileActionDelete {
+			im.releaseObject(item.Key)
+			item.Action = "deleted"
+			continue
+		}
+
+		item.Action = "quarantined"
+		var refs int64
+		if err := im.db.Clauses(dbresolver.Write).Model(&image.SysImage{}).Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: item.Key}).Count(&refs).Error; err != nil {
+			item.Error = fmt.Sprintf("查询存储文件引用失败: %v", err)
+			continue
+		}
+		if refs > 0 {
+			continue
+		}
+		obj, err := im.store.Stat(item.Key)
+		if err != nil {
+			item.Error = fmt.Sprintf("获取存储文件失败: %v", err)
+			continue
+		}
+		item.Target = quarantineDir + item.Key
+		if err := im.moveObject(obj, item.Target); err != nil {
+			item.Error = err.Error()
+			continue
+		}
+		// 衍生图可以从原图重新生成，不隔离
+		im.deleteDerivatives(item.Key)
+	}
+
+	// 丢失文件的记录可能是所属对象的封面
+	im.syncGalleries(galleries)
+	report.FinishedAt = time.Now()
+}
+
+// isLiveDerivative 是否为仍被引用的原图的衍生图
+// 按 key 中每个下划线位置截取前缀查找原图，不依赖当前的衍生图配置，旧规格、旧格式生成的衍生图也计为已引用
+func isLiveDerivative(key string, originals map[string]string) bool {
+	for i := strings.LastIndex(key, "_"); i >= 0; i = strings.LastIndex(key[:i], "_") {
+		if original, ok := originals[key[:i+1]]; ok && isDerivativeOf(key, original) {
+			return true
+		}
+	}
+	return false
+}
+
+// ownerExists 图片所属对象是否存在，结果缓存在 owners 中
+// 不关联对象的模块（common、avatar 等）和模块ID为 0 的记录视为存在
+func (im *ImageManager) ownerExists(owners map[gallery]bool, module string, moduleID uint64) (bool, error) {
+	owner, ok := imageOwners[module]
+	if !ok || moduleID == 0 {
+		return true, nil
+	}
+	g := gallery{module: module, moduleID: moduleID}
+	if exists, ok := owners[g]; ok {
+		return exists, nil
+	}
+	var count int64
+	if err := im.db.Table(owner.table).Where("id = ? AND deleted_at IS NULL", moduleID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询%s失败: %v", owner.title, err)
+	}
+	owners[g] = count > 0
+	return count > 0, nil
+}
+
+// moveObject 移动存储文件（复制到新key后删除原文件）
+func (im *ImageManager) moveObject(obj *storage.Object, target string) error {
+	reader, err := im.store.Get(obj.Key)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := im.store.Put(target, reader, obj.Size, obj.MimeType); err != nil {
+		return fmt.Errorf("复制文件失败: %v", err)
+	}
+	if err := im.store.Delete(obj.Key); err != nil && err != storage.ErrNotFound {
+		return fmt.Errorf("删除原文件失败: %v", err)
+	}
+	return nil
+}
+
+// reconcileImage 对账报告中的图片记录
+func reconcileImage(img *image.SysImage, reason string) ReconcileImage {
+	return ReconcileImage{
+		ID:       img.ID,
+		Key:      img.Key,
+		Category: img.Category,
+		Module:   img.Module,
+		ModuleID: img.ModuleID,
+		Reason:   reason,
+	}
+}
@@ -0,0 +1,443 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font/opentype"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
+
+	"rentPro/rentpro-admin/common/config"
+	"rentPro/rentpro-admin/common/imaging"
+	"rentPro/rentpro-admin/common/models/image"
+)
+
+// 衍生图水印
+//
+// sys_watermark_policies 按图片分类、模块配置水印（文字或Logo、位置、不透明度、大小），
+// 服务端生成衍生图时叠加，原图不加水印。一张图片只使用一个策略：分类和模块都匹配的优先，
+// 其次只匹配分类、只匹配模块、都为空的通用策略，同级取最新的策略。
+// 多条记录共享同一存储文件（内容去重）时，衍生图按最早的记录选择策略。
+// 水印只作用于服务端生成的衍生图，未开启服务端生成（七牛云默认使用图片样式URL）时不能启用策略。
+// 策略变更后受影响的文件加入重新生成队列，由一个后台任务按最新的策略串行处理，多次变更合并。
+
+// derivativeWatermark 一张图片使用的水印
+type derivativeWatermark struct {
+	mark  *imaging.Watermark
+	sizes []string // 适用的衍生图规格，为空适用所有规格
+}
+
+// forSize 衍生图规格使用的水印，不适用时返回 nil
+func (w *derivativeWatermark) forSize(name string) *imaging.Watermark {
+	if w == nil {
+		return nil
+	}
+	if len(w.sizes) == 0 {
+		return w.mark
+	}
+	for _, size := range w.sizes {
+		if size == name {
+			return w.mark
+		}
+	}
+	return nil
+}
+
+// watermarkSet 生效的水印策略，Logo 图片在一次处理中只读取一次
+type watermarkSet struct {
+	im       *ImageManager
+	policies []image.SysWatermarkPolicy
+	marks    map[uint64]*derivativeWatermark
+}
+
+// loadWatermarks 加载生效的水印策略
+func (im *ImageManager) loadWatermarks() (*watermarkSet, error) {
+	var policies []image.SysWatermarkPolicy
+	if err := im.db.Where("status = ?", "active").Order("id DESC").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("查询水印策略失败: %v", err)
+	}
+	return &watermarkSet{im: im, policies: policies, marks: make(map[uint64]*derivativeWatermark)}, nil
+}
+
+// watermarkFor 上传时选择图片的水印，策略加载失败时不加水印
+func (im *ImageManager) watermarkFor(category, module string) *derivativeWatermark {
+	set, err := im.loadWatermarks()
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return nil
+	}
+	return set.forImage(category, module)
+}
+
+// forImage 图片使用的水印，没有匹配的策略时返回 nil
+func (s *watermarkSet) forImage(category, module string) *derivativeWatermark {
+	policy := matchWatermarkPolicy(s.policies, category, module)
+	if policy == nil {
+		return nil
+	}
+	if mark, ok := s.marks[policy.ID]; ok {
+		return mark
+	}
+
+	var mark *derivativeWatermark
+	built, err := s.im.buildWatermark(policy)
+	if err != nil {
+		fmt.Printf("⚠️  水印策略「%s」不可用: %v\n", policy.Name, err)
+	} else {
+		mark = &derivativeWatermark{mark: built, sizes: policy.Sizes}
+	}
+	s.marks[policy.ID] = mark
+	return mark
+}
+
+// matchWatermarkPolicy 选择图片使用的水印策略，policies 按 ID 倒序（新的在前）
+func matchWatermarkPolicy(policies []image.SysWatermarkPolicy, category, module string) *image.SysWatermarkPolicy {
+	var best *image.SysWatermarkPolicy
+	bestScore := -1
+	for i := range policies {
+		p := &policies[i]
+		if (p.Category != "" && p.Category != category) || (p.Module != "" && p.Module != module) {
+			continue
+		}
+		score := 0
+		if p.Category != "" {
+			score += 2
+		}
+		if p.Module != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	if best != nil && best.ExemptFloorPlans && isFloorPlan(category, module) {
+		return nil
+	}
+	return best
+}
+
+// isFloorPlan 是否为户型图
+func isFloorPlan(category, module string) bool {
+	return category == floorPlanCategory || module == "house_floor_plan"
+}
+
+// buildWatermark 按策略生成水印，Logo 从存储读取
+func (im *ImageManager) buildWatermark(policy *image.SysWatermarkPolicy) (*imaging.Watermark, error) {
+	mark := &imaging.Watermark{
+		Position: policy.Position,
+		Opacity:  policy.Opacity,
+		Scale:    policy.Scale,
+		Margin:   policy.Margin,
+	}
+
+	if policy.Type == "logo" {
+		reader, err := im.store.Get(policy.LogoKey)
+		if err != nil {
+			return nil, fmt.Errorf("读取Logo失败: %v", err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取Logo失败: %v", err)
+		}
+		if mark.Logo, err = imaging.Decode(data); err != nil {
+			return nil, fmt.Errorf("解码Logo失败: %v", err)
+		}
+		return mark, nil
+	}
+
+	mark.Text = policy.Text
+	mark.Color = color.White
+	if policy.Color != "" {
+		c, err := parseHexColor(policy.Color)
+		if err != nil {
+			return nil, err
+		}
+		mark.Color = c
+	}
+	font, err := watermarkFont()
+	if err != nil {
+		return nil, err
+	}
+	mark.Font = font
+	return mark, nil
+}
+
+var (
+	fontMu     sync.Mutex
+	fontPath   string
+	loadedFont *opentype.Font
+)
+
+// watermarkFont 文字水印字体，使用 settings.storage.watermark.font，未配置时使用内置字体
+func watermarkFont() (*opentype.Font, error) {
+	path := config.GetStorageConfig().Watermark.Font
+	if path == "" {
+		return imaging.DefaultFont()
+	}
+
+	fontMu.Lock()
+	defer fontMu.Unlock()
+	if loadedFont != nil && fontPath == path {
+		return loadedFont, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取水印字体失败: %v", err)
+	}
+	f, err := imaging.ParseFont(data)
+	if err != nil {
+		return nil, err
+	}
+	fontPath, loadedFont = path, f
+	return f, nil
+}
+
+// parseHexColor 解析 #RGB、#RRGGBB 格式的颜色
+func parseHexColor(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("颜色格式错误: %s", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("颜色格式错误: %s", s)
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// ListWatermarkPolicies 获取水印策略列表，status 为空时返回全部
+func (im *ImageManager) ListWatermarkPolicies(status string) ([]image.SysWatermarkPolicy, error) {
+	var policies []image.SysWatermarkPolicy
+	query := im.db.Order("id ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("查询水印策略失败: %v", err)
+	}
+	return policies, nil
+}
+
+// GetWatermarkPolicy 获取水印策略
+func (im *ImageManager) GetWatermarkPolicy(id uint64) (*image.SysWatermarkPolicy, error) {
+	return getWatermarkPolicy(im.db, id)
+}
+
+// getWatermarkPolicy 在 db 上查询水印策略
+func getWatermarkPolicy(db *gorm.DB, id uint64) (*image.SysWatermarkPolicy, error) {
+	var policy image.SysWatermarkPolicy
+	if err := db.First(&policy, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, rejectf("水印策略不存在")
+		}
+		return nil, fmt.Errorf("查询水印策略失败: %v", err)
+	}
+	return &policy, nil
+}
+
+// CreateWatermarkPolicy 创建水印策略，返回需要重新生成衍生图的文件数
+func (im *ImageManager) CreateWatermarkPolicy(req *image.WatermarkPolicyRequest, userID uint64) (*image.SysWatermarkPolicy, int, error) {
+	policy := &image.SysWatermarkPolicy{CreatedBy: userID}
+	if err := im.applyWatermarkRequest(policy, req, userID); err != nil {
+		return nil, 0, err
+	}
+	if err := im.db.Create(policy).Error; err != nil {
+		return nil, 0, fmt.Errorf("创建水印策略失败: %v", err)
+	}
+	return policy, im.regenerateWatermarked(policy), nil
+}
+
+// UpdateWatermarkPolicy 更新水印策略，原适用范围和新适用范围的图片都重新生成衍生图
+// 读取后整行写回，从主库读取
+func (im *ImageManager) UpdateWatermarkPolicy(id uint64, req *image.WatermarkPolicyRequest, userID uint64) (*image.SysWatermarkPolicy, int, error) {
+	policy, err := getWatermarkPolicy(im.db.Clauses(dbresolver.Write), id)
+	if err != nil {
+		return nil, 0, err
+	}
+	previous := *policy
+	if err := im.applyWatermarkRequest(policy, req, userID); err != nil {
+		return nil, 0, err
+	}
+	if err := im.db.Save(policy).Error; err != nil {
+		return nil, 0, fmt.Errorf("更新水印策略失败: %v", err)
+	}
+	return policy, im.regenerateWatermarked(&previous, policy), nil
+}
+
+// DeleteWatermarkPolicy 删除水印策略，适用范围内的图片重新生成衍生图
+func (im *ImageManager) DeleteWatermarkPolicy(id uint64) (int, error) {
+	policy, err := getWatermarkPolicy(im.db.Clauses(dbresolver.Write), id)
+	if err != nil {
+		return 0, err
+	}
+	if err := im.db.Delete(&image.SysWatermarkPolicy{}, id).Error; err != nil {
+		return 0, fmt.Errorf("删除水印策略失败: %v", err)
+	}
+	return im.regenerateWatermarked(policy), nil
+}
+
+// applyWatermarkRequest 校验请求并写入策略
+func (im *ImageManager) applyWatermarkRequest(policy *image.SysWatermarkPolicy, req *image.WatermarkPolicyRequest, userID uint64) error {
+	cfg := config.GetStorageConfig()
+	if req.Status != "inactive" && !cfg.DerivativesEnabled() {
+		return rejectf("未开启服务端生成衍生图（settings.storage.derivatives.enabled），水印不会生效，只能保存为停用状态")
+	}
+	switch req.Type {
+	case "text":
+		if strings.TrimSpace(req.Text) == "" {
+			return rejectf("文字水印需要填写水印文字")
+		}
+	case "logo":
+		if req.LogoKey == "" {
+			return rejectf("Logo水印需要指定Logo图片")
+		}
+	}
+	sizes := cfg.Derivatives.Sizes
+	for _, name := range req.Sizes {
+		if _, ok := sizes[name]; !ok {
+			return rejectf("衍生图规格不存在: %s", name)
+		}
+	}
+
+	policy.Name = req.Name
+	policy.Category = req.Category
+	policy.Module = req.Module
+	policy.Type = req.Type
+	policy.Text = req.Text
+	policy.Color = req.Color
+	policy.LogoKey = req.LogoKey
+	policy.Position = req.Position
+	policy.Opacity = req.Opacity
+	policy.Scale = req.Scale
+	policy.Margin = req.Margin
+	policy.Sizes = req.Sizes
+	policy.ExemptFloorPlans = req.ExemptFloorPlans
+	policy.Status = req.Status
+	if policy.Status == "" {
+		policy.Status = "active"
+	}
+	policy.UpdatedBy = userID
+
+	// 保存前试生成一次，Logo 不存在、字体不支持等问题直接返回
+	if _, err := im.buildWatermark(policy); err != nil {
+		return rejectf("水印不可用: %v", err)
+	}
+	return nil
+}
+
+// regenerateWatermarked 将策略适用范围内的文件加入重新生成队列，返回文件数
+// 未开启服务端生成衍生图时不处理
+func (im *ImageManager) regenerateWatermarked(policies ...*image.SysWatermarkPolicy) int {
+	if !config.GetStorageConfig().DerivativesEnabled() {
+		return 0
+	}
+
+	keys := make(map[string]bool)
+	for _, policy := range policies {
+		query := im.db.Model(&image.SysImage{})
+		if policy.Category != "" {
+			query = query.Where("category = ?", policy.Category)
+		}
+		if policy.Module != "" {
+			query = query.Where("module = ?", policy.Module)
+		}
+		var found []string
+		if err := query.Distinct().Pluck("key", &found).Error; err != nil {
+			fmt.Printf("⚠️  查询水印策略影响的图片失败: %v\n", err)
+			continue
+		}
+		for _, key := range found {
+			keys[key] = true
+		}
+	}
+	if len(keys) == 0 {
+		return 0
+	}
+
+	root := im.root()
+	if root.regenerate.add(keys) {
+		go root.runRegenerate()
+	}
+	return len(keys)
+}
+
+// regenerateQueue 等待重新生成衍生图的文件
+// 同一文件只排队一次，处理时按最新的策略生成，策略连续变更只重新生成一次；同时只有一个后台任务在处理
+type regenerateQueue struct {
+	mu      sync.Mutex
+	pending map[string]bool
+	running bool
+}
+
+// add 加入队列，没有后台任务在处理时返回 true，由调用方启动
+func (q *regenerateQueue) add(keys map[string]bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == nil {
+		q.pending = make(map[string]bool)
+	}
+	for key := range keys {
+		q.pending[key] = true
+	}
+	if q.running {
+		return false
+	}
+	q.running = true
+	return true
+}
+
+// take 取出当前排队的全部文件，队列为空时结束后台任务
+func (q *regenerateQueue) take() map[string]bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		q.running = false
+		return nil
+	}
+	keys := q.pending
+	q.pending = make(map[string]bool)
+	return keys
+}
+
+// runRegenerate 后台任务：逐批处理队列，每批重新加载策略，处理期间新加入的文件在下一批处理
+func (im *ImageManager) runRegenerate() {
+	for {
+		keys := im.regenerate.take()
+		if keys == nil {
+			return
+		}
+
+		set, err := im.loadWatermarks()
+		if err != nil {
+			fmt.Printf("⚠️  重新生成衍生图失败: %v\n", err)
+			continue
+		}
+		failed := 0
+		for key := range keys {
+			var img image.SysImage
+			err := im.db.Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: key}).
+				Order("id ASC").
+				First(&img).Error
+			if err == nil {
+				err = im.backfillOne(&img, set)
+			}
+			if err != nil {
+				failed++
+				fmt.Printf("❌ 重新生成衍生图失败 [%s]: %v\n", key, err)
+			}
+		}
+		fmt.Printf("✅ 水印策略变更，重新生成 %d 个文件的衍生图，失败 %d\n", len(keys), failed)
+	}
+}
@@ -32,8 +32,15 @@ func NewJWT(config JWTConfig) *JWT {
 	}
 }
 
+// errEmptySecret 未配置密钥时不签发也不接受 token
+var errEmptySecret = errors.New("未配置 JWT 密钥")
+
 // GenerateToken 生成token
 func (j *JWT) GenerateToken(userID uint, username string) (string, error) {
+	if j.Config.Secret == "" {
+		return "", errEmptySecret
+	}
+
 	// 设置token过期时间
 	expireTime := time.Now().Add(time.Duration(j.Config.Timeout) * time.Second)
 
@@ -63,6 +70,10 @@ func (j *JWT) GenerateToken(userID uint, username string) (string, error) {
 
 // ParseToken 解析token
 func (j *JWT) ParseToken(tokenString string) (*Claims, error) {
+	if j.Config.Secret == "" {
+		return nil, errEmptySecret
+	}
+
 	// 解析token
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(j.Config.Secret), nil
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/auth"
+	qiniustorage "github.com/qiniu/go-sdk/v7/storage"
+
+	"rentPro/rentpro-admin/common/storage"
+)
+
+// QiniuService 实现 storage.Storage 接口，作为七牛云存储驱动
+var (
+	_ storage.Storage          = (*QiniuService)(nil)
+	_ storage.DirectUploader   = (*QiniuService)(nil)
+	_ storage.CallbackVerifier = (*QiniuService)(nil)
+)
+
+// Driver 驱动名称
+func (q *QiniuService) Driver() string {
+	return storage.DriverQiniu
+}
+
+// Put 上传文件，key 原样使用
+func (q *QiniuService) Put(key string, r io.Reader, size int64, contentType string) (*storage.Object, error) {
+	putPolicy := qiniustorage.PutPolicy{
+		Scope: q.bucket + ":" + key,
+	}
+	upToken := putPolicy.UploadToken(q.mac)
+
+	uploader := qiniustorage.NewFormUploader(&q.config)
+	ret := qiniustorage.PutRet{}
+	putExtra := qiniustorage.PutExtra{MimeType: contentType}
+	if err := uploader.Put(context.Background(), &ret, upToken, key, r, size, &putExtra); err != nil {
+		return nil, fmt.Errorf("上传到七牛云失败: %v", err)
+	}
+
+	return &storage.Object{
+		Key:      ret.Key,
+		Size:     size,
+		Hash:     ret.Hash,
+		MimeType: contentType,
+		PutTime:  time.Now(),
+	}, nil
+}
+
+// Delete 删除文件
+func (q *QiniuService) Delete(key string) error {
+	bucketManager := qiniustorage.NewBucketManager(q.mac, &q.config)
+	if err := bucketManager.Delete(q.bucket, key); err != nil {
+		if isQiniuNotFound(err) {
+			return storage.ErrNotFound
+		}
+		return fmt.Errorf("删除文件失败: %v", err)
+	}
+	return nil
+}
+
+// Stat 获取文件信息
+func (q *QiniuService) Stat(key string) (*storage.Object, error) {
+	bucketManager := qiniustorage.NewBucketManager(q.mac, &q.config)
+	info, err := bucketManager.Stat(q.bucket, key)
+	if err != nil {
+		if isQiniuNotFound(err) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+	return &storage.Object{
+		Key:      key,
+		Size:     info.Fsize,
+		Hash:     info.Hash,
+		MimeType: info.MimeType,
+		PutTime:  qiniuPutTime(info.PutTime),
+	}, nil
+}
+
+// List 按前缀分页列出文件
+func (q *QiniuService) List(prefix, marker string, limit int) ([]storage.Object, string, error) {
+	bucketManager := qiniustorage.NewBucketManager(q.mac, &q.config)
+	entries, _, nextMarker, hasNext, err := bucketManager.ListFiles(q.bucket, prefix, "", marker, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("列出文件失败: %v", err)
+	}
+
+	objects := make([]storage.Object, 0, len(entries))
+	for _, entry := range entries {
+		objects = append(objects, storage.Object{
+			Key:      entry.Key,
+			Size:     entry.Fsize,
+			Hash:     entry.Hash,
+			MimeType: entry.MimeType,
+			PutTime:  qiniuPutTime(entry.PutTime),
+		})
+	}
+	if !hasNext {
+		nextMarker = ""
+	}
+	return objects, nextMarker, nil
+}
+
+// PublicURL 文件的公开访问URL
+func (q *QiniuService) PublicURL(key string) string {
+	return q.configManager.GetPublicURL(key)
+}
+
+// SignedURL 私有空间带有效期的下载URL
+func (q *QiniuService) SignedURL(key string, expires time.Duration) (string, error) {
+	domain := "http://" + q.domain
+	if q.qiniuConfig.UseHTTPS {
+		domain = "https://" + q.domain
+	}
+	deadline := time.Now().Add(expires).Unix()
+	return qiniustorage.MakePrivateURLv2(q.mac, domain, key, deadline), nil
+}
+
+// Get 通过签名下载URL读取文件内容（公开空间同样适用）
+func (q *QiniuService) Get(key string) (io.ReadCloser, error) {
+	downloadURL, err := q.SignedURL(key, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Get(downloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("下载文件失败: %v", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, storage.ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("下载文件失败: HTTP %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// StyleURL 图片样式URL
+func (q *QiniuService) StyleURL(url, style string) string {
+	return q.GetStyleURL(url, style)
+}
+
+// DirectUpload 生成表单上传参数，浏览器直接上传到七牛云
+// 上传凭证限定 key、大小和类型，且不允许覆盖已有文件；配置了回调地址时由七牛云回调服务端
+func (q *QiniuService) DirectUpload(key string, policy storage.UploadPolicy) (*storage.DirectUpload, error) {
+	putPolicy := qiniustorage.PutPolicy{
+		Scope:      q.bucket + ":" + key,
+		Expires:    uint64(policy.Expires / time.Second),
+		InsertOnly: 1,
+		FsizeLimit: policy.MaxSize,
+		DetectMime: 1,
+		MimeLimit:  strings.Join(policy.MimeTypes, ";"),
+	}
+	if policy.CallbackURL != "" {
+		putPolicy.CallbackURL = policy.CallbackURL
+		putPolicy.CallbackBody = policy.CallbackBody
+		putPolicy.CallbackBodyType = "application/x-www-form-urlencoded"
+	}
+
+	return &storage.DirectUpload{
+		Method: "POST",
+		URL:    q.upHost(),
+		Fields: map[string]string{
+			"token": putPolicy.UploadToken(q.mac),
+			"key":   key,
+		},
+		FileField:      "file",
+		ServerCallback: policy.CallbackURL != "",
+	}, nil
+}
+
+// upHost 存储区域的表单上传地址
+func (q *QiniuService) upHost() string {
+	scheme := "http://"
+	if q.config.UseHTTPS {
+		scheme = "https://"
+	}
+	hosts := q.config.Zone.SrcUpHosts
+	if q.config.UseCdnDomains && len(q.config.Zone.CdnUpHosts) > 0 {
+		hosts = q.config.Zone.CdnUpHosts
+	}
+	if len(hosts) == 0 {
+		return scheme + "upload.qiniup.com"
+	}
+	return scheme + hosts[0]
+}
+
+// IsCallback 请求是否带有七牛云回调签名
+func (q *QiniuService) IsCallback(req *http.Request) bool {
+	authorization := req.Header.Get("Authorization")
+	return strings.HasPrefix(authorization, auth.AuthorizationPrefixQBox) ||
+		strings.HasPrefix(authorization, auth.AuthorizationPrefixQiniu)
+}
+
+// VerifyCallback 验证七牛云上传回调签名
+func (q *QiniuService) VerifyCallback(req *http.Request) (bool, error) {
+	return q.mac.VerifyCallback(req)
+}
+
+// qiniuPutTime 七牛云上传时间单位为100纳秒
+func qiniuPutTime(putTime int64) time.Time {
+	return time.Unix(0, putTime*100)
+}
+
+// isQiniuNotFound 七牛云文件不存在（612）
+func isQiniuNotFound(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "no such file")
+}
@@ -30,10 +30,14 @@ INSERT INTO sys_menu (id, name, title, icon, path, redirect, component, permissi
 (25, 'Landlord', '房东管理', 'UserFilled', '/rental/landlord', '', 'rental/landlord/index', 'rental:landlord:view', 2, 'C', 5, '0', '1', '0', '', '0', '', NOW(), NOW()),
 (26, 'Contract', '合同管理', 'Document', '/rental/contract', '', 'rental/contract/index', 'rental:contract:view', 2, 'C', 6, '0', '1', '0', '', '0', '', NOW(), NOW());
 
+-- 按钮权限
+INSERT INTO sys_menu (id, name, title, icon, path, redirect, component, permission, parent_id, type, sort, visible, is_frame, is_cache, menu_type, status, perms, created_at, updated_at) VALUES 
+(211, 'BuildingReview', '楼盘审核', '', '', '', '', 'rental:building:review', 21, 'F', 1, '1', '1', '0', '3', '0', 'rental:building:review', NOW(), NOW());
+
 -- 重新建立角色菜单关联
 -- 超级管理员拥有所有菜单权限
 INSERT INTO sys_role_menu (sys_role_id, sys_menu_id) VALUES 
-(1, 1), (1, 2), (1, 11), (1, 12), (1, 13), (1, 21), (1, 22), (1, 23), (1, 24), (1, 25), (1, 26);
+(1, 1), (1, 2), (1, 11), (1, 12), (1, 13), (1, 21), (1, 22), (1, 23), (1, 24), (1, 25), (1, 26), (1, 211);
 
 -- 普通用户只有租赁管理权限
 INSERT INTO sys_role_menu (sys_role_id, sys_menu_id) VALUES 
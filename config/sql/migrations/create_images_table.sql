@@ -26,7 +26,7 @@ CREATE TABLE IF NOT EXISTS `sys_images` (
     -- 图片属性
     `width` int DEFAULT 0 COMMENT '图片宽度',
     `height` int DEFAULT 0 COMMENT '图片高度',
-    `hash` varchar(100) DEFAULT '' COMMENT '文件Hash',
+    `hash` varchar(100) DEFAULT '' COMMENT '文件内容Hash(SHA-256)',
 
     -- 状态控制
     `is_public` tinyint(1) DEFAULT 1 COMMENT '是否公开访问',
@@ -46,7 +46,8 @@ CREATE TABLE IF NOT EXISTS `sys_images` (
     KEY `idx_module` (`module`, `module_id`),
     KEY `idx_status` (`status`),
     KEY `idx_created_at` (`created_at`),
-    KEY `idx_deleted_at` (`deleted_at`)
+    KEY `idx_deleted_at` (`deleted_at`),
+    KEY `idx_sys_images_hash` (`hash`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='图片管理表';
 
 -- 图片分类配置表
@@ -59,6 +60,7 @@ CREATE TABLE IF NOT EXISTS `sys_image_categories` (
     `allowed_types` json COMMENT '允许的文件类型',
     `max_count` int DEFAULT 10 COMMENT '最大上传数量',
     `is_required` tinyint(1) DEFAULT 0 COMMENT '是否必填',
+    `modules` json COMMENT '适用模块(为空适用所有模块)',
     `status` varchar(20) DEFAULT 'active' COMMENT '状态',
     `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
@@ -69,11 +71,39 @@ CREATE TABLE IF NOT EXISTS `sys_image_categories` (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='图片分类配置表';
 
 -- 插入默认的图片分类配置
-INSERT INTO `sys_image_categories` (`code`, `name`, `description`, `max_size`, `allowed_types`, `max_count`, `is_required`, `status`) VALUES
-('building', '楼盘图片', '楼盘相关的图片文件', 5242880, '["image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp"]', 20, 0, 'active'),
-('house', '房屋图片', '房屋相关的图片文件', 5242880, '["image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp"]', 15, 0, 'active'),
-('avatar', '头像图片', '用户头像图片', 2097152, '["image/jpeg", "image/jpg", "image/png"]', 1, 0, 'active'),
-('banner', '横幅图片', '网站横幅和广告图片', 3145728, '["image/jpeg", "image/jpg", "image/png"]', 5, 0, 'active'),
-('floor_plan', '户型图', '房屋户型图纸', 5242880, '["image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp"]', 10, 0, 'active'),
-('certificate', '证件图片', '身份证、营业执照等证件', 2097152, '["image/jpeg", "image/jpg", "image/png"]', 5, 0, 'active'),
-('default', '默认分类', '未分类的图片文件', 5242880, '["image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp"]', 10, 0, 'active');
+INSERT INTO `sys_image_categories` (`code`, `name`, `description`, `max_size`, `allowed_types`, `max_count`, `is_required`, `modules`, `status`) VALUES
+('building', '楼盘图片', '楼盘相关的图片文件', 5242880, '["image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp"]', 20, 0, '["building"]', 'active'),
+('house', '房屋图片', '房屋相关的图片文件', 5242880, '["image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp"]', 15, 0, '["house"]', 'active'),
+('avatar', '头像图片', '用户头像图片', 2097152, '["image/jpeg", "image/jpg", "image/png"]', 1, 0, NULL, 'active'),
+('banner', '横幅图片', '网站横幅和广告图片', 3145728, '["image/jpeg", "image/jpg", "image/png"]', 5, 0, NULL, 'active'),
+('floor_plan', '户型图', '房屋户型图纸', 5242880, '["image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp"]', 10, 0, '["house", "house_floor_plan"]', 'active'),
+('certificate', '证件图片', '身份证、营业执照等证件', 2097152, '["image/jpeg", "image/jpg", "image/png"]', 5, 0, NULL, 'active'),
+('default', '默认分类', '未分类的图片文件', 5242880, '["image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp"]', 10, 0, NULL, 'active');
+
+-- 图片水印策略表（生成衍生图时按分类、模块叠加水印）
+CREATE TABLE IF NOT EXISTS `sys_watermark_policies` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `name` varchar(100) NOT NULL COMMENT '策略名称',
+    `category` varchar(50) DEFAULT '' COMMENT '适用图片分类(为空适用所有分类)',
+    `module` varchar(50) DEFAULT '' COMMENT '适用模块(为空适用所有模块)',
+    `type` varchar(20) NOT NULL COMMENT '水印类型(text/logo)',
+    `text` varchar(100) DEFAULT '' COMMENT '水印文字',
+    `color` varchar(20) DEFAULT '' COMMENT '文字颜色(#RRGGBB)',
+    `logo_key` varchar(500) DEFAULT '' COMMENT 'Logo图片存储Key',
+    `position` varchar(20) NOT NULL COMMENT '位置(top_left/top_right/bottom_left/bottom_right/center/tile)',
+    `opacity` decimal(3,2) NOT NULL COMMENT '不透明度(0-1)',
+    `scale` decimal(3,2) NOT NULL COMMENT '水印宽度占图片宽度的比例',
+    `margin` decimal(3,2) DEFAULT 0 COMMENT '边距占图片宽度的比例',
+    `sizes` json COMMENT '适用的衍生图规格(为空适用所有规格)',
+    `exempt_floor_plans` tinyint(1) DEFAULT 0 COMMENT '户型图不加水印',
+    `status` varchar(20) DEFAULT 'active' COMMENT '状态(active/inactive)',
+    `created_by` bigint unsigned DEFAULT 0 COMMENT '创建者ID',
+    `updated_by` bigint unsigned DEFAULT 0 COMMENT '更新者ID',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+
+    PRIMARY KEY (`id`),
+    KEY `idx_category` (`category`),
+    KEY `idx_module` (`module`),
+    KEY `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='图片水印策略表';
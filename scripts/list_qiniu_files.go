@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"github.com/qiniu/go-sdk/v7/storage"
@@ -11,9 +12,12 @@ import (
 func main() {
 	fmt.Println("=== 七牛云文件夹结构查看工具 ===")
 
-	// 直接使用配置信息（从配置文件中获取）
-	accessKey := "YRVDLN0uWnICy6OtDyWFy2OgJlUGQI2tMxGU13-z"
-	secretKey := "6VAEYJIads4_EI5zjGhD0zSmy_d2IEdVmQCo3DLd"
+	// 密钥从环境变量读取，不在代码中保存
+	accessKey := os.Getenv("QINIU_ACCESS_KEY")
+	secretKey := os.Getenv("QINIU_SECRET_KEY")
+	if accessKey == "" || secretKey == "" {
+		log.Fatal("请设置环境变量 QINIU_ACCESS_KEY 和 QINIU_SECRET_KEY")
+	}
 	bucket := "rentpro-floor-plans"
 
 	fmt.Printf("🔍 查看七牛云存储空间: %s\n", bucket)